@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/compiler"
+	"github.com/snyk/vervet/internal/textdiff"
+)
+
+// Browse is a command that interactively browses the APIs, resources and
+// versions in a project, previewing resolved specs and the differences
+// between adjacent versions.
+func Browse(ctx *cli.Context) error {
+	projectDir, configFile, err := projectConfig(ctx)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(configFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	proj, err := config.Load(f)
+	if err != nil {
+		return err
+	}
+	err = os.Chdir(projectDir)
+	if err != nil {
+		return err
+	}
+	b, err := newBrowser(proj, ctx.App.Reader, ctx.App.Writer)
+	if err != nil {
+		return err
+	}
+	return b.run()
+}
+
+type browseAPI struct {
+	name      string
+	resources []*vervet.ResourceVersions
+}
+
+type browser struct {
+	in   *bufio.Scanner
+	out  io.Writer
+	apis []browseAPI
+}
+
+func newBrowser(proj *config.Project, in io.Reader, out io.Writer) (*browser, error) {
+	documentOptions, err := compiler.DocumentOptions(proj)
+	if err != nil {
+		return nil, err
+	}
+	b := &browser{in: bufio.NewScanner(in), out: out}
+	for _, apiName := range proj.APINames() {
+		api := browseAPI{name: apiName}
+		for _, rcConfig := range proj.APIs[apiName].Resources {
+			specFiles, err := compiler.ResourceSpecFiles(rcConfig)
+			if err != nil {
+				return nil, err
+			}
+			specVersions, err := vervet.LoadSpecVersionsFileset(specFiles, documentOptions...)
+			if err != nil {
+				return nil, err
+			}
+			api.resources = append(api.resources, specVersions.Resources()...)
+		}
+		b.apis = append(b.apis, api)
+	}
+	return b, nil
+}
+
+// errQuit ends browsing from any level.
+var errQuit = fmt.Errorf("quit")
+
+func (b *browser) run() error {
+	err := b.browseAPIs()
+	if err == errQuit {
+		return nil
+	}
+	return err
+}
+
+// prompt lists choices, and reads a command. Entering the number of a choice
+// returns its index. Other commands are returned as entered, with choice -1.
+// End of input quits.
+func (b *browser) prompt(title string, choices []string, help string) (int, string, error) {
+	fmt.Fprintf(b.out, "\n%s:\n", title)
+	for i, choice := range choices {
+		fmt.Fprintf(b.out, "%4d) %s\n", i+1, choice)
+	}
+	fmt.Fprintf(b.out, "%s\n> ", help)
+	if !b.in.Scan() {
+		if err := b.in.Err(); err != nil {
+			return -1, "", err
+		}
+		return -1, "", errQuit
+	}
+	cmd := strings.TrimSpace(b.in.Text())
+	if cmd == "q" {
+		return -1, "", errQuit
+	}
+	if n, err := strconv.Atoi(cmd); err == nil {
+		if n < 1 || n > len(choices) {
+			fmt.Fprintf(b.out, "no choice %d\n", n)
+			return -1, "", nil
+		}
+		return n - 1, "", nil
+	}
+	return -1, cmd, nil
+}
+
+func (b *browser) browseAPIs() error {
+	var choices []string
+	for _, api := range b.apis {
+		choices = append(choices, fmt.Sprintf("%s (%d resources)", api.name, len(api.resources)))
+	}
+	for {
+		choice, cmd, err := b.prompt("APIs", choices, "Choose an API by number, or q to quit")
+		if err != nil {
+			return err
+		}
+		if choice >= 0 {
+			if err := b.browseResources(&b.apis[choice]); err != nil {
+				return err
+			}
+		} else if cmd != "" {
+			fmt.Fprintf(b.out, "unknown command %q\n", cmd)
+		}
+	}
+}
+
+func (b *browser) browseResources(api *browseAPI) error {
+	var choices []string
+	for _, rc := range api.resources {
+		choices = append(choices, fmt.Sprintf("%s (%d versions)", rc.Name(), len(rc.Versions())))
+	}
+	for {
+		choice, cmd, err := b.prompt("Resources in "+api.name, choices, "Choose a resource by number, b to go back, or q to quit")
+		if err != nil {
+			return err
+		}
+		if choice >= 0 {
+			if err := b.browseVersions(api.resources[choice]); err != nil {
+				return err
+			}
+		} else if cmd == "b" {
+			return nil
+		} else if cmd != "" {
+			fmt.Fprintf(b.out, "unknown command %q\n", cmd)
+		}
+	}
+}
+
+func (b *browser) browseVersions(rc *vervet.ResourceVersions) error {
+	versions := rc.Versions()
+	var choices []string
+	for _, version := range versions {
+		choices = append(choices, version.String())
+	}
+	for {
+		choice, cmd, err := b.prompt("Versions of "+rc.Name(), choices,
+			"Choose a version by number to preview it, d <number> to compare it with the version before, b to go back, or q to quit")
+		if err != nil {
+			return err
+		}
+		switch {
+		case choice >= 0:
+			spec, err := resolvedSpec(rc, versions[choice])
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(b.out, spec)
+		case strings.HasPrefix(cmd, "d "):
+			n, err := strconv.Atoi(strings.TrimSpace(cmd[2:]))
+			if err != nil || n < 1 || n > len(versions) {
+				fmt.Fprintf(b.out, "no choice %q\n", cmd[2:])
+				continue
+			}
+			if n == 1 {
+				fmt.Fprintf(b.out, "%s is the first version of %s\n", versions[0], rc.Name())
+				continue
+			}
+			err = b.diffVersions(rc, versions[n-2], versions[n-1])
+			if err != nil {
+				return err
+			}
+		case cmd == "b":
+			return nil
+		case cmd != "":
+			fmt.Fprintf(b.out, "unknown command %q\n", cmd)
+		}
+	}
+}
+
+func (b *browser) diffVersions(rc *vervet.ResourceVersions, from, to *vervet.Version) error {
+	fromSpec, err := resolvedSpec(rc, from)
+	if err != nil {
+		return err
+	}
+	toSpec, err := resolvedSpec(rc, to)
+	if err != nil {
+		return err
+	}
+	diff := textdiff.Unified(from.String(), to.String(), fromSpec, toSpec, 3)
+	if diff == "" {
+		fmt.Fprintf(b.out, "%s and %s are the same\n", from, to)
+		return nil
+	}
+	fmt.Fprint(b.out, diff)
+	return nil
+}
+
+// resolvedSpec returns a resource version's spec as YAML, with all references
+// localized so that it is self-contained.
+func resolvedSpec(rc *vervet.ResourceVersions, version *vervet.Version) (string, error) {
+	r, err := rc.At(version.String())
+	if err != nil {
+		return "", err
+	}
+	err = vervet.Localize(r.Document)
+	if err != nil {
+		return "", fmt.Errorf("failed to localize refs: %w", err)
+	}
+	buf, err := vervet.ToSpecYAML(r.Document)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,33 @@
+package cmd_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/cmd"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestBrowse(t *testing.T) {
+	c := qt.New(t)
+	cd(c, testdata.Path("."))
+	var out bytes.Buffer
+	c.Patch(&cmd.App.Reader, strings.NewReader("1\n2\n1\nb\n1\nd 2\nd 1\nx\nq\n"))
+	c.Patch(&cmd.App.Writer, &out)
+	err := cmd.App.Run([]string{"vervet", "browse"})
+	c.Assert(err, qt.IsNil)
+
+	output := out.String()
+	c.Assert(output, qt.Contains, "   1) testdata (2 resources)\n")
+	c.Assert(output, qt.Contains, "   2) projects (1 versions)\n")
+	// Preview of the resolved spec
+	c.Assert(output, qt.Contains, "x-snyk-api-stability: experimental\n")
+	c.Assert(output, qt.Contains, "  /orgs/{orgId}/projects:\n")
+	// Diff of adjacent versions
+	c.Assert(output, qt.Contains, "--- 2021-06-01\n+++ 2021-06-07\n")
+	c.Assert(output, qt.Contains, "2021-06-01 is the first version of hello-world\n")
+	c.Assert(output, qt.Contains, `unknown command "x"`)
+}
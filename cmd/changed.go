@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/internal/compiler"
+)
+
+// defaultTargetBranch is the branch changes are compared against with
+// --changed-only, when not specified.
+const defaultTargetBranch = "origin/main"
+
+// changedOnlyOptions returns compiler options which restrict linting to the
+// resource versions changed since the merge-base of the current commit and
+// the target branch, if --changed-only is set. Changes to the project
+// configuration affect every resource version, so nothing is restricted.
+func changedOnlyOptions(ctx *cli.Context) ([]compiler.CompilerOption, error) {
+	if !ctx.Bool("changed-only") {
+		return nil, nil
+	}
+	target := ctx.String("target-branch")
+	if target == "" {
+		target = defaultTargetBranch
+	}
+	files, err := gitChangedFiles(target)
+	if err != nil {
+		return nil, err
+	}
+	configPath := ctx.String("config")
+	if configPath == "" {
+		configPath = vervet.ProjectConfigFile
+	}
+	for _, file := range files {
+		if filepath.Clean(file) == filepath.Clean(configPath) {
+			log.Printf("%s changed, linting all resource versions", configPath)
+			return nil, nil
+		}
+	}
+	return []compiler.CompilerOption{compiler.ChangedFiles(files)}, nil
+}
+
+// gitChangedFiles returns the files which differ from the merge-base of HEAD
+// and the target, including uncommitted and untracked files, relative to the
+// current directory. Files outside of the current directory are left out.
+func gitChangedFiles(target string) ([]string, error) {
+	base, err := git("merge-base", target, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find merge-base with %q: %w", target, err)
+	}
+	if len(base) != 1 {
+		return nil, fmt.Errorf("no merge-base with %q", target)
+	}
+	changed, err := git("diff", "--name-only", "--relative", base[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files: %w", err)
+	}
+	untracked, err := git("ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	var result []string
+	for _, file := range append(changed, untracked...) {
+		result = append(result, filepath.FromSlash(file))
+	}
+	return result, nil
+}
+
+// git runs a git command, returning the lines of its output.
+func git(args ...string) ([]string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
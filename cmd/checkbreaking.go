@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/compiler"
+	"github.com/snyk/vervet/internal/signing"
+	"github.com/snyk/vervet/internal/specdiff"
+	"github.com/snyk/vervet/internal/waiver"
+)
+
+// CheckBreaking compares the resources of each API in a project at one
+// version to those at another, and fails if any change may break clients,
+// unless the API waives it.
+func CheckBreaking(ctx *cli.Context) error {
+	from, to := ctx.String("from"), ctx.String("to")
+	if from == "" || to == "" {
+		return fmt.Errorf("--from and --to versions are required")
+	}
+	for _, v := range []string{from, to} {
+		if _, err := vervet.ParseVersion(v); err != nil {
+			return err
+		}
+	}
+	projectDir, configFile, err := projectConfig(ctx)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(configFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	proj, err := config.Load(f)
+	if err != nil {
+		return err
+	}
+	apiNames := proj.APINames()
+	if apiName := ctx.String("api"); apiName != "" {
+		if _, ok := proj.APIs[apiName]; !ok {
+			return fmt.Errorf("api not found (apis.%s)", apiName)
+		}
+		apiNames = []string{apiName}
+	}
+	err = os.Chdir(projectDir)
+	if err != nil {
+		return err
+	}
+	documentOptions, err := compiler.DocumentOptions(proj)
+	if err != nil {
+		return err
+	}
+	checker := &waiver.Checker{Now: time.Now()}
+	if keyPath := ctx.String("waiver-key"); keyPath != "" {
+		checker.Key, err = signing.LoadPublicKey(keyPath)
+		if err != nil {
+			return err
+		}
+	}
+	breaking, waived := 0, 0
+	for _, apiName := range apiNames {
+		var fromResources, toResources []*vervet.Resource
+		for rcIndex, rcConfig := range proj.APIs[apiName].Resources {
+			specFiles, err := compiler.ResourceSpecFiles(rcConfig)
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.resources[%d])", err, apiName, rcIndex)
+			}
+			specVersions, err := vervet.LoadSpecVersionsFileset(specFiles, documentOptions...)
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.resources[%d])", err, apiName, rcIndex)
+			}
+			rcFrom, err := resourcesAt(specVersions, from)
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.resources[%d])", err, apiName, rcIndex)
+			}
+			rcTo, err := resourcesAt(specVersions, to)
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.resources[%d])", err, apiName, rcIndex)
+			}
+			fromResources = append(fromResources, rcFrom...)
+			toResources = append(toResources, rcTo...)
+		}
+		diff := specdiff.Compare(mergeResources(fromResources), mergeResources(toResources))
+		for _, change := range diff.Breaking() {
+			w, err := checker.Find(apiName, proj.APIs[apiName].Waivers, change.String())
+			switch {
+			case w != nil:
+				fmt.Fprintf(ctx.App.Writer, "%s: %s (waived by %s until %s: %s)\n",
+					apiName, change, w.Author, w.Expires, w.Reason)
+				waived++
+			case err != nil:
+				fmt.Fprintf(ctx.App.Writer, "%s: %s (%v)\n", apiName, change, err)
+				breaking++
+			default:
+				fmt.Fprintf(ctx.App.Writer, "%s: %s\n", apiName, change)
+				breaking++
+			}
+		}
+	}
+	if breaking > 0 {
+		return fmt.Errorf("%d breaking changes from %s to %s", breaking, from, to)
+	}
+	if waived > 0 {
+		fmt.Fprintf(ctx.App.Writer, "No breaking changes from %s to %s, other than %d waived.\n", from, to, waived)
+		return nil
+	}
+	fmt.Fprintf(ctx.App.Writer, "No breaking changes from %s to %s.\n", from, to)
+	return nil
+}
+
+// SignWaiver signs a waiver of a breaking change to an API in a project, and
+// prints it to be added to the API's waivers.
+func SignWaiver(ctx *cli.Context) error {
+	keyPath := ctx.String("key")
+	if keyPath == "" {
+		return fmt.Errorf("missing private key, use --key to locate it")
+	}
+	key, err := signing.LoadPrivateKey(keyPath)
+	if err != nil {
+		return err
+	}
+	_, configFile, err := projectConfig(ctx)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(configFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	proj, err := config.Load(f)
+	if err != nil {
+		return err
+	}
+	apiName := ctx.String("api")
+	if _, ok := proj.APIs[apiName]; !ok {
+		return fmt.Errorf("api not found (apis.%s)", apiName)
+	}
+	w := &config.Waiver{
+		Change:  ctx.String("change"),
+		Author:  ctx.String("author"),
+		Reason:  ctx.String("reason"),
+		Expires: ctx.String("expires"),
+	}
+	if w.Change == "" || w.Author == "" || w.Reason == "" {
+		return fmt.Errorf("--change, --author and --reason are required")
+	}
+	if _, err := time.Parse("2006-01-02", w.Expires); err != nil {
+		return fmt.Errorf("invalid --expires %q, expected YYYY-MM-DD", w.Expires)
+	}
+	waiver.Sign(apiName, w, key)
+	buf, err := yaml.Marshal([]*config.Waiver{w})
+	if err != nil {
+		return err
+	}
+	_, err = ctx.App.Writer.Write(buf)
+	return err
+}
+
+// resourcesAt returns the resource versions at a version, or none if no
+// resource has a version there yet.
+func resourcesAt(specVersions *vervet.SpecVersions, version string) ([]*vervet.Resource, error) {
+	resources, err := specVersions.ResourcesAt(version)
+	if errors.Is(err, vervet.ErrNoMatchingVersion) {
+		return nil, nil
+	}
+	return resources, err
+}
+
+// mergeResources returns the document merged from resource versions, which
+// is empty if there are none. It is only compared, never modified, so it
+// shares its contents with the resources.
+func mergeResources(resources []*vervet.Resource) *openapi3.T {
+	if doc := vervet.MergeResourcesShared(resources); doc != nil {
+		return doc
+	}
+	return &openapi3.T{Paths: openapi3.Paths{}}
+}
@@ -0,0 +1,107 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/cmd"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestCheckBreaking(t *testing.T) {
+	c := qt.New(t)
+	cd(c, testdata.Path("."))
+	var out bytes.Buffer
+	c.Patch(&cmd.App.Writer, &out)
+
+	err := cmd.App.Run([]string{"vervet", "check-breaking", "--from", "2021-06-01", "--to", "2021-06-13"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Equals, "No breaking changes from 2021-06-01 to 2021-06-13.\n")
+
+	// The experimental projects resource is not released at beta.
+	out.Reset()
+	err = cmd.App.Run([]string{"vervet", "check-breaking", "--from", "2021-06-04~experimental", "--to", "2021-06-13~beta"})
+	c.Assert(err, qt.ErrorMatches, `1 breaking changes from 2021-06-04~experimental to 2021-06-13~beta`)
+	c.Assert(out.String(), qt.Equals, "testdata: /orgs/{orgId}/projects: path removed\n")
+
+	err = cmd.App.Run([]string{"vervet", "check-breaking", "--from", "2021-06-01"})
+	c.Assert(err, qt.ErrorMatches, `--from and --to versions are required`)
+	err = cmd.App.Run([]string{"vervet", "check-breaking", "--from", "2021-06-01", "--to", "2021-06-13", "--api", "nope"})
+	c.Assert(err, qt.ErrorMatches, `api not found \(apis.nope\)`)
+}
+
+const waiversConfig = `
+apis:
+  testdata:
+    resources:
+      - path: RESOURCES
+    waivers:
+WAIVERS`
+
+func TestCheckBreakingWaivers(t *testing.T) {
+	c := qt.New(t)
+	privatePath, publicPath := writeKeys(c)
+	projectDir := c.Mkdir()
+	// check-breaking changes to the project directory.
+	cd(c, projectDir)
+	configPath := filepath.Join(projectDir, ".vervet.yaml")
+	writeConfig := func(waivers string) {
+		conf := strings.Replace(waiversConfig, "RESOURCES", testdata.Path("resources"), 1)
+		conf = strings.Replace(conf, "WAIVERS", waivers, 1)
+		c.Assert(ioutil.WriteFile(configPath, []byte(conf), 0644), qt.IsNil)
+	}
+	writeConfig("      []")
+	var out bytes.Buffer
+	c.Patch(&cmd.App.Writer, &out)
+	checkBreaking := func() error {
+		out.Reset()
+		return cmd.App.Run([]string{"vervet", "check-breaking", "-c", configPath, "--waiver-key", publicPath,
+			"--from", "2021-06-04~experimental", "--to", "2021-06-13~beta"})
+	}
+	signWaiver := func(expires string) string {
+		out.Reset()
+		err := cmd.App.Run([]string{"vervet", "sign-waiver", "-c", configPath, "--key", privatePath,
+			"--api", "testdata", "--change", "/orgs/{orgId}/projects: path removed",
+			"--author", "jo@example.com", "--reason", "Projects were never released", "--expires", expires})
+		c.Assert(err, qt.IsNil)
+		return regexp.MustCompile(`(?m)^`).ReplaceAllString(out.String(), "      ")
+	}
+
+	// A signed waiver of the breaking change applies until it expires.
+	writeConfig(signWaiver("2099-01-01"))
+	err := checkBreaking()
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Equals, `
+testdata: /orgs/{orgId}/projects: path removed (waived by jo@example.com until 2099-01-01: Projects were never released)
+No breaking changes from 2021-06-04~experimental to 2021-06-13~beta, other than 1 waived.
+`[1:])
+
+	writeConfig(signWaiver("2021-01-01"))
+	err = checkBreaking()
+	c.Assert(err, qt.ErrorMatches, `1 breaking changes from .*`)
+	c.Assert(out.String(), qt.Equals,
+		"testdata: /orgs/{orgId}/projects: path removed (waiver by jo@example.com expired on 2021-01-01)\n")
+
+	// Waivers changed once signed do not apply.
+	writeConfig(strings.Replace(signWaiver("2099-01-01"), "2099-01-01", "2199-01-01", 1))
+	err = checkBreaking()
+	c.Assert(err, qt.ErrorMatches, `1 breaking changes from .*`)
+	c.Assert(out.String(), qt.Equals,
+		"testdata: /orgs/{orgId}/projects: path removed (waiver signature is not valid (waiver by jo@example.com))\n")
+
+	writeConfig(`
+      - change: '/orgs/{orgId}/projects: path removed'
+        author: jo@example.com
+        reason: Projects were never released
+        expires: 2099-01-01`[1:])
+	err = checkBreaking()
+	c.Assert(err, qt.ErrorMatches, `1 breaking changes from .*`)
+	c.Assert(out.String(), qt.Equals,
+		"testdata: /orgs/{orgId}/projects: path removed (waiver is not signed (waiver by jo@example.com))\n")
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet/internal/scratch"
+)
+
+// Clean removes temporary files left in the scratch directory by vervet
+// processes which were killed before they could clean up.
+func Clean(ctx *cli.Context) error {
+	strays, err := scratch.Strays(scratch.Dir())
+	if err != nil {
+		return fmt.Errorf("failed to find temporary files: %w", err)
+	}
+	for _, path := range strays {
+		if ctx.Bool("dry-run") {
+			fmt.Println("would remove", path)
+			continue
+		}
+		err := os.RemoveAll(path)
+		if err != nil {
+			return fmt.Errorf("failed to remove %q: %w", path, err)
+		}
+		fmt.Println("removed", path)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package cmd_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/cmd"
+)
+
+func TestClean(t *testing.T) {
+	c := qt.New(t)
+	scratchDir := c.Mkdir()
+	stray := filepath.Join(scratchDir, "vervet-scratch-rules-123.yaml")
+	c.Assert(ioutil.WriteFile(stray, nil, 0666), qt.IsNil)
+	other := filepath.Join(scratchDir, "other.yaml")
+	c.Assert(ioutil.WriteFile(other, nil, 0666), qt.IsNil)
+
+	err := cmd.App.Run([]string{"vervet", "--scratch-dir", scratchDir, "clean", "--dry-run"})
+	c.Assert(err, qt.IsNil)
+	_, err = os.Stat(stray)
+	c.Assert(err, qt.IsNil)
+
+	err = cmd.App.Run([]string{"vervet", "--scratch-dir", scratchDir, "clean"})
+	c.Assert(err, qt.IsNil)
+	_, err = os.Stat(stray)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+	_, err = os.Stat(other)
+	c.Assert(err, qt.IsNil)
+}
@@ -8,6 +8,11 @@ import (
 	"time"
 
 	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/scratch"
+	"github.com/snyk/vervet/internal/specdiff"
 )
 
 // App is the vervet CLI application.
@@ -19,6 +24,19 @@ var App = &cli.App{
 			Name:  "debug",
 			Usage: "Turn on debug logging to troubleshoot templates",
 		},
+		&cli.BoolFlag{
+			Name:  "debug-templates",
+			Usage: "Write rendered template output to a temporary directory to troubleshoot templates",
+		},
+		&cli.StringFlag{
+			Name:    "scratch-dir",
+			Usage:   "Directory for temporary files, instead of the system default",
+			EnvVars: []string{"VERVET_SCRATCH_DIR"},
+		},
+	},
+	Before: func(ctx *cli.Context) error {
+		scratch.SetDir(ctx.String("scratch-dir"))
+		return nil
 	},
 	Commands: []*cli.Command{{
 		Name:      "resolve",
@@ -28,6 +46,35 @@ var App = &cli.App{
 			&cli.StringFlag{Name: "at"},
 		},
 		Action: Resolve,
+	}, {
+		Name:      "init",
+		Usage:     "Initialize a new project, asking for its settings",
+		ArgsUsage: "[project directory]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "api",
+				Usage: "Name of the project's API",
+			},
+			&cli.StringFlag{
+				Name:  "resources",
+				Usage: "Directory of the API's resources",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Directory of the API's compiled specs",
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "Accept the default for each setting not given, without asking",
+			},
+			&cli.BoolFlag{
+				Name:    "force",
+				Aliases: []string{"f", "overwrite"},
+				Usage:   "Overwrite an existing project configuration",
+			},
+		},
+		Action: Init,
 	}, {
 		Name: "scaffold",
 		Subcommands: []*cli.Command{{
@@ -45,6 +92,7 @@ var App = &cli.App{
 		}},
 	}, {
 		Name:      "compile",
+		Aliases:   []string{"build"},
 		Usage:     "Compile versioned resources into versioned OpenAPI specs",
 		ArgsUsage: "[input resources root] [output api root]",
 		Flags: []cli.Flag{
@@ -63,18 +111,106 @@ var App = &cli.App{
 				Aliases: []string{"I"},
 				Usage:   "OpenAPI specification to include in all compiled versions",
 			},
+			&cli.StringFlag{
+				Name:  "api",
+				Usage: "Only compile the named API",
+			},
+			&cli.StringFlag{
+				Name:  "resource",
+				Usage: "Only compile the named resource; output will contain no other resources",
+			},
+			&cli.StringFlag{
+				Name:  "version",
+				Usage: "Only compile this version, or all stabilities of a version date",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "List the output files that would be added, changed or removed, without writing them",
+			},
+			&cli.BoolFlag{
+				Name:  "diff",
+				Usage: "With --dry-run, show a unified diff of each output file that would change",
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Watch resources and overlays, and rebuild the APIs built from them when they change",
+			},
+			&cli.BoolFlag{
+				Name:  "profile",
+				Usage: "Report time spent in each build phase, per API and version",
+			},
+			&cli.StringFlag{
+				Name:  "cpuprofile",
+				Usage: "Write a pprof CPU profile of the build to a file",
+			},
+			&cli.StringFlag{
+				Name:  "memprofile",
+				Usage: "Write a pprof heap profile to a file after the build",
+			},
+			&cli.StringFlag{
+				Name:    "signing-key",
+				Usage:   "Sign compiled output with this PEM encoded ed25519 private key",
+				EnvVars: []string{"VERVET_SIGNING_KEY"},
+			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "Build up to this many APIs, and compile up to this many specs of each, at once",
+				Value: 1,
+			},
+			&cli.StringFlag{
+				Name:    "build-cache",
+				Usage:   "Reuse specs compiled from the same inputs, cached in this directory or at this http(s) URL",
+				EnvVars: []string{"VERVET_BUILD_CACHE"},
+			},
+			&cli.StringSliceFlag{
+				Name:  "report",
+				Usage: "Report lint and validation results: junit=<path> writes JUnit XML, sarif=<path> writes SARIF, github[=<check name>] posts a GitHub check run",
+			},
+			&cli.StringFlag{
+				Name:  "codeowners",
+				Usage: "CODEOWNERS file mapping spec files to owners, to include in lint output and reports",
+			},
+			&cli.StringFlag{
+				Name:  "only-owned-by",
+				Usage: "Only lint spec files owned by this team or user in CODEOWNERS",
+			},
 		},
 		Action: Compile,
 	}, {
 		Name:      "lint",
-		Usage:     "Lint  versioned resources",
-		ArgsUsage: "[input resources root] [output api root]",
+		Usage:     "Lint versioned resources, or the given files",
+		ArgsUsage: "[files or globs...]",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "config",
 				Aliases: []string{"c", "conf"},
 				Usage:   "Project configuration file",
 			},
+			&cli.StringFlag{
+				Name:  "linter",
+				Usage: "Linter to apply to the given files; may be omitted if only one is configured",
+			},
+			&cli.StringSliceFlag{
+				Name:  "report",
+				Usage: "Report lint and validation results: junit=<path> writes JUnit XML, sarif=<path> writes SARIF, github[=<check name>] posts a GitHub check run",
+			},
+			&cli.StringFlag{
+				Name:  "codeowners",
+				Usage: "CODEOWNERS file mapping spec files to owners, to include in lint output and reports",
+			},
+			&cli.StringFlag{
+				Name:  "only-owned-by",
+				Usage: "Only lint spec files owned by this team or user in CODEOWNERS",
+			},
+			&cli.BoolFlag{
+				Name:  "changed-only",
+				Usage: "Only lint resource versions changed since the merge-base with the target branch, using git",
+			},
+			&cli.StringFlag{
+				Name:  "target-branch",
+				Usage: "Branch to compare changes against with --changed-only",
+				Value: defaultTargetBranch,
+			},
 		},
 		Action: Lint,
 	}, {
@@ -82,6 +218,166 @@ var App = &cli.App{
 		Usage:     "Localize references and validate a single OpenAPI spec file",
 		ArgsUsage: "[spec.yaml file]",
 		Action:    Localize,
+	}, {
+		Name:      "diff",
+		Usage:     "Describe the changes from one OpenAPI spec file to another",
+		ArgsUsage: "<spec-a> <spec-b>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format: text, json or markdown",
+				Value: string(specdiff.FormatText),
+			},
+			&cli.BoolFlag{
+				Name:  "advise",
+				Usage: "Advise whether the changes need a new version date, a stability change, or may be made in place",
+			},
+		},
+		Action: Diff,
+	}, {
+		Name:  "check-breaking",
+		Usage: "Fail if resources changed from one version to another in ways which may break clients",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c", "conf"},
+				Usage:   "Project configuration file",
+			},
+			&cli.StringFlag{
+				Name:  "from",
+				Usage: "Version to compare from",
+			},
+			&cli.StringFlag{
+				Name:  "to",
+				Usage: "Version to compare to",
+			},
+			&cli.StringFlag{
+				Name:  "api",
+				Usage: "Only check the named API",
+			},
+			&cli.StringFlag{
+				Name:    "waiver-key",
+				Usage:   "Only apply waivers signed by the private key of this PEM encoded ed25519 public key",
+				EnvVars: []string{"VERVET_WAIVER_KEY"},
+			},
+		},
+		Action: CheckBreaking,
+	}, {
+		Name:  "sign-waiver",
+		Usage: "Sign a waiver of a breaking change, to be added to an API's waivers",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c", "conf"},
+				Usage:   "Project configuration file",
+			},
+			&cli.StringFlag{
+				Name:    "key",
+				Usage:   "PEM encoded ed25519 private key to sign with",
+				EnvVars: []string{"VERVET_WAIVER_SIGNING_KEY"},
+			},
+			&cli.StringFlag{
+				Name:  "api",
+				Usage: "API changed",
+			},
+			&cli.StringFlag{
+				Name:  "change",
+				Usage: "Breaking change waived, as described by check-breaking",
+			},
+			&cli.StringFlag{
+				Name:  "author",
+				Usage: "Who accepts the change",
+			},
+			&cli.StringFlag{
+				Name:  "reason",
+				Usage: "Why the change is accepted",
+			},
+			&cli.StringFlag{
+				Name:  "expires",
+				Usage: "Last day the waiver applies, as YYYY-MM-DD",
+			},
+		},
+		Action: SignWaiver,
+	}, {
+		Name:  "clean",
+		Usage: "Remove temporary files left by interrupted vervet processes",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "List the files that would be removed, without removing them",
+			},
+		},
+		Action: Clean,
+	}, {
+		Name:  "browse",
+		Usage: "Interactively browse the APIs, resources and versions in a vervet project",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c", "conf"},
+				Usage:   "Project configuration file",
+			},
+		},
+		Action: Browse,
+	}, {
+		Name:      "check-consumers",
+		Usage:     "Check that compiled APIs provide the versions and operations pinned by their consumers",
+		ArgsUsage: "[consumers file, default " + config.ConsumersFile + "]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c", "conf"},
+				Usage:   "Project configuration file",
+			},
+		},
+		Action: CheckConsumers,
+	}, {
+		Name:      "verify",
+		Usage:     "Verify that compiled output was signed, and has not changed since",
+		ArgsUsage: "<output dir>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "key",
+				Usage:   "PEM encoded ed25519 public key of the signing key",
+				EnvVars: []string{"VERVET_VERIFY_KEY"},
+			},
+		},
+		Action: Verify,
+	}, {
+		Name:  "duplicate-schemas",
+		Usage: "Report schemas with the same structure declared across resources and versions",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c", "conf"},
+				Usage:   "Project configuration file",
+			},
+			&cli.IntFlag{
+				Name:  "min-properties",
+				Usage: "Only report object schemas with at least this many properties",
+				Value: 2,
+			},
+		},
+		Action: DuplicateSchemas,
+	}, {
+		Name:  "release-notes",
+		Usage: "Render notes on the resource versions released since a date or git tag",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c", "conf"},
+				Usage:   "Project configuration file",
+			},
+			&cli.StringFlag{
+				Name:  "since",
+				Usage: "Include versions released after this date (YYYY-mm-dd) or git tag",
+			},
+			&cli.StringFlag{
+				Name:  "template",
+				Usage: "Go template to render release notes with, instead of the default Markdown",
+			},
+		},
+		Action: ReleaseNotes,
 	}, {
 		Name: "version",
 		Flags: []cli.Flag{
@@ -95,7 +391,13 @@ var App = &cli.App{
 			Name:      "files",
 			Usage:     "List resource spec files in a vervet project",
 			ArgsUsage: "[api [resource]]",
-			Action:    VersionFiles,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "explain",
+					Usage: "Explain why each spec file is included or excluded",
+				},
+			},
+			Action: VersionFiles,
 		}, {
 			Name:      "list",
 			Usage:     "List resource versions in a vervet project",
@@ -113,7 +415,7 @@ var App = &cli.App{
 				},
 				&cli.StringFlag{
 					Name:  "version",
-					Usage: "Set version date (defaults to today UTC)",
+					Usage: "Set version date, or semantic version such as v1.2 (defaults to today UTC)",
 					Value: time.Now().UTC().Format("2006-01-02"),
 				},
 				&cli.StringFlag{
@@ -144,7 +446,7 @@ func projectConfig(ctx *cli.Context) (string, string, error) {
 		}
 		projectDir = filepath.Dir(configFile)
 	} else {
-		configFile = filepath.Join(projectDir, ".vervet.yaml")
+		configFile = filepath.Join(projectDir, vervet.ProjectConfigFile)
 		projectDir, err = os.Getwd()
 		if err != nil {
 			return "", "", err
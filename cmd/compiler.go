@@ -1,13 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/urfave/cli/v2"
 
+	"github.com/snyk/vervet"
 	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/buildcache"
+	"github.com/snyk/vervet/internal/codeowners"
 	"github.com/snyk/vervet/internal/compiler"
+	"github.com/snyk/vervet/internal/github"
+	"github.com/snyk/vervet/internal/signing"
 )
 
 // Compile compiles versioned resources into versioned API specs.
@@ -16,11 +27,25 @@ func Compile(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	return runCompiler(ctx, project, ctx.Bool("lint"), true)
+	if !ctx.Bool("watch") {
+		return runCompiler(ctx, project, ctx.Bool("lint"), true)
+	}
+	// Build failures are reported, rather than ending the watch, so that
+	// they can be fixed while watching.
+	if err := runCompiler(ctx, project, ctx.Bool("lint"), true); err != nil {
+		fmt.Fprintf(ctx.App.Writer, "Build failed: %v\n", err)
+	}
+	return watchProject(ctx.Context, ctx.App.Writer, project, func(apiNames []string) error {
+		return runCompiler(ctx, projectSubset(project, apiNames), ctx.Bool("lint"), true)
+	})
 }
 
-// Lint checks versioned resources against linting rules.
+// Lint checks versioned resources against linting rules. Given files, it
+// checks just those files with a linter from the project configuration.
 func Lint(ctx *cli.Context) error {
+	if ctx.Args().Len() > 0 {
+		return lintFiles(ctx)
+	}
 	project, err := projectFromContext(ctx)
 	if err != nil {
 		return err
@@ -28,21 +53,103 @@ func Lint(ctx *cli.Context) error {
 	return runCompiler(ctx, project, true, false)
 }
 
-func projectFromContext(ctx *cli.Context) (*config.Project, error) {
-	var project *config.Project
-	if ctx.Args().Len() == 0 {
-		var configPath string
-		if s := ctx.String("config"); s != "" {
-			configPath = s
-		} else {
-			configPath = ".vervet.yaml"
+func lintFiles(ctx *cli.Context) (err error) {
+	project, err := loadProject(ctx)
+	if err != nil {
+		return err
+	}
+	linterName, err := lintFilesLinter(ctx.String("linter"), project)
+	if err != nil {
+		return err
+	}
+	files, err := globFiles(ctx.Args().Slice())
+	if err != nil {
+		return err
+	}
+	ownersOptions, err := codeOwnersOptions(ctx)
+	if err != nil {
+		return err
+	}
+	options, writeReports, err := reportOptions(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		writeErr := writeReports()
+		if err == nil {
+			err = writeErr
+		}
+	}()
+	options = append(options, ownersOptions...)
+	comp, err := compiler.New(ctx.Context, project, options...)
+	if err != nil {
+		return err
+	}
+	return comp.LintFiles(ctx.Context, linterName, files...)
+}
+
+// lintFilesLinter returns the name of the linter to apply to files: the one
+// requested, or the only linter in the project if there is just one.
+func lintFilesLinter(linterName string, project *config.Project) (string, error) {
+	if linterName != "" {
+		if _, ok := project.Linters[linterName]; !ok {
+			return "", fmt.Errorf("linter %q not found (linters)", linterName)
+		}
+		return linterName, nil
+	}
+	switch len(project.Linters) {
+	case 0:
+		return "", fmt.Errorf("no linters configured (linters)")
+	case 1:
+		for linterName = range project.Linters {
 		}
-		f, err := os.Open(configPath)
+		return linterName, nil
+	default:
+		return "", fmt.Errorf("more than one linter configured, choose one with --linter")
+	}
+}
+
+// globFiles returns the files matching each pattern, which may be a plain file
+// path or a glob. An error is returned if a pattern matches nothing, as that
+// is likely a mistake.
+func globFiles(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		base, glob := doublestar.SplitPattern(filepath.ToSlash(pattern))
+		matches, err := doublestar.Glob(os.DirFS(base), glob)
 		if err != nil {
-			return nil, fmt.Errorf("failed to open %q: %w", configPath, err)
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
 		}
-		defer f.Close()
-		project, err = config.Load(f)
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%q did not match any files", pattern)
+		}
+		for _, match := range matches {
+			files = append(files, filepath.Join(filepath.FromSlash(base), filepath.FromSlash(match)))
+		}
+	}
+	return files, nil
+}
+
+func loadProject(ctx *cli.Context) (*config.Project, error) {
+	var configPath string
+	if s := ctx.String("config"); s != "" {
+		configPath = s
+	} else {
+		configPath = vervet.ProjectConfigFile
+	}
+	f, err := os.Open(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %q: %w", configPath, err)
+	}
+	defer f.Close()
+	return config.Load(f)
+}
+
+func projectFromContext(ctx *cli.Context) (*config.Project, error) {
+	var project *config.Project
+	if ctx.Args().Len() == 0 {
+		var err error
+		project, err = loadProject(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -69,8 +176,78 @@ func projectFromContext(ctx *cli.Context) (*config.Project, error) {
 	return project, nil
 }
 
-func runCompiler(ctx *cli.Context, project *config.Project, lint, build bool) error {
-	comp, err := compiler.New(ctx.Context, project)
+func runCompiler(ctx *cli.Context, project *config.Project, lint, build bool) (err error) {
+	ownersOptions, err := codeOwnersOptions(ctx)
+	if err != nil {
+		return err
+	}
+	options, writeReports, err := reportOptions(ctx)
+	if err != nil {
+		return err
+	}
+	// Reports are written even when lint or build fails, as that is when they
+	// are needed most.
+	defer func() {
+		writeErr := writeReports()
+		if err == nil {
+			err = writeErr
+		}
+	}()
+	if cpuProfilePath := ctx.String("cpuprofile"); cpuProfilePath != "" {
+		f, err := os.Create(cpuProfilePath)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %w", err)
+		}
+		defer f.Close()
+		err = pprof.StartCPUProfile(f)
+		if err != nil {
+			return fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+	options = append(options, ownersOptions...)
+	if lint && !build {
+		changedOptions, err := changedOnlyOptions(ctx)
+		if err != nil {
+			return err
+		}
+		options = append(options, changedOptions...)
+	}
+	options = append(options, compiler.Filter(compiler.BuildFilter{
+		API:      ctx.String("api"),
+		Resource: ctx.String("resource"),
+		Version:  ctx.String("version"),
+	}))
+	if keyPath := ctx.String("signing-key"); keyPath != "" && build {
+		key, err := signing.LoadPrivateKey(keyPath)
+		if err != nil {
+			return err
+		}
+		options = append(options, compiler.Signer(key))
+	}
+	if location := ctx.String("build-cache"); location != "" && build {
+		cache, err := buildcache.New(location)
+		if err != nil {
+			return err
+		}
+		options = append(options, compiler.BuildCache(cache))
+	}
+	if n := ctx.Int("concurrency"); n != 0 && build {
+		options = append(options, compiler.Concurrency(n))
+	}
+	var profile *compiler.Profile
+	if ctx.Bool("profile") {
+		profile = compiler.NewProfile()
+		options = append(options, compiler.Profiler(profile))
+	}
+	var dryRun []dryRunOutput
+	if ctx.Bool("dry-run") && build {
+		dryRun, err = dryRunOutputs(project)
+		if err != nil {
+			return err
+		}
+	}
+	comp, err := compiler.New(ctx.Context, project, options...)
 	if err != nil {
 		return err
 	}
@@ -85,6 +262,21 @@ func runCompiler(ctx *cli.Context, project *config.Project, lint, build bool) er
 		if err != nil {
 			return err
 		}
+		if dryRun != nil {
+			err = writeDryRunChanges(ctx.App.Writer, dryRun, ctx.Bool("diff"))
+			if err != nil {
+				return err
+			}
+		}
+		if profile != nil {
+			profile.WriteReport(ctx.App.Writer)
+		}
+		if memProfilePath := ctx.String("memprofile"); memProfilePath != "" {
+			err = writeHeapProfile(memProfilePath)
+			if err != nil {
+				return err
+			}
+		}
 	}
 	if lint {
 		err = comp.LintOutputAll(ctx.Context)
@@ -94,3 +286,129 @@ func runCompiler(ctx *cli.Context, project *config.Project, lint, build bool) er
 	}
 	return nil
 }
+
+// codeOwnersOptions returns compiler options which lint files by their owners
+// in a CODEOWNERS file, if one is given with --codeowners. Filtering with
+// --only-owned-by uses the CODEOWNERS file in the repository, if no other is
+// given.
+func codeOwnersOptions(ctx *cli.Context) ([]compiler.CompilerOption, error) {
+	ownersPath, onlyOwnedBy := ctx.String("codeowners"), ctx.String("only-owned-by")
+	if ownersPath == "" && onlyOwnedBy == "" {
+		return nil, nil
+	}
+	if ownersPath == "" {
+		var err error
+		ownersPath, err = codeowners.Find(".")
+		if err != nil {
+			return nil, fmt.Errorf("%w, use --codeowners to locate it", err)
+		}
+	}
+	owners, err := codeowners.Load(ownersPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CODEOWNERS: %w", err)
+	}
+	return []compiler.CompilerOption{compiler.CodeOwners(owners, onlyOwnedBy)}, nil
+}
+
+// Formats of lint and validation reports.
+const (
+	reportJUnit  = "junit"
+	reportSARIF  = "sarif"
+	reportGitHub = "github"
+)
+
+// defaultCheckRunName is the name of GitHub check runs, when not specified.
+const defaultCheckRunName = "vervet"
+
+// parseReports parses report flags of the form format=value, returning the
+// value for each format of report. JUnit and SARIF reports are written to the
+// path given as their value. GitHub reports are posted as a check run, named
+// by their optional value.
+func parseReports(reports []string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, report := range reports {
+		parts := strings.SplitN(report, "=", 2)
+		switch parts[0] {
+		case reportJUnit, reportSARIF:
+			if len(parts) != 2 || parts[1] == "" {
+				return nil, fmt.Errorf("invalid report %q, expected %s=path", report, parts[0])
+			}
+		case reportGitHub:
+			if len(parts) != 2 || parts[1] == "" {
+				parts = []string{reportGitHub, defaultCheckRunName}
+			}
+		default:
+			return nil, fmt.Errorf("unsupported report format %q", parts[0])
+		}
+		result[parts[0]] = parts[1]
+	}
+	return result, nil
+}
+
+// reportOptions returns compiler options which record lint and validation
+// results for the reports requested with --report, and a function which
+// writes the reports once the compiler is done.
+func reportOptions(ctx *cli.Context) ([]compiler.CompilerOption, func() error, error) {
+	reports, err := parseReports(ctx.StringSlice("report"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(reports) == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	report := compiler.NewReport()
+	return []compiler.CompilerOption{compiler.Reporter(report)}, func() error {
+		return writeReports(ctx.Context, report, reports)
+	}, nil
+}
+
+func writeReports(ctx context.Context, report *compiler.Report, reports map[string]string) error {
+	if path, ok := reports[reportJUnit]; ok {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create junit report: %w", err)
+		}
+		defer f.Close()
+		err = report.WriteJUnit(f)
+		if err != nil {
+			return fmt.Errorf("failed to write junit report: %w", err)
+		}
+	}
+	if path, ok := reports[reportSARIF]; ok {
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create sarif report: %w", err)
+		}
+		defer f.Close()
+		err = report.WriteSARIF(f)
+		if err != nil {
+			return fmt.Errorf("failed to write sarif report: %w", err)
+		}
+	}
+	if name, ok := reports[reportGitHub]; ok {
+		client, headSHA, err := github.NewClientFromEnv()
+		if err != nil {
+			return err
+		}
+		err = client.CreateCheckRun(ctx, report.CheckRun(name, headSHA))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create heap profile: %w", err)
+	}
+	defer f.Close()
+	// Collect garbage first so that the profile reflects live allocations.
+	runtime.GC()
+	err = pprof.WriteHeapProfile(f)
+	if err != nil {
+		return fmt.Errorf("failed to write heap profile: %w", err)
+	}
+	return nil
+}
@@ -1,12 +1,16 @@
 package cmd_test
 
 import (
+	"bytes"
 	"context"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/urfave/cli/v2"
 
 	"github.com/snyk/vervet"
 	"github.com/snyk/vervet/cmd"
@@ -91,3 +95,154 @@ func TestCompileConflict(t *testing.T) {
 	err := cmd.App.Run([]string{"vervet", "compile", "../testdata/conflict", dstDir})
 	c.Assert(err, qt.ErrorMatches, `failed to load spec versions: conflict: .*`)
 }
+
+// resetReportFlag clears the values given to the compile command's --report
+// flag, which would otherwise accumulate across runs of the App.
+func resetReportFlag(c *qt.C) {
+	c.Cleanup(func() {
+		for _, command := range cmd.App.Commands {
+			for _, flag := range command.Flags {
+				if f, ok := flag.(*cli.StringSliceFlag); ok && f.Name == "report" {
+					f.Value = nil
+				}
+			}
+		}
+	})
+}
+
+func TestCompileReport(t *testing.T) {
+	c := qt.New(t)
+	resetReportFlag(c)
+	dstDir := c.Mkdir()
+	reportPath := c.Mkdir() + "/junit.xml"
+	err := cmd.App.Run([]string{"vervet", "compile", "--report", "junit=" + reportPath, "../testdata/conflict", dstDir})
+	c.Assert(err, qt.ErrorMatches, `failed to load spec versions: conflict: .*`)
+
+	// The report is written even though the build failed
+	buf, err := ioutil.ReadFile(reportPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Contains, `<testsuites tests="1" failures="1">`)
+	c.Assert(string(buf), qt.Contains, `<failure type="build" message="failed to load spec versions: conflict: `)
+
+	err = cmd.App.Run([]string{"vervet", "compile", "--report", "tap=" + reportPath, "../testdata/conflict", dstDir})
+	c.Assert(err, qt.ErrorMatches, `unsupported report format "tap"`)
+}
+
+func TestCompileReportSARIF(t *testing.T) {
+	c := qt.New(t)
+	resetReportFlag(c)
+	dstDir := c.Mkdir()
+	reportPath := c.Mkdir() + "/results.sarif"
+	err := cmd.App.Run([]string{"vervet", "compile", "--report", "sarif=" + reportPath, "../testdata/conflict", dstDir})
+	c.Assert(err, qt.ErrorMatches, `failed to load spec versions: conflict: .*`)
+
+	// The report is written even though the build failed
+	buf, err := ioutil.ReadFile(reportPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Contains, `"version": "2.1.0"`)
+	c.Assert(string(buf), qt.Contains, `"text": "failed to load spec versions: conflict: `)
+
+	err = cmd.App.Run([]string{"vervet", "compile", "--report", "sarif", "../testdata/conflict", dstDir})
+	c.Assert(err, qt.ErrorMatches, `invalid report "sarif", expected sarif=path`)
+}
+
+func TestCompileProfile(t *testing.T) {
+	c := qt.New(t)
+	var out bytes.Buffer
+	c.Patch(&cmd.App.Writer, &out)
+	err := cmd.App.Run([]string{"vervet", "compile", "--profile", testdata.Path("resources"), c.Mkdir()})
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Matches, `(?s).*VERSION.*MERGE.*2021-06-13.*total.*`)
+}
+
+func TestLintFilesErrors(t *testing.T) {
+	c := qt.New(t)
+	cd(c, testdata.Path("."))
+	tests := []struct {
+		args []string
+		err  string
+	}{{
+		args: []string{"output/**/spec.json"},
+		err:  `more than one linter configured, choose one with --linter`,
+	}, {
+		args: []string{"--linter", "nope", "output/**/spec.json"},
+		err:  `linter "nope" not found \(linters\)`,
+	}, {
+		args: []string{"--linter", "compiled-rules", "nope/**/spec.json"},
+		err:  `"nope/\*\*/spec.json" did not match any files`,
+	}}
+	for _, test := range tests {
+		err := cmd.App.Run(append([]string{"vervet", "lint"}, test.args...))
+		c.Assert(err, qt.ErrorMatches, test.err)
+	}
+}
+
+func TestLintChangedOnlyErrors(t *testing.T) {
+	c := qt.New(t)
+	cd(c, testdata.Path("."))
+	err := cmd.App.Run([]string{"vervet", "lint", "--changed-only", "--target-branch", "no-such-branch"})
+	c.Assert(err, qt.ErrorMatches, `failed to find merge-base with "no-such-branch": exit status .*`)
+}
+
+func TestCompileCodeOwnersErrors(t *testing.T) {
+	c := qt.New(t)
+	dstDir := c.Mkdir()
+	cd(c, c.Mkdir())
+	err := cmd.App.Run([]string{"vervet", "compile", "--only-owned-by", "@acme/api-guild", testdata.Path("resources"), dstDir})
+	c.Assert(err, qt.ErrorMatches, `CODEOWNERS not found in "\.", use --codeowners to locate it`)
+	err = cmd.App.Run([]string{"vervet", "compile", "--codeowners", "nope/CODEOWNERS", testdata.Path("resources"), dstDir})
+	c.Assert(err, qt.ErrorMatches, `failed to load CODEOWNERS: open .*nope/CODEOWNERS: no such file or directory`)
+}
+
+func TestCompileBuildCache(t *testing.T) {
+	c := qt.New(t)
+	cacheDir := c.Mkdir()
+	var outputs []string
+	for i := 0; i < 2; i++ {
+		dstDir := c.Mkdir()
+		err := cmd.App.Run([]string{"vervet", "compile", "--build-cache", cacheDir, testdata.Path("resources"), dstDir})
+		c.Assert(err, qt.IsNil)
+		buf, err := ioutil.ReadFile(dstDir + "/2021-06-13~beta/spec.yaml")
+		c.Assert(err, qt.IsNil)
+		outputs = append(outputs, string(buf))
+	}
+	c.Assert(outputs[1], qt.Equals, outputs[0])
+	entries, err := ioutil.ReadDir(cacheDir)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(entries) > 0, qt.IsTrue)
+}
+
+func TestCompileDryRun(t *testing.T) {
+	c := qt.New(t)
+	dstDir := c.Mkdir()
+	err := cmd.App.Run([]string{"vervet", "compile", testdata.Path("resources"), dstDir})
+	c.Assert(err, qt.IsNil)
+	changedPath := filepath.Join(dstDir, "2021-06-01", "spec.yaml")
+	err = ioutil.WriteFile(changedPath, []byte("changed\n"), 0644)
+	c.Assert(err, qt.IsNil)
+	addedPath := filepath.Join(dstDir, "2021-06-07", "spec.json")
+	err = os.Remove(addedPath)
+	c.Assert(err, qt.IsNil)
+	removedPath := filepath.Join(dstDir, "stray.txt")
+	err = ioutil.WriteFile(removedPath, []byte("stray\n"), 0644)
+	c.Assert(err, qt.IsNil)
+
+	var out bytes.Buffer
+	c.Patch(&cmd.App.Writer, &out)
+	err = cmd.App.Run([]string{"vervet", "compile", "--dry-run", "--diff", testdata.Path("resources"), dstDir})
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Contains, "changed "+changedPath+"\n--- "+changedPath+"\n+++ "+changedPath+"\n")
+	c.Assert(out.String(), qt.Contains, "-changed\n")
+	c.Assert(out.String(), qt.Contains, "added   "+addedPath+"\n")
+	c.Assert(out.String(), qt.Contains, "removed "+removedPath+"\n")
+	c.Assert(out.String(), qt.Not(qt.Contains), "2021-06-13~beta")
+
+	// Nothing was written.
+	buf, err := ioutil.ReadFile(changedPath)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Equals, "changed\n")
+	_, err = os.Stat(addedPath)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+	_, err = os.Stat(removedPath)
+	c.Assert(err, qt.IsNil)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/consumers"
+)
+
+// CheckConsumers checks the compiled output of a project against the API
+// versions and operations pinned by its consumers, failing if any pinned
+// operation is no longer available.
+func CheckConsumers(ctx *cli.Context) error {
+	projectDir, configFile, err := projectConfig(ctx)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(configFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	proj, err := config.Load(f)
+	if err != nil {
+		return err
+	}
+	consumersFile := ctx.Args().Get(0)
+	if consumersFile == "" {
+		consumersFile = config.ConsumersFile
+	}
+	cf, err := os.Open(consumersFile)
+	if err != nil {
+		return err
+	}
+	defer cf.Close()
+	contracts, err := config.LoadConsumers(cf)
+	if err != nil {
+		return fmt.Errorf("%w (%s)", err, consumersFile)
+	}
+	err = os.Chdir(projectDir)
+	if err != nil {
+		return err
+	}
+	specs := map[string]*vervet.SpecVersions{}
+	for _, consumer := range contracts.Consumers {
+		for _, pin := range consumer.Pins {
+			if _, ok := specs[pin.API]; ok {
+				continue
+			}
+			api, ok := proj.APIs[pin.API]
+			if !ok {
+				continue
+			}
+			if api.Output == nil || api.Output.Path == "" {
+				return fmt.Errorf("api %q has no compiled output to check (apis.%s.output)", pin.API, pin.API)
+			}
+			specs[pin.API], err = vervet.LoadCompiledSpecVersionsFS(os.DirFS(api.Output.Path))
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.output)", err, pin.API)
+			}
+		}
+	}
+	breakages, err := consumers.Check(contracts, specs)
+	if err != nil {
+		return err
+	}
+	for _, breakage := range breakages {
+		fmt.Fprintln(ctx.App.Writer, breakage)
+	}
+	if len(breakages) > 0 {
+		return fmt.Errorf("%d pinned operations not available to consumers", len(breakages))
+	}
+	return nil
+}
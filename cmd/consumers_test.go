@@ -0,0 +1,47 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/cmd"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestCheckConsumers(t *testing.T) {
+	c := qt.New(t)
+	cd(c, testdata.Path("."))
+	consumersFile := filepath.Join(c.Mkdir(), "consumers.yaml")
+	c.Assert(ioutil.WriteFile(consumersFile, []byte(`
+consumers:
+  billing:
+    contact: billing@example.com
+    pins:
+      - api: testdata
+        version: 2021-06-13~beta
+        operations: [helloWorldCreate, helloWorldGetOne]
+`[1:]), 0666), qt.IsNil)
+	var out bytes.Buffer
+	c.Patch(&cmd.App.Writer, &out)
+	err := cmd.App.Run([]string{"vervet", "check-consumers", consumersFile})
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Equals, "")
+
+	c.Assert(ioutil.WriteFile(consumersFile, []byte(`
+consumers:
+  billing:
+    contact: billing@example.com
+    pins:
+      - api: testdata
+        version: 2021-06-07
+        operations: [helloWorldCreate, helloWorldGetOne]
+`[1:]), 0666), qt.IsNil)
+	err = cmd.App.Run([]string{"vervet", "check-consumers", consumersFile})
+	c.Assert(err, qt.ErrorMatches, "1 pinned operations not available to consumers")
+	c.Assert(out.String(), qt.Equals,
+		"helloWorldCreate: testdata at 2021-06-07: operation not found, contact billing@example.com (consumers.billing.pins[0])\n")
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/internal/specdiff"
+)
+
+// Diff describes the changes from one OpenAPI spec file to another: the
+// paths and operations added or removed, the parameters changed, and the
+// changes to request and response schema properties. With --advise, it also
+// advises how the changes may be released.
+func Diff(ctx *cli.Context) error {
+	if ctx.Args().Len() != 2 {
+		return fmt.Errorf("expected two spec files to compare")
+	}
+	format, err := specdiff.ParseFormat(ctx.String("format"))
+	if err != nil {
+		return err
+	}
+	var docs [2]*vervet.Document
+	for i := range docs {
+		specFile, err := absPath(ctx.Args().Get(i))
+		if err != nil {
+			return err
+		}
+		docs[i], err = vervet.NewDocumentFile(specFile)
+		if err != nil {
+			return fmt.Errorf("failed to load spec from %q: %w", specFile, err)
+		}
+	}
+	diff := specdiff.Compare(docs[0].T, docs[1].T)
+	if !ctx.Bool("advise") {
+		return diff.Write(ctx.App.Writer, format)
+	}
+	var stabilities [2]vervet.Stability
+	for i := range docs {
+		stabilities[i], err = docStability(docs[i])
+		if err != nil {
+			return err
+		}
+	}
+	advice := specdiff.Advise(diff, stabilities[0], stabilities[1])
+	if format == specdiff.FormatJSON {
+		enc := json.NewEncoder(ctx.App.Writer)
+		enc.SetIndent("", "  ")
+		return enc.Encode(&struct {
+			*specdiff.Diff
+			Advice *specdiff.Advice `json:"advice"`
+		}{diff, advice})
+	}
+	err = diff.Write(ctx.App.Writer, format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.App.Writer)
+	return advice.Write(ctx.App.Writer, format)
+}
+
+// docStability returns the stability of a spec: that declared by its
+// x-snyk-api-stability extension, as resource versions do, otherwise that of
+// the version directory containing it, as compiled versions are.
+func docStability(doc *vervet.Document) (vervet.Stability, error) {
+	if _, ok := doc.ExtensionProps.Extensions[vervet.ExtSnykApiStability]; ok {
+		s, err := vervet.ExtensionString(doc.ExtensionProps, vervet.ExtSnykApiStability)
+		if err != nil {
+			return 0, fmt.Errorf("%w (%s)", err, doc.Location())
+		}
+		return vervet.ParseStability(s)
+	}
+	version, err := vervet.ParseVersion(filepath.Base(doc.RelativePath()))
+	if err != nil {
+		return 0, fmt.Errorf("unknown stability of %q, which has no %s extension and is not in a version directory",
+			doc.Location(), vervet.ExtSnykApiStability)
+	}
+	return version.Stability, nil
+}
@@ -0,0 +1,81 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/cmd"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestDiff(t *testing.T) {
+	c := qt.New(t)
+	specA := testdata.Path("resources/_examples/hello-world/2021-06-07/spec.yaml")
+	specB := testdata.Path("resources/_examples/hello-world/2021-06-13/spec.yaml")
+
+	var buf bytes.Buffer
+	c.Patch(&cmd.App.Writer, &buf)
+	err := cmd.App.Run([]string{"vervet", "diff", specA, specB})
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "/examples/hello-world: path added\n")
+
+	buf.Reset()
+	err = cmd.App.Run([]string{"vervet", "diff", "--format", "json", specA, specB})
+	c.Assert(err, qt.IsNil)
+	var diff struct {
+		Changes []struct {
+			Kind string `json:"kind"`
+			Path string `json:"path"`
+		} `json:"changes"`
+	}
+	c.Assert(json.Unmarshal(buf.Bytes(), &diff), qt.IsNil)
+	c.Assert(diff.Changes, qt.HasLen, 1)
+	c.Assert(diff.Changes[0].Kind, qt.Equals, "path-added")
+	c.Assert(diff.Changes[0].Path, qt.Equals, "/examples/hello-world")
+
+	buf.Reset()
+	err = cmd.App.Run([]string{"vervet", "diff", "--format", "markdown", specA, specA})
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "No changes.\n")
+
+	err = cmd.App.Run([]string{"vervet", "diff", "--format", "html", specA, specB})
+	c.Assert(err, qt.ErrorMatches, `invalid format "html", expected text, json or markdown`)
+	err = cmd.App.Run([]string{"vervet", "diff", specA})
+	c.Assert(err, qt.ErrorMatches, `expected two spec files to compare`)
+}
+
+func TestDiffAdvise(t *testing.T) {
+	c := qt.New(t)
+	var buf bytes.Buffer
+	c.Patch(&cmd.App.Writer, &buf)
+	err := cmd.App.Run([]string{"vervet", "diff", "--advise",
+		testdata.Path("resources/_examples/hello-world/2021-06-07/spec.yaml"),
+		testdata.Path("resources/_examples/hello-world/2021-06-13/spec.yaml")})
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `
+/examples/hello-world: path added
+
+Advice: new-stability: the stability changes from ga to beta, which requires a new version date at beta.
+`[1:])
+
+	// Compiled specs have the stability of their version directory.
+	buf.Reset()
+	err = cmd.App.Run([]string{"vervet", "diff", "--advise", "--format", "json",
+		testdata.Path("output/2021-06-01~beta/spec.yaml"),
+		testdata.Path("output/2021-06-13~beta/spec.yaml")})
+	c.Assert(err, qt.IsNil)
+	var diff struct {
+		Changes []json.RawMessage `json:"changes"`
+		Advice  struct {
+			Release       string `json:"release"`
+			FromStability string `json:"from-stability"`
+		} `json:"advice"`
+	}
+	c.Assert(json.Unmarshal(buf.Bytes(), &diff), qt.IsNil)
+	c.Assert(diff.Changes, qt.HasLen, 1)
+	c.Assert(diff.Advice.Release, qt.Equals, "in-place")
+	c.Assert(diff.Advice.FromStability, qt.Equals, "beta")
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/scratch"
+	"github.com/snyk/vervet/internal/textdiff"
+)
+
+// dryRunOutput is the output directory of an API, redirected to a scratch
+// directory for a dry run.
+type dryRunOutput struct {
+	path, scratchPath string
+}
+
+// dryRunOutputs redirects the output of each API in a project to a scratch
+// directory, so that it can be built without changing its output. Each
+// scratch directory starts as a copy of the existing output, so that a build
+// treats it the same way, such as when only some versions are rebuilt.
+func dryRunOutputs(project *config.Project) ([]dryRunOutput, error) {
+	var outputs []dryRunOutput
+	for _, apiName := range project.APINames() {
+		api := project.APIs[apiName]
+		if api.Output == nil || api.Output.Path == "" {
+			continue
+		}
+		scratchPath, err := scratch.TempDir("dry-run-")
+		if err != nil {
+			return nil, err
+		}
+		err = copyDir(api.Output.Path, scratchPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy output: %w (apis.%s.output.path)", err, apiName)
+		}
+		outputs = append(outputs, dryRunOutput{path: api.Output.Path, scratchPath: scratchPath})
+		api.Output.Path = scratchPath
+	}
+	return outputs, nil
+}
+
+// copyDir copies the files, directories and symlinks in src to dst. Nothing is
+// copied if src does not exist.
+func copyDir(src, dst string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0755)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			buf, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(target, buf, info.Mode().Perm())
+		}
+	})
+}
+
+// outputFiles returns the contents of the files in an output directory, by
+// their slash-separated path relative to it. Symlinks are compared by their
+// target rather than followed, as compiled aliases are symlinks to versions.
+func outputFiles(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return files, nil
+	}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = []byte("symlink to " + link + "\n")
+			return nil
+		}
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = buf
+		return nil
+	})
+	return files, err
+}
+
+// writeDryRunChanges writes the files that a dry run would add, change or
+// remove in each output directory, with unified diffs of their contents if
+// showDiff is set.
+func writeDryRunChanges(w io.Writer, outputs []dryRunOutput, showDiff bool) error {
+	n := 0
+	for _, out := range outputs {
+		before, err := outputFiles(out.path)
+		if err != nil {
+			return err
+		}
+		after, err := outputFiles(out.scratchPath)
+		if err != nil {
+			return err
+		}
+		paths := map[string]bool{}
+		for path := range before {
+			paths[path] = true
+		}
+		for path := range after {
+			paths[path] = true
+		}
+		sorted := make([]string, 0, len(paths))
+		for path := range paths {
+			sorted = append(sorted, path)
+		}
+		sort.Strings(sorted)
+		for _, path := range sorted {
+			a, inBefore := before[path]
+			b, inAfter := after[path]
+			var change string
+			switch {
+			case !inBefore:
+				change = "added"
+			case !inAfter:
+				change = "removed"
+			case !bytes.Equal(a, b):
+				change = "changed"
+			default:
+				continue
+			}
+			n++
+			name := filepath.Join(out.path, filepath.FromSlash(path))
+			fmt.Fprintf(w, "%-7s %s\n", change, name)
+			if showDiff {
+				aName, bName := name, name
+				if !inBefore {
+					aName = os.DevNull
+				}
+				if !inAfter {
+					bName = os.DevNull
+				}
+				fmt.Fprint(w, textdiff.Unified(aName, bName, string(a), string(b), 3))
+			}
+		}
+	}
+	if n == 0 {
+		fmt.Fprintln(w, "No changes to compiled output.")
+	}
+	return nil
+}
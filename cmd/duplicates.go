@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/compiler"
+	"github.com/snyk/vervet/internal/schemadup"
+)
+
+// DuplicateSchemas reports schemas with the same structure declared in
+// several places across the resources and versions of a project.
+func DuplicateSchemas(ctx *cli.Context) error {
+	projectDir, configFile, err := projectConfig(ctx)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(configFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	proj, err := config.Load(f)
+	if err != nil {
+		return err
+	}
+	err = os.Chdir(projectDir)
+	if err != nil {
+		return err
+	}
+	documentOptions, err := compiler.DocumentOptions(proj)
+	if err != nil {
+		return err
+	}
+	finder := schemadup.New(ctx.Int("min-properties"))
+	for _, apiName := range proj.APINames() {
+		for rcIndex, rcConfig := range proj.APIs[apiName].Resources {
+			specFiles, err := compiler.ResourceSpecFiles(rcConfig)
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.resources[%d])", err, apiName, rcIndex)
+			}
+			specVersions, err := vervet.LoadSpecVersionsFileset(specFiles, documentOptions...)
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.resources[%d])", err, apiName, rcIndex)
+			}
+			for _, rv := range specVersions.Resources() {
+				for _, version := range rv.Versions() {
+					rc, err := rv.At(version.String())
+					if err != nil {
+						return err
+					}
+					finder.AddResource(apiName, rv.Name(), rc)
+				}
+			}
+		}
+	}
+	groups := finder.Duplicates()
+	if len(groups) == 0 {
+		fmt.Fprintln(ctx.App.Writer, "No duplicate schemas found.")
+		return nil
+	}
+	for i, g := range groups {
+		if i > 0 {
+			fmt.Fprintln(ctx.App.Writer)
+		}
+		fmt.Fprintf(ctx.App.Writer, "%s: %d properties, declared in %d places:\n",
+			g.Fingerprint, g.Properties, len(g.Locations))
+		for _, loc := range g.Locations {
+			fmt.Fprintf(ctx.App.Writer, "  %s\n", loc)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package cmd_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/cmd"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestDuplicateSchemas(t *testing.T) {
+	c := qt.New(t)
+	cd(c, testdata.Path("."))
+	var out bytes.Buffer
+	c.Patch(&cmd.App.Writer, &out)
+	err := cmd.App.Run([]string{"vervet", "duplicate-schemas"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Matches, `(?s)[0-9a-f]{12}: 3 properties, declared in 2 places:
+  testdata hello-world GET /examples/hello-world/\{id\} 200 response \(.*\)
+  testdata hello-world POST /examples/hello-world 201 response \(2021-06-13~beta\)
+`)
+
+	out.Reset()
+	err = cmd.App.Run([]string{"vervet", "duplicate-schemas", "--min-properties", "4"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Equals, "No duplicate schemas found.\n")
+}
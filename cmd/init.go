@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+)
+
+// initSettings are the choices made when initializing a project.
+type initSettings struct {
+	API       string
+	Resources string
+	Output    string
+}
+
+// initPrompts are the settings asked for when initializing a project
+// interactively, with the flag which sets each instead and its default.
+var initPrompts = []struct {
+	flag, prompt, value string
+	setting             func(*initSettings) *string
+}{{
+	flag: "api", prompt: "API name", value: "my-api",
+	setting: func(s *initSettings) *string { return &s.API },
+}, {
+	flag: "resources", prompt: "Resources directory", value: "resources",
+	setting: func(s *initSettings) *string { return &s.Resources },
+}, {
+	flag: "output", prompt: "Output directory for compiled specs", value: "versions",
+	setting: func(s *initSettings) *string { return &s.Output },
+}}
+
+// initConfigTemplate renders the project configuration of a new project. It
+// lints resources with built-in rules, so that nothing else needs to be
+// installed to get started, and generates a starter spec for each new
+// resource version.
+var initConfigTemplate = template.Must(template.New(vervet.ProjectConfigFile).Parse(`
+linters:
+  resource-rules:
+    vervet-rules: {}
+
+generators:
+  version-spec:
+    scope: version
+    filename: "{{ .Resources }}/{{ "{{" }} .Resource {{ "}}" }}/{{ "{{" }} .Version {{ "}}" }}/spec.yaml"
+    template: ".vervet/resource/version/spec.yaml.tmpl"
+
+apis:
+  {{ .API }}:
+    resources:
+      - path: "{{ .Resources }}"
+        linter: resource-rules
+        generators:
+          - version-spec
+    output:
+      path: "{{ .Output }}"
+`[1:]))
+
+// initSpecTemplateFile is the generator template for the spec of a new
+// resource version.
+const initSpecTemplateFile = ".vervet/resource/version/spec.yaml.tmpl"
+
+const initSpecTemplate = `openapi: 3.0.3
+{{ if .Stability -}}
+x-snyk-api-stability: {{ .Stability }}
+{{ end -}}
+info:
+  title: {{ .Resource }}
+  version: 3.0.0
+paths:
+  /{{ .Resource }}:
+    get:
+      description: List instances of {{ .Resource }}
+      operationId: list{{ .Resource|capitalize }}
+      responses:
+        '200':
+          description: Returns a list of {{ .Resource }} instances
+  /{{ .Resource }}/{{ "{" }}{{ .Resource|uncapitalize }}Id{{ "}" }}:
+    get:
+      description: Get an instance of {{ .Resource }}
+      operationId: get{{ .Resource|capitalize }}
+      parameters:
+        - name: {{ .Resource|uncapitalize }}Id
+          in: path
+          required: true
+          description: Unique identifier for {{ .Resource }} instances
+          schema:
+            type: string
+      responses:
+        '200':
+          description: Returns an instance of {{ .Resource }}
+`
+
+// Init creates a new project: a project configuration with an API, its
+// resources and a generator for new resource versions, and the directories
+// of the API. Settings not given as flags are asked for interactively,
+// unless --yes accepts their defaults.
+func Init(ctx *cli.Context) error {
+	projectDir := ctx.Args().Get(0)
+	if projectDir == "" {
+		projectDir = "."
+	}
+	configFile := filepath.Join(projectDir, vervet.ProjectConfigFile)
+	if _, err := os.Stat(configFile); err == nil && !ctx.Bool("force") {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", configFile)
+	}
+
+	var settings initSettings
+	in := bufio.NewScanner(ctx.App.Reader)
+	for _, p := range initPrompts {
+		value := p.value
+		if ctx.IsSet(p.flag) {
+			value = ctx.String(p.flag)
+		} else if !ctx.Bool("yes") {
+			fmt.Fprintf(ctx.App.Writer, "%s [%s]: ", p.prompt, p.value)
+			if in.Scan() {
+				if answer := strings.TrimSpace(in.Text()); answer != "" {
+					value = answer
+				}
+			}
+		}
+		*p.setting(&settings) = value
+	}
+
+	var buf strings.Builder
+	err := initConfigTemplate.Execute(&buf, &settings)
+	if err != nil {
+		return err
+	}
+	// The configuration is checked before anything is written, so that
+	// invalid settings leave nothing behind.
+	if _, err := config.Load(strings.NewReader(buf.String())); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+	files := []struct {
+		path, contents string
+	}{
+		{vervet.ProjectConfigFile, buf.String()},
+		{initSpecTemplateFile, initSpecTemplate},
+	}
+	for _, f := range files {
+		path := filepath.Join(projectDir, filepath.FromSlash(f.path))
+		err := os.MkdirAll(filepath.Dir(path), 0777)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(path, []byte(f.contents), 0666)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(ctx.App.Writer, "Created %s\n", f.path)
+	}
+	for _, dir := range []string{settings.Resources, settings.Output} {
+		err := os.MkdirAll(filepath.Join(projectDir, dir), 0777)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(ctx.App.Writer, "Created %s/\n", filepath.ToSlash(filepath.Clean(dir)))
+	}
+	writeInitNextSteps(ctx.App.Writer, &settings)
+	return nil
+}
+
+func writeInitNextSteps(w io.Writer, settings *initSettings) {
+	fmt.Fprintf(w, `
+Next, create a version of a resource:
+  vervet version new %s <resource>
+Then compile the API into %s:
+  vervet compile
+`, settings.API, settings.Output)
+}
@@ -0,0 +1,58 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/cmd"
+)
+
+func TestInit(t *testing.T) {
+	c := qt.New(t)
+	projectDir := c.Mkdir()
+	cd(c, projectDir)
+	var out bytes.Buffer
+	c.Patch(&cmd.App.Writer, &out)
+	c.Patch(&cmd.App.Reader, strings.NewReader("things\n\n"))
+	err := cmd.App.Run([]string{"vervet", "init", "--output", "compiled"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Equals, `API name [my-api]: Resources directory [resources]: Created .vervet.yaml
+Created .vervet/resource/version/spec.yaml.tmpl
+Created resources/
+Created compiled/
+
+Next, create a version of a resource:
+  vervet version new things <resource>
+Then compile the API into compiled:
+  vervet compile
+`)
+	buf, err := ioutil.ReadFile(filepath.Join(projectDir, ".vervet.yaml"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Contains, "\n  things:\n    resources:\n      - path: \"resources\"\n")
+	c.Assert(string(buf), qt.Contains, "\n      path: \"compiled\"\n")
+
+	// The new project is ready to create, lint and compile resource versions.
+	err = cmd.App.Run([]string{"vervet", "version", "new", "--version", "2021-06-01", "--stability", "ga", "things", "widgets"})
+	c.Assert(err, qt.IsNil)
+	_, err = os.Stat(filepath.Join(projectDir, "resources", "widgets", "2021-06-01", "spec.yaml"))
+	c.Assert(err, qt.IsNil)
+	err = cmd.App.Run([]string{"vervet", "compile"})
+	c.Assert(err, qt.IsNil)
+	buf, err = ioutil.ReadFile(filepath.Join(projectDir, "compiled", "2021-06-01", "spec.yaml"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Contains, "operationId: listWidgets\n")
+
+	// An existing project is not overwritten unless forced.
+	err = cmd.App.Run([]string{"vervet", "init", "--yes"})
+	c.Assert(err, qt.ErrorMatches, `.vervet.yaml already exists, use --force to overwrite it`)
+	out.Reset()
+	err = cmd.App.Run([]string{"vervet", "init", "--yes", "--force"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Contains, "vervet version new my-api <resource>\n")
+}
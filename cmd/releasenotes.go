@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/compiler"
+	"github.com/snyk/vervet/internal/releasenotes"
+)
+
+// ReleaseNotes renders notes on the resource versions released in a project
+// since a date or git tag, grouped by API and stability.
+func ReleaseNotes(ctx *cli.Context) error {
+	projectDir, configFile, err := projectConfig(ctx)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(configFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	proj, err := config.Load(f)
+	if err != nil {
+		return err
+	}
+	templateText := releasenotes.DefaultTemplate
+	if templateFile := ctx.String("template"); templateFile != "" {
+		buf, err := ioutil.ReadFile(templateFile)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		templateText = string(buf)
+	}
+	tmpl, err := releasenotes.NewTemplate(templateText)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	err = os.Chdir(projectDir)
+	if err != nil {
+		return err
+	}
+	since, err := sinceDate(ctx.String("since"))
+	if err != nil {
+		return err
+	}
+	documentOptions, err := compiler.DocumentOptions(proj)
+	if err != nil {
+		return err
+	}
+	notes := &releasenotes.Notes{Since: ctx.String("since")}
+	for _, apiName := range proj.APINames() {
+		var resources []*vervet.ResourceVersions
+		for rcIndex, rcConfig := range proj.APIs[apiName].Resources {
+			specFiles, err := compiler.ResourceSpecFiles(rcConfig)
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.resources[%d])", err, apiName, rcIndex)
+			}
+			specVersions, err := vervet.LoadSpecVersionsFileset(specFiles, documentOptions...)
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.resources[%d])", err, apiName, rcIndex)
+			}
+			resources = append(resources, specVersions.Resources()...)
+		}
+		err = notes.AddAPI(apiName, resources, since)
+		if err != nil {
+			return fmt.Errorf("%w (apis.%s)", err, apiName)
+		}
+	}
+	return notes.Write(ctx.App.Writer, tmpl)
+}
+
+// sinceDate returns the date of a YYYY-mm-dd date string, or of the commit
+// a git tag refers to.
+func sinceDate(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, fmt.Errorf("--since is required")
+	}
+	if t, err := time.Parse("2006-01-02", since); err == nil {
+		return t, nil
+	}
+	out, err := exec.Command("git", "log", "-1", "--format=%cI", since, "--").Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date (YYYY-mm-dd) or git tag: %w", since, err)
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse date of %q: %w", since, err)
+	}
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
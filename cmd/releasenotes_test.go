@@ -0,0 +1,71 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/cmd"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestReleaseNotes(t *testing.T) {
+	c := qt.New(t)
+	cd(c, testdata.Path("."))
+	templateFile := filepath.Join(c.Mkdir(), "notes.tmpl")
+	c.Assert(ioutil.WriteFile(templateFile, []byte(
+		`{{ range .APIs }}{{ range .Stabilities }}{{ range .Releases }}{{ .Resource }} {{ .Version }}
+{{ end }}{{ end }}{{ end }}`), 0666), qt.IsNil)
+	var out bytes.Buffer
+	c.Patch(&cmd.App.Writer, &out)
+	err := cmd.App.Run([]string{"vervet", "release-notes", "--since", "2021-06-04", "--template", templateFile})
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Equals, "hello-world 2021-06-07\nhello-world 2021-06-13~beta\n")
+}
+
+func TestReleaseNotesSinceTag(t *testing.T) {
+	c := qt.New(t)
+	if _, err := exec.LookPath("git"); err != nil {
+		c.Skip("git not found")
+	}
+	projectDir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(projectDir, ".vervet.yaml"), []byte(`
+apis:
+  testdata:
+    resources:
+      - path: `+testdata.Path("resources")+`
+        excludes:
+          - '`+testdata.Path("resources/schemas")+`/**'
+`[1:]), 0666), qt.IsNil)
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = projectDir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_AUTHOR_DATE=2021-06-05T12:00:00Z", "GIT_COMMITTER_DATE=2021-06-05T12:00:00Z")
+		out, err := cmd.CombinedOutput()
+		c.Assert(err, qt.IsNil, qt.Commentf("%s", out))
+	}
+	git("init", "-q")
+	git("add", ".vervet.yaml")
+	git("commit", "-q", "-m", "release")
+	git("tag", "v1.0.0")
+	cd(c, projectDir)
+
+	var out bytes.Buffer
+	c.Patch(&cmd.App.Writer, &out)
+	err := cmd.App.Run([]string{"vervet", "release-notes", "--since", "v1.0.0"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(out.String(), qt.Contains, "# testdata changes since v1.0.0\n")
+	c.Assert(out.String(), qt.Contains, "### hello-world 2021-06-07\n")
+	c.Assert(out.String(), qt.Not(qt.Contains), "projects")
+
+	err = cmd.App.Run([]string{"vervet", "release-notes", "--since", "nope"})
+	c.Assert(err, qt.ErrorMatches, `"nope" is not a date \(YYYY-mm-dd\) or git tag: .*`)
+}
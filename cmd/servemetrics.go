@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet/internal/projectmetrics"
+)
+
+// ServeMetrics is a command that serves Prometheus gauges describing the
+// resource versions in a project, such as how many are deprecated, so that
+// the hygiene of APIs across projects can be tracked on dashboards.
+func ServeMetrics(ctx *cli.Context) error {
+	projectDir := ctx.Args().Get(0)
+	if projectDir == "" {
+		projectDir = "."
+	}
+	projectDir, err := absPath(projectDir)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(projectDir); err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", projectmetrics.Handler(os.DirFS(projectDir)))
+	log.Printf("serving metrics for %s on %s/metrics", projectDir, ctx.String("listen"))
+	return http.ListenAndServe(ctx.String("listen"), mux)
+}
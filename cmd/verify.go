@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/snyk/vervet/internal/signing"
+)
+
+// Verify checks that compiled output was signed with the private key of the
+// given public key, and has not been changed since.
+func Verify(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return fmt.Errorf("expected an output directory to verify")
+	}
+	dir := ctx.Args().Get(0)
+	keyPath := ctx.String("key")
+	if keyPath == "" {
+		return fmt.Errorf("missing public key, use --key to locate it")
+	}
+	key, err := signing.LoadPublicKey(keyPath)
+	if err != nil {
+		return err
+	}
+	diffs, err := signing.Verify(dir, key)
+	if err != nil {
+		return fmt.Errorf("%w (%s)", err, dir)
+	}
+	for _, diff := range diffs {
+		fmt.Fprintln(ctx.App.Writer, diff)
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("%d files changed since %s was signed", len(diffs), dir)
+	}
+	fmt.Fprintf(ctx.App.Writer, "%s verified\n", dir)
+	return nil
+}
@@ -0,0 +1,56 @@
+package cmd_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/cmd"
+	"github.com/snyk/vervet/testdata"
+)
+
+// writeKeys writes a new ed25519 key pair, returning the paths of its private
+// and public keys.
+func writeKeys(c *qt.C) (privatePath, publicPath string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, qt.IsNil)
+	keyDir := c.Mkdir()
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	c.Assert(err, qt.IsNil)
+	privatePath = filepath.Join(keyDir, "key.pem")
+	c.Assert(ioutil.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600), qt.IsNil)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	c.Assert(err, qt.IsNil)
+	publicPath = filepath.Join(keyDir, "key.pub.pem")
+	c.Assert(ioutil.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644), qt.IsNil)
+	return privatePath, publicPath
+}
+
+func TestCompileSignVerify(t *testing.T) {
+	c := qt.New(t)
+	privatePath, publicPath := writeKeys(c)
+
+	dstDir := c.Mkdir()
+	err := cmd.App.Run([]string{"vervet", "compile", "--signing-key", privatePath, testdata.Path("resources"), dstDir})
+	c.Assert(err, qt.IsNil)
+
+	var buf bytes.Buffer
+	c.Patch(&cmd.App.Writer, &buf)
+	err = cmd.App.Run([]string{"vervet", "verify", "--key", publicPath, dstDir})
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, dstDir+" verified\n")
+
+	// Hand edits to signed output fail verification.
+	buf.Reset()
+	c.Assert(ioutil.WriteFile(filepath.Join(dstDir, "2021-06-01", "spec.json"), []byte(`{}`), 0644), qt.IsNil)
+	err = cmd.App.Run([]string{"vervet", "verify", "--key", publicPath, dstDir})
+	c.Assert(err, qt.ErrorMatches, `1 files changed since .* was signed`)
+	c.Assert(buf.String(), qt.Equals, "2021-06-01/spec.json: modified\n")
+}
@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
-	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
@@ -14,7 +16,8 @@ import (
 	"github.com/snyk/vervet"
 	"github.com/snyk/vervet/config"
 	"github.com/snyk/vervet/internal/compiler"
-	"github.com/snyk/vervet/internal/generator"
+	"github.com/snyk/vervet/internal/scratch"
+	"github.com/snyk/vervet/versions"
 )
 
 // VersionList is a command that lists all the versions of matching resources.
@@ -37,6 +40,10 @@ func VersionList(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	documentOptions, err := compiler.DocumentOptions(proj)
+	if err != nil {
+		return err
+	}
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetHeader([]string{"API", "Resource", "Version", "Path", "Method", "Operation"})
 	for _, apiName := range proj.APINames() {
@@ -49,7 +56,7 @@ func VersionList(ctx *cli.Context) error {
 			if err != nil {
 				return err
 			}
-			specVersions, err := vervet.LoadSpecVersionsFileset(specFiles)
+			specVersions, err := vervet.LoadSpecVersionsFileset(specFiles, documentOptions...)
 			if err != nil {
 				return err
 			}
@@ -119,7 +126,14 @@ func VersionFiles(ctx *cli.Context) error {
 			continue
 		}
 		api := proj.APIs[apiName]
-		for _, rcConfig := range api.Resources {
+		for rcIndex, rcConfig := range api.Resources {
+			if ctx.Bool("explain") {
+				err := explainResourceSpecFiles(ctx, apiName, rcIndex, rcConfig)
+				if err != nil {
+					return err
+				}
+				continue
+			}
 			specFiles, err := compiler.ResourceSpecFiles(rcConfig)
 			if err != nil {
 				return err
@@ -137,6 +151,42 @@ func VersionFiles(ctx *cli.Context) error {
 	return nil
 }
 
+// explainResourceSpecFiles prints each spec file found in a resource set,
+// whether it was included or excluded, and which exclude pattern excluded
+// it. Exclude patterns which do not match any spec file are reported, as
+// these are likely to be mistakes.
+func explainResourceSpecFiles(ctx *cli.Context, apiName string, rcIndex int, rcConfig *config.ResourceSet) error {
+	specFiles, err := compiler.ExplainResourceSpecFiles(rcConfig)
+	if err != nil {
+		return err
+	}
+	sort.Slice(specFiles, func(i, j int) bool { return specFiles[i].Path < specFiles[j].Path })
+	excludeMatched := make([]bool, len(rcConfig.Excludes))
+	for i := range specFiles {
+		if specFiles[i].Excluded() {
+			excludeMatched[specFiles[i].ExcludeIndex] = true
+		}
+		rcName := filepath.Base(filepath.Dir(filepath.Dir(specFiles[i].Path)))
+		if rcArg := ctx.Args().Get(1); rcArg != "" && rcArg != rcName {
+			continue
+		}
+		if specFiles[i].Excluded() {
+			fmt.Printf("exclude %s: matched %q (apis.%s.resources[%d].excludes[%d])\n",
+				specFiles[i].Path, rcConfig.Excludes[specFiles[i].ExcludeIndex],
+				apiName, rcIndex, specFiles[i].ExcludeIndex)
+		} else {
+			fmt.Printf("include %s\n", specFiles[i].Path)
+		}
+	}
+	for i := range excludeMatched {
+		if !excludeMatched[i] {
+			fmt.Printf("warning: %q did not match any spec files (apis.%s.resources[%d].excludes[%d])\n",
+				rcConfig.Excludes[i], apiName, rcIndex, i)
+		}
+	}
+	return nil
+}
+
 type specVersionKey struct {
 	API      string
 	Resource string
@@ -158,20 +208,17 @@ func VersionNew(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	var options []generator.Option
-	if ctx.Bool("force") {
-		options = append(options, generator.Force(true))
-	}
-	if ctx.Bool("debug") {
-		options = append(options, generator.Debug(true))
+	options := []versions.Option{
+		versions.Force(ctx.Bool("force")),
+		versions.Debug(ctx.Bool("debug")),
 	}
-	generators, err := generator.NewMap(proj, options...)
-	if err != nil {
-		return err
-	}
-	err = os.Chdir(projectDir)
-	if err != nil {
-		return err
+	if ctx.Bool("debug-templates") {
+		debugDir, err := ioutil.TempDir(scratch.Dir(), scratch.Prefix+"templates-")
+		if err != nil {
+			return err
+		}
+		log.Printf("writing rendered templates to %s", debugDir)
+		options = append(options, versions.DebugTemplates(debugDir))
 	}
 	apiName, resourceName := ctx.Args().Get(0), ctx.Args().Get(1)
 	if apiName == "" || resourceName == "" {
@@ -188,36 +235,20 @@ func VersionNew(ctx *cli.Context) error {
 `+"`%s api new %s <resource path>`"+` to start a new API`,
 			apiName, strings.Join(apiNames, ", "), os.Args[0], apiName)
 	}
-	if len(api.Resources) == 0 {
+	if ok && len(api.Resources) == 0 {
 		return fmt.Errorf(`API %q does not seem to have a resource set defined.
 Please add a `+"`resources:`"+` section to
 %q and try again`, apiName, configFile)
 	}
-
-	versionTime, err := time.Parse("2006-01-02", ctx.String("version"))
-	if err != nil {
-		return err
-	}
-	version := versionTime.Format("2006-01-02")
-	resourceDir := api.Resources[0].Path
-	versionDir := filepath.Join(resourceDir, resourceName, version)
-	err = os.MkdirAll(versionDir, 0777)
-	if err != nil {
-		return fmt.Errorf("failed to create version path %q: %w", versionDir, err)
-	}
-
-	for _, genName := range api.Resources[0].Generators {
-		gen := generators[genName]
-		context := &generator.VersionScope{
-			API:       apiName,
-			Resource:  resourceName,
-			Version:   version,
-			Stability: ctx.String("stability"),
-		}
-		err := gen.Run(context)
-		if err != nil {
-			return fmt.Errorf("%w (generators.%s)", err, genName)
-		}
-	}
-	return nil
+	_, err = versions.Create(projectDir, proj, &versions.Request{
+		API:       apiName,
+		Resource:  resourceName,
+		Version:   ctx.String("version"),
+		Stability: ctx.String("stability"),
+	}, options...)
+	var existsErr *versions.ExistsError
+	if errors.As(err, &existsErr) {
+		return fmt.Errorf("%w, use --force to overwrite it", err)
+	}
+	return err
 }
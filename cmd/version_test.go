@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
@@ -54,6 +55,30 @@ resources/projects/2021-06-04/spec.yaml
 `[1:])
 }
 
+func TestVersionFilesExplain(t *testing.T) {
+	c := qt.New(t)
+	tmp := c.Mkdir()
+	tmpFile := filepath.Join(tmp, "out")
+	c.Run("cmd", func(c *qt.C) {
+		output, err := os.Create(tmpFile)
+		c.Assert(err, qt.IsNil)
+		defer output.Close()
+		c.Patch(&os.Stdout, output)
+		cd(c, testdata.Path("."))
+		err = cmd.App.Run([]string{"vervet", "version", "files", "--explain"})
+		c.Assert(err, qt.IsNil)
+	})
+	out, err := ioutil.ReadFile(tmpFile)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(out), qt.Equals, `
+include resources/_examples/hello-world/2021-06-01/spec.yaml
+include resources/_examples/hello-world/2021-06-07/spec.yaml
+include resources/_examples/hello-world/2021-06-13/spec.yaml
+include resources/projects/2021-06-04/spec.yaml
+warning: "resources/schemas/**" did not match any spec files (apis.testdata.resources[0].excludes[0])
+`[1:])
+}
+
 func TestVersionList(t *testing.T) {
 	c := qt.New(t)
 	tmp := c.Mkdir()
@@ -106,3 +131,195 @@ func TestVersionNew(t *testing.T) {
 	c.Assert(err, qt.IsNil)
 	c.Assert(rc.Paths, qt.HasLen, 2)
 }
+
+const versionNewConfig = `
+generators:
+  version-spec:
+    scope: version
+    filename: "resources/{{ .Resource }}/{{ .Version }}/spec.yaml"
+    template: "spec.yaml.tmpl"
+apis:
+  test:
+    resources:
+      - path: resources
+        generators:
+          - version-spec
+`
+
+func TestVersionNewStability(t *testing.T) {
+	c := qt.New(t)
+	projectDir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(projectDir, ".vervet.yaml"), []byte(versionNewConfig), 0666), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(projectDir, "spec.yaml.tmpl"), []byte(`
+openapi: 3.0.3
+info:
+  title: {{ .Resource }}
+  version: 3.0.0
+paths: {}
+`[1:]), 0666), qt.IsNil)
+	cd(c, projectDir)
+
+	err := cmd.App.Run([]string{"vervet", "version", "new", "--version", "2021-10-01", "--stability", "stable", "test", "foo"})
+	c.Assert(err, qt.ErrorMatches, `invalid stability "stable", expected one of wip, experimental, beta, ga`)
+
+	// Stability is declared in the spec, even though the template does not
+	err = cmd.App.Run([]string{"vervet", "version", "new", "--version", "2021-10-01", "--stability", "beta", "test", "foo"})
+	c.Assert(err, qt.IsNil)
+	buf, err := ioutil.ReadFile(filepath.Join(projectDir, "resources", "foo", "2021-10-01", "spec.yaml"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Equals, `
+x-snyk-api-stability: beta
+openapi: 3.0.3
+info:
+  title: foo
+  version: 3.0.0
+paths: {}
+`[1:])
+
+	err = cmd.App.Run([]string{"vervet", "version", "new", "--version", "2021-10-01", "--stability", "ga", "test", "foo"})
+	c.Assert(err, qt.ErrorMatches, `version 2021-10-01 of resource "foo" already exists in "resources/foo/2021-10-01", use --force to overwrite it`)
+
+	// Overwriting regenerates the spec with the new stability
+	err = cmd.App.Run([]string{"vervet", "version", "new", "--force", "--version", "2021-10-01", "--stability", "ga", "test", "foo"})
+	c.Assert(err, qt.IsNil)
+	buf, err = ioutil.ReadFile(filepath.Join(projectDir, "resources", "foo", "2021-10-01", "spec.yaml"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Matches, `x-snyk-api-stability: ga\n(?s).*`)
+}
+
+func TestVersionNewGranularity(t *testing.T) {
+	c := qt.New(t)
+	tests := []struct {
+		granularity, version string
+	}{
+		{"month", "2021-10"},
+		{"week", "2021-W41"},
+	}
+	for _, test := range tests {
+		c.Run(test.granularity, func(c *qt.C) {
+			projectDir := c.Mkdir()
+			c.Assert(ioutil.WriteFile(filepath.Join(projectDir, ".vervet.yaml"), []byte(versionNewConfig+`
+        granularity: `+test.granularity+`
+`), 0666), qt.IsNil)
+			c.Assert(ioutil.WriteFile(filepath.Join(projectDir, "spec.yaml.tmpl"), []byte(`
+openapi: 3.0.3
+info:
+  title: {{ .Resource }}
+  version: 3.0.0
+paths: {}
+`[1:]), 0666), qt.IsNil)
+			cd(c, projectDir)
+
+			// A date within the month or week is named for the month or week.
+			err := cmd.App.Run([]string{"vervet", "version", "new", "--version", "2021-10-14", "test", "foo"})
+			c.Assert(err, qt.IsNil)
+			_, err = os.Stat(filepath.Join(projectDir, "resources", "foo", test.version, "spec.yaml"))
+			c.Assert(err, qt.IsNil)
+		})
+	}
+}
+
+func TestVersionNewSemver(t *testing.T) {
+	c := qt.New(t)
+	projectDir := c.Mkdir()
+	conf := strings.Replace(versionNewConfig, "  test:\n", "  test:\n    versioning: semver\n", 1)
+	c.Assert(ioutil.WriteFile(filepath.Join(projectDir, ".vervet.yaml"), []byte(conf), 0666), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(projectDir, "spec.yaml.tmpl"), []byte(`
+openapi: 3.0.3
+info:
+  title: {{ .Resource }}
+  version: 3.0.0
+paths: {}
+`[1:]), 0666), qt.IsNil)
+	cd(c, projectDir)
+
+	err := cmd.App.Run([]string{"vervet", "version", "new", "--version", "v1.2", "test", "foo"})
+	c.Assert(err, qt.IsNil)
+	_, err = os.Stat(filepath.Join(projectDir, "resources", "foo", "v1.2", "spec.yaml"))
+	c.Assert(err, qt.IsNil)
+
+	err = cmd.App.Run([]string{"vervet", "version", "new", "--version", "2021-10-14", "test", "foo"})
+	c.Assert(err, qt.ErrorMatches, `invalid version "2021-10-14", API "test" is versioned semantically \(v1, v1.2\)`)
+}
+
+const versionNewReadmeConfig = `
+generators:
+  version-spec:
+    scope: version
+    filename: "resources/{{ .Resource }}/{{ .Version }}/spec.yaml"
+    template: "spec.yaml.tmpl"
+  readme:
+    builtin: resource-readme
+    filename: "resources/{{ .Resource }}/README.md"
+apis:
+  test:
+    resources:
+      - path: resources
+        generators:
+          - version-spec
+          - readme
+`
+
+func TestVersionNewReadme(t *testing.T) {
+	c := qt.New(t)
+	projectDir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(projectDir, ".vervet.yaml"), []byte(versionNewReadmeConfig), 0666), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(projectDir, "spec.yaml.tmpl"), []byte(`
+openapi: 3.0.3
+info:
+  title: {{ .Resource }}
+  version: 3.0.0
+paths:
+  /{{ .Resource }}:
+    get:
+      operationId: list{{ .Resource | capitalize }}
+      summary: List {{ .Resource }}
+      responses:
+        '200':
+          description: OK
+{{- if eq .Stability "ga" }}
+    post:
+      operationId: create{{ .Resource | capitalize }}
+      responses:
+        '201':
+          description: Created
+{{- end }}
+`[1:]), 0666), qt.IsNil)
+	cd(c, projectDir)
+
+	err := cmd.App.Run([]string{"vervet", "version", "new", "--version", "2021-10-01", "--stability", "beta", "test", "things"})
+	c.Assert(err, qt.IsNil)
+	err = cmd.App.Run([]string{"vervet", "version", "new", "--version", "2021-11-01", "--stability", "ga", "test", "things"})
+	c.Assert(err, qt.IsNil)
+	buf, err := ioutil.ReadFile(filepath.Join(projectDir, "resources", "things", "README.md"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Equals, "# things\n"+`
+<!-- Generated by vervet from the resource's versions. Do not edit. -->
+
+## Operations
+
+As of version 2021-11-01:
+
+| Method | Path | Operation | Summary |
+|---|---|---|---|
+| GET | `+"`/things`"+` | listThings | List things |
+| POST | `+"`/things`"+` | createThings |  |
+
+## Versions
+
+| Version | Stability | Date |
+|---|---|---|
+| 2021-10-01~beta | beta | 2021-10-01 |
+| 2021-11-01 | ga | 2021-11-01 |
+
+## Recent changes
+
+### 2021-11-01
+
+* Added `+"`POST /things`"+`
+
+### 2021-10-01~beta
+
+* Added `+"`GET /things`"+`
+`)
+}
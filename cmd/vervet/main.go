@@ -1,14 +1,31 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/snyk/vervet/cmd"
+	"github.com/snyk/vervet/internal/scratch"
 )
 
 func main() {
-	err := cmd.App.Run(os.Args)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// When interrupted, stop any linters still running and remove temporary
+	// files before exiting.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		cancel()
+		scratch.Cleanup()
+		log.Fatalf("%v", sig)
+	}()
+	err := cmd.App.RunContext(ctx, os.Args)
+	scratch.Cleanup()
 	if err != nil {
 		log.Fatal(err)
 	}
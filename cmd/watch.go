@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/snyk/vervet/config"
+)
+
+// watchDebounce is how long to wait for changes to settle before rebuilding,
+// so that an editor saving several files, or a file in several writes, only
+// rebuilds once.
+var watchDebounce = 200 * time.Millisecond
+
+// watchSource is a resource set directory or overlay file an API is built
+// from.
+type watchSource struct {
+	api  string
+	path string
+	dir  bool
+}
+
+// contains returns whether a changed file is part of the source.
+func (s *watchSource) contains(path string) bool {
+	if !s.dir {
+		return path == s.path
+	}
+	return path == s.path || strings.HasPrefix(path, s.path+string(filepath.Separator))
+}
+
+// watchSources returns the resource set directories and overlay files each
+// API in a project is built from, and the output directories to ignore
+// changes in.
+func watchSources(project *config.Project) (sources []*watchSource, outputs []string, err error) {
+	for _, apiName := range project.APINames() {
+		api := project.APIs[apiName]
+		for _, rcConfig := range api.Resources {
+			path, err := filepath.Abs(rcConfig.Path)
+			if err != nil {
+				return nil, nil, err
+			}
+			sources = append(sources, &watchSource{api: apiName, path: path, dir: true})
+		}
+		for _, overlay := range api.Overlays {
+			if overlay.Include == "" {
+				continue
+			}
+			path, err := filepath.Abs(overlay.Include)
+			if err != nil {
+				return nil, nil, err
+			}
+			sources = append(sources, &watchSource{api: apiName, path: path})
+		}
+		if api.Output != nil && api.Output.Path != "" {
+			path, err := filepath.Abs(api.Output.Path)
+			if err != nil {
+				return nil, nil, err
+			}
+			outputs = append(outputs, path)
+		}
+	}
+	return sources, outputs, nil
+}
+
+// watchProject watches the resources and overlays of a project, calling
+// rebuild with the names of the APIs built from those which changed. Results
+// are written to w. Watching stops when ctx is done.
+func watchProject(ctx context.Context, w io.Writer, project *config.Project, rebuild func(apiNames []string) error) error {
+	sources, outputs, err := watchSources(project)
+	if err != nil {
+		return err
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch for changes: %w", err)
+	}
+	defer fsw.Close()
+	for _, source := range sources {
+		if source.dir {
+			err = watchDirs(fsw, source.path)
+		} else {
+			// Editors often replace a file rather than writing to it, so
+			// the directory containing it is watched instead.
+			err = fsw.Add(filepath.Dir(source.path))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to watch %q: %w", source.path, err)
+		}
+	}
+	fmt.Fprintln(w, "Watching for changes...")
+
+	pending := map[string]bool{}
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Op == fsnotify.Chmod || inAny(ev.Name, outputs) {
+				continue
+			}
+			if ev.Op&fsnotify.Create != 0 {
+				if st, err := os.Stat(ev.Name); err == nil && st.IsDir() {
+					if err := watchDirs(fsw, ev.Name); err != nil {
+						log.Printf("failed to watch %q: %v", ev.Name, err)
+					}
+				}
+			}
+			for _, source := range sources {
+				if source.contains(ev.Name) {
+					pending[source.api] = true
+				}
+			}
+			if len(pending) > 0 {
+				settled = time.After(watchDebounce)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("watch error: %v", err)
+		case <-settled:
+			settled = nil
+			apiNames := make([]string, 0, len(pending))
+			for apiName := range pending {
+				apiNames = append(apiNames, apiName)
+			}
+			sort.Strings(apiNames)
+			pending = map[string]bool{}
+			start := time.Now()
+			if err := rebuild(apiNames); err != nil {
+				fmt.Fprintf(w, "Build failed: %v\n", err)
+			} else {
+				fmt.Fprintf(w, "Rebuilt%s in %s\n", apiList(apiNames), time.Since(start).Round(time.Millisecond))
+			}
+		}
+	}
+}
+
+// watchDirs watches a directory and all the directories beneath it.
+func watchDirs(fsw *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return fsw.Add(path)
+		}
+		return nil
+	})
+}
+
+func inAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// apiList returns the names of the APIs rebuilt for messages. A project
+// given on the command line has a single unnamed API, which is left out.
+func apiList(apiNames []string) string {
+	if len(apiNames) == 1 && apiNames[0] == "" {
+		return ""
+	}
+	return " " + strings.Join(apiNames, ", ")
+}
+
+// projectSubset returns a copy of a project with only the named APIs.
+func projectSubset(project *config.Project, apiNames []string) *config.Project {
+	subset := *project
+	subset.APIs = map[string]*config.API{}
+	for _, apiName := range apiNames {
+		subset.APIs[apiName] = project.APIs[apiName]
+	}
+	return &subset
+}
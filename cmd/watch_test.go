@@ -0,0 +1,99 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/cmd"
+)
+
+const watchSpec = `
+openapi: 3.0.3
+x-snyk-api-stability: ga
+info:
+  title: things
+  version: 3.0.0
+paths:
+  /things:
+    get:
+      operationId: listThings
+      description: %s
+      responses:
+        '200':
+          description: OK
+`
+
+// syncBuffer is a bytes.Buffer which may be written while it is read.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestCompileWatch(t *testing.T) {
+	c := qt.New(t)
+	resourcesDir, outputDir := c.Mkdir(), c.Mkdir()
+	specDir := filepath.Join(resourcesDir, "things", "2021-06-01")
+	c.Assert(os.MkdirAll(specDir, 0777), qt.IsNil)
+	writeSpec := func(description string) {
+		spec := strings.Replace(watchSpec[1:], "%s", description, 1)
+		c.Assert(ioutil.WriteFile(filepath.Join(specDir, "spec.yaml"), []byte(spec), 0666), qt.IsNil)
+	}
+	writeSpec("before")
+
+	var out syncBuffer
+	c.Patch(&cmd.App.Writer, &out)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.App.RunContext(ctx, []string{"vervet", "build", "--watch", "--lint=false", resourcesDir, outputDir})
+	}()
+	waitFor := func(what string, f func() bool) {
+		for deadline := time.Now().Add(10 * time.Second); !f(); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				c.Fatalf("timed out waiting for %s, output: %s", what, out.String())
+			}
+		}
+	}
+	compiled := func(description string) func() bool {
+		return func() bool {
+			buf, err := ioutil.ReadFile(filepath.Join(outputDir, "2021-06-01", "spec.yaml"))
+			return err == nil && strings.Contains(string(buf), "description: "+description)
+		}
+	}
+	waitFor("watch", func() bool { return strings.Contains(out.String(), "Watching for changes...\n") })
+	c.Assert(compiled("before")(), qt.IsTrue)
+
+	writeSpec("after")
+	waitFor("rebuild", compiled("after"))
+	waitFor("rebuild message", func() bool { return strings.Contains(out.String(), "Rebuilt in ") })
+
+	// Build failures are reported, and watching continues.
+	c.Assert(ioutil.WriteFile(filepath.Join(specDir, "spec.yaml"), []byte("paths: ["), 0666), qt.IsNil)
+	waitFor("build failure", func() bool { return strings.Contains(out.String(), "Build failed: ") })
+	writeSpec("fixed")
+	waitFor("rebuild after failure", compiled("fixed"))
+
+	cancel()
+	c.Assert(<-done, qt.IsNil)
+}
@@ -0,0 +1,252 @@
+package vervet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// CommonRequirements are the parameters every operation in a compiled spec
+// must have, and the headers every response must have, such as the headers
+// which report the version of the API served.
+type CommonRequirements struct {
+	headers    openapi3.Headers
+	parameters openapi3.Parameters
+
+	// components contains the components the headers and parameters refer
+	// to, which are added to specs they are added to.
+	components *openapi3.T
+}
+
+// LoadCommonRequirements loads the common headers and parameters required in
+// compiled specs. Headers are included from a reference to a document of
+// headers, as with ExtSnykIncludeHeaders, such as
+// "schemas/headers/common-response.yaml#/Common". Parameters are each given
+// by a reference to a parameter. References are relative to dir. Either may
+// be empty, to require no headers or parameters.
+func LoadCommonRequirements(dir, headersRef string, parameterRefs []string, options ...DocumentOption) (*CommonRequirements, error) {
+	var opts documentOptions
+	for i := range options {
+		options[i](&opts)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	// Headers and parameters are loaded the same way as those in resource
+	// specs, by referring to them from an operation in a document which is
+	// then localized.
+	headerRefs := map[string]interface{}{}
+	if headersRef != "" {
+		headers := openapi3.Headers{}
+		_, err := (&Document{}).LoadReference(dir, headersRef, &headers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load headers %q: %w", headersRef, err)
+		}
+		for name := range headers {
+			headerRefs[name] = map[string]string{"$ref": headersRef + "/" + name}
+		}
+	}
+	paramRefs := []interface{}{}
+	for _, ref := range parameterRefs {
+		paramRefs = append(paramRefs, map[string]string{"$ref": ref})
+	}
+	buf, err := json.Marshal(map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]string{"title": "common", "version": "0.0.0"},
+		"paths": map[string]interface{}{
+			"/": map[string]interface{}{
+				"get": map[string]interface{}{
+					"parameters": paramRefs,
+					"responses": map[string]interface{}{
+						"default": map[string]interface{}{
+							"description": "common",
+							"headers":     headerRefs,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	location := filepath.Join(dir, "common.json")
+	t, err := opts.newLoader().LoadFromDataWithPath(buf, &url.URL{Path: location})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load common headers and parameters: %w", err)
+	}
+	doc := &Document{T: t, path: location, url: &url.URL{Path: location}, options: opts}
+	err = Localize(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to localize common headers and parameters: %w", err)
+	}
+	op := t.Paths["/"].Get
+	return &CommonRequirements{
+		headers:    op.Responses["default"].Value.Headers,
+		parameters: op.Parameters,
+		components: t,
+	}, nil
+}
+
+// MarshalJSON implements json.Marshaler, so that the requirements may be
+// compared by their serialized form.
+func (r *CommonRequirements) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.components)
+}
+
+// Apply checks that each operation in a spec has the common parameters, and
+// that each of its responses has the common headers. If fix is set, those
+// missing are added, referring to components added to the spec. Otherwise,
+// an error is returned for the first one missing.
+//
+// Operations and responses are copied rather than modified when fixed, as
+// these may be shared with the resource documents the spec was merged from.
+func (r *CommonRequirements) Apply(doc *openapi3.T, fix bool) error {
+	// fixedResponses are the copies of component responses fixed so far, by
+	// reference.
+	fixedResponses := map[string]*openapi3.ResponseRef{}
+	fixed := false
+	for _, path := range sortedPaths(doc.Paths) {
+		pathItem := doc.Paths[path]
+		ops := pathItem.Operations()
+		var methods []string
+		for method := range ops {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		fixedOps := map[string]*openapi3.Operation{}
+		for _, method := range methods {
+			op := ops[method]
+			fixOp := func() *openapi3.Operation {
+				if fixedOp, ok := fixedOps[method]; ok {
+					return fixedOp
+				}
+				fixedOp := *op
+				fixedOp.Parameters = append(openapi3.Parameters(nil), op.Parameters...)
+				fixedOp.Responses = make(openapi3.Responses, len(op.Responses))
+				for k, v := range op.Responses {
+					fixedOp.Responses[k] = v
+				}
+				fixedOps[method] = &fixedOp
+				return &fixedOp
+			}
+			for _, required := range r.parameters {
+				if hasParameter(pathItem.Parameters, required.Value) || hasParameter(op.Parameters, required.Value) {
+					continue
+				}
+				if !fix {
+					return fmt.Errorf("%s %s: missing required %s parameter %q",
+						method, path, required.Value.In, required.Value.Name)
+				}
+				fixedOp := fixOp()
+				fixedOp.Parameters = append(fixedOp.Parameters, &openapi3.ParameterRef{Ref: required.Ref, Value: required.Value})
+			}
+			var statuses []string
+			for status := range op.Responses {
+				statuses = append(statuses, status)
+			}
+			sort.Strings(statuses)
+			for _, status := range statuses {
+				ref := op.Responses[status]
+				if ref == nil || ref.Value == nil {
+					continue
+				}
+				if fixedRef, ok := fixedResponses[ref.Ref]; ok && ref.Ref != "" {
+					fixOp().Responses[status] = fixedRef
+					continue
+				}
+				var missing []string
+				for _, name := range sortedHeaderNames(r.headers) {
+					if !hasHeader(ref.Value.Headers, name) {
+						missing = append(missing, name)
+					}
+				}
+				if len(missing) == 0 {
+					continue
+				}
+				if !fix {
+					return fmt.Errorf("%s %s: response %s missing required header %q", method, path, status, missing[0])
+				}
+				resp := *ref.Value
+				resp.Headers = make(openapi3.Headers, len(ref.Value.Headers)+len(missing))
+				for k, v := range ref.Value.Headers {
+					resp.Headers[k] = v
+				}
+				for _, name := range missing {
+					required := r.headers[name]
+					resp.Headers[name] = &openapi3.HeaderRef{Ref: required.Ref, Value: required.Value}
+				}
+				fixedRef := &openapi3.ResponseRef{Value: &resp}
+				if name := strings.TrimPrefix(ref.Ref, "#/components/responses/"); name != ref.Ref && doc.Components.Responses[name] != nil {
+					// Responses referred to are fixed in the components, so
+					// that every operation referring to them is fixed.
+					doc.Components.Responses[name] = &openapi3.ResponseRef{Value: &resp}
+					fixedRef.Ref = ref.Ref
+					fixedResponses[ref.Ref] = fixedRef
+				}
+				fixOp().Responses[status] = fixedRef
+			}
+		}
+		if len(fixedOps) > 0 {
+			fixedPathItem := *pathItem
+			for method, op := range fixedOps {
+				fixedPathItem.SetOperation(method, op)
+			}
+			doc.Paths[path] = &fixedPathItem
+			fixed = true
+		}
+	}
+	if fixed {
+		mergeComponents(doc, r.components, false)
+	}
+	return nil
+}
+
+func sortedPaths(paths openapi3.Paths) []string {
+	result := make([]string, 0, len(paths))
+	for path := range paths {
+		result = append(result, path)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func sortedHeaderNames(headers openapi3.Headers) []string {
+	result := make([]string, 0, len(headers))
+	for name := range headers {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// hasParameter returns whether params has a parameter with the name and
+// location of p. Header names are not case sensitive.
+func hasParameter(params openapi3.Parameters, p *openapi3.Parameter) bool {
+	for _, ref := range params {
+		if ref == nil || ref.Value == nil || ref.Value.In != p.In {
+			continue
+		}
+		if ref.Value.Name == p.Name || (p.In == openapi3.ParameterInHeader && strings.EqualFold(ref.Value.Name, p.Name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHeader returns whether headers has a header, which is not case
+// sensitive.
+func hasHeader(headers openapi3.Headers, name string) bool {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package vervet_test
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	. "github.com/snyk/vervet"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestCommonRequirements(t *testing.T) {
+	c := qt.New(t)
+	common, err := LoadCommonRequirements(testdata.Path("resources"),
+		"schemas/headers/common-response.yaml#/Common",
+		[]string{"schemas/parameters/version.yaml#/Version"})
+	c.Assert(err, qt.IsNil)
+
+	newSpec := func() *openapi3.T {
+		doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.3
+info: {title: test, version: 1.0.0}
+paths:
+  /things:
+    get:
+      parameters:
+        - {name: version, in: query, required: true, schema: {type: string}}
+      responses:
+        '200':
+          description: OK
+          headers:
+            Snyk-Version-Requested: {schema: {type: string}}
+            snyk-version-served: {schema: {type: string}}
+            snyk-request-id: {schema: {type: string}}
+    post:
+      responses:
+        '201':
+          description: Created
+`[1:]))
+		c.Assert(err, qt.IsNil)
+		return doc
+	}
+
+	doc := newSpec()
+	err = common.Apply(doc, false)
+	c.Assert(err, qt.ErrorMatches, `POST /things: missing required query parameter "version"`)
+
+	doc = newSpec()
+	origPost := doc.Paths["/things"].Post
+	err = common.Apply(doc, true)
+	c.Assert(err, qt.IsNil)
+	// Operations are copied rather than modified when fixed.
+	c.Assert(origPost.Parameters, qt.HasLen, 0)
+	c.Assert(origPost.Responses["201"].Value.Headers, qt.HasLen, 0)
+	post := doc.Paths["/things"].Post
+	c.Assert(post.Parameters, qt.HasLen, 1)
+	c.Assert(post.Parameters[0].Ref, qt.Equals, "#/components/parameters/Version")
+	c.Assert(post.Responses["201"].Value.Headers, qt.HasLen, 3)
+	c.Assert(post.Responses["201"].Value.Headers["snyk-request-id"].Ref, qt.Equals,
+		"#/components/headers/snyk-request-id")
+	// Headers are not case sensitive, so none are added to the existing
+	// operation.
+	c.Assert(doc.Paths["/things"].Get.Parameters, qt.HasLen, 1)
+	c.Assert(doc.Paths["/things"].Get.Responses["200"].Value.Headers, qt.HasLen, 3)
+	// Components referred to are added, and the spec is complete.
+	c.Assert(doc.Components.Parameters["Version"], qt.Not(qt.IsNil))
+	c.Assert(doc.Components.Headers["snyk-request-id"], qt.Not(qt.IsNil))
+	buf, err := doc.MarshalJSON()
+	c.Assert(err, qt.IsNil)
+	fixed, err := openapi3.NewLoader().LoadFromData(buf)
+	c.Assert(err, qt.IsNil)
+	c.Assert(fixed.Validate(context.Background()), qt.IsNil)
+	c.Assert(common.Apply(fixed, false), qt.IsNil)
+}
@@ -0,0 +1,171 @@
+package vervet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// CompiledAliasesFile is the name of the file in compiled output which indexes
+// versions that were not written because they compiled to the same spec as a
+// prior version. It contains a JSON object, mapping each such version to the
+// version whose spec it shares.
+const CompiledAliasesFile = "aliases.json"
+
+// CompiledVersionAliasesFile is the name of the file in compiled output which
+// indexes the named aliases of versions, such as "latest" or "stable". It
+// contains a JSON object, mapping each alias to the compiled version it was
+// resolved to when building.
+const CompiledVersionAliasesFile = "version-aliases.json"
+
+// compiledSpecFiles are the files which may contain a compiled OpenAPI spec in
+// each version directory of compiled output, in order of preference.
+var compiledSpecFiles = []string{"spec.json", "spec.yaml"}
+
+// LoadCompiledSpecVersionsFS returns SpecVersions loaded from the compiled
+// output of an API, as built by `vervet compile`. fsys must be rooted at the
+// output directory, which contains a subdirectory for each compiled version.
+//
+// Compiled specs are self-contained, so they may be embedded into a binary
+// and served without deploying any files alongside it:
+//
+//     //go:embed versions
+//     var versionsFS embed.FS
+//
+//     func loadSpecs() (*vervet.SpecVersions, error) {
+//         outputFS, err := fs.Sub(versionsFS, "versions")
+//         if err != nil {
+//             return nil, err
+//         }
+//         return vervet.LoadCompiledSpecVersionsFS(outputFS)
+//     }
+//
+// Versions resolve the same way as resource versions do: the latest compiled
+// version on or before the requested date, with a stability equal to or
+// greater than the requested stability. Named version aliases indexed in the
+// output are available from VersionAlias.
+func LoadCompiledSpecVersionsFS(fsys fs.FS) (*SpecVersions, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compiled output: %w", err)
+	}
+	var eps ResourceVersions
+	for _, entry := range entries {
+		version, err := ParseVersion(entry.Name())
+		if err != nil {
+			// Not a version directory
+			continue
+		}
+		doc, err := loadCompiledSpec(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			continue
+		}
+		eps.versions = append(eps.versions, &Resource{
+			Document:     doc,
+			Version:      version,
+			sourcePrefix: doc.path,
+		})
+	}
+	err = loadCompiledAliases(fsys, &eps)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(resourceVersionSlice(eps.versions))
+	svs := &SpecVersions{}
+	if len(eps.versions) > 0 {
+		svs.resources = append(svs.resources, &eps)
+	}
+	svs.versionAliases, err = loadCompiledVersionAliases(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return svs, nil
+}
+
+// loadCompiledSpec loads the compiled OpenAPI spec in versionDir, or returns
+// nil if the directory does not contain one.
+func loadCompiledSpec(fsys fs.FS, versionDir string) (*Document, error) {
+	for _, specFile := range compiledSpecFiles {
+		specPath := path.Join(versionDir, specFile)
+		buf, err := fs.ReadFile(fsys, specPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", specPath, err)
+		}
+		t, err := openapi3.NewLoader().LoadFromData(buf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %q: %w", specPath, err)
+		}
+		return &Document{T: t, path: specPath}, nil
+	}
+	return nil, nil
+}
+
+// loadCompiledAliases adds the versions indexed in the compiled aliases file,
+// if there is one, to eps. Each alias shares the spec of the version it
+// refers to.
+func loadCompiledAliases(fsys fs.FS, eps *ResourceVersions) error {
+	buf, err := fs.ReadFile(fsys, CompiledAliasesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read %q: %w", CompiledAliasesFile, err)
+	}
+	var aliases map[string]string
+	err = json.Unmarshal(buf, &aliases)
+	if err != nil {
+		return fmt.Errorf("failed to load %q: %w", CompiledAliasesFile, err)
+	}
+	docs := map[string]*Document{}
+	for _, rv := range eps.versions {
+		docs[rv.Version.String()] = rv.Document
+	}
+	for alias, target := range aliases {
+		version, err := ParseVersion(alias)
+		if err != nil {
+			return fmt.Errorf("invalid alias %q: %w (%s)", alias, err, CompiledAliasesFile)
+		}
+		doc, ok := docs[target]
+		if !ok {
+			return fmt.Errorf("alias %q refers to missing version %q (%s)", alias, target, CompiledAliasesFile)
+		}
+		eps.versions = append(eps.versions, &Resource{
+			Document:     doc,
+			Version:      version,
+			sourcePrefix: doc.path,
+		})
+	}
+	return nil
+}
+
+// loadCompiledVersionAliases loads the named version aliases indexed in the
+// compiled version aliases file, if there is one.
+func loadCompiledVersionAliases(fsys fs.FS) (map[string]string, error) {
+	buf, err := fs.ReadFile(fsys, CompiledVersionAliasesFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", CompiledVersionAliasesFile, err)
+	}
+	var versionAliases map[string]string
+	err = json.Unmarshal(buf, &versionAliases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %q: %w", CompiledVersionAliasesFile, err)
+	}
+	for alias, target := range versionAliases {
+		_, err := ParseVersion(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version alias %q: %w (%s)", alias, err, CompiledVersionAliasesFile)
+		}
+	}
+	return versionAliases, nil
+}
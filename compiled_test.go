@@ -0,0 +1,129 @@
+package vervet_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+
+	. "github.com/snyk/vervet"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestLoadCompiledSpecVersionsFS(t *testing.T) {
+	c := qt.New(t)
+	specs, err := LoadCompiledSpecVersionsFS(os.DirFS(testdata.Path("output")))
+	c.Assert(err, qt.IsNil)
+	c.Assert(specs.Versions(), qt.HasLen, 12)
+
+	tests := []struct {
+		query   string
+		paths   map[string]string
+		noMatch bool
+	}{{
+		query: "2021-06-05",
+		paths: map[string]string{
+			"/examples/hello-world/{id}": "2021-06-01",
+		},
+	}, {
+		query: "2021-06-05~experimental",
+		paths: map[string]string{
+			"/examples/hello-world/{id}": "2021-06-01",
+			"/orgs/{orgId}/projects":     "2021-06-04~experimental",
+		},
+	}, {
+		query: "2021-07-01~beta",
+		paths: map[string]string{
+			"/examples/hello-world":      "2021-06-13~beta",
+			"/examples/hello-world/{id}": "2021-06-13~beta",
+		},
+	}, {
+		query: "2021-07-01~wip",
+		paths: map[string]string{
+			"/examples/hello-world":      "2021-06-13~beta",
+			"/examples/hello-world/{id}": "2021-06-13~beta",
+			"/orgs/{orgId}/projects":     "2021-06-04~experimental",
+		},
+	}, {
+		query:   "2021-05-01",
+		noMatch: true,
+	}}
+	for i, t := range tests {
+		c.Logf("test#%d: %#v", i, t)
+		spec, err := specs.At(t.query)
+		if t.noMatch {
+			c.Assert(err, qt.Equals, ErrNoMatchingVersion)
+			continue
+		}
+		c.Assert(err, qt.IsNil)
+		// Compiled output also contains the /openapi paths from overlays.
+		c.Assert(spec.Paths, qt.HasLen, len(t.paths)+2)
+		for path, version := range t.paths {
+			c.Assert(spec.Paths[path], qt.Not(qt.IsNil))
+			pathVersion, err := ExtensionString(spec.Paths[path].ExtensionProps, ExtSnykApiVersion)
+			c.Assert(err, qt.IsNil)
+			c.Assert(pathVersion, qt.Equals, version)
+		}
+	}
+}
+
+func TestLoadCompiledSpecVersionsFSYAML(t *testing.T) {
+	c := qt.New(t)
+	yamlSpec, err := ioutil.ReadFile(testdata.Path("output/2021-06-01/spec.yaml"))
+	c.Assert(err, qt.IsNil)
+	specs, err := LoadCompiledSpecVersionsFS(fstest.MapFS{
+		"2021-06-01/spec.yaml": &fstest.MapFile{Data: yamlSpec},
+		"README.md":            &fstest.MapFile{Data: []byte("not a version")},
+		"not-a-version/foo":    &fstest.MapFile{Data: []byte("foo")},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(specs.Versions(), qt.HasLen, 1)
+	spec, err := specs.At("2021-06-02")
+	c.Assert(err, qt.IsNil)
+	c.Assert(spec.Paths["/examples/hello-world/{id}"], qt.Not(qt.IsNil))
+}
+
+func TestLoadCompiledSpecVersionsFSAliases(t *testing.T) {
+	c := qt.New(t)
+	jsonSpec, err := ioutil.ReadFile(testdata.Path("output/2021-06-01/spec.json"))
+	c.Assert(err, qt.IsNil)
+	specs, err := LoadCompiledSpecVersionsFS(fstest.MapFS{
+		"2021-06-01/spec.json": &fstest.MapFile{Data: jsonSpec},
+		CompiledAliasesFile:    &fstest.MapFile{Data: []byte(`{"2021-06-04": "2021-06-01"}`)},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(specs.Versions(), qt.ContentEquals, []*Version{
+		mustParseVersion("2021-06-01"),
+		mustParseVersion("2021-06-04"),
+	})
+	spec, err := specs.At("2021-06-05")
+	c.Assert(err, qt.IsNil)
+	c.Assert(spec.Paths["/examples/hello-world/{id}"], qt.Not(qt.IsNil))
+
+	_, err = LoadCompiledSpecVersionsFS(fstest.MapFS{
+		"2021-06-01/spec.json": &fstest.MapFile{Data: jsonSpec},
+		CompiledAliasesFile:    &fstest.MapFile{Data: []byte(`{"2021-06-04": "2021-06-02"}`)},
+	})
+	c.Assert(err, qt.ErrorMatches, `alias "2021-06-04" refers to missing version "2021-06-02" \(aliases.json\)`)
+}
+
+func TestLoadCompiledSpecVersionsFSVersionAliases(t *testing.T) {
+	c := qt.New(t)
+	specs, err := LoadCompiledSpecVersionsFS(os.DirFS(testdata.Path("output")))
+	c.Assert(err, qt.IsNil)
+	version, ok := specs.VersionAlias("latest")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(version, qt.Equals, "2021-06-13")
+	_, ok = specs.VersionAlias("2021-06-13")
+	c.Assert(ok, qt.IsFalse)
+
+	jsonSpec, err := ioutil.ReadFile(testdata.Path("output/2021-06-01/spec.json"))
+	c.Assert(err, qt.IsNil)
+	_, err = LoadCompiledSpecVersionsFS(fstest.MapFS{
+		"2021-06-01/spec.json":     &fstest.MapFile{Data: jsonSpec},
+		CompiledVersionAliasesFile: &fstest.MapFile{Data: []byte(`{"latest": "soon"}`)},
+	})
+	c.Assert(err, qt.ErrorMatches, `invalid version alias "latest": invalid version "soon" \(version-aliases.json\)`)
+}
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/ghodss/yaml"
@@ -15,7 +20,106 @@ type Project struct {
 	Version    string                `json:"version"`
 	Linters    map[string]*Linter    `json:"linters,omitempty"`
 	Generators map[string]*Generator `json:"generators,omitempty"`
+	RemoteRefs *RemoteRefs           `json:"remote-refs,omitempty"`
+	Extensions *Extensions           `json:"extensions,omitempty"`
+	Common     *Common               `json:"common,omitempty"`
+	Env        *Env                  `json:"env,omitempty"`
 	APIs       map[string]*API       `json:"apis"`
+
+	// PathCollisions checks that no path in the compiled specs of one API
+	// overlaps a path in those of another, for projects whose APIs are
+	// served under one host.
+	PathCollisions PathCollisions `json:"path-collisions,omitempty"`
+}
+
+// PathCollisions determines how paths which overlap across the compiled
+// specs of different APIs are reported.
+type PathCollisions string
+
+const (
+	// PathCollisionsIgnore does not check for path collisions. This is the
+	// default.
+	PathCollisionsIgnore PathCollisions = ""
+
+	// PathCollisionsWarn logs path collisions.
+	PathCollisionsWarn PathCollisions = "warn"
+
+	// PathCollisionsError fails the build on path collisions.
+	PathCollisionsError PathCollisions = "error"
+)
+
+// Common declares the headers and parameters which every operation in
+// compiled specs must have, such as the snyk-version-* response headers, so
+// that a resource which leaves them out is caught at build time.
+type Common struct {
+	// Headers is a reference, relative to the project, to a document of
+	// response headers as included with x-snyk-include-headers, such as
+	// "schemas/headers/common-response.yaml#/Common". Every response must
+	// have these headers.
+	Headers string `json:"headers,omitempty"`
+
+	// Parameters are references, relative to the project, to parameters
+	// every operation must have, such as
+	// "schemas/parameters/version.yaml#/Version".
+	Parameters []string `json:"parameters,omitempty"`
+
+	// Fix adds the headers and parameters an operation is missing to
+	// compiled specs, rather than failing the build.
+	Fix bool `json:"fix,omitempty"`
+}
+
+// Extensions declares the vendor extensions used in resource specs, in
+// addition to vervet's own x-snyk extensions, so that their values are
+// validated when resources are loaded.
+type Extensions struct {
+	// Schemas maps extension names to the file, relative to the project,
+	// containing the OpenAPI schema their values must match.
+	Schemas map[string]string `json:"schemas,omitempty"`
+
+	// Strict lists extension name prefixes, such as x-acme-, under which
+	// every extension must be declared. Undeclared extensions under these
+	// prefixes are errors, rather than being ignored.
+	Strict []string `json:"strict,omitempty"`
+}
+
+// Env controls which environment variables may be expanded in the project
+// configuration, such as in inline overlays and output servers. Without it,
+// any variable is expanded, which may publish one never meant to be in a spec.
+type Env struct {
+	// Allow lists the names of the variables which may be expanded. Referring
+	// to any other variable is an error.
+	Allow []string `json:"allow,omitempty"`
+}
+
+// IsAllowed returns whether an environment variable may be expanded.
+func (e *Env) IsAllowed(name string) bool {
+	if e == nil {
+		return true
+	}
+	for _, allowed := range e.Allow {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteRefs allows resource specs to reference remote documents, such as a
+// library of schemas shared across an organization. Remote references are
+// not resolved unless configured here.
+type RemoteRefs struct {
+	// Allow lists the hosts from which remote documents may be referenced. A
+	// leading "*." allows any subdomain of a host. Only https URLs are
+	// resolved.
+	Allow []string `json:"allow"`
+
+	// Cache is a directory, relative to the project, in which remote
+	// documents are cached once fetched.
+	Cache string `json:"cache,omitempty"`
+
+	// Pins maps remote document URLs to the sha256 digest of their expected
+	// contents, of the form "sha256:<hex>".
+	Pins map[string]string `json:"pins,omitempty"`
 }
 
 // Linter describes a set of standards and rules that an API should satisfy.
@@ -24,6 +128,7 @@ type Linter struct {
 	Description string             `json:"description,omitempty"`
 	Spectral    *SpectralLinter    `json:"spectral"`
 	SweaterComb *SweaterCombLinter `json:"sweater-comb"`
+	VervetRules *VervetRulesLinter `json:"vervet-rules"`
 }
 
 // SpectralLinter identifies a Linter as a collection of Spectral rulesets.
@@ -42,6 +147,15 @@ type SpectralLinter struct {
 	ExtraArgs []string `json:"extraArgs"`
 }
 
+// VervetRulesLinter identifies a Linter as a set of rules built into vervet,
+// which lint without running any external tools.
+type VervetRulesLinter struct {
+
+	// Rules are the names of the built-in rules to check. If not specified,
+	// all built-in rules are checked.
+	Rules []string `json:"rules"`
+}
+
 const defaultSweaterCombImage = "gcr.io/snyk-main/sweater-comb:latest"
 
 // SweaterCombLinter identifies a Sweater Comb Linter, which is distributed as
@@ -52,7 +166,9 @@ type SweaterCombLinter struct {
 
 	// Rules are a list of Spectral ruleset file locations
 	// These may be absolute paths to Sweater Comb rules, such as /rules/apinext.yaml.
-	// Or, they may be relative paths to files in this project.
+	// Or, they may be relative paths to files in this project. Local rules
+	// outside of the project are mounted into the container along with the
+	// rest of their directory.
 	Rules []string `json:"rules"`
 
 	// ExtraArgs may be used to pass extra arguments to `spectral lint`. The
@@ -62,15 +178,24 @@ type SweaterCombLinter struct {
 }
 
 // Generator describes how files are generated for a resource.
+//
+// A built-in generator provides its own template and data, so it only needs
+// a filename. Its template may still be replaced.
 type Generator struct {
 	Name     string                    `json:"-"`
 	Scope    GeneratorScope            `json:"scope"`
+	Builtin  string                    `json:"builtin,omitempty"`
 	Filename string                    `json:"filename,omitempty"`
 	Template string                    `json:"template"`
 	Files    string                    `json:"files,omitempty"`
 	Data     map[string]*GeneratorData `json:"data,omitempty"`
 }
 
+// GeneratorBuiltinResourceReadme is a built-in generator which renders a
+// README summarizing the versions, operations and recent changes of a
+// resource, with its history as the template data field History.
+const GeneratorBuiltinResourceReadme = "resource-readme"
+
 type GeneratorScope string
 
 const (
@@ -81,19 +206,109 @@ const (
 
 // GeneratorData describes an item that is added to a generator's template data
 // context.
+//
+// Include is a template which resolves to the path of a file to load. If the
+// path is a glob pattern, all matching files are loaded into a list, in
+// lexical order of their paths. Format determines how the file is parsed into
+// template data; if not specified, it is inferred from the file extension.
 type GeneratorData struct {
-	FieldName string `json:"-"`
-	Include   string `json:"include"`
+	FieldName string              `json:"-"`
+	Include   string              `json:"include"`
+	Format    GeneratorDataFormat `json:"format,omitempty"`
 }
 
+// GeneratorDataFormat identifies how generator data is parsed.
+type GeneratorDataFormat string
+
+const (
+	// GeneratorDataFormatDefault infers the format from the file extension.
+	GeneratorDataFormatDefault = ""
+
+	// GeneratorDataFormatYAML parses YAML into structured data.
+	GeneratorDataFormatYAML = "yaml"
+
+	// GeneratorDataFormatJSON parses JSON into structured data.
+	GeneratorDataFormatJSON = "json"
+
+	// GeneratorDataFormatText loads file contents as a string.
+	GeneratorDataFormatText = "text"
+
+	// GeneratorDataFormatOpenAPI loads an OpenAPI document, with its
+	// references resolved, for templates that need schemas in full, such as
+	// when generating Terraform provider schemas.
+	GeneratorDataFormatOpenAPI = "openapi"
+)
+
 // An API defines how and where to build versioned OpenAPI documents from a
 // source collection of individual resource specifications and additional
 // overlay content to merge.
+//
+// Versioning selects how the API's resources are versioned: by date (the
+// default), or semantically ("semver"), in version directories named like v1
+// or v1.2. Semantic versions are compiled, linted and resolved in the same
+// way as dates, ordered by their major and minor numbers.
+//
+// PathComparison selects how the paths of resources and overlays merged into
+// a compiled spec are compared for conflicts: exactly (the default), or by
+// their structure ("structural"), so that paths which differ only in the
+// names of their parameters, such as /orgs/{orgId} and /orgs/{org_id},
+// conflict rather than both being published.
+//
+// Waivers acknowledge breaking changes to the API, so that checks for
+// breaking changes do not fail on them until they expire.
 type API struct {
-	Name      string         `json:"-"`
-	Resources []*ResourceSet `json:"resources"`
-	Overlays  []*Overlay     `json:"overlays"`
-	Output    *Output        `json:"output"`
+	Name           string            `json:"-"`
+	Versioning     Versioning        `json:"versioning,omitempty"`
+	PathComparison PathComparison    `json:"path-comparison,omitempty"`
+	Defaults       *ResourceDefaults `json:"defaults,omitempty"`
+	Resources      []*ResourceSet    `json:"resources"`
+	Overlays       []*Overlay        `json:"overlays"`
+	Output         *Output           `json:"output"`
+	Waivers        []*Waiver         `json:"waivers,omitempty"`
+}
+
+// A Waiver acknowledges a breaking change, recording who accepted it, why,
+// and until when. Change is the breaking change as described by
+// check-breaking, such as "GET /things: query parameter limit: parameter is
+// now required". The waiver applies through the Expires date, as
+// YYYY-MM-DD.
+//
+// Signature is the base64 ed25519 signature of the waiver, so that only
+// waivers approved by the holder of a signing key apply when checks are
+// given its public key.
+type Waiver struct {
+	Change    string `json:"change"`
+	Author    string `json:"author"`
+	Reason    string `json:"reason"`
+	Expires   string `json:"expires"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// PathComparison is how paths are compared for conflicts when merging.
+type PathComparison string
+
+const (
+	PathComparisonExact      PathComparison = ""
+	PathComparisonStructural PathComparison = "structural"
+)
+
+// Versioning is a scheme by which the resources of an API are versioned.
+type Versioning string
+
+const (
+	VersioningDefault Versioning = ""
+	VersioningDate    Versioning = "date"
+	VersioningSemver  Versioning = "semver"
+)
+
+// ResourceDefaults defines settings which are inherited by each resource set
+// in an API, unless the resource set declares its own.
+//
+// An empty list of generators may be declared on a resource set to opt out of
+// the default generators.
+type ResourceDefaults struct {
+	Linter     string   `json:"linter,omitempty"`
+	Generators []string `json:"generators,omitempty"`
 }
 
 // A ResourceSet defines a set of versioned resources that adhere to the same
@@ -118,31 +333,326 @@ type API struct {
 // Each YYYY-mm-dd directory under a resource is a version.  The spec.yaml
 // in each version is a complete OpenAPI document describing the resource
 // at that version.
+//
+// Specs is a filename pattern for the spec in each version directory, such
+// as openapi.yaml or *.oas.yaml, for projects which do not name their specs
+// spec.yaml. Only one spec file may match in each version.
+//
+// Granularity is how often resources in the set may be versioned, which
+// determines how version directories are named: daily as YYYY-mm-dd (the
+// default), monthly as YYYY-mm or weekly as an ISO week, YYYY-Www. Monthly
+// and weekly versions are released on the first day of their month or week,
+// and resolve and compile as versions of that date.
+//
+// Excludes are glob patterns of spec files to leave out of the resource set.
+// See ResourceSet.ExcludedBy for how these are matched.
 type ResourceSet struct {
 	Description     string                        `json:"description"`
 	Linter          string                        `json:"linter"`
 	LinterOverrides map[string]map[string]*Linter `json:"linter-overrides"`
 	Generators      []string                      `json:"generators"`
 	Path            string                        `json:"path"`
+	Specs           string                        `json:"specs,omitempty"`
+	Granularity     Granularity                   `json:"granularity,omitempty"`
 	Excludes        []string                      `json:"excludes"`
+
+	// Versioning is inherited from the API.
+	Versioning Versioning `json:"-"`
+}
+
+// Granularity is how often the resources in a resource set may be versioned.
+type Granularity string
+
+const (
+	GranularityDefault Granularity = ""
+	GranularityDay     Granularity = "day"
+	GranularityMonth   Granularity = "month"
+	GranularityWeek    Granularity = "week"
+)
+
+// DefaultSpecs is the filename of the spec in each version directory of a
+// resource set, unless the resource set declares its own.
+const DefaultSpecs = "spec.yaml"
+
+// SpecsPattern returns the filename pattern of the spec in each version
+// directory of the resource set.
+func (r *ResourceSet) SpecsPattern() string {
+	if r.Specs == "" {
+		return DefaultSpecs
+	}
+	return r.Specs
 }
 
 // An Overlay defines additional OpenAPI documents to merge into the aggregate
 // OpenAPI spec when compiling an API. These might include special endpoints
 // that should be included in the aggregate API but are not versioned, or
 // top-level descriptions of the API itself.
+//
+// Overlays are validated when the project is compiled. Linter may name a
+// linter to lint the overlay document itself with, along with resources.
 type Overlay struct {
 	Include string `json:"include"`
 	Inline  string `json:"inline"`
+	Linter  string `json:"linter,omitempty"`
 }
 
 // Output defines where the aggregate versioned OpenAPI specs should be created
 // during compilation.
+//
+// Aliases may be set to avoid writing duplicate copies of specs, when several
+// versions compile to exactly the same spec. This is common when a stability
+// level has no releases of its own on a given date.
+//
+// Servers may be set to replace the servers in each compiled spec, after all
+// overlays are merged. This allows the same compiled content to be published
+// with different server URLs, for example per region or environment.
+//
+// Naming may be set to translate the naming conventions of property and
+// header names in each compiled spec, so that resources authored in one
+// convention can be published in another.
+//
+// VersionAliases may be set to name versions of the compiled API, such as
+// "latest", "stable" or a milestone, which clients may request in place of a
+// version. Each alias maps to a version, which is resolved to a compiled
+// version when building and indexed in the output:
+//
+//     version-aliases:
+//       latest: 2021-10-01
+//       stable: 2021-06-01~beta
+//
+// Redact may be set to extensions which mark internal parts of the API, such
+// as x-internal. Operations, parameters, components and schema properties
+// marked with any of these are removed from compiled specs, so that a public
+// output omits what an internal output of the same resources retains.
 type Output struct {
-	Path   string `json:"path"`
-	Linter string `json:"linter"`
+	Path           string            `json:"path"`
+	Linter         string            `json:"linter"`
+	Aliases        OutputAliases     `json:"aliases,omitempty"`
+	Servers        []*Server         `json:"servers,omitempty"`
+	Exports        *Exports          `json:"exports,omitempty"`
+	Naming         *Naming           `json:"naming,omitempty"`
+	VersionAliases map[string]string `json:"version-aliases,omitempty"`
+	Redact         []string          `json:"redact,omitempty"`
+	Layout         *Layout           `json:"layout,omitempty"`
+	Info           *InfoPolicy       `json:"info,omitempty"`
+	Budget         *Budget           `json:"budget,omitempty"`
+
+	// Downconvert converts resources written in OpenAPI 3.1 to OpenAPI 3.0
+	// when compiling them, for consumers whose tooling only supports 3.0.
+	// Constructs which cannot be converted exactly are reported.
+	Downconvert bool `json:"downconvert,omitempty"`
+}
+
+// Budget limits the size of compiled specs, so that a spec grown too large
+// for downstream tooling, such as by merging an enormous generated schema,
+// is caught at build time rather than published. Limits of zero are not
+// enforced.
+type Budget struct {
+	// MaxBytes is the largest a compiled spec may be, serialized as JSON.
+	MaxBytes int `json:"max-bytes,omitempty"`
+
+	// MaxOperations is the most operations a compiled spec may have.
+	MaxOperations int `json:"max-operations,omitempty"`
+
+	// Warn logs compiled specs over budget, rather than failing the build.
+	Warn bool `json:"warn,omitempty"`
+}
+
+// InfoPolicy requires compiled specs to describe who publishes them and on
+// what terms, rather than publishing them with incomplete metadata. Fields
+// missing from the info of a compiled spec are filled in with the defaults
+// given here, if any:
+//
+//     info:
+//       required: [contact, license, terms-of-service]
+//       license:
+//         name: Apache 2.0
+//         url: https://www.apache.org/licenses/LICENSE-2.0.html
+//
+// A required field which is missing and has no default fails the build.
+type InfoPolicy struct {
+	Required       []string     `json:"required,omitempty"`
+	Contact        *InfoContact `json:"contact,omitempty"`
+	License        *InfoLicense `json:"license,omitempty"`
+	TermsOfService string       `json:"terms-of-service,omitempty"`
+}
+
+// Info fields which may be required by an InfoPolicy.
+const (
+	InfoFieldContact        = "contact"
+	InfoFieldLicense        = "license"
+	InfoFieldTermsOfService = "terms-of-service"
+)
+
+// InfoContact is the default contact of compiled specs.
+type InfoContact struct {
+	Name  string `json:"name,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// InfoLicense is the default license of compiled specs.
+type InfoLicense struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// Layout names the files compiled specs are written to, relative to the
+// output path, in place of <version>/spec.json and <version>/spec.yaml, so
+// that publishing targets which expect a fixed layout can be fed directly.
+// Each is a template, with the same fields as output servers:
+//
+//     layout:
+//       json: 'openapi-{{ .Version }}.json'
+//       yaml: '{{ .Stability }}/openapi-{{ .Date }}.yaml'
+//
+// A format with no template is not written. Output in a custom layout cannot
+// be read back by vervet, such as to serve it, so it does not support
+// aliases, version aliases or gateway exports.
+type Layout struct {
+	JSON string `json:"json,omitempty"`
+	YAML string `json:"yaml,omitempty"`
+}
+
+// Naming translates names in compiled specs to a naming convention.
+//
+// Properties rewrites schema property names, along with the required
+// properties, discriminators and references which refer to them. Headers
+// rewrites the names of header parameters and response headers.
+//
+//     naming:
+//       properties: snake_case
+//       headers: lowercase
+//
+// Examples are not rewritten.
+type Naming struct {
+	Properties PropertyNaming `json:"properties,omitempty"`
+	Headers    HeaderNaming   `json:"headers,omitempty"`
+}
+
+// PropertyNaming is a naming convention for schema property names.
+type PropertyNaming string
+
+const (
+	// PropertyNamingNone leaves property names as they are.
+	PropertyNamingNone PropertyNaming = ""
+
+	// PropertyNamingSnakeCase names properties like org_id.
+	PropertyNamingSnakeCase PropertyNaming = "snake_case"
+
+	// PropertyNamingCamelCase names properties like orgId.
+	PropertyNamingCamelCase PropertyNaming = "camelCase"
+)
+
+// HeaderNaming is a naming convention for header names.
+type HeaderNaming string
+
+const (
+	// HeaderNamingNone leaves header names as they are.
+	HeaderNamingNone HeaderNaming = ""
+
+	// HeaderNamingCanonical names headers in canonical MIME form, like
+	// Snyk-Request-Id.
+	HeaderNamingCanonical HeaderNaming = "canonical"
+
+	// HeaderNamingLowercase names headers in lower case, like
+	// snyk-request-id.
+	HeaderNamingLowercase HeaderNaming = "lowercase"
+)
+
+// Exports defines API gateway configuration to generate from each compiled
+// spec of an output, so that routing is derived from the versioned spec.
+// Exported files are written alongside the spec in each version directory,
+// except for manifests describing all versions, which are written to the
+// output directory.
+type Exports struct {
+	Kong          *KongExport          `json:"kong,omitempty"`
+	Envoy         *EnvoyExport         `json:"envoy,omitempty"`
+	AWSAPIGateway *AWSAPIGatewayExport `json:"aws-api-gateway,omitempty"`
+	APIsJSON      *APIsJSONExport      `json:"apis-json,omitempty"`
+	ErrorCatalog  *ErrorCatalogExport  `json:"error-catalog,omitempty"`
+}
+
+// gateway returns whether any API gateway configuration is exported.
+func (e *Exports) gateway() bool {
+	return e.Kong != nil || e.Envoy != nil || e.AWSAPIGateway != nil
+}
+
+// KongExport generates Kong declarative configuration (kong.yaml), with a
+// service routing each operation in the spec to an upstream URL.
+type KongExport struct {
+	Upstream string `json:"upstream"`
+}
+
+// EnvoyExport generates an Envoy route configuration (envoy.yaml), routing
+// each operation in the spec to a cluster.
+type EnvoyExport struct {
+	Cluster string `json:"cluster"`
+}
+
+// AWSAPIGatewayExport generates an OpenAPI spec with AWS API Gateway
+// extensions (spec.aws.json), proxying each operation to an upstream URL.
+type AWSAPIGatewayExport struct {
+	Upstream string `json:"upstream"`
+}
+
+// APIsJSONExport generates an APIs.json manifest (apis.json) describing each
+// compiled version of an API, its stability and where its spec and
+// documentation are published, so that API catalogs can discover them.
+//
+// The spec and docs URLs are templates, with the same fields as output
+// servers:
+//
+//     spec-url: https://api.example.com/openapi/{{ .Version }}
+//     docs-url: https://docs.example.com/{{ .Version }}
+type APIsJSONExport struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	SpecURL     string `json:"spec-url"`
+	DocsURL     string `json:"docs-url,omitempty"`
+}
+
+// ErrorCatalogExport generates a catalog of the error responses in all
+// compiled versions of an API, with the operations and versions returning
+// each, as JSON (errors.json) and Markdown (errors.md). Title is the heading
+// of the Markdown document.
+type ErrorCatalogExport struct {
+	Title string `json:"title,omitempty"`
+}
+
+// Server defines a server in the compiled specs of an output.
+//
+// The URL and description may refer to environment variables, as ${VAR},
+// and are Go templates which may refer to the version being compiled:
+//
+//     url: https://${REGION}.example.com/api/{{ .Version }}
+//
+// The template fields available are .API, .Version (such as 2021-06-01~beta),
+// .Date and .Stability.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
 }
 
+// OutputAliases determines how versions which compile to the same spec as a
+// prior version are output.
+type OutputAliases string
+
+const (
+	// OutputAliasesNone writes a copy of the spec for each version. This is
+	// the default.
+	OutputAliasesNone OutputAliases = ""
+
+	// OutputAliasesSymlink links the directory of each duplicate version to
+	// that of the first version with the same spec.
+	OutputAliasesSymlink OutputAliases = "symlink"
+
+	// OutputAliasesIndex writes an index of duplicate versions and the first
+	// version with the same spec, rather than writing the duplicates. This
+	// works where symlinks do not, such as when embedding output.
+	OutputAliasesIndex OutputAliases = "index"
+)
+
 // APINames returns the API names in deterministic ascending order.
 func (p *Project) APINames() []string {
 	var result []string
@@ -174,6 +684,20 @@ func (p *Project) init() {
 	}
 	for apiName, api := range p.APIs {
 		api.Name = apiName
+		for _, resource := range api.Resources {
+			resource.Versioning = api.Versioning
+		}
+		if api.Defaults == nil {
+			continue
+		}
+		for _, resource := range api.Resources {
+			if resource.Linter == "" {
+				resource.Linter = api.Defaults.Linter
+			}
+			if resource.Generators == nil {
+				resource.Generators = api.Defaults.Generators
+			}
+		}
 	}
 }
 
@@ -187,11 +711,67 @@ func (p *Project) validate() error {
 	if len(p.APIs) == 0 {
 		return fmt.Errorf("no apis defined")
 	}
+	if p.RemoteRefs != nil {
+		if err := p.RemoteRefs.validate(); err != nil {
+			return err
+		}
+	}
+	if p.Extensions != nil {
+		if err := p.Extensions.validate(); err != nil {
+			return err
+		}
+	}
+	if p.Env != nil {
+		if err := p.Env.validate(); err != nil {
+			return err
+		}
+	}
+	if p.Common != nil {
+		if err := p.Common.validate(); err != nil {
+			return err
+		}
+	}
+	switch p.PathCollisions {
+	case PathCollisionsIgnore, PathCollisionsWarn, PathCollisionsError:
+	default:
+		return fmt.Errorf("invalid path-collisions %q, expected warn or error (path-collisions)", p.PathCollisions)
+	}
 	// Referenced linters and generators all exist
 	for _, api := range p.APIs {
 		if len(api.Resources) == 0 {
 			return fmt.Errorf("no resources defined (apis.%s.resources)", api.Name)
 		}
+		switch api.Versioning {
+		case VersioningDefault, VersioningDate, VersioningSemver:
+		default:
+			return fmt.Errorf("invalid versioning %q, expected date or semver (apis.%s.versioning)",
+				api.Versioning, api.Name)
+		}
+		switch api.PathComparison {
+		case PathComparisonExact, PathComparisonStructural:
+		default:
+			return fmt.Errorf("invalid path-comparison %q, expected structural (apis.%s.path-comparison)",
+				api.PathComparison, api.Name)
+		}
+		if api.Defaults != nil {
+			if api.Defaults.Linter != "" {
+				if _, ok := p.Linters[api.Defaults.Linter]; !ok {
+					return fmt.Errorf("linter %q not found (apis.%s.defaults.linter)",
+						api.Defaults.Linter, api.Name)
+				}
+			}
+			for genIndex, genName := range api.Defaults.Generators {
+				if _, ok := p.Generators[genName]; !ok {
+					return fmt.Errorf("generator %q not found (apis.%s.defaults.generators[%d])",
+						genName, api.Name, genIndex)
+				}
+			}
+		}
+		for waiverIndex, waiver := range api.Waivers {
+			if err := waiver.validate(); err != nil {
+				return fmt.Errorf("%w (apis.%s.waivers[%d])", err, api.Name, waiverIndex)
+			}
+		}
 		for rcIndex, resource := range api.Resources {
 			if resource.Linter != "" {
 				if _, ok := p.Linters[resource.Linter]; !ok {
@@ -218,6 +798,18 @@ func (p *Project) validate() error {
 				}
 			}
 		}
+		for overlayIndex, overlay := range api.Overlays {
+			if overlay.Include != "" && overlay.Inline != "" {
+				return fmt.Errorf("include and inline are mutually exclusive (apis.%s.overlays[%d])",
+					api.Name, overlayIndex)
+			}
+			if overlay.Linter != "" {
+				if _, ok := p.Linters[overlay.Linter]; !ok {
+					return fmt.Errorf("linter %q not found (apis.%s.overlays[%d].linter)",
+						overlay.Linter, api.Name, overlayIndex)
+				}
+			}
+		}
 		if api.Output != nil && api.Output.Linter != "" {
 			if api.Output.Linter != "" {
 				if _, ok := p.Linters[api.Output.Linter]; !ok {
@@ -226,6 +818,79 @@ func (p *Project) validate() error {
 				}
 			}
 		}
+		if api.Output != nil {
+			switch api.Output.Aliases {
+			case OutputAliasesNone, OutputAliasesSymlink, OutputAliasesIndex:
+			default:
+				return fmt.Errorf("invalid aliases %q (apis.%s.output.aliases)",
+					api.Output.Aliases, api.Name)
+			}
+			if exports := api.Output.Exports; exports != nil {
+				if exports.Kong != nil && exports.Kong.Upstream == "" {
+					return fmt.Errorf("missing upstream (apis.%s.output.exports.kong.upstream)", api.Name)
+				}
+				if exports.Envoy != nil && exports.Envoy.Cluster == "" {
+					return fmt.Errorf("missing cluster (apis.%s.output.exports.envoy.cluster)", api.Name)
+				}
+				if exports.AWSAPIGateway != nil && exports.AWSAPIGateway.Upstream == "" {
+					return fmt.Errorf("missing upstream (apis.%s.output.exports.aws-api-gateway.upstream)", api.Name)
+				}
+				if exports.APIsJSON != nil && exports.APIsJSON.SpecURL == "" {
+					return fmt.Errorf("missing spec-url (apis.%s.output.exports.apis-json.spec-url)", api.Name)
+				}
+				// Gateway exports are written for each version, which an
+				// alias does not have a directory of its own for.
+				if exports.gateway() && api.Output.Aliases != OutputAliasesNone {
+					return fmt.Errorf("aliases are not supported with gateway exports (apis.%s.output.aliases)", api.Name)
+				}
+			}
+			for serverIndex, server := range api.Output.Servers {
+				if server.URL == "" {
+					return fmt.Errorf("missing url (apis.%s.output.servers[%d].url)",
+						api.Name, serverIndex)
+				}
+			}
+			if naming := api.Output.Naming; naming != nil {
+				switch naming.Properties {
+				case PropertyNamingNone, PropertyNamingSnakeCase, PropertyNamingCamelCase:
+				default:
+					return fmt.Errorf("invalid property naming %q (apis.%s.output.naming.properties)",
+						naming.Properties, api.Name)
+				}
+				switch naming.Headers {
+				case HeaderNamingNone, HeaderNamingCanonical, HeaderNamingLowercase:
+				default:
+					return fmt.Errorf("invalid header naming %q (apis.%s.output.naming.headers)",
+						naming.Headers, api.Name)
+				}
+			}
+			for i, ext := range api.Output.Redact {
+				if !strings.HasPrefix(ext, "x-") {
+					return fmt.Errorf("invalid extension %q, expected x- prefix (apis.%s.output.redact[%d])",
+						ext, api.Name, i)
+				}
+			}
+			for alias := range api.Output.VersionAliases {
+				if alias == "" || strings.ContainsAny(alias, "/~") {
+					return fmt.Errorf("invalid version alias %q (apis.%s.output.version-aliases)", alias, api.Name)
+				}
+			}
+			if budget := api.Output.Budget; budget != nil {
+				if budget.MaxBytes < 0 || budget.MaxOperations < 0 {
+					return fmt.Errorf("negative limit (apis.%s.output.budget)", api.Name)
+				}
+			}
+			if info := api.Output.Info; info != nil {
+				if err := info.validate(); err != nil {
+					return fmt.Errorf("%w (apis.%s.output.info)", err, api.Name)
+				}
+			}
+			if layout := api.Output.Layout; layout != nil {
+				if err := layout.validate(api.Output); err != nil {
+					return fmt.Errorf("%w (apis.%s.output.layout)", err, api.Name)
+				}
+			}
+		}
 	}
 	for _, linter := range p.Linters {
 		if err := linter.validate(); err != nil {
@@ -251,9 +916,145 @@ func (p *Project) validate() error {
 	return nil
 }
 
+var pinRE = regexp.MustCompile(`^sha256:[0-9a-fA-F]{64}$`)
+
+func (w *Waiver) validate() error {
+	if w.Change == "" {
+		return fmt.Errorf("missing change")
+	}
+	if w.Author == "" {
+		return fmt.Errorf("missing author")
+	}
+	if w.Reason == "" {
+		return fmt.Errorf("missing reason")
+	}
+	if _, err := time.Parse("2006-01-02", w.Expires); err != nil {
+		return fmt.Errorf("invalid expires %q, expected YYYY-MM-DD", w.Expires)
+	}
+	if _, err := base64.StdEncoding.DecodeString(w.Signature); err != nil {
+		return fmt.Errorf("invalid signature, expected base64")
+	}
+	return nil
+}
+
+func (r *RemoteRefs) validate() error {
+	if len(r.Allow) == 0 {
+		return fmt.Errorf("no hosts allowed (remote-refs.allow)")
+	}
+	for i, host := range r.Allow {
+		if host == "" || strings.ContainsAny(host, "/:") {
+			return fmt.Errorf("invalid host %q (remote-refs.allow[%d])", host, i)
+		}
+	}
+	for pinURL, pin := range r.Pins {
+		if !strings.HasPrefix(pinURL, "https://") {
+			return fmt.Errorf("only https URLs may be pinned (remote-refs.pins.%s)", pinURL)
+		}
+		if !pinRE.MatchString(pin) {
+			return fmt.Errorf("invalid digest %q, expected sha256:<hex> (remote-refs.pins.%s)", pin, pinURL)
+		}
+	}
+	return nil
+}
+
+func (e *Extensions) validate() error {
+	for name, schemaFile := range e.Schemas {
+		if !strings.HasPrefix(name, "x-") {
+			return fmt.Errorf("invalid extension %q, expected x- prefix (extensions.schemas.%s)", name, name)
+		}
+		if schemaFile == "" {
+			return fmt.Errorf("no schema file (extensions.schemas.%s)", name)
+		}
+	}
+	for i, prefix := range e.Strict {
+		if !strings.HasPrefix(prefix, "x-") || len(prefix) <= len("x-") {
+			return fmt.Errorf("invalid extension prefix %q, expected x- prefix (extensions.strict[%d])", prefix, i)
+		}
+	}
+	return nil
+}
+
+func (p *InfoPolicy) validate() error {
+	for _, field := range p.Required {
+		switch field {
+		case InfoFieldContact, InfoFieldLicense, InfoFieldTermsOfService:
+		default:
+			return fmt.Errorf("unknown info field %q, expected %s, %s or %s",
+				field, InfoFieldContact, InfoFieldLicense, InfoFieldTermsOfService)
+		}
+	}
+	if p.Contact != nil && *p.Contact == (InfoContact{}) {
+		return fmt.Errorf("empty contact")
+	}
+	if p.License != nil && p.License.Name == "" {
+		return fmt.Errorf("missing license name")
+	}
+	return nil
+}
+
+func (l *Layout) validate(out *Output) error {
+	if l.JSON == "" && l.YAML == "" {
+		return fmt.Errorf("no json or yaml file name")
+	}
+	if out.Aliases != OutputAliasesNone {
+		return fmt.Errorf("aliases are not supported with a custom layout")
+	}
+	if len(out.VersionAliases) > 0 {
+		return fmt.Errorf("version aliases are not supported with a custom layout")
+	}
+	if out.Exports != nil && out.Exports.gateway() {
+		return fmt.Errorf("gateway exports are not supported with a custom layout")
+	}
+	return nil
+}
+
+var envNameRE = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (e *Env) validate() error {
+	for i, name := range e.Allow {
+		if !envNameRE.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q (env.allow[%d])", name, i)
+		}
+	}
+	return nil
+}
+
+func (c *Common) validate() error {
+	if c.Headers == "" && len(c.Parameters) == 0 {
+		return fmt.Errorf("no headers or parameters declared (common)")
+	}
+	if c.Headers != "" && !isFileElementRef(c.Headers) {
+		return fmt.Errorf("invalid reference %q, expected <file>#/<name> (common.headers)", c.Headers)
+	}
+	for i, ref := range c.Parameters {
+		if !isFileElementRef(ref) {
+			return fmt.Errorf("invalid reference %q, expected <file>#/<name> (common.parameters[%d])", ref, i)
+		}
+	}
+	return nil
+}
+
+// isFileElementRef returns whether ref refers to a top-level element of a
+// file, such as headers.yaml#/Common.
+func isFileElementRef(ref string) bool {
+	parts := strings.SplitN(ref, "#/", 2)
+	return len(parts) == 2 && parts[0] != "" && parts[1] != "" && !strings.Contains(parts[1], "/")
+}
+
 var defaultSpectralExtraArgs = []string{"--format", "text"}
 
 func (r *ResourceSet) validate() error {
+	if r.Specs != "" && (strings.Contains(r.Specs, "/") || !doublestar.ValidatePattern(r.Specs)) {
+		return fmt.Errorf("invalid specs pattern %q, expected a filename pattern", r.Specs)
+	}
+	switch r.Granularity {
+	case GranularityDefault, GranularityDay, GranularityMonth, GranularityWeek:
+	default:
+		return fmt.Errorf("invalid granularity %q, expected day, month or week", r.Granularity)
+	}
+	if r.Versioning == VersioningSemver && r.Granularity != GranularityDefault {
+		return fmt.Errorf("granularity does not apply to semver versioning")
+	}
 	for _, exclude := range r.Excludes {
 		if !doublestar.ValidatePattern(exclude) {
 			return fmt.Errorf("invalid exclude pattern %q", exclude)
@@ -262,10 +1063,35 @@ func (r *ResourceSet) validate() error {
 	return nil
 }
 
+// ExcludedBy returns the index of the first exclude pattern matching a spec
+// file path, and whether the file is excluded at all.
+//
+// The path is relative to the project directory, as the resource set path is.
+// Exclude patterns are matched against this path, and also against the path
+// relative to the resource set path. So with a resource set path of
+// "resources", both "resources/schemas/**" and "schemas/**" exclude
+// "resources/schemas/2021-06-01/spec.yaml".
+func (r *ResourceSet) ExcludedBy(path string) (int, bool) {
+	paths := []string{filepath.ToSlash(path)}
+	if relPath, err := filepath.Rel(r.Path, path); err == nil && !strings.HasPrefix(relPath, "..") {
+		paths = append(paths, filepath.ToSlash(relPath))
+	}
+	for i := range r.Excludes {
+		for _, matchPath := range paths {
+			// Patterns are validated when the project is loaded, so an
+			// error here can only be a non-match.
+			if ok, _ := doublestar.Match(r.Excludes[i], matchPath); ok {
+				return i, true
+			}
+		}
+	}
+	return -1, false
+}
+
 func (l *Linter) validate() error {
 	// This can be a linter variant dispatch off non-nil if/when more linter
 	// types are supported.
-	if l.Spectral == nil && l.SweaterComb == nil {
+	if l.Spectral == nil && l.SweaterComb == nil && l.VervetRules == nil {
 		return fmt.Errorf("missing configuration (linters.%s)", l.Name)
 	}
 	return nil
@@ -273,12 +1099,25 @@ func (l *Linter) validate() error {
 
 func (g *Generator) validate() error {
 	switch g.Scope {
-	case GeneratorScopeVersion:
-	//case GeneratorScopeResource:  // TODO: support resource scope
+	case GeneratorScopeVersion, GeneratorScopeResource:
 	default:
 		return fmt.Errorf("invalid scope %q (generators.%s.scope)", g.Scope, g.Name)
 	}
-	if g.Template == "" {
+	switch g.Builtin {
+	case "":
+	case GeneratorBuiltinResourceReadme:
+		if g.Files != "" {
+			return fmt.Errorf("files not supported by built-in generator %q (generators.%s.files)",
+				g.Builtin, g.Name)
+		}
+	default:
+		return fmt.Errorf("unknown built-in generator %q (generators.%s.builtin)", g.Builtin, g.Name)
+	}
+	if _, ok := g.Data["History"]; ok && g.Builtin == GeneratorBuiltinResourceReadme {
+		return fmt.Errorf("data field provided by built-in generator %q (generators.%s.data.History)",
+			g.Builtin, g.Name)
+	}
+	if g.Template == "" && g.Builtin == "" {
 		return fmt.Errorf("required field not specified (generators.%s.contents)", g.Name)
 	}
 	if g.Filename == "" && g.Files == "" {
@@ -291,6 +1130,12 @@ func (g *Generator) validate() error {
 		if v.Include == "" {
 			return fmt.Errorf("required field not specified (generators.%s.data.%s.include)", g.Name, k)
 		}
+		switch v.Format {
+		case GeneratorDataFormatDefault, GeneratorDataFormatYAML, GeneratorDataFormatJSON, GeneratorDataFormatText,
+			GeneratorDataFormatOpenAPI:
+		default:
+			return fmt.Errorf("invalid format %q (generators.%s.data.%s.format)", v.Format, g.Name, k)
+		}
 	}
 	return nil
 }
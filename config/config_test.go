@@ -163,6 +163,336 @@ apis:
       - path: resources
         linter: foo`[1:],
 		err: `linter "foo" not found \(apis\.testapi\.resources\[0\]\.linter\)`,
+	}, {
+		conf: `
+version: "1"
+generators:
+  foo:
+    filename: foo
+    template: foo.tmpl
+    data:
+      Foo:
+        include: foo.csv
+        format: csv
+apis:
+  testapi:
+    resources:
+      - path: resources`[1:],
+		err: `invalid format "csv" \(generators\.foo\.data\.Foo\.format\)`,
+	}, {
+		conf: `
+version: "1"
+generators:
+  foo:
+    builtin: resource-changelog
+    filename: foo
+apis:
+  testapi:
+    resources:
+      - path: resources`[1:],
+		err: `unknown built-in generator "resource-changelog" \(generators\.foo\.builtin\)`,
+	}, {
+		conf: `
+version: "1"
+common:
+  headers: schemas/headers/common-response.yaml
+apis:
+  testapi:
+    resources:
+      - path: resources`[1:],
+		err: `invalid reference "schemas/headers/common-response.yaml", expected <file>#/<name> \(common\.headers\)`,
+	}, {
+		conf: `
+version: "1"
+env:
+  allow: [API_REGION, API-TOKEN]
+apis:
+  testapi:
+    resources:
+      - path: resources`[1:],
+		err: `invalid environment variable name "API-TOKEN" \(env\.allow\[1\]\)`,
+	}, {
+		conf: `
+version: "1"
+path-collisions: fail
+apis:
+  testapi:
+    resources:
+      - path: resources`[1:],
+		err: `invalid path-collisions "fail", expected warn or error \(path-collisions\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      aliases: symlink
+      layout:
+        json: 'openapi-{{ .Version }}.json'`[1:],
+		err: `aliases are not supported with a custom layout \(apis\.testapi\.output\.layout\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      info:
+        required: [contact, terms]`[1:],
+		err: `unknown info field "terms", expected contact, license or terms-of-service \(apis\.testapi\.output\.info\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    overlays:
+      - inline: |-
+          servers:
+            - url: https://example.com
+        linter: nope`[1:],
+		err: `linter "nope" not found \(apis\.testapi\.overlays\[0\]\.linter\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    defaults:
+      generators: [nope]
+    resources:
+      - path: resources`[1:],
+		err: `generator "nope" not found \(apis\.testapi\.defaults\.generators\[0\]\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      aliases: hardlink`[1:],
+		err: `invalid aliases "hardlink" \(apis\.testapi\.output\.aliases\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+        specs: 'specs/*.yaml'`[1:],
+		err: `invalid specs pattern "specs/\*.yaml", expected a filename pattern \(apis\.testapi\.resources\[0\]\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+        granularity: quarter`[1:],
+		err: `invalid granularity "quarter", expected day, month or week \(apis\.testapi\.resources\[0\]\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    versioning: calver
+    resources:
+      - path: resources`[1:],
+		err: `invalid versioning "calver", expected date or semver \(apis\.testapi\.versioning\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    path-comparison: loose
+    resources:
+      - path: resources`[1:],
+		err: `invalid path-comparison "loose", expected structural \(apis\.testapi\.path-comparison\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    versioning: semver
+    resources:
+      - path: resources
+        granularity: month`[1:],
+		err: `granularity does not apply to semver versioning \(apis\.testapi\.resources\[0\]\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      naming:
+        properties: kebab-case`[1:],
+		err: `invalid property naming "kebab-case" \(apis\.testapi\.output\.naming\.properties\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      naming:
+        headers: upper`[1:],
+		err: `invalid header naming "upper" \(apis\.testapi\.output\.naming\.headers\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      version-aliases:
+        latest~beta: 2021-06-01`[1:],
+		err: `invalid version alias "latest~beta" \(apis\.testapi\.output\.version-aliases\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      redact: [internal]`[1:],
+		err: `invalid extension "internal", expected x- prefix \(apis\.testapi\.output\.redact\[0\]\)`,
+	}, {
+		conf: `
+version: "1"
+remote-refs:
+  allow: [schemas.example.com]
+  pins:
+    https://schemas.example.com/common.yaml: md5:abc
+apis:
+  testapi:
+    resources:
+      - path: resources`[1:],
+		err: `invalid digest "md5:abc", expected sha256:<hex> \(remote-refs\.pins\.https://schemas\.example\.com/common\.yaml\)`,
+	}, {
+		conf: `
+version: "1"
+remote-refs:
+  cache: .vervet/cache
+apis:
+  testapi:
+    resources:
+      - path: resources`[1:],
+		err: `no hosts allowed \(remote-refs\.allow\)`,
+	}, {
+		conf: `
+version: "1"
+extensions:
+  schemas:
+    acme-tier: extensions/tier.yaml
+apis:
+  testapi:
+    resources:
+      - path: resources`[1:],
+		err: `invalid extension "acme-tier", expected x- prefix \(extensions\.schemas\.acme-tier\)`,
+	}, {
+		conf: `
+version: "1"
+extensions:
+  strict: [x-]
+apis:
+  testapi:
+    resources:
+      - path: resources`[1:],
+		err: `invalid extension prefix "x-", expected x- prefix \(extensions\.strict\[0\]\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      servers:
+        - description: no url`[1:],
+		err: `missing url \(apis\.testapi\.output\.servers\[0\]\.url\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      exports:
+        envoy: {}`[1:],
+		err: `missing cluster \(apis\.testapi\.output\.exports\.envoy\.cluster\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      aliases: symlink
+      exports:
+        kong:
+          upstream: http://testapi`[1:],
+		err: `aliases are not supported with gateway exports \(apis\.testapi\.output\.aliases\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    waivers:
+      - change: '/things: path removed'
+        author: jo@example.com
+        reason: Things were never released
+        expires: next week`[1:],
+		err: `invalid expires "next week", expected YYYY-MM-DD \(apis\.testapi\.waivers\[0\]\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      aliases: index
+      exports:
+        envoy:
+          cluster: testapi`[1:],
+		err: `aliases are not supported with gateway exports \(apis\.testapi\.output\.aliases\)`,
+	}, {
+		conf: `
+version: "1"
+apis:
+  testapi:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      exports:
+        apis-json:
+          docs-url: https://docs.example.com`[1:],
+		err: `missing spec-url \(apis\.testapi\.output\.exports\.apis-json\.spec-url\)`,
 	}}
 	for i := range tests {
 		c.Logf("test#%d: %s", i, tests[i].conf)
@@ -170,3 +500,75 @@ apis:
 		c.Assert(err, qt.ErrorMatches, tests[i].err)
 	}
 }
+
+func TestLoadResourceDefaults(t *testing.T) {
+	c := qt.New(t)
+	conf := bytes.NewBufferString(`
+version: "1"
+linters:
+  default-rules:
+    spectral:
+      rules:
+        - default-rules.yaml
+  special-rules:
+    spectral:
+      rules:
+        - special-rules.yaml
+generators:
+  version-readme:
+    filename: "{{ .Resource }}/{{ .Version }}/README"
+    template: README.tmpl
+apis:
+  test:
+    defaults:
+      linter: default-rules
+      generators:
+        - version-readme
+    resources:
+      - path: resources
+      - path: special-resources
+        linter: special-rules
+      - path: no-generator-resources
+        generators: []
+`)
+	proj, err := config.Load(conf)
+	c.Assert(err, qt.IsNil)
+	resources := proj.APIs["test"].Resources
+	c.Assert(resources[0].Linter, qt.Equals, "default-rules")
+	c.Assert(resources[0].Generators, qt.DeepEquals, []string{"version-readme"})
+	c.Assert(resources[1].Linter, qt.Equals, "special-rules")
+	c.Assert(resources[1].Generators, qt.DeepEquals, []string{"version-readme"})
+	c.Assert(resources[2].Linter, qt.Equals, "default-rules")
+	c.Assert(resources[2].Generators, qt.HasLen, 0)
+}
+
+func TestResourceSetExcludedBy(t *testing.T) {
+	c := qt.New(t)
+	rs := &config.ResourceSet{
+		Path: "resources",
+		Excludes: []string{
+			"resources/schemas/**",
+			"internal/**",
+		},
+	}
+	tests := []struct {
+		path     string
+		index    int
+		excluded bool
+	}{{
+		path: "resources/schemas/2021-06-01/spec.yaml", index: 0, excluded: true,
+	}, {
+		path: "resources/internal/foo/2021-06-01/spec.yaml", index: 1, excluded: true,
+	}, {
+		path: "resources/foo/2021-06-01/spec.yaml", index: -1, excluded: false,
+	}, {
+		path: "resources/foo/internal/2021-06-01/spec.yaml", index: -1, excluded: false,
+	}}
+	for _, test := range tests {
+		c.Run(test.path, func(c *qt.C) {
+			index, excluded := rs.ExcludedBy(test.path)
+			c.Assert(index, qt.Equals, test.index)
+			c.Assert(excluded, qt.Equals, test.excluded)
+		})
+	}
+}
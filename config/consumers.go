@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// ConsumersFile is the default name of the file in which downstream consumers
+// of a project's APIs pin the versions and operations they depend on.
+const ConsumersFile = "consumers.yaml"
+
+// Consumers declares the API versions and operations which downstream
+// consumers depend on, so that producers can tell when a build would break
+// them.
+type Consumers struct {
+	Consumers map[string]*Consumer `json:"consumers"`
+}
+
+// Consumer is a downstream team or service which depends on a project's APIs.
+type Consumer struct {
+	Name    string         `json:"-"`
+	Contact string         `json:"contact,omitempty"`
+	Pins    []*ConsumerPin `json:"pins"`
+}
+
+// ConsumerPin pins the operations a consumer uses in an API at a version.
+//
+// Operations are identified by operationId, or by method and path, such as
+// "GET /orgs/{orgId}/projects".
+type ConsumerPin struct {
+	API        string   `json:"api"`
+	Version    string   `json:"version"`
+	Operations []string `json:"operations"`
+}
+
+// ConsumerNames returns the names of all consumers, sorted.
+func (c *Consumers) ConsumerNames() []string {
+	var names []string
+	for name := range c.Consumers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var operationMethodPathRE = regexp.MustCompile(`^[A-Za-z]+ /`)
+
+// ParseOperation returns the method and path of an operation pinned by method
+// and path, or the operationId of an operation pinned by ID.
+func ParseOperation(s string) (method, path, operationID string) {
+	if operationMethodPathRE.MatchString(s) {
+		parts := strings.SplitN(s, " ", 2)
+		return strings.ToUpper(parts[0]), strings.TrimSpace(parts[1]), ""
+	}
+	return "", "", s
+}
+
+func (c *Consumers) init() {
+	if c.Consumers == nil {
+		c.Consumers = map[string]*Consumer{}
+	}
+	for name, consumer := range c.Consumers {
+		if consumer == nil {
+			consumer = &Consumer{}
+			c.Consumers[name] = consumer
+		}
+		consumer.Name = name
+	}
+}
+
+func (c *Consumers) validate() error {
+	for _, name := range c.ConsumerNames() {
+		consumer := c.Consumers[name]
+		for pinIndex, pin := range consumer.Pins {
+			if pin == nil || pin.API == "" {
+				return fmt.Errorf("missing api (consumers.%s.pins[%d].api)", name, pinIndex)
+			}
+			if pin.Version == "" {
+				return fmt.Errorf("missing version (consumers.%s.pins[%d].version)", name, pinIndex)
+			}
+			if len(pin.Operations) == 0 {
+				return fmt.Errorf("missing operations (consumers.%s.pins[%d].operations)", name, pinIndex)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadConsumers loads consumer pins from a reader.
+func LoadConsumers(r io.Reader) (*Consumers, error) {
+	var c Consumers
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read consumers: %w", err)
+	}
+	err = yaml.Unmarshal(buf, &c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal consumers: %w", err)
+	}
+	c.init()
+	return &c, c.validate()
+}
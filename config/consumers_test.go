@@ -0,0 +1,79 @@
+package config_test
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestLoadConsumers(t *testing.T) {
+	c := qt.New(t)
+	contracts, err := config.LoadConsumers(bytes.NewBufferString(`
+consumers:
+  billing:
+    contact: billing@example.com
+    pins:
+      - api: v3
+        version: 2021-06-13~beta
+        operations:
+          - createThing
+          - GET /things/{id}
+`[1:]))
+	c.Assert(err, qt.IsNil)
+	c.Assert(contracts.ConsumerNames(), qt.DeepEquals, []string{"billing"})
+	billing := contracts.Consumers["billing"]
+	c.Assert(billing.Name, qt.Equals, "billing")
+	c.Assert(billing.Contact, qt.Equals, "billing@example.com")
+	c.Assert(billing.Pins, qt.DeepEquals, []*config.ConsumerPin{{
+		API:        "v3",
+		Version:    "2021-06-13~beta",
+		Operations: []string{"createThing", "GET /things/{id}"},
+	}})
+}
+
+func TestLoadConsumersErrors(t *testing.T) {
+	c := qt.New(t)
+	tests := []struct {
+		conf, err string
+	}{{
+		conf: `
+consumers:
+  billing:
+    pins:
+      - version: 2021-06-13
+        operations: [createThing]`[1:],
+		err: `missing api \(consumers\.billing\.pins\[0\]\.api\)`,
+	}, {
+		conf: `
+consumers:
+  billing:
+    pins:
+      - api: v3
+        operations: [createThing]`[1:],
+		err: `missing version \(consumers\.billing\.pins\[0\]\.version\)`,
+	}, {
+		conf: `
+consumers:
+  billing:
+    pins:
+      - api: v3
+        version: 2021-06-13`[1:],
+		err: `missing operations \(consumers\.billing\.pins\[0\]\.operations\)`,
+	}}
+	for i := range tests {
+		c.Logf("test#%d: %s", i, tests[i].conf)
+		_, err := config.LoadConsumers(bytes.NewBufferString(tests[i].conf))
+		c.Assert(err, qt.ErrorMatches, tests[i].err)
+	}
+}
+
+func TestParseOperation(t *testing.T) {
+	c := qt.New(t)
+	method, path, operationID := config.ParseOperation("get /things/{id}")
+	c.Assert([]string{method, path, operationID}, qt.DeepEquals, []string{"GET", "/things/{id}", ""})
+	method, path, operationID = config.ParseOperation("createThing")
+	c.Assert([]string{method, path, operationID}, qt.DeepEquals, []string{"", "", "createThing"})
+}
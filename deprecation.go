@@ -0,0 +1,106 @@
+package vervet
+
+import (
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	// ExtSnykDeprecatedBy is used to annotate a deprecated operation with the
+	// version of its resource which removed it, its successor.
+	ExtSnykDeprecatedBy = "x-snyk-deprecated-by"
+
+	// ExtSnykSunsetEligible is used to annotate a deprecated operation with
+	// the date on which it may be removed from the versions that still
+	// provide it.
+	ExtSnykSunsetEligible = "x-snyk-sunset-eligible"
+)
+
+// SunsetPeriod returns how long a version of this stability remains
+// available once deprecated, before it may be sunset.
+func (s Stability) SunsetPeriod() time.Duration {
+	const day = 24 * time.Hour
+	switch s {
+	case StabilityExperimental:
+		return 31 * day
+	case StabilityBeta:
+		return 91 * day
+	case StabilityGA:
+		return 181 * day
+	}
+	return 0
+}
+
+// DeprecateRemovedOperations marks operations deprecated in each resource
+// version when a later version of the resource removes them, so that specs
+// compiled from earlier versions warn of the removal.
+//
+// An operation is deprecated by the next version of its resource with equal
+// or greater stability, which would replace it at every stability it is
+// available at. Deprecated operations are annotated with their successor
+// version and the date they are eligible to be sunset. Operations which move
+// to another resource in the same version are not deprecated.
+//
+// Resource documents are modified in place.
+func (s *SpecVersions) DeprecateRemovedOperations() {
+	for _, rv := range s.resources {
+		for i, rc := range rv.versions {
+			successor := nextVersionAtStability(rv.versions[i+1:], rc.Version.Stability)
+			if successor == nil {
+				continue
+			}
+			// The operations available in place of the resource's, at its
+			// stability, once the successor is released.
+			var replacements []*Resource
+			replacedAt := *successor.Version
+			replacedAt.Stability = rc.Version.Stability
+			if at, err := s.ResourcesAt(replacedAt.String()); err == nil {
+				replacements = at
+			}
+			for path, pathItem := range rc.Paths {
+				for method, op := range pathItem.Operations() {
+					if hasOperation(replacements, path, method) {
+						continue
+					}
+					deprecateOperation(op, rc.Version, successor.Version)
+				}
+			}
+		}
+	}
+}
+
+// nextVersionAtStability returns the first resource version with equal or
+// greater stability, or nil if there is none.
+func nextVersionAtStability(versions []*Resource, stability Stability) *Resource {
+	for _, rc := range versions {
+		if rc.Version.Stability.Compare(stability) >= 0 {
+			return rc
+		}
+	}
+	return nil
+}
+
+func hasOperation(resources []*Resource, path, method string) bool {
+	for _, rc := range resources {
+		if pathItem, ok := rc.Paths[path]; ok && pathItem.GetOperation(method) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func deprecateOperation(op *openapi3.Operation, version, successor *Version) {
+	op.Deprecated = true
+	if op.ExtensionProps.Extensions == nil {
+		op.ExtensionProps.Extensions = map[string]interface{}{}
+	}
+	if _, ok := op.ExtensionProps.Extensions[ExtSnykDeprecatedBy]; !ok {
+		op.ExtensionProps.Extensions[ExtSnykDeprecatedBy] = successor.String()
+	}
+	// Semantic versions have no release date to count the sunset period from.
+	if _, ok := op.ExtensionProps.Extensions[ExtSnykSunsetEligible]; !ok && !successor.Semantic {
+		sunset := successor.Date.Add(version.Stability.SunsetPeriod())
+		op.ExtensionProps.Extensions[ExtSnykSunsetEligible] = sunset.Format("2006-01-02")
+	}
+}
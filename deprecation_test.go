@@ -0,0 +1,116 @@
+package vervet_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	. "github.com/snyk/vervet"
+)
+
+// writeResourceSpec writes a resource version spec declaring operations, given
+// as "METHOD /path".
+func writeResourceSpec(c *qt.C, root, resource, version, stability string, paths ...string) {
+	dir := filepath.Join(root, resource, version)
+	c.Assert(os.MkdirAll(dir, 0777), qt.IsNil)
+	var sb strings.Builder
+	sb.WriteString("openapi: 3.0.3\nx-snyk-api-stability: " + stability + "\n")
+	sb.WriteString("info: {title: " + resource + ", version: 3.0.0}\npaths:\n")
+	methods := map[string][]string{}
+	var pathNames []string
+	for _, path := range paths {
+		parts := strings.SplitN(path, " ", 2)
+		if _, ok := methods[parts[1]]; !ok {
+			pathNames = append(pathNames, parts[1])
+		}
+		methods[parts[1]] = append(methods[parts[1]], strings.ToLower(parts[0]))
+	}
+	for _, pathName := range pathNames {
+		sb.WriteString("  " + pathName + ":\n")
+		for _, method := range methods[pathName] {
+			sb.WriteString("    " + method + ":\n      responses: {'204': {description: ok}}\n")
+		}
+	}
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "spec.yaml"), []byte(sb.String()), 0666), qt.IsNil)
+}
+
+func TestDeprecateRemovedOperations(t *testing.T) {
+	c := qt.New(t)
+	root := c.Mkdir()
+	writeResourceSpec(c, root, "things", "2021-06-01", "ga",
+		"GET /things", "DELETE /things", "GET /status")
+	writeResourceSpec(c, root, "things", "2021-07-01", "beta",
+		"GET /things", "DELETE /things")
+	writeResourceSpec(c, root, "things", "2021-08-01", "ga",
+		"GET /things")
+	// The status operation moves to another resource, rather than being
+	// removed.
+	writeResourceSpec(c, root, "statuses", "2021-08-01", "ga",
+		"GET /status")
+	specs, err := LoadSpecVersions(root)
+	c.Assert(err, qt.IsNil)
+	specs.DeprecateRemovedOperations()
+
+	tests := []struct {
+		version, path, method string
+		deprecatedBy, sunset  string
+	}{{
+		// Removed by the next GA version; the beta version in between does
+		// not replace it at GA.
+		version:      "2021-06-01",
+		path:         "/things",
+		method:       "DELETE",
+		deprecatedBy: "2021-08-01",
+		sunset:       "2022-01-29",
+	}, {
+		version:      "2021-07-01~beta",
+		path:         "/things",
+		method:       "DELETE",
+		deprecatedBy: "2021-08-01",
+		sunset:       "2021-10-31",
+	}, {
+		version: "2021-06-01",
+		path:    "/status",
+		method:  "GET",
+	}, {
+		version: "2021-06-01",
+		path:    "/things",
+		method:  "GET",
+	}, {
+		version: "2021-08-01",
+		path:    "/things",
+		method:  "GET",
+	}}
+	for _, test := range tests {
+		c.Logf("%s %s at %s", test.method, test.path, test.version)
+		doc, err := specs.At(test.version)
+		c.Assert(err, qt.IsNil)
+		op := doc.Paths[test.path].GetOperation(test.method)
+		c.Assert(op, qt.Not(qt.IsNil))
+		if test.deprecatedBy == "" {
+			c.Assert(op.Deprecated, qt.IsFalse)
+			c.Assert(op.ExtensionProps.Extensions[ExtSnykDeprecatedBy], qt.IsNil)
+			continue
+		}
+		c.Assert(op.Deprecated, qt.IsTrue)
+		deprecatedBy, err := ExtensionString(op.ExtensionProps, ExtSnykDeprecatedBy)
+		c.Assert(err, qt.IsNil)
+		c.Assert(deprecatedBy, qt.Equals, test.deprecatedBy)
+		sunset, err := ExtensionString(op.ExtensionProps, ExtSnykSunsetEligible)
+		c.Assert(err, qt.IsNil)
+		c.Assert(sunset, qt.Equals, test.sunset)
+	}
+}
+
+func TestSunsetPeriod(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(StabilityWIP.SunsetPeriod(), qt.Equals, time.Duration(0))
+	c.Assert(StabilityExperimental.SunsetPeriod(), qt.Equals, 31*24*time.Hour)
+	c.Assert(StabilityBeta.SunsetPeriod(), qt.Equals, 91*24*time.Hour)
+	c.Assert(StabilityGA.SunsetPeriod(), qt.Equals, 181*24*time.Hour)
+}
@@ -8,10 +8,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/ghodss/yaml"
 	"github.com/google/uuid"
+	yaml3 "gopkg.in/yaml.v3"
 )
 
 func init() {
@@ -25,13 +27,25 @@ func init() {
 // Document is an OpenAPI 3 document object model.
 type Document struct {
 	*openapi3.T
-	path string
-	url  *url.URL
+	path    string
+	url     *url.URL
+	options documentOptions
+
+	// The source file, parsed on demand to locate errors.
+	sourceOnce sync.Once
+	source     []byte
+	sourceRoot *yaml3.Node
+	sourceErr  error
 }
 
 // NewDocumentFile loads an OpenAPI spec file from the given file path,
 // returning a document object.
-func NewDocumentFile(specFile string) (*Document, error) {
+func NewDocumentFile(specFile string, options ...DocumentOption) (*Document, error) {
+	var opts documentOptions
+	for i := range options {
+		options[i](&opts)
+	}
+
 	// Restore current working directory upon returning
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -57,16 +71,16 @@ func NewDocumentFile(specFile string) (*Document, error) {
 		return nil, err
 	}
 
-	l := openapi3.NewLoader()
-	l.IsExternalRefsAllowed = true
+	l := opts.newLoader()
 	t, err := l.LoadFromFile(specBase)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load %q: %w", specBase, err)
 	}
 	return &Document{
-		T:    t,
-		path: specFile,
-		url:  specURL,
+		T:       t,
+		path:    specFile,
+		url:     specURL,
+		options: opts,
 	}, nil
 }
 
@@ -90,8 +104,7 @@ func (d *Document) Location() *url.URL {
 // ResolveRefs resolves all Ref types in the document, causing the Value field
 // of each Ref to be loaded and populated from its referenced location.
 func (d *Document) ResolveRefs() error {
-	l := openapi3.NewLoader()
-	l.IsExternalRefsAllowed = true
+	l := d.options.newLoader()
 	return l.ResolveRefsIn(d.T, d.url)
 }
 
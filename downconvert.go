@@ -0,0 +1,299 @@
+package vervet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/snyk/vervet/internal/specjson"
+)
+
+// Downconversion converts OpenAPI 3.1 documents to OpenAPI 3.0 as they are
+// loaded, so that resources written in 3.1 can be compiled to specs which
+// tooling supporting only 3.0 can consume.
+//
+// Conversion is best-effort. Constructs with an equivalent in 3.0 are
+// converted, such as `type: [string, "null"]` to `type: string` and
+// `nullable: true`, and `const` to a single-valued `enum`. Constructs without
+// one, such as webhooks and conditional schemas, are removed. Each
+// construct which could not be converted exactly is recorded, so that it can
+// be reported.
+type Downconversion struct {
+	mu    sync.Mutex
+	lossy []LossyConstruct
+}
+
+// LossyConstruct is a construct in an OpenAPI 3.1 document which could not be
+// converted to OpenAPI 3.0 exactly.
+type LossyConstruct struct {
+	// Location is the location of the document containing the construct.
+	Location string
+
+	// Pointer is a JSON pointer to the construct in the document.
+	Pointer string
+
+	// Reason describes how the construct was converted.
+	Reason string
+}
+
+// String returns a description of the lossy construct.
+func (l LossyConstruct) String() string {
+	return fmt.Sprintf("%s%s: %s", l.Location, l.Pointer, l.Reason)
+}
+
+// Lossy returns the constructs in the documents converted so far which could
+// not be converted exactly, in order of location.
+func (d *Downconversion) Lossy() []LossyConstruct {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	result := append([]LossyConstruct(nil), d.lossy...)
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Location != result[j].Location {
+			return result[i].Location < result[j].Location
+		}
+		return result[i].Pointer < result[j].Pointer
+	})
+	return result
+}
+
+// WithDownconversion converts OpenAPI 3.1 documents to OpenAPI 3.0 as they
+// are loaded, recording lossy constructs in d.
+func WithDownconversion(d *Downconversion) DocumentOption {
+	return func(o *documentOptions) {
+		o.downconversion = d
+	}
+}
+
+// convert converts a document read from location, which may be YAML or JSON,
+// to OpenAPI 3.0 if it is OpenAPI 3.1 or a document referenced from one.
+// Documents which need no conversion are returned as they are.
+func (d *Downconversion) convert(location *url.URL, buf []byte) ([]byte, error) {
+	var doc interface{}
+	err := yaml.Unmarshal(buf, &doc)
+	if err != nil {
+		// The loader reports documents which fail to parse.
+		return buf, nil
+	}
+	c := &downconverter{location: location.String()}
+	if location.Scheme == "" && location.Host == "" {
+		// Local documents are loaded relative to the directory of the
+		// document referring to them, which is not where they are reported.
+		path, err := filepath.Abs(filepath.FromSlash(location.Path))
+		if err != nil {
+			return nil, err
+		}
+		c.location = path
+	}
+	doc = c.walk(doc, "", false)
+	if m, ok := doc.(map[string]interface{}); ok {
+		c.convertRoot(m)
+	}
+	if !c.changed {
+		return buf, nil
+	}
+	d.mu.Lock()
+	d.lossy = append(d.lossy, c.lossy...)
+	d.mu.Unlock()
+	return json.Marshal(doc)
+}
+
+// downconverter converts a single document.
+type downconverter struct {
+	location string
+	changed  bool
+	lossy    []LossyConstruct
+}
+
+func (c *downconverter) lose(pointer, format string, args ...interface{}) {
+	c.lossy = append(c.lossy, LossyConstruct{
+		Location: c.location,
+		Pointer:  "#" + pointer,
+		Reason:   fmt.Sprintf(format, args...),
+	})
+}
+
+// downconvertNameMaps are the keys of OpenAPI and JSON Schema objects which
+// map names, rather than field names, to objects, so that a property named
+// const, for example, is not mistaken for the const keyword.
+var downconvertNameMaps = map[string]bool{
+	"properties": true, "headers": true, "encoding": true, "variables": true,
+	"mapping": true, "scopes": true, "schemas": true, "parameters": true,
+	"securitySchemes": true, "requestBodies": true, "links": true,
+	"callbacks": true, "responses": true, "content": true, "paths": true,
+	"pathItems": true, "webhooks": true,
+}
+
+// downconvertDataKeys are the keys of objects with values that are data,
+// rather than OpenAPI objects or schemas.
+var downconvertDataKeys = map[string]bool{
+	"example": true, "default": true, "enum": true, "const": true, "value": true,
+}
+
+// downconvertRemoved are JSON Schema keywords supported by OpenAPI 3.1 but
+// not 3.0, which are removed.
+var downconvertRemoved = []string{
+	"$schema", "$id", "$anchor", "$dynamicRef", "$dynamicAnchor", "$comment",
+	"$defs", "if", "then", "else", "dependentSchemas", "dependentRequired",
+	"prefixItems", "unevaluatedProperties", "unevaluatedItems", "propertyNames",
+	"contains", "minContains", "maxContains", "patternProperties",
+	"contentEncoding", "contentMediaType", "contentSchema",
+}
+
+func (c *downconverter) walk(v interface{}, pointer string, names bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if !names {
+			c.convertObject(v, pointer)
+		}
+		for k := range v {
+			kPointer := pointer + "/" + specjson.EscapePointer(k)
+			if !names && strings.HasPrefix(k, "x-") {
+				continue
+			}
+			if !names && downconvertDataKeys[k] {
+				continue
+			}
+			if _, ok := v[k].(map[string]interface{}); ok && !names && k == "examples" {
+				// Examples of parameters and media types, rather than of
+				// schemas, are data.
+				continue
+			}
+			v[k] = c.walk(v[k], kPointer, !names && downconvertNameMaps[k])
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = c.walk(v[i], fmt.Sprintf("%s/%d", pointer, i), false)
+		}
+	}
+	return v
+}
+
+// convertObject converts the JSON Schema keywords in an object which differ
+// between OpenAPI 3.1 and 3.0.
+func (c *downconverter) convertObject(m map[string]interface{}, pointer string) {
+	switch typ := m["type"].(type) {
+	case []interface{}:
+		c.changed = true
+		var types []interface{}
+		nullable := false
+		for _, t := range typ {
+			if t == "null" {
+				nullable = true
+			} else {
+				types = append(types, t)
+			}
+		}
+		delete(m, "type")
+		switch len(types) {
+		case 0:
+			c.lose(pointer+"/type", "type null has no equivalent, converted to nullable without a type")
+		case 1:
+			m["type"] = types[0]
+		default:
+			anyOf, _ := m["anyOf"].([]interface{})
+			for _, t := range types {
+				anyOf = append(anyOf, map[string]interface{}{"type": t})
+			}
+			m["anyOf"] = anyOf
+		}
+		if nullable {
+			m["nullable"] = true
+		}
+	case string:
+		if typ == "null" {
+			c.changed = true
+			delete(m, "type")
+			m["nullable"] = true
+			c.lose(pointer+"/type", "type null has no equivalent, converted to nullable without a type")
+		}
+	}
+	if value, ok := m["const"]; ok {
+		c.changed = true
+		delete(m, "const")
+		if _, ok := m["enum"]; !ok {
+			m["enum"] = []interface{}{value}
+		}
+	}
+	if examples, ok := m["examples"].([]interface{}); ok {
+		c.changed = true
+		delete(m, "examples")
+		if len(examples) > 0 {
+			if _, ok := m["example"]; !ok {
+				m["example"] = examples[0]
+			}
+		}
+		if len(examples) > 1 {
+			c.lose(pointer+"/examples", "only the first of %d examples is kept", len(examples))
+		}
+	}
+	for _, bound := range []struct{ exclusive, inclusive string }{
+		{"exclusiveMinimum", "minimum"},
+		{"exclusiveMaximum", "maximum"},
+	} {
+		if value, ok := m[bound.exclusive].(float64); ok {
+			c.changed = true
+			m[bound.inclusive] = value
+			m[bound.exclusive] = true
+		}
+	}
+	for _, keyword := range downconvertRemoved {
+		if _, ok := m[keyword]; ok {
+			c.changed = true
+			delete(m, keyword)
+			c.lose(pointer+"/"+specjson.EscapePointer(keyword), "%s is not supported by OpenAPI 3.0, removed", keyword)
+		}
+	}
+	if _, ok := m["$ref"]; ok && len(m) > 1 {
+		var siblings []string
+		for k := range m {
+			if k != "$ref" {
+				siblings = append(siblings, k)
+			}
+		}
+		sort.Strings(siblings)
+		c.lose(pointer, "%s beside $ref are ignored by OpenAPI 3.0", strings.Join(siblings, ", "))
+	}
+}
+
+// convertRoot converts the top-level objects of an OpenAPI 3.1 document.
+func (c *downconverter) convertRoot(doc map[string]interface{}) {
+	version, _ := doc["openapi"].(string)
+	if !strings.HasPrefix(version, "3.1") {
+		return
+	}
+	c.changed = true
+	doc["openapi"] = "3.0.3"
+	if _, ok := doc["paths"]; !ok {
+		doc["paths"] = map[string]interface{}{}
+	}
+	for _, key := range []string{"webhooks", "jsonSchemaDialect"} {
+		if _, ok := doc[key]; ok {
+			delete(doc, key)
+			c.lose("/"+key, "%s is not supported by OpenAPI 3.0, removed", key)
+		}
+	}
+	if info, ok := doc["info"].(map[string]interface{}); ok {
+		if _, ok := info["summary"]; ok {
+			delete(info, "summary")
+			c.lose("/info/summary", "summary is not supported by OpenAPI 3.0, removed")
+		}
+		if license, ok := info["license"].(map[string]interface{}); ok {
+			if _, ok := license["identifier"]; ok {
+				delete(license, "identifier")
+				c.lose("/info/license/identifier", "identifier is not supported by OpenAPI 3.0, removed")
+			}
+		}
+	}
+	if components, ok := doc["components"].(map[string]interface{}); ok {
+		if _, ok := components["pathItems"]; ok {
+			delete(components, "pathItems")
+			c.lose("/components/pathItems", "pathItems is not supported by OpenAPI 3.0, removed")
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package vervet_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	. "github.com/snyk/vervet"
+	"github.com/snyk/vervet/testdata"
+)
+
+const downconvertSpecYAML = `
+openapi: 3.1.0
+info:
+  title: test
+  summary: a test
+  version: 0.0.0
+  license:
+    name: Apache 2.0
+    identifier: Apache-2.0
+webhooks:
+  newThing:
+    post:
+      responses:
+        '200':
+          description: OK
+paths:
+  /things:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            exclusiveMinimum: 0
+          examples:
+            small:
+              value: 10
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: 'thing.yaml#/Thing'
+`
+
+const downconvertThingYAML = `
+Thing:
+  type: object
+  properties:
+    name:
+      type: [string, "null"]
+      examples: [foo, bar]
+    kind:
+      const: thing
+    const:
+      type: string
+    size:
+      type: [integer, number]
+    labels:
+      type: object
+      propertyNames:
+        pattern: '^[a-z]+$'
+`
+
+func TestDownconversion(t *testing.T) {
+	c := qt.New(t)
+	dir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "spec.yaml"), []byte(downconvertSpecYAML[1:]), 0666), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "thing.yaml"), []byte(downconvertThingYAML[1:]), 0666), qt.IsNil)
+
+	// OpenAPI 3.1 cannot be loaded as 3.0 without conversion.
+	_, err := NewDocumentFile(filepath.Join(dir, "spec.yaml"))
+	c.Assert(err, qt.IsNotNil)
+
+	d := &Downconversion{}
+	doc, err := NewDocumentFile(filepath.Join(dir, "spec.yaml"), WithDownconversion(d))
+	c.Assert(err, qt.IsNil)
+	c.Assert(doc.OpenAPI, qt.Equals, "3.0.3")
+	c.Assert(doc.Info.License.Name, qt.Equals, "Apache 2.0")
+
+	limit := doc.Paths["/things"].Get.Parameters[0].Value
+	c.Assert(*limit.Schema.Value.Min, qt.Equals, 0.0)
+	c.Assert(limit.Schema.Value.ExclusiveMin, qt.IsTrue)
+	c.Assert(limit.Examples["small"].Value.Value, qt.Equals, 10.0)
+
+	thing := doc.Paths["/things"].Get.Responses["200"].Value.Content["application/json"].Schema.Value
+	name := thing.Properties["name"].Value
+	c.Assert(name.Type, qt.Equals, "string")
+	c.Assert(name.Nullable, qt.IsTrue)
+	c.Assert(name.Example, qt.Equals, "foo")
+	c.Assert(thing.Properties["kind"].Value.Enum, qt.DeepEquals, []interface{}{"thing"})
+	// A property named for a keyword is not converted.
+	c.Assert(thing.Properties["const"].Value.Type, qt.Equals, "string")
+	size := thing.Properties["size"].Value
+	c.Assert(size.AnyOf, qt.HasLen, 2)
+	c.Assert(size.AnyOf[0].Value.Type, qt.Equals, "integer")
+	c.Assert(size.AnyOf[1].Value.Type, qt.Equals, "number")
+
+	var lossy []string
+	for _, l := range d.Lossy() {
+		rel, err := filepath.Rel(dir, l.Location)
+		c.Assert(err, qt.IsNil)
+		lossy = append(lossy, rel+l.Pointer+": "+l.Reason)
+	}
+	c.Assert(lossy, qt.DeepEquals, []string{
+		"spec.yaml#/info/license/identifier: identifier is not supported by OpenAPI 3.0, removed",
+		"spec.yaml#/info/summary: summary is not supported by OpenAPI 3.0, removed",
+		"spec.yaml#/webhooks: webhooks is not supported by OpenAPI 3.0, removed",
+		"thing.yaml#/Thing/properties/labels/propertyNames: propertyNames is not supported by OpenAPI 3.0, removed",
+		"thing.yaml#/Thing/properties/name/examples: only the first of 2 examples is kept",
+	})
+}
+
+func TestDownconversionUnchanged(t *testing.T) {
+	c := qt.New(t)
+	d := &Downconversion{}
+	doc, err := NewDocumentFile(testdata.Path("resources/projects/2021-06-04/spec.yaml"), WithDownconversion(d))
+	c.Assert(err, qt.IsNil)
+	c.Assert(doc.OpenAPI, qt.Equals, "3.0.3")
+	c.Assert(d.Lossy(), qt.HasLen, 0)
+}
@@ -0,0 +1,220 @@
+package vervet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+
+	"github.com/snyk/vervet/internal/specjson"
+)
+
+// ExtensionSchemas validates the vendor extensions in OpenAPI documents
+// against the schemas registered for them.
+//
+// Extensions which are not registered are allowed, unless they are under a
+// strict prefix, or are so close to the name of a registered extension that
+// they are most likely a misspelling of it, such as x-snyk-api-stabillity.
+type ExtensionSchemas struct {
+	schemas map[string]*openapi3.Schema
+	strict  []string
+}
+
+// NewExtensionSchemas returns ExtensionSchemas with the extensions vervet
+// itself uses registered. Other tools may use other x-snyk- extensions, so
+// x-snyk- is not a strict prefix unless made one.
+func NewExtensionSchemas() *ExtensionSchemas {
+	e := &ExtensionSchemas{schemas: map[string]*openapi3.Schema{}}
+	e.Register(ExtSnykApiStability, openapi3.NewStringSchema().WithEnum("wip", "experimental", "beta", "ga"))
+	e.Register(ExtSnykApiVersion, openapi3.NewStringSchema())
+	e.Register(ExtSnykIncludeHeaders, openapi3.NewObjectSchema())
+	e.Register(ExtSnykDeprecatedBy, openapi3.NewStringSchema())
+	e.Register(ExtSnykSunsetEligible, openapi3.NewStringSchema().WithFormat("date"))
+	return e
+}
+
+// Register registers the schema which values of an extension must match.
+func (e *ExtensionSchemas) Register(name string, schema *openapi3.Schema) {
+	e.schemas[name] = schema
+}
+
+// RegisterFile registers the schema in a YAML or JSON file, which values of
+// an extension must match.
+func (e *ExtensionSchemas) RegisterFile(name, path string) error {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var schema openapi3.Schema
+	err = yaml.Unmarshal(buf, &schema)
+	if err != nil {
+		return fmt.Errorf("failed to load schema: %w (%s)", err, path)
+	}
+	err = schema.Validate(context.Background())
+	if err != nil {
+		return fmt.Errorf("invalid schema: %w (%s)", err, path)
+	}
+	e.Register(name, &schema)
+	return nil
+}
+
+// Strict requires all extensions with a name prefix, such as x-acme-, to be
+// registered.
+func (e *ExtensionSchemas) Strict(prefix string) {
+	e.strict = append(e.strict, prefix)
+}
+
+// WithExtensionSchemas validates the vendor extensions in resource documents
+// when they are loaded.
+func WithExtensionSchemas(e *ExtensionSchemas) DocumentOption {
+	return func(o *documentOptions) {
+		o.extensionSchemas = e
+	}
+}
+
+// Validate returns an error if any extension in an OpenAPI document is not
+// valid. Errors are located by a JSON pointer to the object with the
+// extension, and returned as a PointerError to the extension itself.
+func (e *ExtensionSchemas) Validate(doc *openapi3.T) error {
+	buf, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var v interface{}
+	err = json.Unmarshal(buf, &v)
+	if err != nil {
+		return err
+	}
+	return e.validate(v, "#", false)
+}
+
+// extensionNameMaps are the keys of OpenAPI objects which map names, rather
+// than field names, to values. Their keys are never extensions, even those
+// starting with x-, such as header names.
+var extensionNameMaps = map[string]bool{
+	"properties": true, "headers": true, "encoding": true, "variables": true,
+	"mapping": true, "scopes": true, "schemas": true, "parameters": true,
+	"securitySchemes": true, "requestBodies": true, "links": true,
+	"callbacks": true,
+}
+
+// extensionDataKeys are the keys of OpenAPI objects with values that are
+// data, such as examples, rather than OpenAPI objects.
+var extensionDataKeys = map[string]bool{
+	"example": true, "examples": true, "default": true, "enum": true,
+}
+
+func (e *ExtensionSchemas) validate(v interface{}, location string, names bool) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			kLocation := location + "/" + specjson.EscapePointer(k)
+			if !names && strings.HasPrefix(k, "x-") {
+				err := e.validateExtension(k, v[k], location)
+				if err != nil {
+					return &PointerError{Pointer: kLocation, Err: err}
+				}
+				continue
+			}
+			if !names && extensionDataKeys[k] {
+				continue
+			}
+			// The values in a map of names are OpenAPI objects again.
+			err := e.validate(v[k], kLocation, !names && extensionNameMaps[k])
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			err := e.validate(v[i], fmt.Sprintf("%s/%d", location, i), false)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *ExtensionSchemas) validateExtension(name string, value interface{}, location string) error {
+	schema, ok := e.schemas[name]
+	if !ok {
+		if similar := e.similar(name); similar != "" {
+			return fmt.Errorf("unknown extension %q at %s, did you mean %q?", name, location, similar)
+		}
+		for _, prefix := range e.strict {
+			if strings.HasPrefix(name, prefix) {
+				return fmt.Errorf("unknown extension %q at %s", name, location)
+			}
+		}
+		return nil
+	}
+	err := schema.VisitJSON(value)
+	if err != nil {
+		// Schema errors describe the entire schema and value, which is
+		// more than is needed to locate the problem.
+		var schemaErr *openapi3.SchemaError
+		if errors.As(err, &schemaErr) {
+			if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+				return fmt.Errorf("invalid extension %q at %s: %s: %s",
+					name, location, strings.Join(pointer, "/"), schemaErr.Reason)
+			}
+			return fmt.Errorf("invalid extension %q at %s: %s", name, location, schemaErr.Reason)
+		}
+		return fmt.Errorf("invalid extension %q at %s: %w", name, location, err)
+	}
+	return nil
+}
+
+// similar returns the registered extension most similar to name, if there is
+// one likely to have been misspelled as name.
+func (e *ExtensionSchemas) similar(name string) string {
+	const maxDistance = 2
+	var result string
+	best := maxDistance + 1
+	for registered := range e.schemas {
+		d := editDistance(name, registered)
+		if d < best || (d == best && registered < result) {
+			result, best = registered, d
+		}
+	}
+	return result
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
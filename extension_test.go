@@ -0,0 +1,97 @@
+package vervet_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	. "github.com/snyk/vervet"
+)
+
+const extensionSpec = `
+openapi: 3.0.3
+x-snyk-api-stability: %s
+info: {title: test, version: 3.0.0}
+paths:
+  /orgs:
+    get:
+      x-acme-tier: %s
+      responses:
+        "200":
+          description: ok
+          headers:
+            x-request-id:
+              schema: {type: string}
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  x-name: {type: string}
+              example: {x-snyk-api-stabillity: data}
+`
+
+func loadExtensionSpec(c *qt.C, stability, tier string) *openapi3.T {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(
+		fmt.Sprintf(extensionSpec, stability, tier)))
+	c.Assert(err, qt.IsNil)
+	return doc
+}
+
+func TestExtensionSchemas(t *testing.T) {
+	c := qt.New(t)
+	e := NewExtensionSchemas()
+	tierSchema := filepath.Join(c.Mkdir(), "tier.yaml")
+	c.Assert(ioutil.WriteFile(tierSchema, []byte(`{type: string, enum: [free, paid]}`), 0644), qt.IsNil)
+	c.Assert(e.RegisterFile("x-acme-tier", tierSchema), qt.IsNil)
+
+	// Header and property names, and examples, are not extensions.
+	c.Assert(e.Validate(loadExtensionSpec(c, "beta", "free")), qt.IsNil)
+
+	err := e.Validate(loadExtensionSpec(c, "alpha", "free"))
+	c.Assert(err, qt.ErrorMatches, `invalid extension "x-snyk-api-stability" at #: value is not one of the allowed values`)
+	err = e.Validate(loadExtensionSpec(c, "beta", "gold"))
+	c.Assert(err, qt.ErrorMatches, `invalid extension "x-acme-tier" at #/paths/~1orgs/get: value is not one of the allowed values`)
+
+	doc := loadExtensionSpec(c, "beta", "free")
+	doc.Extensions["x-snyk-api-stabillity"] = "beta"
+	err = e.Validate(doc)
+	c.Assert(err, qt.ErrorMatches, `unknown extension "x-snyk-api-stabillity" at #, did you mean "x-snyk-api-stability"\?`)
+
+	// Unknown extensions are allowed, unless under a strict prefix.
+	doc = loadExtensionSpec(c, "beta", "free")
+	doc.Extensions["x-acme-owner"] = "platform"
+	c.Assert(e.Validate(doc), qt.IsNil)
+	e.Strict("x-acme-")
+	err = e.Validate(doc)
+	c.Assert(err, qt.ErrorMatches, `unknown extension "x-acme-owner" at #`)
+}
+
+func TestLoadResourceExtensionSchemas(t *testing.T) {
+	c := qt.New(t)
+	specFile := filepath.Join(c.Mkdir(), "things", "2021-06-01", "spec.yaml")
+	c.Assert(os.MkdirAll(filepath.Dir(specFile), 0777), qt.IsNil)
+	c.Assert(ioutil.WriteFile(specFile, []byte(`
+openapi: 3.0.3
+x-snyk-api-stabillity: beta
+info: {title: test, version: 3.0.0}
+paths:
+  /things:
+    get:
+      responses:
+        "204": {description: ok}
+`), 0644), qt.IsNil)
+	_, err := LoadSpecVersionsFileset([]string{specFile}, WithExtensionSchemas(NewExtensionSchemas()))
+	var sourceErr *SourceError
+	c.Assert(errors.As(err, &sourceErr), qt.IsTrue)
+	c.Assert(sourceErr.File, qt.Equals, specFile)
+	c.Assert(sourceErr.Line, qt.Equals, 3)
+	c.Assert(sourceErr.Column, qt.Equals, 1)
+	c.Assert(sourceErr.Err, qt.ErrorMatches, `unknown extension "x-snyk-api-stabillity" at #, did you mean "x-snyk-api-stability"\?`)
+}
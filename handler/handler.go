@@ -0,0 +1,390 @@
+// Package handler provides an http.Handler which serves versioned OpenAPI
+// specs, so that services can publish the API versions they implement.
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/uuid"
+
+	"github.com/snyk/vervet"
+)
+
+const (
+	// HeaderVersionRequested is the response header containing the version
+	// requested by the caller.
+	HeaderVersionRequested = "snyk-version-requested"
+
+	// HeaderVersionServed is the response header containing the version
+	// resolved from the request, which was actually served.
+	HeaderVersionServed = "snyk-version-served"
+
+	// HeaderRequestID is the response header containing a unique ID for
+	// tracking the request. A request ID provided by the caller in this
+	// header is used if present.
+	HeaderRequestID = "snyk-request-id"
+
+	contentTypeJSONAPI = "application/vnd.api+json"
+	contentTypeYAML    = "application/x-yaml"
+
+	defaultPrefix = "/openapi"
+	channelsPath  = "channels"
+	defaultMaxAge = time.Hour
+)
+
+// Handler serves the list of available versions at its prefix (/openapi by
+// default), and the OpenAPI spec resolved at each version under it
+// (/openapi/{version}).
+//
+// Requested versions resolve to specs the same way vervet.SpecVersions.At
+// does. Specs are rendered as JSON, or YAML if requested with an Accept
+// header of application/x-yaml.
+//
+// Specs may be slimmed down to the operations a client needs with the tag,
+// path (a path prefix) and operationId query parameters, each of which may be
+// repeated or comma-separated. Only the matching operations are included,
+// along with the components they use (/openapi/2021-10-01?tag=Projects, for
+// example). Slimmed specs are generated on request from the full spec.
+//
+// Release channels are served under the prefix too, listing only the
+// versions at or above a stability: /openapi/channels/ga lists GA versions,
+// and /openapi/channels/beta lists beta and GA versions. Clients can discover
+// the versions they are willing to use without filtering the full list.
+//
+// Named version aliases, such as "latest" or "stable", indexed in compiled
+// output are redirected to the version they were resolved to when building
+// (/openapi/latest to /openapi/2021-10-01, for example). Clients may pin to an
+// alias, while the version it refers to is controlled by the build.
+//
+// Registering the Handler on a mux is typically all that's needed:
+//
+//     h := handler.New(specVersions)
+//     mux.Handle("/openapi", h)
+//     mux.Handle("/openapi/", h)
+type Handler struct {
+	specs  *vervet.SpecVersions
+	prefix string
+	maxAge time.Duration
+
+	mu       sync.Mutex
+	versions *rendered
+	channels map[vervet.Stability]*rendered
+	specsAt  map[string]*rendered
+}
+
+type rendered struct {
+	json     []byte
+	yaml     []byte
+	etag     string
+	yamlETag string
+}
+
+// Option configures a Handler.
+type Option func(h *Handler)
+
+// Prefix sets the URL path at which the Handler serves the list of versions.
+// Specs are served from subpaths of the prefix. Default is "/openapi".
+func Prefix(prefix string) Option {
+	return func(h *Handler) {
+		h.prefix = "/" + strings.Trim(prefix, "/")
+	}
+}
+
+// MaxAge sets the max-age directive of the Cache-Control header on
+// successful responses. Default is one hour.
+func MaxAge(maxAge time.Duration) Option {
+	return func(h *Handler) {
+		h.maxAge = maxAge
+	}
+}
+
+// New returns a new Handler serving the given spec versions.
+func New(specs *vervet.SpecVersions, options ...Option) *Handler {
+	h := &Handler{
+		specs:    specs,
+		prefix:   defaultPrefix,
+		maxAge:   defaultMaxAge,
+		channels: map[vervet.Stability]*rendered{},
+		specsAt:  map[string]*rendered{},
+	}
+	for i := range options {
+		options[i](h)
+	}
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Header.Get(HeaderRequestID)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	w.Header().Set(HeaderRequestID, requestID)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		h.writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, h.prefix) {
+		h.writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	versionArg := strings.Trim(strings.TrimPrefix(r.URL.Path, h.prefix), "/")
+	if versionArg == "" {
+		h.serveVersions(w, r)
+		return
+	}
+	if strings.HasPrefix(versionArg, channelsPath+"/") {
+		h.serveChannel(w, r, strings.TrimPrefix(versionArg, channelsPath+"/"))
+		return
+	}
+	if strings.Contains(versionArg, "/") {
+		h.writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	h.serveSpec(w, r, versionArg)
+}
+
+func (h *Handler) serveVersions(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.renderVersions()
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeRendered(w, r, resp, false)
+}
+
+func (h *Handler) serveChannel(w http.ResponseWriter, r *http.Request, stabilityArg string) {
+	stability, err := vervet.ParseStability(stabilityArg)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	resp, err := h.renderChannel(stability)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeRendered(w, r, resp, false)
+}
+
+func (h *Handler) serveSpec(w http.ResponseWriter, r *http.Request, versionArg string) {
+	w.Header().Set(HeaderVersionRequested, versionArg)
+	if target, ok := h.specs.VersionAlias(versionArg); ok {
+		h.redirect(w, r, target)
+		return
+	}
+	if _, err := vervet.ParseVersion(versionArg); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	served, err := h.specs.Resolve(versionArg)
+	if err == vervet.ErrNoMatchingVersion {
+		h.writeError(w, http.StatusNotFound, fmt.Sprintf("no spec matching version %q", versionArg))
+		return
+	} else if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set(HeaderVersionServed, served.String())
+	resp, err := h.renderSpec(served)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if filter := parseSlimFilter(r.URL.Query()); filter != nil {
+		doc, kept, err := filter.slim(resp.json)
+		if err != nil {
+			h.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if kept == 0 {
+			h.writeError(w, http.StatusNotFound, fmt.Sprintf("no operations matching %s", filter))
+			return
+		}
+		resp, err = newRendered(doc)
+		if err != nil {
+			h.writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	h.writeRendered(w, r, resp, acceptsYAML(r))
+}
+
+// redirect responds with a redirect to the spec at a version, keeping any
+// query parameters.
+func (h *Handler) redirect(w http.ResponseWriter, r *http.Request, version string) {
+	w.Header().Set(HeaderVersionServed, version)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	location := h.prefix + "/" + version
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+func (h *Handler) renderVersions() (*rendered, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.versions != nil {
+		return h.versions, nil
+	}
+	versions := h.specs.Versions()
+	versionStrings := make([]string, len(versions))
+	for i := range versions {
+		versionStrings[i] = versions[i].String()
+	}
+	resp, err := newRendered(versionStrings)
+	if err != nil {
+		return nil, err
+	}
+	h.versions = resp
+	return resp, nil
+}
+
+// renderChannel renders the list of versions at or above a stability.
+func (h *Handler) renderChannel(stability vervet.Stability) (*rendered, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if resp, ok := h.channels[stability]; ok {
+		return resp, nil
+	}
+	versionStrings := []string{}
+	for _, version := range h.specs.Versions() {
+		if version.Stability.Compare(stability) >= 0 {
+			versionStrings = append(versionStrings, version.String())
+		}
+	}
+	resp, err := newRendered(versionStrings)
+	if err != nil {
+		return nil, err
+	}
+	h.channels[stability] = resp
+	return resp, nil
+}
+
+func (h *Handler) renderSpec(v *vervet.Version) (*rendered, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if resp, ok := h.specsAt[v.String()]; ok {
+		return resp, nil
+	}
+	spec, err := h.specs.At(v.String())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := newRendered(spec)
+	if err != nil {
+		return nil, err
+	}
+	h.specsAt[v.String()] = resp
+	return resp, nil
+}
+
+func newRendered(v interface{}) (*rendered, error) {
+	jsonBuf, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	yamlBuf, err := yaml.JSONToYAML(jsonBuf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	// The YAML is tagged by its own digest, so that the weak comparison of
+	// If-None-Match does not mistake one representation for the other.
+	sum := sha256.Sum256(jsonBuf)
+	yamlSum := sha256.Sum256(yamlBuf)
+	return &rendered{
+		json:     jsonBuf,
+		yaml:     yamlBuf,
+		etag:     `"` + hex.EncodeToString(sum[:16]) + `"`,
+		yamlETag: `W/"` + hex.EncodeToString(yamlSum[:16]) + `"`,
+	}, nil
+}
+
+func (h *Handler) writeRendered(w http.ResponseWriter, r *http.Request, resp *rendered, asYAML bool) {
+	contentType, body, etag := contentTypeJSONAPI, resp.json, resp.etag
+	if asYAML {
+		contentType, body, etag = contentTypeYAML, resp.yaml, resp.yamlETag
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Vary", "Accept")
+	if noneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// noneMatch returns whether an If-None-Match header matches an entity tag, so
+// that the response is not modified. The header is "*", or a list of entity
+// tags compared weakly, as in RFC 7232, section 3.2.
+func noneMatch(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+type errorDocument struct {
+	JSONAPI jsonapi      `json:"jsonapi"`
+	Errors  []errorEntry `json:"errors"`
+}
+
+type jsonapi struct {
+	Version string `json:"version"`
+}
+
+type errorEntry struct {
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", contentTypeJSONAPI)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&errorDocument{
+		JSONAPI: jsonapi{Version: "1.0"},
+		Errors: []errorEntry{{
+			Status: fmt.Sprintf("%d", status),
+			Detail: detail,
+		}},
+	})
+}
+
+func acceptsYAML(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType = strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0])
+			switch mediaType {
+			case contentTypeYAML, "application/yaml", "text/yaml":
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,353 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/handler"
+	"github.com/snyk/vervet/testdata"
+)
+
+func setup(c *qt.C, options ...handler.Option) *httptest.Server {
+	specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(testdata.Path("output")))
+	c.Assert(err, qt.IsNil)
+	h := handler.New(specs, options...)
+	mux := http.NewServeMux()
+	mux.Handle("/openapi", h)
+	mux.Handle("/openapi/", h)
+	srv := httptest.NewServer(mux)
+	c.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVersions(t *testing.T) {
+	c := qt.New(t)
+	srv := setup(c)
+	resp, err := http.Get(srv.URL + "/openapi")
+	c.Assert(err, qt.IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), qt.Equals, "application/vnd.api+json")
+	c.Assert(resp.Header.Get("Cache-Control"), qt.Equals, "public, max-age=3600")
+	c.Assert(resp.Header.Get("snyk-request-id"), qt.Not(qt.Equals), "")
+	var versions []string
+	c.Assert(json.NewDecoder(resp.Body).Decode(&versions), qt.IsNil)
+	c.Assert(versions, qt.HasLen, 12)
+	c.Assert(versions[0], qt.Equals, "2021-06-01")
+	c.Assert(versions[11], qt.Equals, "2021-06-13~experimental")
+}
+
+func TestChannels(t *testing.T) {
+	c := qt.New(t)
+	srv := setup(c)
+	tests := []struct {
+		stability string
+		versions  []string
+	}{{
+		stability: "ga",
+		versions:  []string{"2021-06-01", "2021-06-04", "2021-06-07", "2021-06-13"},
+	}, {
+		stability: "beta",
+		versions: []string{
+			"2021-06-01", "2021-06-01~beta", "2021-06-04", "2021-06-04~beta",
+			"2021-06-07", "2021-06-07~beta", "2021-06-13", "2021-06-13~beta",
+		},
+	}, {
+		stability: "wip",
+		versions:  nil,
+	}}
+	for _, test := range tests {
+		c.Run(test.stability, func(c *qt.C) {
+			resp, err := http.Get(srv.URL + "/openapi/channels/" + test.stability)
+			c.Assert(err, qt.IsNil)
+			defer resp.Body.Close()
+			c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+			c.Assert(resp.Header.Get("Content-Type"), qt.Equals, "application/vnd.api+json")
+			var versions []string
+			c.Assert(json.NewDecoder(resp.Body).Decode(&versions), qt.IsNil)
+			if test.versions == nil {
+				c.Assert(versions, qt.HasLen, 12)
+			} else {
+				c.Assert(versions, qt.DeepEquals, test.versions)
+			}
+		})
+	}
+
+	resp, err := http.Get(srv.URL + "/openapi/channels/stable")
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusBadRequest)
+}
+
+func TestSpec(t *testing.T) {
+	c := qt.New(t)
+	srv := setup(c)
+	tests := []struct {
+		requested, served string
+		hasPath           string
+	}{{
+		requested: "2021-06-05",
+		served:    "2021-06-04",
+		hasPath:   "/examples/hello-world/{id}",
+	}, {
+		requested: "2021-07-01~beta",
+		served:    "2021-06-13~beta",
+		hasPath:   "/examples/hello-world",
+	}, {
+		requested: "2021-06-13~experimental",
+		served:    "2021-06-13~experimental",
+		hasPath:   "/orgs/{orgId}/projects",
+	}}
+	for _, test := range tests {
+		c.Run(test.requested, func(c *qt.C) {
+			resp, err := http.Get(srv.URL + "/openapi/" + test.requested)
+			c.Assert(err, qt.IsNil)
+			defer resp.Body.Close()
+			c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+			c.Assert(resp.Header.Get("snyk-version-requested"), qt.Equals, test.requested)
+			c.Assert(resp.Header.Get("snyk-version-served"), qt.Equals, test.served)
+			c.Assert(resp.Header.Get("ETag"), qt.Not(qt.Equals), "")
+			var doc struct {
+				Paths map[string]interface{} `json:"paths"`
+			}
+			c.Assert(json.NewDecoder(resp.Body).Decode(&doc), qt.IsNil)
+			c.Assert(doc.Paths[test.hasPath], qt.Not(qt.IsNil))
+		})
+	}
+}
+
+func TestSpecYAML(t *testing.T) {
+	c := qt.New(t)
+	srv := setup(c)
+	req, err := http.NewRequest("GET", srv.URL+"/openapi/2021-06-05", nil)
+	c.Assert(err, qt.IsNil)
+	req.Header.Set("Accept", "application/x-yaml")
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, qt.IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), qt.Equals, "application/x-yaml")
+	buf, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, qt.IsNil)
+	var doc map[string]interface{}
+	c.Assert(yaml.Unmarshal(buf, &doc), qt.IsNil)
+	c.Assert(doc["openapi"], qt.Equals, "3.0.3")
+}
+
+func TestNotModified(t *testing.T) {
+	c := qt.New(t)
+	srv := setup(c)
+	resp, err := http.Get(srv.URL + "/openapi/2021-06-05")
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+
+	req, err := http.NewRequest("GET", srv.URL+"/openapi/2021-06-05", nil)
+	c.Assert(err, qt.IsNil)
+	req.Header.Set("Accept", "application/x-yaml")
+	resp, err = http.DefaultClient.Do(req)
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	yamlETag := resp.Header.Get("ETag")
+	c.Assert(yamlETag, qt.Matches, `W/".+"`)
+
+	tests := []struct {
+		name, ifNoneMatch, accept string
+		status                    int
+	}{{
+		name:        "same",
+		ifNoneMatch: etag,
+		status:      http.StatusNotModified,
+	}, {
+		name:        "weak",
+		ifNoneMatch: "W/" + etag,
+		status:      http.StatusNotModified,
+	}, {
+		name:        "list",
+		ifNoneMatch: `"abc", ` + etag + `,W/"def"`,
+		status:      http.StatusNotModified,
+	}, {
+		name:        "any",
+		ifNoneMatch: "*",
+		status:      http.StatusNotModified,
+	}, {
+		name:        "none in list",
+		ifNoneMatch: `"abc", W/"def"`,
+		status:      http.StatusOK,
+	}, {
+		name:        "yaml",
+		ifNoneMatch: yamlETag,
+		accept:      "application/x-yaml",
+		status:      http.StatusNotModified,
+	}, {
+		name:        "yaml tag of json",
+		ifNoneMatch: yamlETag,
+		status:      http.StatusOK,
+	}, {
+		name:        "json tag of yaml",
+		ifNoneMatch: etag,
+		accept:      "application/x-yaml",
+		status:      http.StatusOK,
+	}}
+	for _, test := range tests {
+		c.Run(test.name, func(c *qt.C) {
+			req, err := http.NewRequest("GET", srv.URL+"/openapi/2021-06-06", nil)
+			c.Assert(err, qt.IsNil)
+			req.Header.Set("If-None-Match", test.ifNoneMatch)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			c.Assert(err, qt.IsNil)
+			resp.Body.Close()
+			c.Assert(resp.StatusCode, qt.Equals, test.status)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := qt.New(t)
+	srv := setup(c)
+	tests := []struct {
+		method, path string
+		status       int
+	}{{
+		method: "GET", path: "/openapi/nope", status: http.StatusBadRequest,
+	}, {
+		method: "GET", path: "/openapi/2020-01-01", status: http.StatusNotFound,
+	}, {
+		method: "GET", path: "/openapi/2021-06-05/foo", status: http.StatusNotFound,
+	}, {
+		method: "POST", path: "/openapi", status: http.StatusMethodNotAllowed,
+	}}
+	for _, test := range tests {
+		c.Run(test.method+" "+test.path, func(c *qt.C) {
+			req, err := http.NewRequest(test.method, srv.URL+test.path, nil)
+			c.Assert(err, qt.IsNil)
+			resp, err := http.DefaultClient.Do(req)
+			c.Assert(err, qt.IsNil)
+			defer resp.Body.Close()
+			c.Assert(resp.StatusCode, qt.Equals, test.status)
+			var errDoc struct {
+				Errors []struct {
+					Status string `json:"status"`
+				} `json:"errors"`
+			}
+			c.Assert(json.NewDecoder(resp.Body).Decode(&errDoc), qt.IsNil)
+			c.Assert(errDoc.Errors, qt.HasLen, 1)
+		})
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	c := qt.New(t)
+	specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(testdata.Path("output")))
+	c.Assert(err, qt.IsNil)
+	srv := httptest.NewServer(handler.New(specs, handler.Prefix("/api/v3/openapi/")))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/api/v3/openapi/2021-06-05")
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+}
+
+func TestVersionAlias(t *testing.T) {
+	c := qt.New(t)
+	srv := setup(c)
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(srv.URL + "/openapi/launch")
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusFound)
+	c.Assert(resp.Header.Get("Location"), qt.Equals, "/openapi/2021-06-04~beta")
+	c.Assert(resp.Header.Get("snyk-version-requested"), qt.Equals, "launch")
+	c.Assert(resp.Header.Get("snyk-version-served"), qt.Equals, "2021-06-04~beta")
+
+	// Following the redirect serves the spec at the aliased version.
+	resp, err = http.Get(srv.URL + "/openapi/latest")
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("snyk-version-served"), qt.Equals, "2021-06-13")
+}
+
+func TestSlimSpec(t *testing.T) {
+	c := qt.New(t)
+	srv := setup(c)
+	tests := []struct {
+		query      string
+		paths      []string
+		hasSchema  string
+		notSchemas []string
+	}{{
+		query:      "operationId=helloWorldGetOne",
+		paths:      []string{"/examples/hello-world/{id}"},
+		hasSchema:  "HelloWorld",
+		notSchemas: []string{"Project"},
+	}, {
+		query:      "path=/orgs",
+		paths:      []string{"/orgs/{orgId}/projects"},
+		hasSchema:  "Project",
+		notSchemas: []string{"HelloWorld"},
+	}, {
+		query: "path=/examples&operationId=helloWorldCreate,getOrgsProjects",
+		paths: []string{"/examples/hello-world"},
+	}}
+	for _, test := range tests {
+		c.Run(test.query, func(c *qt.C) {
+			resp, err := http.Get(srv.URL + "/openapi/2021-06-13~experimental?" + test.query)
+			c.Assert(err, qt.IsNil)
+			defer resp.Body.Close()
+			c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+			var doc struct {
+				Paths      map[string]interface{} `json:"paths"`
+				Components struct {
+					Schemas map[string]interface{} `json:"schemas"`
+				} `json:"components"`
+			}
+			c.Assert(json.NewDecoder(resp.Body).Decode(&doc), qt.IsNil)
+			var paths []string
+			for path := range doc.Paths {
+				paths = append(paths, path)
+			}
+			c.Assert(paths, qt.ContentEquals, test.paths)
+			if test.hasSchema != "" {
+				c.Assert(doc.Components.Schemas[test.hasSchema], qt.Not(qt.IsNil))
+			}
+			for _, name := range test.notSchemas {
+				c.Assert(doc.Components.Schemas[name], qt.IsNil)
+			}
+		})
+	}
+
+	resp, err := http.Get(srv.URL + "/openapi/2021-06-13~experimental?tag=Nope")
+	c.Assert(err, qt.IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusNotFound)
+
+	// Slimmed specs are valid OpenAPI.
+	resp, err = http.Get(srv.URL + "/openapi/latest?path=/examples")
+	c.Assert(err, qt.IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+	buf, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, qt.IsNil)
+	doc, err := openapi3.NewLoader().LoadFromData(buf)
+	c.Assert(err, qt.IsNil)
+	c.Assert(doc.Validate(context.Background()), qt.IsNil)
+	c.Assert(doc.Paths.Find("/orgs/{orgId}/projects"), qt.IsNil)
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/snyk/vervet/internal/specjson"
+)
+
+// slimFilter selects the operations to include in a slimmed spec. An
+// operation is included if it matches every kind of criteria given, and any
+// one of the values given for each.
+type slimFilter struct {
+	tags         []string
+	pathPrefixes []string
+	operationIDs []string
+}
+
+// parseSlimFilter returns the filter requested by the tag, path and
+// operationId query parameters, or nil if none were given. Each may be
+// repeated, or given as a comma-separated list.
+func parseSlimFilter(query url.Values) *slimFilter {
+	f := &slimFilter{
+		tags:         queryValues(query, "tag"),
+		pathPrefixes: queryValues(query, "path"),
+		operationIDs: queryValues(query, "operationId"),
+	}
+	if len(f.tags) == 0 && len(f.pathPrefixes) == 0 && len(f.operationIDs) == 0 {
+		return nil
+	}
+	return f
+}
+
+func queryValues(query url.Values, key string) []string {
+	var values []string
+	for _, value := range query[key] {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+// String returns a canonical form of the filter, identifying the spec it
+// slims to.
+func (f *slimFilter) String() string {
+	return url.Values{
+		"tag":         f.tags,
+		"path":        f.pathPrefixes,
+		"operationId": f.operationIDs,
+	}.Encode()
+}
+
+func (f *slimFilter) matches(path string, op map[string]interface{}) bool {
+	if len(f.pathPrefixes) > 0 && !matchesAny(f.pathPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(path, prefix)
+	}) {
+		return false
+	}
+	if len(f.operationIDs) > 0 {
+		operationID, _ := op["operationId"].(string)
+		if !matchesAny(f.operationIDs, func(id string) bool { return id == operationID }) {
+			return false
+		}
+	}
+	if len(f.tags) > 0 {
+		tags, _ := op["tags"].([]interface{})
+		if !matchesAny(f.tags, func(tag string) bool { return containsValue(tags, tag) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(values []string, f func(string) bool) bool {
+	for _, v := range values {
+		if f(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(values []interface{}, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// slim returns the spec given as JSON with only the operations matching the
+// filter, along with the components they use, directly or transitively. Paths
+// left without operations are removed, as are tags no longer used by any
+// operation. The number of operations kept is returned with the spec.
+func (f *slimFilter) slim(jsonBuf []byte) (map[string]interface{}, int, error) {
+	var doc map[string]interface{}
+	err := json.Unmarshal(jsonBuf, &doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	paths, _ := doc["paths"].(map[string]interface{})
+	usedTags := map[string]bool{}
+	kept := 0
+	for path, v := range paths {
+		pathItem, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		keptPath := false
+		for method, v := range pathItem {
+			if !specjson.OperationMethods[method] {
+				continue
+			}
+			op, _ := v.(map[string]interface{})
+			if !f.matches(path, op) {
+				delete(pathItem, method)
+				continue
+			}
+			keptPath = true
+			kept++
+			tags, _ := op["tags"].([]interface{})
+			for _, tag := range tags {
+				if s, ok := tag.(string); ok {
+					usedTags[s] = true
+				}
+			}
+		}
+		if !keptPath {
+			delete(paths, path)
+		}
+	}
+
+	if tags, ok := doc["tags"].([]interface{}); ok {
+		var keptTags []interface{}
+		for _, v := range tags {
+			tag, _ := v.(map[string]interface{})
+			if name, _ := tag["name"].(string); usedTags[name] {
+				keptTags = append(keptTags, v)
+			}
+		}
+		if len(keptTags) == 0 {
+			delete(doc, "tags")
+		} else {
+			doc["tags"] = keptTags
+		}
+	}
+
+	// Security schemes are referred to by name rather than reference, and
+	// are kept regardless.
+	reached := specjson.ReachableComponents(doc)
+	components, _ := doc["components"].(map[string]interface{})
+	for sectionName, v := range components {
+		section, ok := v.(map[string]interface{})
+		if !ok || sectionName == "securitySchemes" {
+			continue
+		}
+		for name := range section {
+			if !reached["#/components/"+sectionName+"/"+specjson.EscapePointer(name)] {
+				delete(section, name)
+			}
+		}
+		if len(section) == 0 {
+			delete(components, sectionName)
+		}
+	}
+	return doc, kept, nil
+}
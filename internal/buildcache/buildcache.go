@@ -0,0 +1,155 @@
+// Package buildcache stores compiled specs by a digest of their inputs, so
+// that builds may reuse specs compiled by prior builds, including those on
+// other machines such as ephemeral CI runners.
+package buildcache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A Cache stores build outputs by key.
+type Cache interface {
+	// Get returns the value stored at key, and whether there was one.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Put stores a value at key.
+	Put(ctx context.Context, key string, value []byte) error
+}
+
+// httpTimeout limits how long each request to a remote cache opened by New
+// may take, so that an unresponsive cache does not stall builds.
+const httpTimeout = 30 * time.Second
+
+// New returns the Cache at a location, which is either an http or https URL
+// of a remote cache, or a local directory.
+func New(location string) (Cache, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid build cache URL: %w", err)
+		}
+		return NewHTTP(u, &http.Client{Timeout: httpTimeout}), nil
+	}
+	dir, err := filepath.Abs(location)
+	if err != nil {
+		return nil, err
+	}
+	return Dir(dir), nil
+}
+
+// Dir is a Cache which stores each value in a file in a local directory.
+type Dir string
+
+// Get implements Cache.
+func (d Dir) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	buf, err := ioutil.ReadFile(filepath.Join(string(d), key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return buf, true, nil
+}
+
+// Put implements Cache. Values are written to a temporary file and renamed
+// into place, so that concurrent builds sharing the directory never read a
+// partially written value.
+func (d Dir) Put(ctx context.Context, key string, value []byte) error {
+	err := os.MkdirAll(string(d), 0777)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(string(d), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(value)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(string(d), key))
+}
+
+// HTTP is a Cache stored on an HTTP server, such as a build cache service or
+// an object store bucket endpoint. Values are read with a GET, and stored
+// with a PUT, of the key under the cache URL. Credentials in the URL are sent
+// as basic authentication.
+type HTTP struct {
+	url    *url.URL
+	client *http.Client
+}
+
+// NewHTTP returns a new HTTP cache at u.
+func NewHTTP(u *url.URL, client *http.Client) *HTTP {
+	return &HTTP{url: u, client: client}
+}
+
+func (h *HTTP) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	u := *h.url
+	u.User = nil
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if h.url.User != nil {
+		password, _ := h.url.User.Password()
+		req.SetBasicAuth(h.url.User.Username(), password)
+	}
+	return req, nil
+}
+
+// Get implements Cache.
+func (h *HTTP) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	req, err := h.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("build cache GET %s: %s", key, resp.Status)
+	}
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	return buf, true, nil
+}
+
+// Put implements Cache.
+func (h *HTTP) Put(ctx context.Context, key string, value []byte) error {
+	req, err := h.newRequest(ctx, http.MethodPut, key, value)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("build cache PUT %s: %s", key, resp.Status)
+	}
+	return nil
+}
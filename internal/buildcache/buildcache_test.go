@@ -0,0 +1,74 @@
+package buildcache_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/internal/buildcache"
+)
+
+func testCache(c *qt.C, cache buildcache.Cache) {
+	ctx := context.Background()
+	_, ok, err := cache.Get(ctx, "abc")
+	c.Assert(err, qt.IsNil)
+	c.Assert(ok, qt.IsFalse)
+
+	c.Assert(cache.Put(ctx, "abc", []byte("hello")), qt.IsNil)
+	buf, ok, err := cache.Get(ctx, "abc")
+	c.Assert(err, qt.IsNil)
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(string(buf), qt.Equals, "hello")
+}
+
+func TestDir(t *testing.T) {
+	c := qt.New(t)
+	cache, err := buildcache.New(c.Mkdir())
+	c.Assert(err, qt.IsNil)
+	testCache(c, cache)
+}
+
+func TestHTTP(t *testing.T) {
+	c := qt.New(t)
+	var mu sync.Mutex
+	values := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "ci" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			value, ok := values[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(value)
+		case http.MethodPut:
+			value, err := ioutil.ReadAll(r.Body)
+			c.Check(err, qt.IsNil)
+			values[r.URL.Path] = value
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	c.Cleanup(srv.Close)
+
+	cache, err := buildcache.New(strings.Replace(srv.URL, "http://", "http://ci:secret@", 1) + "/vervet/")
+	c.Assert(err, qt.IsNil)
+	testCache(c, cache)
+	c.Assert(values["/vervet/abc"], qt.Not(qt.IsNil))
+
+	cache, err = buildcache.New(srv.URL)
+	c.Assert(err, qt.IsNil)
+	_, _, err = cache.Get(context.Background(), "abc")
+	c.Assert(err, qt.ErrorMatches, `build cache GET abc: 401 Unauthorized`)
+}
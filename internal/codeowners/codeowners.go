@@ -0,0 +1,158 @@
+// Package codeowners maps files to their owners, according to a GitHub
+// CODEOWNERS file.
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Locations are the paths, relative to the root of a repository, at which a
+// CODEOWNERS file may be found, in order of preference.
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Owners maps files in a repository to their owners.
+type Owners struct {
+	root  string
+	rules []rule
+}
+
+type rule struct {
+	patterns []string
+	owners   []string
+}
+
+// Find returns the path of the CODEOWNERS file in the repository rooted at
+// dir, or an error if there is none.
+func Find(dir string) (string, error) {
+	for _, location := range Locations {
+		path := filepath.Join(dir, filepath.FromSlash(location))
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("CODEOWNERS not found in %q", dir)
+}
+
+// Load returns the Owners declared in a CODEOWNERS file. Paths are owned
+// relative to the root of the repository the file is in: the parent of its
+// directory if it is in .github or docs, otherwise its own directory.
+func Load(path string) (*Owners, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	root := filepath.Dir(path)
+	switch filepath.Base(root) {
+	case ".github", "docs":
+		root = filepath.Dir(root)
+	}
+	owners, err := Parse(f, root)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s)", err, path)
+	}
+	return owners, nil
+}
+
+// Parse returns the Owners declared in CODEOWNERS content, for files in the
+// repository rooted at root.
+//
+// Each line declares a pattern, followed by the owners of the files it
+// matches. Where several patterns match a file, the last one wins. A pattern
+// without owners leaves the files it matches unowned.
+func Parse(r io.Reader, root string) (*Owners, error) {
+	o := &Owners{root: root}
+	sc := bufio.NewScanner(r)
+	lineNum := 0
+	for sc.Scan() {
+		lineNum++
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		patterns := ownerPatterns(fields[0])
+		for _, pattern := range patterns {
+			if !doublestar.ValidatePattern(pattern) {
+				return nil, fmt.Errorf("invalid pattern %q on line %d", fields[0], lineNum)
+			}
+		}
+		ownerRule := rule{patterns: patterns}
+		if len(fields) > 1 {
+			ownerRule.owners = fields[1:]
+		}
+		o.rules = append(o.rules, ownerRule)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// ownerPatterns returns the doublestar patterns which match the same files as
+// a CODEOWNERS pattern.
+//
+// Patterns starting with "/" match from the root of the repository, as do
+// patterns containing "/" elsewhere; other patterns match at any depth. A
+// pattern matching a directory matches everything in it, unless it ends in a
+// wildcard, such as "docs/*", which only matches the files directly in it.
+func ownerPatterns(pattern string) []string {
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+	if !anchored && !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	if strings.Contains(path.Base(pattern), "*") && !strings.HasSuffix(pattern, "**") {
+		return []string{pattern}
+	}
+	return []string{pattern, pattern + "/**"}
+}
+
+// Of returns the owners of a file, or nil if the file is not owned. Relative
+// paths are relative to the working directory.
+func (o *Owners) Of(file string) []string {
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return nil
+	}
+	relFile, err := filepath.Rel(o.root, absFile)
+	if err != nil || strings.HasPrefix(relFile, "..") {
+		return nil
+	}
+	relFile = filepath.ToSlash(relFile)
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		for _, pattern := range o.rules[i].patterns {
+			if ok, _ := doublestar.Match(pattern, relFile); ok {
+				return o.rules[i].owners
+			}
+		}
+	}
+	return nil
+}
+
+// IsOwner returns whether owner is one of the owners of a file. Owners are
+// compared case-insensitively, and the leading "@" of a user or team may be
+// omitted.
+func (o *Owners) IsOwner(file, owner string) bool {
+	for _, fileOwner := range o.Of(file) {
+		if strings.EqualFold(fileOwner, owner) || strings.EqualFold(fileOwner, "@"+owner) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package codeowners_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/internal/codeowners"
+)
+
+const testCodeowners = `
+# Default owners
+*                          @acme/platform
+
+# Resources are owned by their teams
+/resources/                @acme/api-guild
+/resources/orgs/           @acme/orgs @alice
+projects/                  @acme/projects   # at any depth
+*.oas.yaml                 @acme/oas
+/resources/schemas/*       @acme/schemas
+/resources/unowned/
+`
+
+func TestOf(t *testing.T) {
+	c := qt.New(t)
+	owners, err := codeowners.Parse(strings.NewReader(testCodeowners), "/repo")
+	c.Assert(err, qt.IsNil)
+	tests := []struct {
+		file   string
+		owners []string
+	}{{
+		file: "/repo/README.md", owners: []string{"@acme/platform"},
+	}, {
+		file: "/repo/resources/foo/2021-06-01/spec.yaml", owners: []string{"@acme/api-guild"},
+	}, {
+		file: "/repo/resources/orgs/2021-06-01/spec.yaml", owners: []string{"@acme/orgs", "@alice"},
+	}, {
+		file: "/repo/resources/orgs/projects/2021-06-01/spec.yaml", owners: []string{"@acme/projects"},
+	}, {
+		file: "/repo/resources/orgs/2021-06-01/spec.oas.yaml", owners: []string{"@acme/oas"},
+	}, {
+		file: "/repo/resources/schemas/common.yaml", owners: []string{"@acme/schemas"},
+	}, {
+		// Only files directly in a directory matched by a wildcard
+		file: "/repo/resources/schemas/errors/common.yaml", owners: []string{"@acme/api-guild"},
+	}, {
+		file: "/repo/resources/unowned/2021-06-01/spec.yaml", owners: nil,
+	}, {
+		file: "/elsewhere/spec.yaml", owners: nil,
+	}}
+	for _, test := range tests {
+		c.Run(test.file, func(c *qt.C) {
+			c.Assert(owners.Of(test.file), qt.DeepEquals, test.owners)
+		})
+	}
+	c.Assert(owners.IsOwner("/repo/resources/orgs/2021-06-01/spec.yaml", "acme/ORGS"), qt.IsTrue)
+	c.Assert(owners.IsOwner("/repo/resources/orgs/2021-06-01/spec.yaml", "@alice"), qt.IsTrue)
+	c.Assert(owners.IsOwner("/repo/resources/orgs/2021-06-01/spec.yaml", "@acme/platform"), qt.IsFalse)
+}
+
+func TestParseError(t *testing.T) {
+	c := qt.New(t)
+	_, err := codeowners.Parse(strings.NewReader("\n/resources/[ @acme/api-guild\n"), "/repo")
+	c.Assert(err, qt.ErrorMatches, `invalid pattern "/resources/\[" on line 2`)
+}
+
+func TestFindLoad(t *testing.T) {
+	c := qt.New(t)
+	root := c.Mkdir()
+	_, err := codeowners.Find(root)
+	c.Assert(err, qt.ErrorMatches, `CODEOWNERS not found in .*`)
+
+	c.Assert(os.MkdirAll(filepath.Join(root, ".github"), 0777), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte(testCodeowners), 0666), qt.IsNil)
+	path, err := codeowners.Find(root)
+	c.Assert(err, qt.IsNil)
+	c.Assert(path, qt.Equals, filepath.Join(root, ".github", "CODEOWNERS"))
+
+	// Files are owned relative to the repository, not the .github directory.
+	owners, err := codeowners.Load(path)
+	c.Assert(err, qt.IsNil)
+	c.Assert(owners.Of(filepath.Join(root, "resources/orgs/2021-06-01/spec.yaml")), qt.DeepEquals,
+		[]string{"@acme/orgs", "@alice"})
+}
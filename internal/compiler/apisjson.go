@@ -0,0 +1,181 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"text/template"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+)
+
+// APIsJSONFile is the name of the APIs.json manifest written to an output
+// directory, when configured.
+const APIsJSONFile = "apis.json"
+
+const apisJSONSpecificationVersion = "0.14"
+
+// apisJSON is an APIs.json manifest, as described at http://apisjson.org.
+type apisJSON struct {
+	Name                 string         `json:"name"`
+	Description          string         `json:"description,omitempty"`
+	SpecificationVersion string         `json:"specificationVersion"`
+	Modified             string         `json:"modified,omitempty"`
+	APIs                 []*apisJSONAPI `json:"apis"`
+
+	// Channels maps each stability to the latest version released at that
+	// stability.
+	Channels map[string]string `json:"x-stability-channels,omitempty"`
+}
+
+type apisJSONAPI struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	HumanURL    string             `json:"humanURL,omitempty"`
+	BaseURL     string             `json:"baseURL,omitempty"`
+	Version     string             `json:"version"`
+	Tags        []string           `json:"tags,omitempty"`
+	Properties  []apisJSONProperty `json:"properties"`
+}
+
+type apisJSONProperty struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// apisJSONTemplate renders an APIs.json manifest from the versions compiled
+// into an output.
+type apisJSONTemplate struct {
+	name        string
+	description string
+	specURL     *template.Template
+	docsURL     *template.Template
+}
+
+func newAPIsJSONTemplate(env *envExpander, apiName string, export *config.APIsJSONExport) (*apisJSONTemplate, error) {
+	if export == nil {
+		return nil, nil
+	}
+	specURLStr, err := env.expand(export.SpecURL, fmt.Sprintf("apis.%s.output.exports.apis-json.spec-url", apiName))
+	if err != nil {
+		return nil, err
+	}
+	specURL, err := template.New("spec-url").Parse(specURLStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w (apis.%s.output.exports.apis-json.spec-url)", err, apiName)
+	}
+	docsURLStr, err := env.expand(export.DocsURL, fmt.Sprintf("apis.%s.output.exports.apis-json.docs-url", apiName))
+	if err != nil {
+		return nil, err
+	}
+	docsURL, err := template.New("docs-url").Parse(docsURLStr)
+	if err != nil {
+		return nil, fmt.Errorf("%w (apis.%s.output.exports.apis-json.docs-url)", err, apiName)
+	}
+	name := export.Name
+	if name == "" {
+		name = apiName
+	}
+	return &apisJSONTemplate{
+		name:        name,
+		description: export.Description,
+		specURL:     specURL,
+		docsURL:     docsURL,
+	}, nil
+}
+
+// api renders the manifest entry of a compiled version.
+func (t *apisJSONTemplate) api(apiName string, version *vervet.Version, spec *openapi3.T) (*apisJSONAPI, error) {
+	scope := &serverScope{
+		API:       apiName,
+		Version:   version.String(),
+		Date:      version.DateString(),
+		Stability: version.Stability.String(),
+	}
+	var specURL, docsURL bytes.Buffer
+	if err := t.specURL.Execute(&specURL, scope); err != nil {
+		return nil, fmt.Errorf("%w (apis.%s.output.exports.apis-json.spec-url)", err, apiName)
+	}
+	if err := t.docsURL.Execute(&docsURL, scope); err != nil {
+		return nil, fmt.Errorf("%w (apis.%s.output.exports.apis-json.docs-url)", err, apiName)
+	}
+	result := &apisJSONAPI{
+		Name:     t.name,
+		HumanURL: docsURL.String(),
+		Version:  version.String(),
+		Tags:     []string{version.Stability.String()},
+		Properties: []apisJSONProperty{{
+			Type: "OpenAPI",
+			URL:  specURL.String(),
+		}},
+	}
+	if spec.Info != nil {
+		if spec.Info.Title != "" {
+			result.Name = spec.Info.Title
+		}
+		result.Description = spec.Info.Description
+	}
+	if len(spec.Servers) > 0 {
+		result.BaseURL = spec.Servers[0].URL
+	}
+	return result, nil
+}
+
+// manifest returns the APIs.json manifest describing the given entries, which
+// are sorted by version.
+func (t *apisJSONTemplate) manifest(apis map[string]*apisJSONAPI) (*apisJSON, error) {
+	result := &apisJSON{
+		Name:                 t.name,
+		Description:          t.description,
+		SpecificationVersion: apisJSONSpecificationVersion,
+		APIs:                 []*apisJSONAPI{},
+	}
+	var versions []*vervet.Version
+	for versionStr := range apis {
+		version, err := vervet.ParseVersion(versionStr)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Compare(versions[j]) < 0
+	})
+	for _, version := range versions {
+		result.APIs = append(result.APIs, apis[version.String()])
+		if result.Channels == nil {
+			result.Channels = map[string]string{}
+		}
+		result.Channels[version.Stability.String()] = version.String()
+		if !version.Semantic {
+			result.Modified = version.DateString()
+		}
+	}
+	return result, nil
+}
+
+// readAPIsJSON adds the entries of an existing APIs.json manifest in an
+// output, if any, to apis.
+func readAPIsJSON(outputPath string, apis map[string]*apisJSONAPI) error {
+	buf, err := ioutil.ReadFile(outputPath + "/" + APIsJSONFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read %s: %w", APIsJSONFile, err)
+	}
+	var manifest apisJSON
+	err = json.Unmarshal(buf, &manifest)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", APIsJSONFile, err)
+	}
+	for _, api := range manifest.APIs {
+		apis[api.Version] = api
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package compiler
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestBuildAPIsJSON(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	outputPath := c.Mkdir()
+	exportAPIsJSON := func(proj *config.Project) {
+		proj.APIs["v3-api"].Output.Exports = &config.Exports{
+			APIsJSON: &config.APIsJSONExport{
+				Description: "Versions of the v3 API",
+				SpecURL:     "https://api.example.com/openapi/{{ .Version }}",
+				DocsURL:     "https://docs.example.com/{{ .API }}/{{ .Date }}",
+			},
+		}
+		proj.APIs["v3-api"].Output.Aliases = config.OutputAliasesIndex
+	}
+	compiler := newTestCompiler(c, outputPath, exportAPIsJSON)
+	err := compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+
+	buf, err := ioutil.ReadFile(outputPath + "/" + APIsJSONFile)
+	c.Assert(err, qt.IsNil)
+	var manifest apisJSON
+	err = json.Unmarshal(buf, &manifest)
+	c.Assert(err, qt.IsNil)
+	c.Assert(manifest.Name, qt.Equals, "v3-api")
+	c.Assert(manifest.Description, qt.Equals, "Versions of the v3 API")
+	c.Assert(manifest.SpecificationVersion, qt.Equals, "0.14")
+
+	// Aliased versions are listed, as these are published too.
+	versions := map[string]*apisJSONAPI{}
+	for _, api := range manifest.APIs {
+		versions[api.Version] = api
+	}
+	c.Assert(versions["2021-06-01~beta"], qt.DeepEquals, &apisJSONAPI{
+		Name:     "Registry",
+		HumanURL: "https://docs.example.com/v3-api/2021-06-01",
+		BaseURL:  "https://example.com/api/v3",
+		Version:  "2021-06-01~beta",
+		Tags:     []string{"beta"},
+		Properties: []apisJSONProperty{{
+			Type: "OpenAPI",
+			URL:  "https://api.example.com/openapi/2021-06-01~beta",
+		}},
+	})
+	c.Assert(manifest.Modified, qt.Equals, manifest.APIs[len(manifest.APIs)-1].Version[:10])
+	c.Assert(manifest.Channels["experimental"], qt.Matches, `\d{4}-\d{2}-\d{2}~experimental`)
+	c.Assert(manifest.Channels["ga"], qt.Matches, `\d{4}-\d{2}-\d{2}`)
+
+	// A partial build keeps the entries of versions which were not rebuilt.
+	compiler = newTestCompiler(c, outputPath, exportAPIsJSON, Filter(BuildFilter{Version: "2021-06-04~experimental"}))
+	err = compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+	buf, err = ioutil.ReadFile(outputPath + "/" + APIsJSONFile)
+	c.Assert(err, qt.IsNil)
+	var partial apisJSON
+	err = json.Unmarshal(buf, &partial)
+	c.Assert(err, qt.IsNil)
+	c.Assert(partial, qt.DeepEquals, manifest)
+}
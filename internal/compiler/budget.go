@@ -0,0 +1,41 @@
+package compiler
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/snyk/vervet/config"
+)
+
+// checkBudget checks a compiled spec against the size budget of its output.
+// Specs over budget are an error, unless the budget only warns of them.
+func checkBudget(apiName string, budget *config.Budget, version string, compiled *compiledSpec) error {
+	if budget == nil {
+		return nil
+	}
+	var errs []error
+	if budget.MaxBytes > 0 && len(compiled.json) > budget.MaxBytes {
+		errs = append(errs, fmt.Errorf("version %s: compiled spec is %d bytes, over the budget of %d (apis.%s.output.budget.max-bytes)",
+			version, len(compiled.json), budget.MaxBytes, apiName))
+	}
+	if budget.MaxOperations > 0 {
+		numOps := 0
+		for _, pathItem := range compiled.spec.Paths {
+			numOps += len(pathItem.Operations())
+		}
+		if numOps > budget.MaxOperations {
+			errs = append(errs, fmt.Errorf("version %s: compiled spec has %d operations, over the budget of %d (apis.%s.output.budget.max-operations)",
+				version, numOps, budget.MaxOperations, apiName))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	if budget.Warn {
+		for _, err := range errs {
+			log.Printf("warning: %v", err)
+		}
+		return nil
+	}
+	return errs[0]
+}
@@ -0,0 +1,43 @@
+package compiler
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestBuildBudget(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	tests := []struct {
+		budget *config.Budget
+		err    string
+	}{{
+		budget: &config.Budget{MaxBytes: 1 << 20, MaxOperations: 100},
+	}, {
+		budget: &config.Budget{MaxBytes: 1000},
+		err:    `version .*: compiled spec is \d+ bytes, over the budget of 1000 \(apis.v3-api.output.budget.max-bytes\)`,
+	}, {
+		budget: &config.Budget{MaxOperations: 2},
+		err:    `version .*: compiled spec has \d+ operations, over the budget of 2 \(apis.v3-api.output.budget.max-operations\)`,
+	}, {
+		budget: &config.Budget{MaxOperations: 2, Warn: true},
+	}}
+	for _, test := range tests {
+		c.Run("", func(c *qt.C) {
+			compiler := newTestCompiler(c, c.Mkdir(), func(proj *config.Project) {
+				proj.APIs["v3-api"].Output.Budget = test.budget
+			})
+			err := compiler.Build(ctx, "v3-api")
+			if test.err == "" {
+				c.Assert(err, qt.IsNil)
+			} else {
+				c.Assert(err, qt.ErrorMatches, test.err)
+			}
+		})
+	}
+}
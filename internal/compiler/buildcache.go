@@ -0,0 +1,145 @@
+package compiler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/buildcache"
+)
+
+// buildCacheFormat identifies the format of cached specs, and is changed
+// whenever it, or how inputs are keyed, changes.
+const buildCacheFormat = "vervet-build-cache-2"
+
+// BuildCache configures a Compiler to look up each spec it compiles in cache
+// by a digest of its inputs before compiling it, and to store the specs it
+// compiles there.
+func BuildCache(cache buildcache.Cache) CompilerOption {
+	return func(c *Compiler) error {
+		c.buildCache = cache
+		return nil
+	}
+}
+
+// cachedSpec is a compiled spec as stored in the build cache.
+type cachedSpec struct {
+	JSON json.RawMessage `json:"json"`
+	YAML string          `json:"yaml"`
+}
+
+// compileSpecCached compiles a spec as compileSpec does, reusing the spec
+// compiled from the same inputs by a prior build if the compiler has a build
+// cache. Resources are checked whether or not the spec is cached. Failing to
+// use the cache does not fail the build; the spec is compiled instead.
+func (c *Compiler) compileSpecCached(ctx context.Context, apiName string, api *api, version *vervet.Version, resources []*vervet.Resource, servers openapi3.Servers) (*compiledSpec, error) {
+	if c.buildCache == nil {
+		return c.compileSpec(apiName, api, version, resources, servers)
+	}
+	err := c.checkResources(apiName, api, version, resources)
+	if err != nil {
+		return nil, err
+	}
+	key, err := buildCacheKey(api, resources, servers, c.common, c.commonConfig)
+	if err != nil {
+		return nil, err
+	}
+	buf, ok, err := c.buildCache.Get(ctx, key)
+	if err != nil {
+		log.Printf("build cache: %v", err)
+	} else if ok {
+		compiled, err := loadCachedSpec(buf)
+		if err == nil {
+			log.Printf("build cache: using %s for version %s (apis.%s)", key, version, apiName)
+			return compiled, nil
+		}
+		log.Printf("build cache: invalid spec %s: %v", key, err)
+	}
+	compiled, err := c.compileCheckedSpec(apiName, api, version, resources, servers)
+	if err != nil {
+		return nil, err
+	}
+	buf, err = json.Marshal(&cachedSpec{JSON: compiled.json, YAML: string(compiled.yaml)})
+	if err != nil {
+		return nil, err
+	}
+	err = c.buildCache.Put(ctx, key, buf)
+	if err != nil {
+		log.Printf("build cache: %v", err)
+	}
+	return compiled, nil
+}
+
+func loadCachedSpec(buf []byte) (*compiledSpec, error) {
+	var cached cachedSpec
+	err := json.Unmarshal(buf, &cached)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := openapi3.NewLoader().LoadFromData(cached.JSON)
+	if err != nil {
+		return nil, err
+	}
+	return &compiledSpec{spec: spec, json: cached.JSON, yaml: []byte(cached.YAML)}, nil
+}
+
+// buildCacheKey returns a digest of everything a compiled spec is compiled
+// from: the resource versions merged into it, the API's overlays, path
+// comparison and output configuration, the common headers and parameters
+// required of it, and the build of vervet compiling it.
+func buildCacheKey(api *api, resources []*vervet.Resource, servers openapi3.Servers, common *vervet.CommonRequirements, commonConfig *config.Common) (string, error) {
+	h := sha256.New()
+	write := func(v interface{}) error {
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to compute build cache key: %w", err)
+		}
+		fmt.Fprintf(h, "%d:", len(buf))
+		h.Write(buf)
+		return nil
+	}
+	inputs := []interface{}{buildCacheFormat, buildVersion()}
+	for _, rc := range resources {
+		inputs = append(inputs, rc.T)
+	}
+	for _, doc := range api.overlayIncludes {
+		inputs = append(inputs, doc.T)
+	}
+	for _, doc := range api.overlayInlines {
+		inputs = append(inputs, doc)
+	}
+	inputs = append(inputs, servers, common, commonConfig, api.pathComparison)
+	if api.output != nil {
+		inputs = append(inputs, api.output.redact, api.output.naming, api.output.info)
+	}
+	for _, input := range inputs {
+		err := write(input)
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// buildVersion identifies the build of vervet compiling specs, so that specs
+// cached by other releases are not used.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/snyk/vervet" {
+			return dep.Version + dep.Sum
+		}
+	}
+	return info.Main.Path + "@" + info.Main.Version + info.Main.Sum
+}
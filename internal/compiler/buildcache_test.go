@@ -0,0 +1,84 @@
+package compiler
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/buildcache"
+)
+
+func TestBuildCache(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	cache := buildcache.Dir(c.Mkdir())
+	build := func() (string, *Profile) {
+		outputPath := c.Mkdir()
+		profile := NewProfile()
+		compiler := newTestCompiler(c, outputPath, nil, Profiler(profile), BuildCache(cache))
+		err := compiler.BuildAll(ctx)
+		c.Assert(err, qt.IsNil)
+		return outputPath, profile
+	}
+
+	firstPath, profile := build()
+	c.Assert(profile.Duration("v3-api", "2021-06-01~experimental", PhaseMerge) > 0, qt.IsTrue)
+	entries, err := ioutil.ReadDir(string(cache))
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(entries) > 0, qt.IsTrue)
+
+	// A later build with the same inputs compiles nothing, and outputs the
+	// same specs.
+	secondPath, profile := build()
+	for _, version := range []string{"2021-06-01~experimental", "2021-06-04~experimental", "2021-06-13"} {
+		c.Assert(profile.Duration("v3-api", version, PhaseMerge), qt.Equals, time.Duration(0))
+		c.Assert(profile.Duration("v3-api", version, PhaseWrite) > 0, qt.IsTrue)
+		first, err := ioutil.ReadFile(firstPath + "/" + version + "/spec.yaml")
+		c.Assert(err, qt.IsNil)
+		second, err := ioutil.ReadFile(secondPath + "/" + version + "/spec.yaml")
+		c.Assert(err, qt.IsNil)
+		c.Assert(string(second), qt.Equals, string(first))
+	}
+
+	// Changing an input, here the servers in an inline overlay, compiles
+	// again.
+	c.Setenv("API_BASE_URL", "https://example.com/api/v4")
+	thirdPath, profile := build()
+	c.Assert(profile.Duration("v3-api", "2021-06-01~experimental", PhaseMerge) > 0, qt.IsTrue)
+	third, err := ioutil.ReadFile(thirdPath + "/2021-06-13/spec.yaml")
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(third), qt.Contains, "https://example.com/api/v4")
+}
+
+func TestBuildCachePathComparison(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	cache := buildcache.Dir(c.Mkdir())
+	build := func(comparison config.PathComparison) error {
+		compiler := newTestCompiler(c, c.Mkdir(), func(proj *config.Project) {
+			api := proj.APIs["v3-api"]
+			api.PathComparison = comparison
+			api.Overlays = append(api.Overlays, &config.Overlay{Inline: `
+paths:
+  /examples/hello-world/{helloId}:
+    get:
+      parameters:
+        - {name: helloId, in: path, required: true, schema: {type: string}}
+      responses:
+        '200': {description: OK}
+`})
+		}, BuildCache(cache))
+		return compiler.Build(ctx, "v3-api")
+	}
+
+	// Specs compiled without comparing paths structurally are cached, but
+	// do not spare the same specs from having their paths compared.
+	c.Assert(build(config.PathComparisonExact), qt.IsNil)
+	c.Assert(build(config.PathComparisonStructural), qt.ErrorMatches, `version .*: conflicting paths .* differ only in parameter names .*`)
+}
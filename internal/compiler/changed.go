@@ -0,0 +1,69 @@
+package compiler
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ChangedFiles configures a Compiler to only lint the resource versions
+// affected by changes to files, such as those changed on a branch, rather
+// than every resource version in the project. Paths are relative to the
+// project.
+//
+// A resource version is affected by a change to any file in its version
+// directory. A change to any other file in a resource set, such as a schema
+// shared by its resources, affects every version in the resource set.
+func ChangedFiles(files []string) CompilerOption {
+	return func(c *Compiler) error {
+		c.changedFiles = map[string]bool{}
+		for _, file := range files {
+			c.changedFiles[filepath.Clean(file)] = true
+		}
+		return nil
+	}
+}
+
+// changedSpecFiles returns the spec files of a resource set affected by the
+// changed files, or all of them if the compiler is not restricted to changes.
+func (c *Compiler) changedSpecFiles(rc *resource) []string {
+	if c.changedFiles == nil {
+		return rc.matchedFiles
+	}
+	versionDirs := map[string]bool{}
+	for _, file := range rc.matchedFiles {
+		versionDirs[filepath.Dir(file)] = true
+	}
+	changedDirs := map[string]bool{}
+	for file := range c.changedFiles {
+		dir := versionDirOf(file, versionDirs)
+		if dir != "" {
+			changedDirs[dir] = true
+		} else if isWithin(file, rc.path) {
+			return rc.matchedFiles
+		}
+	}
+	var result []string
+	for _, file := range rc.matchedFiles {
+		if changedDirs[filepath.Dir(file)] {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// versionDirOf returns the version directory containing file, or an empty
+// string if it is not in any of them.
+func versionDirOf(file string, versionDirs map[string]bool) string {
+	for dir := filepath.Dir(file); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if versionDirs[dir] {
+			return dir
+		}
+	}
+	return ""
+}
+
+// isWithin returns whether file is within dir.
+func isWithin(file, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), file)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,47 @@
+package compiler
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestLintChangedFiles(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	tests := []struct {
+		changed []string
+		linted  [][]string
+	}{{
+		changed: []string{
+			"testdata/resources/_examples/hello-world/2021-06-07/spec.yaml",
+			"README.md",
+		},
+		linted: [][]string{{"testdata/resources/_examples/hello-world/2021-06-07/spec.yaml"}},
+	}, {
+		// Any file in a version directory affects the version.
+		changed: []string{"testdata/resources/projects/2021-06-04/examples/project.json"},
+		linted:  [][]string{{"testdata/resources/projects/2021-06-04/spec.yaml"}},
+	}, {
+		// Shared files affect every version.
+		changed: []string{"testdata/resources/schemas/errors.yaml"},
+		linted: [][]string{{
+			"testdata/resources/_examples/hello-world/2021-06-01/spec.yaml",
+			"testdata/resources/_examples/hello-world/2021-06-07/spec.yaml",
+			"testdata/resources/_examples/hello-world/2021-06-13/spec.yaml",
+			"testdata/resources/projects/2021-06-04/spec.yaml",
+		}},
+	}, {
+		changed: []string{"README.md"},
+	}}
+	for _, test := range tests {
+		c.Run("", func(c *qt.C) {
+			compiler := newTestCompiler(c, c.Mkdir(), nil, ChangedFiles(test.changed))
+			err := compiler.LintResourcesAll(ctx)
+			c.Assert(err, qt.IsNil)
+			c.Assert(compiler.linters["resource-rules"].(*mockLinter).runs, qt.DeepEquals, test.linted)
+		})
+	}
+}
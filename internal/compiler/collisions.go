@@ -0,0 +1,112 @@
+package compiler
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/snyk/vervet/config"
+)
+
+// pathCollision is a path in the compiled specs of one API which overlaps a
+// path in those of another, so that a request to one may be routed to the
+// other when both are served under one host.
+type pathCollision struct {
+	api, path, version                string
+	otherAPI, otherPath, otherVersion string
+}
+
+func (pc *pathCollision) String() string {
+	return fmt.Sprintf("path %q of API %s (version %s) overlaps path %q of API %s (version %s)",
+		pc.path, pc.api, pc.version, pc.otherPath, pc.otherAPI, pc.otherVersion)
+}
+
+// recordPaths records the paths in a compiled spec of an API, with the
+// first version each was compiled in.
+func (a *api) recordPaths(version string, compiled *compiledSpec) {
+	for path := range compiled.spec.Paths {
+		if _, ok := a.compiledPaths[path]; !ok {
+			a.compiledPaths[path] = version
+		}
+	}
+}
+
+// pathCollisions returns the paths compiled for each API which overlap those
+// compiled for another, in order of API and path.
+func (c *Compiler) pathCollisions() []*pathCollision {
+	apiNames := make([]string, 0, len(c.apis))
+	for apiName := range c.apis {
+		apiNames = append(apiNames, apiName)
+	}
+	sort.Strings(apiNames)
+	var result []*pathCollision
+	for i, apiName := range apiNames {
+		paths := sortedKeys(c.apis[apiName].compiledPaths)
+		for _, otherAPI := range apiNames[i+1:] {
+			otherPaths := sortedKeys(c.apis[otherAPI].compiledPaths)
+			for _, path := range paths {
+				for _, otherPath := range otherPaths {
+					if !pathsOverlap(path, otherPath) {
+						continue
+					}
+					result = append(result, &pathCollision{
+						api:          apiName,
+						path:         path,
+						version:      c.apis[apiName].compiledPaths[path],
+						otherAPI:     otherAPI,
+						otherPath:    otherPath,
+						otherVersion: c.apis[otherAPI].compiledPaths[otherPath],
+					})
+				}
+			}
+		}
+	}
+	return result
+}
+
+// checkPathCollisions reports the paths which overlap across APIs, as
+// configured.
+func (c *Compiler) checkPathCollisions() error {
+	if c.pathCollisionsPolicy == config.PathCollisionsIgnore {
+		return nil
+	}
+	collisions := c.pathCollisions()
+	for _, collision := range collisions {
+		log.Printf("%s (path-collisions)", collision)
+	}
+	if len(collisions) > 0 && c.pathCollisionsPolicy == config.PathCollisionsError {
+		return fmt.Errorf("%d paths overlap across APIs (path-collisions)", len(collisions))
+	}
+	return nil
+}
+
+// pathsOverlap returns whether some request path would match both path
+// templates, such as /orgs/{orgId} and /orgs/{org_id}, or /orgs/{orgId} and
+// /orgs/me. A segment with a template parameter is taken to match any
+// segment.
+func pathsOverlap(a, b string) bool {
+	aSegments := strings.Split(strings.Trim(a, "/"), "/")
+	bSegments := strings.Split(strings.Trim(b, "/"), "/")
+	if len(aSegments) != len(bSegments) {
+		return false
+	}
+	for i := range aSegments {
+		if aSegments[i] == bSegments[i] {
+			continue
+		}
+		if !strings.Contains(aSegments[i], "{") && !strings.Contains(bSegments[i], "{") {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]string) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,73 @@
+package compiler
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestPathsOverlap(t *testing.T) {
+	c := qt.New(t)
+	tests := []struct {
+		a, b    string
+		overlap bool
+	}{
+		{"/orgs/{orgId}/things", "/orgs/{orgId}/things", true},
+		{"/orgs/{orgId}/things", "/orgs/{org_id}/things", true},
+		{"/orgs/{orgId}", "/orgs/me", true},
+		{"/orgs/{orgId}", "/orgs/{orgId}/things", false},
+		{"/orgs/me", "/orgs/you", false},
+		{"/orgs/{orgId}/things", "/groups/{groupId}/things", false},
+		{"/things/", "/things", true},
+	}
+	for _, test := range tests {
+		c.Check(pathsOverlap(test.a, test.b), qt.Equals, test.overlap, qt.Commentf("%s %s", test.a, test.b))
+		c.Check(pathsOverlap(test.b, test.a), qt.Equals, test.overlap, qt.Commentf("%s %s", test.b, test.a))
+	}
+}
+
+func TestBuildPathCollisions(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	tests := []struct {
+		policy config.PathCollisions
+		err    string
+	}{{
+		policy: config.PathCollisionsIgnore,
+	}, {
+		policy: config.PathCollisionsWarn,
+	}, {
+		policy: config.PathCollisionsError,
+		err:    `\d+ paths overlap across APIs \(path-collisions\)`,
+	}}
+	for _, test := range tests {
+		c.Run(string(test.policy), func(c *qt.C) {
+			compiler := newTestCompiler(c, c.Mkdir(), func(proj *config.Project) {
+				proj.PathCollisions = test.policy
+				// A second API publishing some of the same resources.
+				proj.APIs["examples"] = &config.API{
+					Name: "examples",
+					Resources: []*config.ResourceSet{{
+						Path: "testdata/resources/_examples",
+					}},
+					Output: &config.Output{Path: c.Mkdir()},
+				}
+			})
+			err := compiler.BuildAll(ctx)
+			if test.err == "" {
+				c.Assert(err, qt.IsNil)
+			} else {
+				c.Assert(err, qt.ErrorMatches, test.err)
+			}
+
+			collisions := compiler.pathCollisions()
+			c.Assert(collisions, qt.Not(qt.HasLen), 0)
+			c.Assert(collisions[0].String(), qt.Equals,
+				`path "/examples/hello-world" of API examples (version 2021-06-13~experimental) overlaps path "/examples/hello-world" of API v3-api (version 2021-06-13~experimental)`)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package compiler
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+)
+
+func TestBuildCommonRequirements(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	newCompiler := func(outputPath string, common *config.Common) *Compiler {
+		return newTestCompiler(c, outputPath, func(proj *config.Project) {
+			proj.Common = common
+		})
+	}
+
+	common := &config.Common{
+		Headers:    "testdata/resources/schemas/headers/common-response.yaml#/Common",
+		Parameters: []string{"testdata/resources/schemas/parameters/pagination.yaml#/Pagination"},
+	}
+	err := newCompiler(c.Mkdir(), common).BuildAll(ctx)
+	c.Assert(err, qt.ErrorMatches, `version .*: .* missing required query parameter "page" \(common\) .*`)
+
+	common.Fix = true
+	outputPath := c.Mkdir()
+	err = newCompiler(outputPath, common).BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+	specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(outputPath))
+	c.Assert(err, qt.IsNil)
+	for _, version := range []string{"2021-06-01", "2021-06-04~beta"} {
+		spec, err := specs.At(version)
+		c.Assert(err, qt.IsNil)
+		c.Assert(spec.Components.Parameters["Pagination"], qt.IsNotNil)
+		for path, pathItem := range spec.Paths {
+			for method, op := range pathItem.Operations() {
+				c.Assert(hasPageParameter(op.Parameters), qt.IsTrue, qt.Commentf("%s %s", method, path))
+				for status, resp := range op.Responses {
+					c.Assert(resp.Value.Headers["snyk-request-id"], qt.IsNotNil,
+						qt.Commentf("%s %s %s", method, path, status))
+				}
+			}
+		}
+	}
+}
+
+func hasPageParameter(params openapi3.Parameters) bool {
+	for _, p := range params {
+		if p.Value.In == openapi3.ParameterInQuery && p.Value.Name == "page" {
+			return true
+		}
+	}
+	return false
+}
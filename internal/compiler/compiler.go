@@ -2,12 +2,19 @@ package compiler
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/getkin/kin-openapi/openapi3"
@@ -15,9 +22,14 @@ import (
 
 	"github.com/snyk/vervet"
 	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/buildcache"
+	"github.com/snyk/vervet/internal/codeowners"
+	"github.com/snyk/vervet/internal/gateway"
+	"github.com/snyk/vervet/internal/signing"
 	"github.com/snyk/vervet/internal/spectral"
 	"github.com/snyk/vervet/internal/sweatercomb"
 	"github.com/snyk/vervet/internal/types"
+	"github.com/snyk/vervet/internal/vervetrules"
 )
 
 // A Compiler checks and builds versioned API resource inputs into aggregated
@@ -25,6 +37,42 @@ import (
 type Compiler struct {
 	apis    map[string]*api
 	linters map[string]types.Linter
+	profile *Profile
+	report  *Report
+	filter  BuildFilter
+
+	owners      *codeowners.Owners
+	onlyOwnedBy string
+
+	// changedFiles restricts linting to the resource versions affected by
+	// changes to these files, if not nil.
+	changedFiles map[string]bool
+
+	signingKey ed25519.PrivateKey
+	buildCache buildcache.Cache
+
+	documentOptions []vervet.DocumentOption
+
+	// common are the headers and parameters required of every operation in
+	// compiled specs, as declared by commonConfig.
+	common       *vervet.CommonRequirements
+	commonConfig *config.Common
+
+	// pathCollisionsPolicy determines how paths which overlap across APIs
+	// are reported.
+	pathCollisionsPolicy config.PathCollisions
+
+	// tagConflicts are the tags already reported as described differently,
+	// by API, so that each is only reported once.
+	tagConflicts   map[string]bool
+	tagConflictsMu sync.Mutex
+
+	// concurrency is how many APIs are built, and specs compiled, at once.
+	concurrency int
+
+	// loadMu serializes loading resource documents, which changes the
+	// working directory.
+	loadMu sync.Mutex
 
 	newLinter func(ctx context.Context, lc *config.Linter) (types.Linter, error)
 }
@@ -46,6 +94,8 @@ func defaultLinterFactory(ctx context.Context, lc *config.Linter) (types.Linter,
 		return spectral.New(ctx, lc.Spectral.Rules, lc.Spectral.ExtraArgs)
 	} else if lc.SweaterComb != nil {
 		return sweatercomb.New(ctx, lc.SweaterComb.Image, lc.SweaterComb.Rules, lc.SweaterComb.ExtraArgs)
+	} else if lc.VervetRules != nil {
+		return vervetrules.New(ctx, lc.VervetRules.Rules)
 	}
 	return nil, fmt.Errorf("invalid linter (linters.%s)", lc.Name)
 }
@@ -54,26 +104,51 @@ type api struct {
 	resources       []*resource
 	overlayIncludes []*vervet.Document
 	overlayInlines  []*openapi3.T
+	overlayLints    map[int]*overlayLint
 	output          *output
+	pathComparison  config.PathComparison
+
+	// compiledPaths are the paths in the specs compiled by the last build,
+	// with the first version each was compiled in.
+	compiledPaths map[string]string
 }
 
 type resource struct {
+	path            string
 	linter          types.Linter
 	linterOverrides map[string]map[string][]string
 	matchedFiles    []string
 }
 
 type output struct {
-	path   string
-	linter types.Linter
+	path     string
+	linter   types.Linter
+	aliases  config.OutputAliases
+	servers  []*serverTemplate
+	exports  *config.Exports
+	naming   *config.Naming
+	apisJSON *apisJSONTemplate
+	redact   []string
+	layout   *layoutTemplate
+	info     *config.InfoPolicy
+	budget   *config.Budget
+
+	// downconvert converts resources from OpenAPI 3.1 to 3.0 as they are
+	// loaded.
+	downconvert bool
+
+	versionAliases map[string]string
 }
 
 // New returns a new Compiler for a given project configuration.
 func New(ctx context.Context, proj *config.Project, options ...CompilerOption) (*Compiler, error) {
 	compiler := &Compiler{
-		apis:      map[string]*api{},
-		linters:   map[string]types.Linter{},
-		newLinter: defaultLinterFactory,
+		apis:                 map[string]*api{},
+		linters:              map[string]types.Linter{},
+		tagConflicts:         map[string]bool{},
+		pathCollisionsPolicy: proj.PathCollisions,
+		concurrency:          1,
+		newLinter:            defaultLinterFactory,
 	}
 	for i := range options {
 		err := options[i](compiler)
@@ -81,6 +156,19 @@ func New(ctx context.Context, proj *config.Project, options ...CompilerOption) (
 			return nil, err
 		}
 	}
+	var err error
+	compiler.documentOptions, err = DocumentOptions(proj)
+	if err != nil {
+		return nil, err
+	}
+	if proj.Common != nil {
+		compiler.common, err = vervet.LoadCommonRequirements(".",
+			proj.Common.Headers, proj.Common.Parameters, compiler.documentOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("%w (common)", err)
+		}
+		compiler.commonConfig = proj.Common
+	}
 	// set up linters
 	for linterName, linterConfig := range proj.Linters {
 		linter, err := compiler.newLinter(ctx, linterConfig)
@@ -90,27 +178,47 @@ func New(ctx context.Context, proj *config.Project, options ...CompilerOption) (
 		compiler.linters[linterName] = linter
 	}
 	// set up APIs
+	if _, ok := proj.APIs[compiler.filter.API]; !ok && compiler.filter.API != "" {
+		return nil, fmt.Errorf("api not found (apis.%s)", compiler.filter.API)
+	}
+	env := newEnvExpander(proj.Env)
 	for apiName, apiConfig := range proj.APIs {
-		a := api{}
+		if !compiler.filter.matchAPI(apiName) {
+			continue
+		}
+		a := api{
+			overlayLints:   map[int]*overlayLint{},
+			pathComparison: apiConfig.PathComparison,
+		}
 
 		// Build resources
 		for rcIndex, rcConfig := range apiConfig.Resources {
 			var err error
 			r := &resource{
+				path:            rcConfig.Path,
 				linter:          compiler.linters[rcConfig.Linter],
 				linterOverrides: map[string]map[string][]string{},
 			}
-			r.matchedFiles, err = ResourceSpecFiles(rcConfig)
+			start := time.Now()
+			matchedFiles, err := ResourceSpecFiles(rcConfig)
 			if err != nil {
 				return nil, fmt.Errorf("%w: (apis.%s.resources[%d].path)", err, apiName, rcIndex)
 			}
+			for i := range matchedFiles {
+				if compiler.filter.matchSpecFile(matchedFiles[i]) {
+					r.matchedFiles = append(r.matchedFiles, matchedFiles[i])
+				}
+			}
+			compiler.profile.record(apiName, allVersions, PhaseMatch, start)
 			linterOverrides := map[string]map[string][]string{}
 			for rcName, versionMap := range rcConfig.LinterOverrides {
 				linterOverrides[rcName] = map[string][]string{}
 				for version, linter := range versionMap {
 					var overrideRules []string
-					for _, rule := range linter.Spectral.Rules {
-						overrideRules = append(overrideRules, rule)
+					if linter.Spectral != nil {
+						overrideRules = append(overrideRules, linter.Spectral.Rules...)
+					} else if linter.VervetRules != nil {
+						overrideRules = append(overrideRules, linter.VervetRules.Rules...)
 					}
 					linterOverrides[rcName][version] = overrideRules
 				}
@@ -122,57 +230,254 @@ func New(ctx context.Context, proj *config.Project, options ...CompilerOption) (
 		// Build overlays
 		for overlayIndex, overlayConfig := range apiConfig.Overlays {
 			if overlayConfig.Include != "" {
-				doc, err := vervet.NewDocumentFile(overlayConfig.Include)
+				doc, err := vervet.NewDocumentFile(overlayConfig.Include, compiler.documentOptions...)
 				if err != nil {
 					return nil, fmt.Errorf("failed to load overlay %q: %w (apis.%s.overlays[%d])",
 						overlayConfig.Include, err, apiName, overlayIndex)
 				}
+				data, err := ioutil.ReadFile(overlayConfig.Include)
+				if err != nil {
+					return nil, fmt.Errorf("failed to load overlay %q: %w (apis.%s.overlays[%d])",
+						overlayConfig.Include, err, apiName, overlayIndex)
+				}
+				err = validateOverlay(ctx, data, doc.T)
+				if err != nil {
+					return nil, fmt.Errorf("invalid overlay %q: %w (apis.%s.overlays[%d])",
+						overlayConfig.Include, err, apiName, overlayIndex)
+				}
 				err = vervet.Localize(doc)
 				if err != nil {
-					return nil, fmt.Errorf("failed to localize references in %q: %w (apis.%s.overlays[%d]",
+					return nil, fmt.Errorf("failed to localize references in %q: %w (apis.%s.overlays[%d])",
 						overlayConfig.Include, err, apiName, overlayIndex)
 				}
 				a.overlayIncludes = append(a.overlayIncludes, doc)
+				if overlayConfig.Linter != "" {
+					a.overlayLints[overlayIndex] = &overlayLint{
+						linter:  compiler.linters[overlayConfig.Linter],
+						include: overlayConfig.Include,
+					}
+				}
 			} else if overlayConfig.Inline != "" {
-				docString := os.ExpandEnv(overlayConfig.Inline)
+				docString, err := env.expand(overlayConfig.Inline, fmt.Sprintf("apis.%s.overlays[%d]", apiName, overlayIndex))
+				if err != nil {
+					return nil, err
+				}
 				l := openapi3.NewLoader()
 				doc, err := l.LoadFromData([]byte(docString))
 				if err != nil {
 					return nil, fmt.Errorf("failed to load template: %w (apis.%s.overlays[%d].template)",
 						err, apiName, overlayIndex)
 				}
+				err = validateOverlay(ctx, []byte(docString), doc)
+				if err != nil {
+					return nil, fmt.Errorf("invalid overlay: %w (apis.%s.overlays[%d])",
+						err, apiName, overlayIndex)
+				}
 				a.overlayInlines = append(a.overlayInlines, doc)
+				if overlayConfig.Linter != "" {
+					a.overlayLints[overlayIndex] = &overlayLint{
+						linter: compiler.linters[overlayConfig.Linter],
+						inline: docString,
+					}
+				}
 			}
 		}
 
 		// Build output
 		if apiConfig.Output != nil && apiConfig.Output.Path != "" {
+			servers, err := newServerTemplates(env, apiName, apiConfig.Output.Servers)
+			if err != nil {
+				return nil, err
+			}
+			err = checkVersionAliases(apiName, apiConfig.Output.VersionAliases)
+			if err != nil {
+				return nil, err
+			}
+			// Resources are loaded by changing into the directory of each
+			// spec, so output is written to an absolute path, which
+			// concurrent builds cannot resolve against the wrong directory.
+			outputPath, err := filepath.Abs(apiConfig.Output.Path)
+			if err != nil {
+				return nil, fmt.Errorf("%w (apis.%s.output.path)", err, apiName)
+			}
 			a.output = &output{
-				path:   apiConfig.Output.Path,
-				linter: compiler.linters[apiConfig.Output.Linter],
+				path:           outputPath,
+				linter:         compiler.linters[apiConfig.Output.Linter],
+				aliases:        apiConfig.Output.Aliases,
+				servers:        servers,
+				exports:        apiConfig.Output.Exports,
+				naming:         apiConfig.Output.Naming,
+				info:           apiConfig.Output.Info,
+				budget:         apiConfig.Output.Budget,
+				redact:         apiConfig.Output.Redact,
+				versionAliases: apiConfig.Output.VersionAliases,
+				downconvert:    apiConfig.Output.Downconvert,
+			}
+			a.output.layout, err = newLayoutTemplate(apiName, apiConfig.Output.Layout)
+			if err != nil {
+				return nil, err
+			}
+			if apiConfig.Output.Exports != nil {
+				a.output.apisJSON, err = newAPIsJSONTemplate(env, apiName, apiConfig.Output.Exports.APIsJSON)
+				if err != nil {
+					return nil, err
+				}
 			}
 		}
 
 		compiler.apis[apiName] = &a
 	}
+	env.report()
 	return compiler, nil
 }
 
+// checkPathConflicts returns an error if any of the resources and overlays
+// merged into a compiled spec declare paths which differ only in the names of
+// their parameters.
+func checkPathConflicts(api *api, resources []*vervet.Resource) error {
+	var docs []*openapi3.T
+	for _, rc := range resources {
+		docs = append(docs, rc.T)
+	}
+	for _, doc := range api.overlayIncludes {
+		docs = append(docs, doc.T)
+	}
+	docs = append(docs, api.overlayInlines...)
+	conflicts := vervet.PathConflicts(docs...)
+	if len(conflicts) == 0 {
+		return nil
+	}
+	var quoted []string
+	for _, path := range conflicts[0].Paths {
+		quoted = append(quoted, strconv.Quote(path))
+	}
+	return fmt.Errorf("conflicting paths %s differ only in parameter names", strings.Join(quoted, " and "))
+}
+
+// reportTagConflicts logs the tags described differently by the resources
+// and overlays merged into a compiled spec. Overlays are merged last,
+// replacing the tags of resources, so their descriptions are the ones
+// compiled; otherwise the description of the first resource is.
+func (c *Compiler) reportTagConflicts(apiName string, api *api, resources []*vervet.Resource) {
+	var docs []*openapi3.T
+	for _, rc := range resources {
+		docs = append(docs, rc.T)
+	}
+	for _, doc := range api.overlayIncludes {
+		docs = append(docs, doc.T)
+	}
+	docs = append(docs, api.overlayInlines...)
+	for _, conflict := range vervet.TagConflicts(docs...) {
+		key := apiName + "\x00" + conflict.Name
+		c.tagConflictsMu.Lock()
+		reported := c.tagConflicts[key]
+		c.tagConflicts[key] = true
+		c.tagConflictsMu.Unlock()
+		if reported {
+			continue
+		}
+		var descs []string
+		for _, desc := range conflict.Descriptions {
+			descs = append(descs, strconv.Quote(desc))
+		}
+		log.Printf("tag %q is described differently by the resources and overlays merged: %s (apis.%s)",
+			conflict.Name, strings.Join(descs, ", "), apiName)
+	}
+}
+
+// reportDownconversion logs the constructs which could not be converted
+// exactly when downconverting the resources of an API from OpenAPI 3.1.
+func reportDownconversion(apiName string, d *vervet.Downconversion) {
+	for _, lossy := range d.Lossy() {
+		log.Printf("lossy conversion to OpenAPI 3.0: %s (apis.%s.output.downconvert)", lossy, apiName)
+	}
+}
+
+// DocumentOptions returns the options for loading the OpenAPI documents in a
+// project, such as how remote references are resolved, and the schemas of
+// the extensions they may use.
+func DocumentOptions(proj *config.Project) ([]vervet.DocumentOption, error) {
+	extensionSchemas := vervet.NewExtensionSchemas()
+	if proj.Extensions != nil {
+		for name, schemaFile := range proj.Extensions.Schemas {
+			err := extensionSchemas.RegisterFile(name, schemaFile)
+			if err != nil {
+				return nil, fmt.Errorf("%w (extensions.schemas.%s)", err, name)
+			}
+		}
+		for _, prefix := range proj.Extensions.Strict {
+			extensionSchemas.Strict(prefix)
+		}
+	}
+	options := []vervet.DocumentOption{vervet.WithExtensionSchemas(extensionSchemas)}
+	if proj.RemoteRefs == nil {
+		return options, nil
+	}
+	remoteRefs := &vervet.RemoteRefs{
+		AllowHosts: proj.RemoteRefs.Allow,
+		Pins:       proj.RemoteRefs.Pins,
+	}
+	if proj.RemoteRefs.Cache != "" {
+		// Documents are loaded from the directory containing them, so the
+		// cache location must not be relative.
+		cacheDir, err := filepath.Abs(proj.RemoteRefs.Cache)
+		if err != nil {
+			return nil, fmt.Errorf("%w (remote-refs.cache)", err)
+		}
+		remoteRefs.CacheDir = cacheDir
+	}
+	return append(options, vervet.WithRemoteRefs(remoteRefs)), nil
+}
+
 // ResourceSpecFiles returns all matching spec files for a config.Resource.
 func ResourceSpecFiles(rcConfig *config.ResourceSet) ([]string, error) {
+	specFiles, err := ExplainResourceSpecFiles(rcConfig)
+	if err != nil {
+		return nil, err
+	}
 	var result []string
+	versionDirs := map[string]string{}
+	for i := range specFiles {
+		if specFiles[i].Excluded() {
+			continue
+		}
+		versionDir := filepath.Dir(specFiles[i].Path)
+		if other, ok := versionDirs[versionDir]; ok {
+			return nil, fmt.Errorf("multiple spec files %q and %q match %q",
+				other, specFiles[i].Path, rcConfig.SpecsPattern())
+		}
+		versionDirs[versionDir] = specFiles[i].Path
+		result = append(result, specFiles[i].Path)
+	}
+	return result, nil
+}
+
+// ResourceSpecFile is a spec file found in a resource set, which may have
+// been excluded from it.
+type ResourceSpecFile struct {
+	// Path is the path of the spec file, relative to the project.
+	Path string
+
+	// ExcludeIndex is the index of the resource set exclude pattern which
+	// excluded the file, or -1 if the file is included.
+	ExcludeIndex int
+}
+
+// Excluded returns whether the spec file was excluded from the resource set.
+func (f *ResourceSpecFile) Excluded() bool {
+	return f.ExcludeIndex >= 0
+}
+
+// ExplainResourceSpecFiles returns all the spec files found in a
+// config.Resource, whether included or excluded.
+func ExplainResourceSpecFiles(rcConfig *config.ResourceSet) ([]ResourceSpecFile, error) {
+	var result []ResourceSpecFile
 	err := doublestar.GlobWalk(os.DirFS(rcConfig.Path),
-		vervet.SpecGlobPattern,
+		vervet.SpecGlob(vervet.SpecVersionPattern(rcConfig), rcConfig.SpecsPattern()),
 		func(path string, d fs.DirEntry) error {
 			rcPath := filepath.Join(rcConfig.Path, path)
-			for i := range rcConfig.Excludes {
-				if ok, err := doublestar.Match(rcConfig.Excludes[i], rcPath); ok {
-					return nil
-				} else if err != nil {
-					return err
-				}
-			}
-			result = append(result, rcPath)
+			excludeIndex, _ := rcConfig.ExcludedBy(rcPath)
+			result = append(result, ResourceSpecFile{Path: rcPath, ExcludeIndex: excludeIndex})
 			return nil
 		})
 	return result, err
@@ -188,13 +493,17 @@ func (c *Compiler) LintResources(ctx context.Context, apiName string) error {
 		if rc.linter == nil {
 			continue
 		}
+		files := c.changedSpecFiles(rc)
+		if len(files) == 0 {
+			continue
+		}
 		if len(rc.linterOverrides) > 0 {
-			err := c.lintWithOverrides(ctx, rc, apiName, rcIndex)
+			err := c.lintWithOverrides(ctx, rc, files, apiName, rcIndex)
 			if err != nil {
 				return err
 			}
 		} else {
-			err := rc.linter.Run(ctx, rc.matchedFiles...)
+			err := c.lint(ctx, rc.linter, fmt.Sprintf("apis.%s.resources[%d]", apiName, rcIndex), files...)
 			if err != nil {
 				return fmt.Errorf("lint failed (apis.%s.resources[%d])", apiName, rcIndex)
 			}
@@ -203,9 +512,9 @@ func (c *Compiler) LintResources(ctx context.Context, apiName string) error {
 	return nil
 }
 
-func (c *Compiler) lintWithOverrides(ctx context.Context, rc *resource, apiName string, rcIndex int) error {
+func (c *Compiler) lintWithOverrides(ctx context.Context, rc *resource, files []string, apiName string, rcIndex int) error {
 	var pending []string
-	for _, matchedFile := range rc.matchedFiles {
+	for _, matchedFile := range files {
 		versionDir := filepath.Dir(matchedFile)
 		rcDir := filepath.Dir(versionDir)
 		versionName := filepath.Base(versionDir)
@@ -216,7 +525,7 @@ func (c *Compiler) lintWithOverrides(ctx context.Context, rc *resource, apiName
 				return fmt.Errorf("failed to apply overrides to linter: %w (apis.%s.resources[%d].linter-overrides.%s.%s)",
 					err, apiName, rcIndex, rcName, versionName)
 			}
-			err = linter.Run(ctx, matchedFile)
+			err = c.lint(ctx, linter, fmt.Sprintf("apis.%s.resources[%d]", apiName, rcIndex), matchedFile)
 			if err != nil {
 				return fmt.Errorf("lint failed on %q: %w (apis.%s.resources[%d])", matchedFile, err, apiName, rcIndex)
 			}
@@ -227,16 +536,73 @@ func (c *Compiler) lintWithOverrides(ctx context.Context, rc *resource, apiName
 	if len(pending) == 0 {
 		return nil
 	}
-	err := rc.linter.Run(ctx, pending...)
+	err := c.lint(ctx, rc.linter, fmt.Sprintf("apis.%s.resources[%d]", apiName, rcIndex), pending...)
 	if err != nil {
 		return fmt.Errorf("lint failed (apis.%s.resources[%d])", apiName, rcIndex)
 	}
 	return nil
 }
 
-// LintResourcesAll lints resources in all APIs in the project.
+// lint runs a linter on files, recording its results to the report under
+// suiteName if the compiler is reporting.
+func (c *Compiler) lint(ctx context.Context, linter types.Linter, suiteName string, files ...string) error {
+	if c.owners == nil {
+		return c.lintOwned(ctx, linter, suiteName, nil, files...)
+	}
+	// Files are linted in groups by owner, so that each failure can be
+	// routed to the owners of the files which failed. All groups are linted,
+	// even once one fails, so that all owners are told of their failures.
+	var lintErr error
+	for _, group := range c.groupByOwners(files) {
+		err := c.lintOwned(ctx, linter, suiteName, group.owners, group.files...)
+		if err != nil {
+			log.Printf("lint failed on files owned by %s: %s",
+				ownersString(group.owners), strings.Join(group.files, ", "))
+			if lintErr == nil {
+				lintErr = err
+			}
+		}
+	}
+	return lintErr
+}
+
+func (c *Compiler) lintOwned(ctx context.Context, linter types.Linter, suiteName string, owners []string, files ...string) error {
+	if c.report == nil {
+		return linter.Run(ctx, files...)
+	}
+	var findings []types.Finding
+	var err error
+	if reportingLinter, ok := linter.(types.ReportingLinter); ok {
+		findings, err = reportingLinter.Report(ctx, files...)
+	} else {
+		err = linter.Run(ctx, files...)
+	}
+	c.report.recordLint(suiteName, owners, files, findings, err)
+	return err
+}
+
+// LintFiles checks files with a linter in the project configuration, by
+// name. Any files may be linted, whether or not they are part of an API.
+func (c *Compiler) LintFiles(ctx context.Context, linterName string, files ...string) error {
+	linter, ok := c.linters[linterName]
+	if !ok {
+		return fmt.Errorf("linter not found (linters.%s)", linterName)
+	}
+	err := c.lint(ctx, linter, "linters."+linterName, files...)
+	if err != nil {
+		return fmt.Errorf("lint failed (linters.%s)", linterName)
+	}
+	return nil
+}
+
+// LintResourcesAll lints resources, and overlays configured with a linter,
+// in all APIs in the project.
 func (c *Compiler) LintResourcesAll(ctx context.Context) error {
-	return c.apisEach(ctx, c.LintResources)
+	err := c.apisEach(ctx, c.LintResources)
+	if err != nil {
+		return err
+	}
+	return c.apisEach(ctx, c.LintOverlays)
 }
 
 func (c *Compiler) apisEach(ctx context.Context, f func(ctx context.Context, apiName string) error) error {
@@ -252,94 +618,481 @@ func (c *Compiler) apisEach(ctx context.Context, f func(ctx context.Context, api
 // Build builds an aggregate versioned OpenAPI spec for a specific API by name
 // in the project.
 func (c *Compiler) Build(ctx context.Context, apiName string) error {
+	err := c.build(ctx, apiName)
+	c.report.recordError("apis."+apiName, "build", err)
+	return err
+}
+
+func (c *Compiler) build(ctx context.Context, apiName string) error {
 	api, ok := c.apis[apiName]
 	if !ok {
 		return fmt.Errorf("api not found (apis.%s)", apiName)
 	}
+	api.compiledPaths = map[string]string{}
 	if api.output == nil || api.output.path == "" {
 		return nil
 	}
-	err := os.RemoveAll(api.output.path)
-	if err != nil {
-		return fmt.Errorf("failed to clear output directory: %w", err)
+	aliases := map[string]string{}
+	apisJSONEntries := map[string]*apisJSONAPI{}
+	if c.filter.partial() {
+		log.Printf("partial build: output versions not matched may be stale, see %s (apis.%s.output)",
+			PartialBuildFile, apiName)
+		err := readAliases(api.output.path, aliases)
+		if err != nil {
+			return fmt.Errorf("%w (apis.%s.output)", err, apiName)
+		}
+		if api.output.apisJSON != nil {
+			err = readAPIsJSON(api.output.path, apisJSONEntries)
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.output)", err, apiName)
+			}
+		}
+	} else {
+		err := os.RemoveAll(api.output.path)
+		if err != nil {
+			return fmt.Errorf("failed to clear output directory: %w", err)
+		}
+	}
+	var layout *layoutWriter
+	if api.output.layout != nil {
+		layout = newLayoutWriter(apiName, api.output)
+	}
+	var catalog *errorCatalog
+	if api.output.exports != nil && api.output.exports.ErrorCatalog != nil {
+		catalog = newErrorCatalog()
+		if c.filter.partial() {
+			var err error
+			catalog, err = readErrorCatalog(api.output.path)
+			if err != nil {
+				return fmt.Errorf("%w (apis.%s.output)", err, apiName)
+			}
+		}
 	}
-	err = os.MkdirAll(api.output.path, 0777)
+	err := os.MkdirAll(api.output.path, 0777)
 	if err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
+	documentOptions := c.documentOptions
+	var downconversion *vervet.Downconversion
+	if api.output.downconvert {
+		downconversion = &vervet.Downconversion{}
+		documentOptions = append(documentOptions[:len(documentOptions):len(documentOptions)],
+			vervet.WithDownconversion(downconversion))
+		defer reportDownconversion(apiName, downconversion)
+	}
 	log.Printf("compiling API %s to output versions", apiName)
+	var rebuilt []string
 	for rcIndex, rc := range api.resources {
-		specVersions, err := vervet.LoadSpecVersionsFileset(rc.matchedFiles)
+		start := time.Now()
+		c.loadMu.Lock()
+		specVersions, err := vervet.LoadSpecVersionsFileset(rc.matchedFiles, documentOptions...)
+		c.loadMu.Unlock()
+		c.profile.record(apiName, allVersions, PhaseLoad, start)
 		if err != nil {
 			return fmt.Errorf("failed to load spec versions: %w (apis.%s.resources[%d])",
 				err, apiName, rcIndex)
 		}
+		// Operations removed by later versions of a resource are deprecated in
+		// the versions that still have them.
+		specVersions.DeprecateRemovedOperations()
 		buildErr := func(err error) error {
 			return fmt.Errorf("%w (apis.%s.resources[%d])", err, apiName, rcIndex)
 		}
 		versions := specVersions.Versions()
 		versionDates := vervet.VersionDateStrings(versions)
 		stabilities := []string{"~experimental", "~beta", ""}
+		// Many versions resolve to the same resource versions, such as
+		// stabilities with no releases of their own on a given date. These
+		// compile to the same spec, which is only merged and serialized once.
+		var versionJobs, jobs []*compileJob
+		jobsByKey := map[string]*compileJob{}
 		for _, versionDate := range versionDates {
 			for _, stabilitySuffix := range stabilities {
 				version, err := vervet.ParseVersion(versionDate + stabilitySuffix)
 				if err != nil {
 					return buildErr(err)
 				}
-				versionDir := api.output.path + "/" + version.String()
-				err = os.MkdirAll(versionDir, 0755)
-				if err != nil {
-					return buildErr(err)
+				if !c.filter.matchVersion(version) {
+					continue
 				}
-				spec, err := specVersions.At(version.String())
+				resources, err := specVersions.ResourcesAt(version.String())
 				if err == vervet.ErrNoMatchingVersion {
 					continue
 				} else if err != nil {
 					return buildErr(err)
 				}
-
-				// Merge all overlays
-				for _, doc := range api.overlayIncludes {
-					vervet.Merge(spec, doc.T, true)
+				servers, err := renderServers(apiName, api.output, version)
+				if err != nil {
+					return err
 				}
-				for _, doc := range api.overlayInlines {
-					vervet.Merge(spec, doc, true)
+				serversKey, err := serversKey(servers)
+				if err != nil {
+					return buildErr(err)
+				}
+				key := resourcesKey(resources) + serversKey
+				job, ok := jobsByKey[key]
+				if !ok {
+					job = &compileJob{key: key, version: version, resources: resources, servers: servers}
+					jobsByKey[key] = job
+					jobs = append(jobs, job)
 				}
+				versionJobs = append(versionJobs, &compileJob{key: key, version: version})
+			}
+		}
+		err = c.compileJobs(ctx, apiName, api, jobs)
+		if err != nil {
+			return buildErr(err)
+		}
 
-				// Write the compiled spec to JSON and YAML
-				jsonBuf, err := vervet.ToSpecJSON(spec)
+		// Compiled specs are written in order of version, so that versions
+		// alias the first version with the same spec.
+		compiledSpecs := map[string]*compiledSpec{}
+		for _, versionJob := range versionJobs {
+			version, key := versionJob.version, versionJob.key
+			rebuilt = append(rebuilt, version.String())
+			compiled, ok := compiledSpecs[key]
+			if !ok {
+				compiled = jobsByKey[key].compiled
+				compiled.version = version.String()
+				compiledSpecs[key] = compiled
+				api.recordPaths(version.String(), compiled)
+				err = checkBudget(apiName, api.output.budget, version.String(), compiled)
+				if err != nil {
+					return err
+				}
+			}
+			if api.output.apisJSON != nil {
+				apisJSONEntries[version.String()], err = api.output.apisJSON.api(apiName, version, compiled.spec)
+				if err != nil {
+					return err
+				}
+			}
+			if catalog != nil {
+				err = catalog.add(version.String(), compiled.spec)
 				if err != nil {
 					return buildErr(err)
 				}
-				jsonSpecPath := versionDir + "/spec.json"
-				err = ioutil.WriteFile(jsonSpecPath, jsonBuf, 0644)
+			}
+			if layout != nil {
+				// Custom layouts have no aliases, so every version is
+				// written in full.
+				start := time.Now()
+				err = layout.write(version, compiled)
 				if err != nil {
 					return buildErr(err)
 				}
-				log.Println(jsonSpecPath)
-				yamlBuf, err := yaml.JSONToYAML(jsonBuf)
+				c.profile.record(apiName, version.String(), PhaseWrite, start)
+				continue
+			}
+			if ok && api.output.aliases != config.OutputAliasesNone {
+				start := time.Now()
+				err = clearVersion(api.output.path, version.String(), aliases)
 				if err != nil {
 					return buildErr(err)
 				}
-				yamlBuf, err = vervet.WithGeneratedComment(yamlBuf)
+				err = writeAlias(api.output, version.String(), compiled.version, aliases)
 				if err != nil {
 					return buildErr(err)
 				}
-				yamlSpecPath := versionDir + "/spec.yaml"
-				err = ioutil.WriteFile(yamlSpecPath, yamlBuf, 0644)
+				c.profile.record(apiName, version.String(), PhaseWrite, start)
+				continue
+			}
+
+			// Write the compiled spec
+			start := time.Now()
+			err = clearVersion(api.output.path, version.String(), aliases)
+			if err != nil {
+				return buildErr(err)
+			}
+			versionDir := api.output.path + "/" + version.String()
+			err = os.MkdirAll(versionDir, 0755)
+			if err != nil {
+				return buildErr(err)
+			}
+			jsonSpecPath := versionDir + "/spec.json"
+			err = ioutil.WriteFile(jsonSpecPath, compiled.json, 0644)
+			if err != nil {
+				return buildErr(err)
+			}
+			log.Println(jsonSpecPath)
+			yamlSpecPath := versionDir + "/spec.yaml"
+			err = ioutil.WriteFile(yamlSpecPath, compiled.yaml, 0644)
+			if err != nil {
+				return buildErr(err)
+			}
+			log.Println(yamlSpecPath)
+			// Gateway exports identify the version they were
+			// generated for, so exported versions are never aliased.
+			exportName := version.String()
+			if apiName != "" {
+				exportName = apiName + "-" + exportName
+			}
+			exports, err := gateway.Export(compiled.spec, exportName, api.output.exports)
+			if err != nil {
+				return buildErr(err)
+			}
+			var exportFiles []string
+			for exportFile := range exports {
+				exportFiles = append(exportFiles, exportFile)
+			}
+			sort.Strings(exportFiles)
+			for _, exportFile := range exportFiles {
+				exportPath := versionDir + "/" + exportFile
+				err = ioutil.WriteFile(exportPath, exports[exportFile], 0644)
 				if err != nil {
 					return buildErr(err)
 				}
-				log.Println(yamlSpecPath)
+				log.Println(exportPath)
 			}
+			c.profile.record(apiName, version.String(), PhaseWrite, start)
+		}
+	}
+	if api.output.apisJSON != nil {
+		manifest, err := api.output.apisJSON.manifest(apisJSONEntries)
+		if err != nil {
+			return fmt.Errorf("%w (apis.%s.output)", err, apiName)
+		}
+		buf, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		apisJSONPath := api.output.path + "/" + APIsJSONFile
+		err = ioutil.WriteFile(apisJSONPath, buf, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w (apis.%s.output)", APIsJSONFile, err, apiName)
+		}
+		log.Println(apisJSONPath)
+	}
+	if catalog != nil {
+		err := writeErrorCatalog(apiName, api.output, catalog)
+		if err != nil {
+			return fmt.Errorf("%w (apis.%s.output.exports.error-catalog)", err, apiName)
+		}
+	}
+	aliasesPath := api.output.path + "/" + vervet.CompiledAliasesFile
+	if len(aliases) == 0 {
+		err := os.Remove(aliasesPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove aliases: %w (apis.%s.output)", err, apiName)
+		}
+	} else {
+		buf, err := json.MarshalIndent(aliases, "", "  ")
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(aliasesPath, buf, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write aliases: %w (apis.%s.output)", err, apiName)
+		}
+		log.Println(aliasesPath)
+	}
+	err = writeVersionAliases(api.output)
+	if err != nil {
+		return fmt.Errorf("%w (apis.%s.output.version-aliases)", err, apiName)
+	}
+	if c.filter.partial() {
+		err = writePartialBuild(api.output.path, c.filter, rebuilt)
+		if err != nil {
+			return fmt.Errorf("%w (apis.%s.output)", err, apiName)
+		}
+		log.Println(api.output.path + "/" + PartialBuildFile)
+	}
+	if c.signingKey != nil {
+		// Output is signed last, once all of it has been written.
+		err = signing.Sign(api.output.path, c.signingKey)
+		if err != nil {
+			return fmt.Errorf("failed to sign output: %w (apis.%s.output)", err, apiName)
+		}
+		log.Println(api.output.path + "/" + signing.ManifestFile)
+	}
+	return nil
+}
+
+// readAliases adds the aliases indexed in existing output, if any, to the
+// aliases map.
+func readAliases(outputPath string, aliases map[string]string) error {
+	buf, err := ioutil.ReadFile(outputPath + "/" + vervet.CompiledAliasesFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read aliases: %w", err)
+	}
+	err = json.Unmarshal(buf, &aliases)
+	if err != nil {
+		return fmt.Errorf("failed to read aliases: %w", err)
+	}
+	return nil
+}
+
+// clearVersion removes the prior output of a version, which may remain from a
+// prior build when building partially.
+func clearVersion(outputPath, version string, aliases map[string]string) error {
+	delete(aliases, version)
+	return os.RemoveAll(outputPath + "/" + version)
+}
+
+// writeAlias outputs a version which compiled to the same spec as a prior
+// target version, according to the output's aliases setting. Index aliases
+// are added to the aliases map, which is written once the build is done.
+func writeAlias(out *output, version, target string, aliases map[string]string) error {
+	switch out.aliases {
+	case config.OutputAliasesSymlink:
+		versionDir := out.path + "/" + version
+		// The link is relative, so that output can be relocated.
+		err := os.Symlink(target, versionDir)
+		if err != nil {
+			return err
+		}
+		log.Printf("%s -> %s", versionDir, target)
+	case config.OutputAliasesIndex:
+		aliases[version] = target
+	}
+	return nil
+}
+
+// compiledSpec is the serialized content of a compiled spec version.
+type compiledSpec struct {
+	spec *openapi3.T
+	json []byte
+	yaml []byte
+
+	// version is the first version the spec was compiled for, which later
+	// versions with the same spec may alias.
+	version string
+}
+
+// compileSpec merges resource versions and API overlays into a compiled spec.
+//
+// If servers are given, these replace the servers in the spec once overlays
+// are merged. If the output redacts internal parts of the spec, or translates
+// naming, these are applied last, in that order.
+func (c *Compiler) compileSpec(apiName string, api *api, version *vervet.Version, resources []*vervet.Resource, servers openapi3.Servers) (*compiledSpec, error) {
+	err := c.checkResources(apiName, api, version, resources)
+	if err != nil {
+		return nil, err
+	}
+	return c.compileCheckedSpec(apiName, api, version, resources, servers)
+}
+
+// checkResources returns an error if the resources and overlays merged into
+// a compiled spec conflict, and reports the tags they describe differently.
+// These do not depend on compiling the spec, so they are checked whether or
+// not it is compiled.
+func (c *Compiler) checkResources(apiName string, api *api, version *vervet.Version, resources []*vervet.Resource) error {
+	err := vervet.CheckSecuritySchemeConflicts(resources)
+	if err != nil {
+		return err
+	}
+	c.reportTagConflicts(apiName, api, resources)
+	if api.pathComparison == config.PathComparisonStructural {
+		err = checkPathConflicts(api, resources)
+		if err != nil {
+			return fmt.Errorf("version %s: %w (apis.%s.path-comparison)", version, err, apiName)
 		}
 	}
 	return nil
 }
 
+// compileCheckedSpec compiles a spec as compileSpec does, from resources
+// already checked by checkResources.
+func (c *Compiler) compileCheckedSpec(apiName string, api *api, version *vervet.Version, resources []*vervet.Resource, servers openapi3.Servers) (*compiledSpec, error) {
+	start := time.Now()
+	// The merged spec shares its path items, operations and components with
+	// resources used by other versions, so these are copied before anything
+	// in them is changed, as common requirements are applied, or changed
+	// only once serialized, as redaction and naming are.
+	spec := vervet.MergeResourcesShared(resources)
+	c.profile.record(apiName, version.String(), PhaseMerge, start)
+
+	// Merge all overlays
+	start = time.Now()
+	for _, doc := range api.overlayIncludes {
+		vervet.Merge(spec, doc.T, true)
+	}
+	for _, doc := range api.overlayInlines {
+		vervet.Merge(spec, doc, true)
+	}
+	if servers != nil {
+		spec.Servers = servers
+	}
+	c.profile.record(apiName, version.String(), PhaseOverlay, start)
+
+	if api.output != nil && api.output.info != nil {
+		err := applyInfoPolicy(spec, api.output.info)
+		if err != nil {
+			return nil, fmt.Errorf("version %s: %w (apis.%s.output.info)", version, err, apiName)
+		}
+	}
+
+	if c.common != nil {
+		err := c.common.Apply(spec, c.commonConfig.Fix)
+		if err != nil {
+			return nil, fmt.Errorf("version %s: %w (common)", version, err)
+		}
+	}
+
+	err := vervet.CheckSecurityRequirements(spec)
+	if err != nil {
+		return nil, fmt.Errorf("version %s: %w", version, err)
+	}
+
+	// Serialize the compiled spec to JSON and YAML
+	start = time.Now()
+	defer c.profile.record(apiName, version.String(), PhaseSerialize, start)
+	jsonBuf, err := vervet.ToSpecJSON(spec)
+	if err != nil {
+		return nil, err
+	}
+	if api.output != nil && len(api.output.redact) > 0 {
+		// Internal parts of the spec are redacted in the serialized spec, so
+		// that resource documents shared by other outputs keep them.
+		jsonBuf, err = redact(api.output.redact, jsonBuf)
+		if err != nil {
+			return nil, fmt.Errorf("version %s: failed to redact: %w", version, err)
+		}
+	}
+	if api.output != nil && api.output.naming != nil {
+		// Names are translated in the serialized spec, so that resource
+		// documents shared by other versions are left as they are.
+		jsonBuf, err = translateNaming(api.output.naming, jsonBuf)
+		if err != nil {
+			return nil, fmt.Errorf("version %s: failed to translate naming: %w", version, err)
+		}
+	}
+	if api.output != nil && (len(api.output.redact) > 0 || api.output.naming != nil) {
+		spec, err = openapi3.NewLoader().LoadFromData(jsonBuf)
+		if err != nil {
+			return nil, fmt.Errorf("version %s: failed to load compiled spec: %w", version, err)
+		}
+	}
+	yamlBuf, err := yaml.JSONToYAML(jsonBuf)
+	if err != nil {
+		return nil, err
+	}
+	yamlBuf, err = vervet.WithGeneratedComment(yamlBuf)
+	if err != nil {
+		return nil, err
+	}
+	return &compiledSpec{spec: spec, json: jsonBuf, yaml: yamlBuf}, nil
+}
+
+// resourcesKey returns a key identifying a set of resource versions, which is
+// the same for any versions that resolve to the same resource versions.
+func resourcesKey(resources []*vervet.Resource) string {
+	var sb strings.Builder
+	for _, rc := range resources {
+		fmt.Fprintf(&sb, "%p;", rc)
+	}
+	return sb.String()
+}
+
 // BuildAll builds all APIs in the project.
 func (c *Compiler) BuildAll(ctx context.Context) error {
-	return c.apisEach(ctx, c.Build)
+	err := c.buildAPIs(ctx)
+	if err != nil {
+		return err
+	}
+	return c.checkPathCollisions()
 }
 
 // LintOutput applies configured linting rules to the build output.
@@ -362,7 +1115,7 @@ func (c *Compiler) LintOutput(ctx context.Context, apiName string) error {
 		if len(outputFiles) == 0 {
 			return fmt.Errorf("lint failed: no output files were produced")
 		}
-		err = api.output.linter.Run(ctx, outputFiles...)
+		err = c.lint(ctx, api.output.linter, fmt.Sprintf("apis.%s.output", apiName), outputFiles...)
 		if err != nil {
 			return fmt.Errorf("lint failed (apis.%s.output)", apiName)
 		}
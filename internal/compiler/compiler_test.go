@@ -3,14 +3,19 @@ package compiler
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"text/template"
+	"time"
 
 	qt "github.com/frankban/quicktest"
 
+	"github.com/snyk/vervet"
 	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/gateway"
 	"github.com/snyk/vervet/internal/types"
 	"github.com/snyk/vervet/testdata"
 )
@@ -132,3 +137,279 @@ func (l *mockLinter) NewRules(ctx context.Context, rules ...string) (types.Linte
 	}
 	return nl, nil
 }
+
+// newTestProject returns the project configured by configTemplate, building
+// to outputPath, with any changes made by edit.
+func newTestProject(c *qt.C, outputPath string, edit func(*config.Project)) *config.Project {
+	var configBuf bytes.Buffer
+	err := configTemplate.Execute(&configBuf, outputPath)
+	c.Assert(err, qt.IsNil)
+	proj, err := config.Load(&configBuf)
+	c.Assert(err, qt.IsNil)
+	if edit != nil {
+		edit(proj)
+	}
+	return proj
+}
+
+// mockLinters is a compiler option which replaces every linter with a
+// mockLinter.
+func mockLinters() CompilerOption {
+	return LinterFactory(func(context.Context, *config.Linter) (types.Linter, error) {
+		return &mockLinter{}, nil
+	})
+}
+
+// newTestCompiler returns a compiler for the project returned by
+// newTestProject. Linters are mocked, unless options include another
+// LinterFactory.
+func newTestCompiler(c *qt.C, outputPath string, edit func(*config.Project), options ...CompilerOption) *Compiler {
+	proj := newTestProject(c, outputPath, edit)
+	compiler, err := New(context.Background(), proj, append([]CompilerOption{mockLinters()}, options...)...)
+	c.Assert(err, qt.IsNil)
+	return compiler
+}
+
+func TestLintFiles(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	compiler := newTestCompiler(c, c.Mkdir(), nil)
+
+	// Files need not be part of any API
+	err := compiler.LintFiles(ctx, "compiled-rules", "testdata/output/2021-06-04~experimental/spec.json")
+	c.Assert(err, qt.IsNil)
+	c.Assert(compiler.linters["compiled-rules"].(*mockLinter).runs, qt.DeepEquals, [][]string{
+		{"testdata/output/2021-06-04~experimental/spec.json"},
+	})
+	c.Assert(compiler.linters["resource-rules"].(*mockLinter).runs, qt.HasLen, 0)
+
+	err = compiler.LintFiles(ctx, "nope", "testdata/output/2021-06-04~experimental/spec.json")
+	c.Assert(err, qt.ErrorMatches, `linter not found \(linters.nope\)`)
+
+	compiler.linters["compiled-rules"].(*mockLinter).err = errors.New("exit status 1")
+	err = compiler.LintFiles(ctx, "compiled-rules", "testdata/output/2021-06-04~experimental/spec.json")
+	c.Assert(err, qt.ErrorMatches, `lint failed \(linters.compiled-rules\)`)
+}
+
+func TestResourceSpecFilesPattern(t *testing.T) {
+	c := qt.New(t)
+	root := c.Mkdir()
+	for _, path := range []string{
+		"orgs/2021-06-01/openapi.yaml",
+		"orgs/2021-06-01/spec.yaml",
+		"orgs/2021-06-13/openapi.yaml",
+		"projects/2021-06-04/projects.oas.yaml",
+		"projects/2021-06-04/openapi.yaml",
+	} {
+		c.Assert(os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0777), qt.IsNil)
+		c.Assert(ioutil.WriteFile(filepath.Join(root, path), nil, 0666), qt.IsNil)
+	}
+
+	specFiles, err := ResourceSpecFiles(&config.ResourceSet{Path: root})
+	c.Assert(err, qt.IsNil)
+	c.Assert(specFiles, qt.DeepEquals, []string{
+		filepath.Join(root, "orgs/2021-06-01/spec.yaml"),
+	})
+
+	specFiles, err = ResourceSpecFiles(&config.ResourceSet{Path: root, Specs: "*.oas.yaml"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(specFiles, qt.DeepEquals, []string{
+		filepath.Join(root, "projects/2021-06-04/projects.oas.yaml"),
+	})
+
+	specFiles, err = ResourceSpecFiles(&config.ResourceSet{Path: root, Specs: "openapi.yaml"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(specFiles, qt.DeepEquals, []string{
+		filepath.Join(root, "orgs/2021-06-01/openapi.yaml"),
+		filepath.Join(root, "orgs/2021-06-13/openapi.yaml"),
+		filepath.Join(root, "projects/2021-06-04/openapi.yaml"),
+	})
+
+	_, err = ResourceSpecFiles(&config.ResourceSet{Path: root, Specs: "*.yaml"})
+	c.Assert(err, qt.ErrorMatches, `multiple spec files ".*/orgs/2021-06-01/openapi.yaml" and ".*/orgs/2021-06-01/spec.yaml" match "\*.yaml"`)
+}
+
+func TestBuildReusesCompiledSpecs(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	outputPath := c.Mkdir()
+	profile := NewProfile()
+	compiler := newTestCompiler(c, outputPath, nil, Profiler(profile))
+	err := compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+
+	// All stabilities on 2021-06-01, and 2021-06-04 GA, resolve to the same
+	// hello-world resource version; the projects resource introduced on
+	// 2021-06-04 is experimental. The spec is compiled once, for the first
+	// of these versions built, and written for each of them.
+	c.Assert(profile.Duration("v3-api", "2021-06-01~experimental", PhaseMerge) > 0, qt.IsTrue)
+	for _, version := range []string{"2021-06-01~beta", "2021-06-01", "2021-06-04"} {
+		c.Assert(profile.Duration("v3-api", version, PhaseMerge), qt.Equals, time.Duration(0))
+		c.Assert(profile.Duration("v3-api", version, PhaseWrite) > 0, qt.IsTrue)
+	}
+	prior, err := ioutil.ReadFile(outputPath + "/2021-06-01~experimental/spec.json")
+	c.Assert(err, qt.IsNil)
+	reused, err := ioutil.ReadFile(outputPath + "/2021-06-04/spec.json")
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(reused), qt.Equals, string(prior))
+}
+
+func TestBuildAliases(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	for _, aliases := range []config.OutputAliases{config.OutputAliasesSymlink, config.OutputAliasesIndex} {
+		c.Run(string(aliases), func(c *qt.C) {
+			outputPath := c.Mkdir()
+			compiler := newTestCompiler(c, outputPath, func(proj *config.Project) {
+				proj.APIs["v3-api"].Output.Aliases = aliases
+			})
+			err := compiler.BuildAll(ctx)
+			c.Assert(err, qt.IsNil)
+
+			// Compiled specs are the same whether aliased or not
+			specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(outputPath))
+			c.Assert(err, qt.IsNil)
+			c.Assert(specs.Versions(), qt.HasLen, 12)
+			spec, err := specs.At("2021-06-04")
+			c.Assert(err, qt.IsNil)
+			c.Assert(spec.Paths["/examples/hello-world/{id}"], qt.Not(qt.IsNil))
+			c.Assert(spec.Paths["/orgs/{orgId}/projects"], qt.IsNil)
+
+			switch aliases {
+			case config.OutputAliasesSymlink:
+				target, err := os.Readlink(outputPath + "/2021-06-04")
+				c.Assert(err, qt.IsNil)
+				c.Assert(target, qt.Equals, "2021-06-01~experimental")
+			case config.OutputAliasesIndex:
+				_, err := os.Stat(outputPath + "/2021-06-04")
+				c.Assert(os.IsNotExist(err), qt.IsTrue)
+				buf, err := ioutil.ReadFile(outputPath + "/" + vervet.CompiledAliasesFile)
+				c.Assert(err, qt.IsNil)
+				c.Assert(string(buf), qt.Contains, `"2021-06-04": "2021-06-01~experimental"`)
+			}
+		})
+	}
+}
+
+func TestBuildExports(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	outputPath := c.Mkdir()
+	compiler := newTestCompiler(c, outputPath, func(proj *config.Project) {
+		proj.APIs["v3-api"].Output.Exports = &config.Exports{
+			Kong: &config.KongExport{Upstream: "http://hello:8080"},
+		}
+	})
+	err := compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+
+	buf, err := ioutil.ReadFile(outputPath + "/2021-06-04~experimental/" + gateway.KongFile)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Contains, "name: v3-api-2021-06-04~experimental\n")
+	c.Assert(string(buf), qt.Contains, "url: http://hello:8080\n")
+	_, err = os.Stat(outputPath + "/2021-06-04~experimental/" + gateway.EnvoyFile)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+}
+
+func TestResourceSpecFilesGranularity(t *testing.T) {
+	c := qt.New(t)
+	root := c.Mkdir()
+	for _, path := range []string{
+		"orgs/2021-06/spec.yaml",
+		"orgs/2021-07/spec.yaml",
+		"orgs/2021-07-15/spec.yaml",
+		"projects/2021-W23/spec.yaml",
+	} {
+		c.Assert(os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0777), qt.IsNil)
+		resourcePath := "/" + filepath.Base(filepath.Dir(filepath.Dir(path)))
+		c.Assert(ioutil.WriteFile(filepath.Join(root, path), []byte(`
+openapi: 3.0.3
+x-snyk-api-stability: ga
+info: {title: test, version: 3.0.0}
+paths:
+  `+resourcePath+`:
+    get:
+      responses:
+        '204': {description: ok}
+`), 0666), qt.IsNil)
+	}
+
+	specFiles, err := ResourceSpecFiles(&config.ResourceSet{Path: root, Granularity: config.GranularityMonth})
+	c.Assert(err, qt.IsNil)
+	c.Assert(specFiles, qt.DeepEquals, []string{
+		filepath.Join(root, "orgs/2021-06/spec.yaml"),
+		filepath.Join(root, "orgs/2021-07/spec.yaml"),
+	})
+	specVersions, err := vervet.LoadSpecVersionsFileset(specFiles)
+	c.Assert(err, qt.IsNil)
+	var versions []string
+	for _, version := range specVersions.Versions() {
+		versions = append(versions, version.String())
+	}
+	c.Assert(versions, qt.DeepEquals, []string{"2021-06-01", "2021-07-01"})
+
+	// Versions requested by month resolve to the monthly version.
+	version, err := specVersions.Resolve("2021-07")
+	c.Assert(err, qt.IsNil)
+	c.Assert(version.String(), qt.Equals, "2021-07-01")
+	version, err = specVersions.Resolve("2021-06-30")
+	c.Assert(err, qt.IsNil)
+	c.Assert(version.String(), qt.Equals, "2021-06-01")
+
+	specFiles, err = ResourceSpecFiles(&config.ResourceSet{Path: root, Granularity: config.GranularityWeek})
+	c.Assert(err, qt.IsNil)
+	c.Assert(specFiles, qt.DeepEquals, []string{
+		filepath.Join(root, "projects/2021-W23/spec.yaml"),
+	})
+	specVersions, err = vervet.LoadSpecVersionsFileset(specFiles)
+	c.Assert(err, qt.IsNil)
+	c.Assert(specVersions.Versions()[0].String(), qt.Equals, "2021-06-07")
+}
+
+func TestResourceSpecFilesSemver(t *testing.T) {
+	c := qt.New(t)
+	root := c.Mkdir()
+	for _, version := range []string{"v1", "v1.2", "v2", "2021-06-01"} {
+		path := filepath.Join(root, "orgs", version, "spec.yaml")
+		c.Assert(os.MkdirAll(filepath.Dir(path), 0777), qt.IsNil)
+		c.Assert(ioutil.WriteFile(path, []byte(`
+openapi: 3.0.3
+x-snyk-api-stability: ga
+info: {title: test, version: 3.0.0}
+paths:
+  /orgs/`+version+`:
+    get:
+      responses:
+        '204': {description: ok}
+`), 0666), qt.IsNil)
+	}
+
+	specFiles, err := ResourceSpecFiles(&config.ResourceSet{Path: root, Versioning: config.VersioningSemver})
+	c.Assert(err, qt.IsNil)
+	c.Assert(specFiles, qt.DeepEquals, []string{
+		filepath.Join(root, "orgs/v1/spec.yaml"),
+		filepath.Join(root, "orgs/v1.2/spec.yaml"),
+		filepath.Join(root, "orgs/v2/spec.yaml"),
+	})
+	specVersions, err := vervet.LoadSpecVersionsFileset(specFiles)
+	c.Assert(err, qt.IsNil)
+	var versions []string
+	for _, version := range specVersions.Versions() {
+		versions = append(versions, version.String())
+	}
+	c.Assert(versions, qt.DeepEquals, []string{"v1", "v1.2", "v2"})
+
+	// Versions between releases resolve to the prior release.
+	version, err := specVersions.Resolve("v1.5")
+	c.Assert(err, qt.IsNil)
+	c.Assert(version.String(), qt.Equals, "v1.2")
+	doc, err := specVersions.At("v1.5")
+	c.Assert(err, qt.IsNil)
+	c.Assert(doc.Paths.Find("/orgs/v1.2"), qt.Not(qt.IsNil))
+	_, err = specVersions.Resolve("v0.9")
+	c.Assert(err, qt.Not(qt.IsNil))
+}
@@ -0,0 +1,81 @@
+package compiler
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+const downconvertResourceSpec = `
+openapi: 3.1.0
+x-snyk-api-stability: ga
+info:
+  title: things
+  version: 3.0.0
+paths:
+  /things:
+    get:
+      operationId: listThings
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  name:
+                    type: [string, "null"]
+`
+
+const downconvertConfig = `
+apis:
+  test:
+    resources:
+      - path: resources
+    output:
+      path: versions
+      downconvert: true
+`
+
+func TestBuildDownconvert(t *testing.T) {
+	c := qt.New(t)
+	projectDir := c.Mkdir()
+	specDir := filepath.Join(projectDir, "resources", "things", "2021-06-01")
+	c.Assert(os.MkdirAll(specDir, 0777), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(specDir, "spec.yaml"), []byte(downconvertResourceSpec[1:]), 0666), qt.IsNil)
+	cwd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(os.Chdir(projectDir), qt.IsNil)
+	c.Cleanup(func() {
+		c.Assert(os.Chdir(cwd), qt.IsNil)
+	})
+
+	ctx := context.Background()
+	build := func(downconvert bool) error {
+		proj, err := config.Load(bytes.NewBufferString(downconvertConfig))
+		c.Assert(err, qt.IsNil)
+		proj.APIs["test"].Output.Downconvert = downconvert
+		compiler, err := New(ctx, proj, mockLinters())
+		c.Assert(err, qt.IsNil)
+		return compiler.Build(ctx, "test")
+	}
+
+	err = build(false)
+	c.Assert(err, qt.ErrorMatches, `failed to load spec versions: .* \(apis.test.resources\[0\]\)`)
+
+	err = build(true)
+	c.Assert(err, qt.IsNil)
+	buf, err := ioutil.ReadFile(filepath.Join(projectDir, "versions", "2021-06-01", "spec.yaml"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Contains, "openapi: 3.0.3\n")
+	c.Assert(string(buf), qt.Contains, "nullable: true\n")
+	c.Assert(string(buf), qt.Not(qt.Contains), "null\n")
+}
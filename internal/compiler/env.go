@@ -0,0 +1,78 @@
+package compiler
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/snyk/vervet/config"
+)
+
+// envExpander expands environment variables in the project configuration,
+// such as in inline overlays, keeping track of which variables were expanded
+// where, so that these can be reported.
+//
+// Variables are written as $NAME or ${NAME}. $$ is a literal $.
+type envExpander struct {
+	env *config.Env
+
+	// expanded maps the name of each variable expanded to the configuration
+	// elements it was expanded in.
+	expanded map[string][]string
+}
+
+func newEnvExpander(env *config.Env) *envExpander {
+	return &envExpander{env: env, expanded: map[string][]string{}}
+}
+
+// expand returns s with environment variables expanded. where locates s in
+// the project configuration, such as "apis.rest.overlays[1]". An error is
+// returned if s refers to a variable which is not allowed.
+func (e *envExpander) expand(s, where string) (string, error) {
+	var err error
+	result := os.Expand(s, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		if !e.env.IsAllowed(name) {
+			if err == nil {
+				err = fmt.Errorf("environment variable %q is not allowed, add it to env.allow or escape it as $$ (%s)", name, where)
+			}
+			return ""
+		}
+		if !containsString(e.expanded[name], where) {
+			e.expanded[name] = append(e.expanded[name], where)
+		}
+		return os.Getenv(name)
+	})
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// report logs which environment variables were expanded where. The values of
+// variables are not logged, as these may be secret.
+func (e *envExpander) report() {
+	var names []string
+	for name := range e.expanded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		wheres := append([]string(nil), e.expanded[name]...)
+		sort.Strings(wheres)
+		log.Printf("expanded environment variable %s in %s", name, strings.Join(wheres, ", "))
+	}
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package compiler
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestEnvExpander(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("API_REGION", "eu")
+	c.Setenv("API_TOKEN", "secret")
+
+	env := newEnvExpander(nil)
+	s, err := env.expand("https://${API_REGION}.example.com/$API_TOKEN", "apis.rest.overlays[0]")
+	c.Assert(err, qt.IsNil)
+	c.Assert(s, qt.Equals, "https://eu.example.com/secret")
+
+	env = newEnvExpander(&config.Env{Allow: []string{"API_REGION"}})
+	s, err = env.expand("url: https://${API_REGION}.example.com, price: $$5", "apis.rest.overlays[0]")
+	c.Assert(err, qt.IsNil)
+	c.Assert(s, qt.Equals, "url: https://eu.example.com, price: $5")
+	_, err = env.expand("url: https://${API_REGION}.example.com", "apis.rest.output.servers[0].url")
+	c.Assert(err, qt.IsNil)
+	c.Assert(env.expanded, qt.DeepEquals, map[string][]string{
+		"API_REGION": {"apis.rest.overlays[0]", "apis.rest.output.servers[0].url"},
+	})
+
+	_, err = env.expand("token: ${API_TOKEN}", "apis.rest.overlays[1]")
+	c.Assert(err, qt.ErrorMatches, `environment variable "API_TOKEN" is not allowed, add it to env.allow or escape it as \$\$ \(apis.rest.overlays\[1\]\)`)
+	c.Assert(env.expanded["API_TOKEN"], qt.IsNil)
+}
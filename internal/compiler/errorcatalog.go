@@ -0,0 +1,254 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Files written to an output directory by the error catalog export.
+const (
+	ErrorCatalogJSONFile     = "errors.json"
+	ErrorCatalogMarkdownFile = "errors.md"
+)
+
+// errorCatalog collects the error responses of all compiled versions of an
+// API, so that the errors an API may return can be looked up across
+// versions.
+type errorCatalog struct {
+	Errors []*errorCatalogEntry `json:"errors"`
+
+	index map[string]*errorCatalogEntry
+}
+
+// errorCatalogEntry is a distinct error response: a status code, with a
+// description and schema.
+type errorCatalogEntry struct {
+	Status      string          `json:"status"`
+	Description string          `json:"description,omitempty"`
+	ContentType string          `json:"contentType,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+
+	// Versions maps each version which returns the error to the operations
+	// returning it, as "METHOD /path".
+	Versions map[string][]string `json:"versions"`
+}
+
+func (e *errorCatalogEntry) key() string {
+	return strings.Join([]string{e.Status, e.Description, e.ContentType, string(e.Schema)}, "\x00")
+}
+
+func newErrorCatalog() *errorCatalog {
+	return &errorCatalog{index: map[string]*errorCatalogEntry{}}
+}
+
+// readErrorCatalog returns the error catalog in existing output, or an empty
+// catalog if there is none.
+func readErrorCatalog(outputPath string) (*errorCatalog, error) {
+	catalog := newErrorCatalog()
+	buf, err := ioutil.ReadFile(outputPath + "/" + ErrorCatalogJSONFile)
+	if os.IsNotExist(err) {
+		return catalog, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ErrorCatalogJSONFile, err)
+	}
+	err = json.Unmarshal(buf, catalog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ErrorCatalogJSONFile, err)
+	}
+	for _, entry := range catalog.Errors {
+		catalog.index[entry.key()] = entry
+	}
+	return catalog, nil
+}
+
+// add adds the error responses of a compiled version to the catalog,
+// replacing any added for it before.
+func (c *errorCatalog) add(version string, spec *openapi3.T) error {
+	for _, entry := range c.Errors {
+		delete(entry.Versions, version)
+	}
+	for path, pathItem := range spec.Paths {
+		for method, op := range pathItem.Operations() {
+			for status, resp := range op.Responses {
+				if !strings.HasPrefix(status, "4") && !strings.HasPrefix(status, "5") {
+					continue
+				}
+				if resp == nil || resp.Value == nil {
+					continue
+				}
+				entry, err := newErrorCatalogEntry(status, resp.Value)
+				if err != nil {
+					return fmt.Errorf("%s %s %s: %w", method, path, status, err)
+				}
+				if existing, ok := c.index[entry.key()]; ok {
+					entry = existing
+				} else {
+					c.index[entry.key()] = entry
+					c.Errors = append(c.Errors, entry)
+				}
+				entry.Versions[version] = append(entry.Versions[version], method+" "+path)
+			}
+		}
+	}
+	return nil
+}
+
+func newErrorCatalogEntry(status string, resp *openapi3.Response) (*errorCatalogEntry, error) {
+	entry := &errorCatalogEntry{Status: status, Versions: map[string][]string{}}
+	if resp.Description != nil {
+		entry.Description = strings.TrimSpace(*resp.Description)
+	}
+	var contentTypes []string
+	for contentType := range resp.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+	for _, contentType := range contentTypes {
+		mediaType := resp.Content[contentType]
+		if mediaType == nil || mediaType.Schema == nil {
+			continue
+		}
+		schema, err := json.Marshal(inlineSchema(mediaType.Schema, map[*openapi3.Schema]bool{}))
+		if err != nil {
+			return nil, err
+		}
+		entry.ContentType, entry.Schema = contentType, schema
+		break
+	}
+	return entry, nil
+}
+
+// inlineSchema returns a copy of a schema with references replaced by the
+// schemas they refer to, so that it may be understood on its own. Recursive
+// references are left as they are.
+func inlineSchema(ref *openapi3.SchemaRef, visiting map[*openapi3.Schema]bool) *openapi3.SchemaRef {
+	if ref == nil || ref.Value == nil || visiting[ref.Value] {
+		return ref
+	}
+	visiting[ref.Value] = true
+	defer delete(visiting, ref.Value)
+	s := *ref.Value
+	if len(s.Properties) > 0 {
+		s.Properties = openapi3.Schemas{}
+		for name, prop := range ref.Value.Properties {
+			s.Properties[name] = inlineSchema(prop, visiting)
+		}
+	}
+	s.Items = inlineSchema(s.Items, visiting)
+	s.Not = inlineSchema(s.Not, visiting)
+	s.AdditionalProperties = inlineSchema(s.AdditionalProperties, visiting)
+	s.AllOf = inlineSchemas(s.AllOf, visiting)
+	s.AnyOf = inlineSchemas(s.AnyOf, visiting)
+	s.OneOf = inlineSchemas(s.OneOf, visiting)
+	return &openapi3.SchemaRef{Value: &s}
+}
+
+func inlineSchemas(refs openapi3.SchemaRefs, visiting map[*openapi3.Schema]bool) openapi3.SchemaRefs {
+	if len(refs) == 0 {
+		return refs
+	}
+	result := make(openapi3.SchemaRefs, len(refs))
+	for i := range refs {
+		result[i] = inlineSchema(refs[i], visiting)
+	}
+	return result
+}
+
+// entries returns the entries of the catalog returned by any version, sorted
+// by status, description and schema.
+func (c *errorCatalog) entries() []*errorCatalogEntry {
+	var result []*errorCatalogEntry
+	for _, entry := range c.Errors {
+		if len(entry.Versions) == 0 {
+			continue
+		}
+		for _, ops := range entry.Versions {
+			sort.Strings(ops)
+		}
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].key() < result[j].key()
+	})
+	return result
+}
+
+// toJSON returns the catalog as a JSON document.
+func (c *errorCatalog) toJSON() ([]byte, error) {
+	return json.MarshalIndent(&errorCatalog{Errors: c.entries()}, "", "  ")
+}
+
+// toMarkdown returns the catalog as a Markdown document for people to read,
+// with the operations and versions returning each error.
+func (c *errorCatalog) toMarkdown(title string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n", title)
+	for _, entry := range c.entries() {
+		fmt.Fprintf(&buf, "\n## %s", entry.Status)
+		if entry.Description != "" {
+			fmt.Fprintf(&buf, ": %s", entry.Description)
+		}
+		buf.WriteString("\n\nReturned by:\n\n")
+		opVersions := map[string][]string{}
+		for version, ops := range entry.Versions {
+			for _, op := range ops {
+				opVersions[op] = append(opVersions[op], version)
+			}
+		}
+		var ops []string
+		for op := range opVersions {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+		for _, op := range ops {
+			sort.Strings(opVersions[op])
+			fmt.Fprintf(&buf, "* `%s` in %s\n", op, strings.Join(opVersions[op], ", "))
+		}
+		if len(entry.Schema) > 0 {
+			var schema bytes.Buffer
+			if err := json.Indent(&schema, entry.Schema, "", "  "); err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&buf, "\n%s:\n\n```json\n%s\n```\n", entry.ContentType, schema.String())
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeErrorCatalog writes the error catalog of an API to its output.
+func writeErrorCatalog(apiName string, out *output, catalog *errorCatalog) error {
+	jsonBuf, err := catalog.toJSON()
+	if err != nil {
+		return err
+	}
+	title := out.exports.ErrorCatalog.Title
+	if title == "" && apiName != "" {
+		title = apiName + " errors"
+	} else if title == "" {
+		title = "Errors"
+	}
+	mdBuf, err := catalog.toMarkdown(title)
+	if err != nil {
+		return err
+	}
+	for filename, buf := range map[string][]byte{
+		ErrorCatalogJSONFile:     jsonBuf,
+		ErrorCatalogMarkdownFile: mdBuf,
+	} {
+		path := out.path + "/" + filename
+		err = ioutil.WriteFile(path, buf, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", filename, err)
+		}
+		log.Println(path)
+	}
+	return nil
+}
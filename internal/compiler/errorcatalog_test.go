@@ -0,0 +1,62 @@
+package compiler
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestBuildErrorCatalog(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	outputPath := c.Mkdir()
+	compiler := newTestCompiler(c, outputPath, func(proj *config.Project) {
+		proj.APIs["v3-api"].Output.Exports = &config.Exports{
+			ErrorCatalog: &config.ErrorCatalogExport{},
+		}
+	})
+	err := compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+
+	buf, err := ioutil.ReadFile(outputPath + "/" + ErrorCatalogJSONFile)
+	c.Assert(err, qt.IsNil)
+	var catalog errorCatalog
+	err = json.Unmarshal(buf, &catalog)
+	c.Assert(err, qt.IsNil)
+	var notFound *errorCatalogEntry
+	for _, entry := range catalog.Errors {
+		c.Assert(entry.Status[0] == '4' || entry.Status[0] == '5', qt.IsTrue)
+		if entry.Status == "404" {
+			c.Assert(notFound, qt.IsNil, qt.Commentf("404 should be the same in all versions"))
+			notFound = entry
+		}
+	}
+	c.Assert(notFound, qt.Not(qt.IsNil))
+	c.Assert(notFound.ContentType, qt.Equals, "application/vnd.api+json")
+	c.Assert(notFound.Versions["2021-06-01"], qt.DeepEquals, []string{
+		"GET /examples/hello-world/{id}",
+		"GET /openapi",
+		"GET /openapi/{version}",
+	})
+	c.Assert(notFound.Versions["2021-06-13~beta"], qt.DeepEquals, []string{
+		"GET /examples/hello-world/{id}",
+		"GET /openapi",
+		"GET /openapi/{version}",
+		"POST /examples/hello-world",
+	})
+	// Referenced schemas are inlined, so that the catalog stands alone.
+	c.Assert(string(notFound.Schema), qt.Not(qt.Contains), `"$ref"`)
+	c.Assert(string(notFound.Schema), qt.Contains, `"errors"`)
+
+	buf, err = ioutil.ReadFile(outputPath + "/" + ErrorCatalogMarkdownFile)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Contains, "# v3-api errors\n")
+	c.Assert(string(buf), qt.Contains, "\n## 404: ")
+	c.Assert(string(buf), qt.Contains, "* `POST /examples/hello-world` in 2021-06-13~beta, 2021-06-13~experimental\n")
+}
@@ -0,0 +1,151 @@
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snyk/vervet"
+)
+
+// BuildFilter restricts compilation to part of a project, so that changes to
+// a single API, resource or version can be checked without building
+// everything. An empty field matches everything.
+//
+// Output of a partial build only reflects what was matched. Output is not
+// cleared before a partial build, so that unmatched versions are left as they
+// were; these may be stale. Each partial build is recorded in the output's
+// PartialBuildFile.
+type BuildFilter struct {
+	// API is the name of the only API to compile.
+	API string `json:"api,omitempty"`
+
+	// Resource is the name of the only resource to compile. Compiled specs
+	// will only contain this resource.
+	Resource string `json:"resource,omitempty"`
+
+	// Version is the only version to compile. If it is a date with no
+	// stability, all stabilities of that date are compiled.
+	Version string `json:"version,omitempty"`
+}
+
+// PartialBuildFile is the name of the manifest written to an output directory
+// by partial builds, so that output which is not complete is not mistaken for
+// the output of a full build. Full builds clear the output, removing it.
+const PartialBuildFile = "partial-build.json"
+
+// partialBuilds is the manifest of the partial builds made to an output since
+// it was last built in full.
+type partialBuilds struct {
+	// Partial lists the versions last rebuilt by a build filtered by
+	// resource, which only contain the matched resource.
+	Partial []string `json:"partial"`
+
+	// Builds lists each partial build, in the order they were made.
+	Builds []partialBuild `json:"builds"`
+}
+
+// partialBuild records the filter a partial build was made with, and the
+// versions it rebuilt. Versions it did not rebuild may be stale.
+type partialBuild struct {
+	Filter   BuildFilter `json:"filter"`
+	Versions []string    `json:"versions"`
+}
+
+// writePartialBuild adds a partial build of versions to the manifest in an
+// output directory.
+func writePartialBuild(outputPath string, f BuildFilter, versions []string) error {
+	path := outputPath + "/" + PartialBuildFile
+	var manifest partialBuilds
+	buf, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(buf, &manifest)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", PartialBuildFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", PartialBuildFile, err)
+	}
+
+	rebuilt := map[string]bool{}
+	for _, version := range versions {
+		rebuilt[version] = true
+	}
+	build := partialBuild{Filter: f, Versions: []string{}}
+	for version := range rebuilt {
+		build.Versions = append(build.Versions, version)
+	}
+	sort.Strings(build.Versions)
+	manifest.Builds = append(manifest.Builds, build)
+
+	// Versions rebuilt without a resource filter are complete again.
+	partial := map[string]bool{}
+	for _, version := range manifest.Partial {
+		partial[version] = true
+	}
+	for version := range rebuilt {
+		partial[version] = f.Resource != ""
+	}
+	manifest.Partial = []string{}
+	for version, ok := range partial {
+		if ok {
+			manifest.Partial = append(manifest.Partial, version)
+		}
+	}
+	sort.Strings(manifest.Partial)
+
+	buf, err = json.MarshalIndent(&manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path, buf, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", PartialBuildFile, err)
+	}
+	return nil
+}
+
+// Filter configures a Compiler to only lint and build the parts of the
+// project matched by f.
+func Filter(f BuildFilter) CompilerOption {
+	return func(c *Compiler) error {
+		if f.Version != "" {
+			if _, err := vervet.ParseVersion(f.Version); err != nil {
+				return err
+			}
+		}
+		c.filter = f
+		return nil
+	}
+}
+
+// partial returns whether the filter restricts the resources or versions
+// which are built into an API's output.
+func (f *BuildFilter) partial() bool {
+	return f.Resource != "" || f.Version != ""
+}
+
+func (f *BuildFilter) matchAPI(apiName string) bool {
+	return f.API == "" || f.API == apiName
+}
+
+// matchSpecFile returns whether a resource spec file path is matched, by the
+// name of the resource that contains it.
+func (f *BuildFilter) matchSpecFile(path string) bool {
+	return f.Resource == "" || f.Resource == filepath.Base(filepath.Dir(filepath.Dir(path)))
+}
+
+func (f *BuildFilter) matchVersion(v *vervet.Version) bool {
+	if f.Version == "" {
+		return true
+	}
+	if strings.Contains(f.Version, "~") {
+		fv, err := vervet.ParseVersion(f.Version)
+		return err == nil && fv.Compare(v) == 0
+	}
+	return v.DateString() == f.Version
+}
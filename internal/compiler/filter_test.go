@@ -0,0 +1,146 @@
+package compiler
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet"
+)
+
+func TestBuildFilter(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	outputPath := c.Mkdir()
+	proj := newTestProject(c, outputPath, nil)
+	linterFactory := mockLinters()
+
+	_, err := New(ctx, proj, linterFactory, Filter(BuildFilter{API: "nope"}))
+	c.Assert(err, qt.ErrorMatches, `api not found \(apis\.nope\)`)
+	_, err = New(ctx, proj, linterFactory, Filter(BuildFilter{Version: "nope"}))
+	c.Assert(err, qt.IsNotNil)
+
+	// Full build
+	compiler, err := New(ctx, proj, linterFactory)
+	c.Assert(err, qt.IsNil)
+	err = compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+	_, err = os.Stat(outputPath + "/" + PartialBuildFile)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+
+	// Partial build of one resource at one version date
+	compiler, err = New(ctx, proj, linterFactory, Filter(BuildFilter{
+		API:      "v3-api",
+		Resource: "projects",
+		Version:  "2021-06-04",
+	}))
+	c.Assert(err, qt.IsNil)
+	c.Assert(compiler.apis["v3-api"].resources[0].matchedFiles, qt.DeepEquals, []string{
+		"testdata/resources/projects/2021-06-04/spec.yaml",
+	})
+	err = compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+
+	specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(outputPath))
+	c.Assert(err, qt.IsNil)
+	// Matched output only contains the matched resource
+	spec, err := specs.At("2021-06-04~experimental")
+	c.Assert(err, qt.IsNil)
+	c.Assert(spec.Paths["/orgs/{orgId}/projects"], qt.Not(qt.IsNil))
+	c.Assert(spec.Paths["/examples/hello-world/{id}"], qt.IsNil)
+	// Unmatched output remains from the full build
+	spec, err = specs.At("2021-06-07~experimental")
+	c.Assert(err, qt.IsNil)
+	c.Assert(spec.Paths["/orgs/{orgId}/projects"], qt.Not(qt.IsNil))
+	c.Assert(spec.Paths["/examples/hello-world/{id}"], qt.Not(qt.IsNil))
+
+	// The partial build is recorded in the output, along with the versions
+	// which only contain the matched resource.
+	c.Assert(readPartialBuilds(c, outputPath), qt.Equals, `{
+  "partial": [
+    "2021-06-04~experimental"
+  ],
+  "builds": [
+    {
+      "filter": {
+        "api": "v3-api",
+        "resource": "projects",
+        "version": "2021-06-04"
+      },
+      "versions": [
+        "2021-06-04~experimental"
+      ]
+    }
+  ]
+}`)
+
+	// Rebuilding the version with all resources completes it again.
+	compiler, err = New(ctx, proj, linterFactory, Filter(BuildFilter{Version: "2021-06-04"}))
+	c.Assert(err, qt.IsNil)
+	err = compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+	c.Assert(readPartialBuilds(c, outputPath), qt.Equals, `{
+  "partial": [],
+  "builds": [
+    {
+      "filter": {
+        "api": "v3-api",
+        "resource": "projects",
+        "version": "2021-06-04"
+      },
+      "versions": [
+        "2021-06-04~experimental"
+      ]
+    },
+    {
+      "filter": {
+        "version": "2021-06-04"
+      },
+      "versions": [
+        "2021-06-04",
+        "2021-06-04~beta",
+        "2021-06-04~experimental"
+      ]
+    }
+  ]
+}`)
+
+	// A full build clears the record of partial builds.
+	compiler, err = New(ctx, proj, linterFactory)
+	c.Assert(err, qt.IsNil)
+	err = compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+	_, err = os.Stat(outputPath + "/" + PartialBuildFile)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+}
+
+func readPartialBuilds(c *qt.C, outputPath string) string {
+	buf, err := ioutil.ReadFile(outputPath + "/" + PartialBuildFile)
+	c.Assert(err, qt.IsNil)
+	return string(buf)
+}
+
+func TestBuildFilterMatchVersion(t *testing.T) {
+	c := qt.New(t)
+	tests := []struct {
+		filter, version string
+		match           bool
+	}{
+		{"", "2021-06-04~beta", true},
+		{"2021-06-04", "2021-06-04~beta", true},
+		{"2021-06-04", "2021-06-04", true},
+		{"2021-06-04", "2021-06-05", false},
+		{"2021-06-04~beta", "2021-06-04~beta", true},
+		{"2021-06-04~beta", "2021-06-04", false},
+	}
+	for _, test := range tests {
+		f := BuildFilter{Version: test.filter}
+		v, err := vervet.ParseVersion(test.version)
+		c.Assert(err, qt.IsNil)
+		c.Assert(f.matchVersion(v), qt.Equals, test.match, qt.Commentf("%q %q", test.filter, test.version))
+	}
+}
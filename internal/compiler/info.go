@@ -0,0 +1,54 @@
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet/config"
+)
+
+// applyInfoPolicy fills in the info fields missing from a compiled spec with
+// the defaults of an output's info policy, returning an error if a required
+// field is missing and has no default.
+//
+// The info of the spec is copied rather than modified, as it may be shared
+// with the resource document the spec was merged from.
+func applyInfoPolicy(spec *openapi3.T, policy *config.InfoPolicy) error {
+	info := openapi3.Info{}
+	if spec.Info != nil {
+		info = *spec.Info
+	}
+	if info.Contact == nil && policy.Contact != nil {
+		info.Contact = &openapi3.Contact{
+			Name:  policy.Contact.Name,
+			URL:   policy.Contact.URL,
+			Email: policy.Contact.Email,
+		}
+	}
+	if info.License == nil && policy.License != nil {
+		info.License = &openapi3.License{
+			Name: policy.License.Name,
+			URL:  policy.License.URL,
+		}
+	}
+	if info.TermsOfService == "" {
+		info.TermsOfService = policy.TermsOfService
+	}
+	for _, field := range policy.Required {
+		var missing bool
+		switch field {
+		case config.InfoFieldContact:
+			missing = info.Contact == nil
+		case config.InfoFieldLicense:
+			missing = info.License == nil
+		case config.InfoFieldTermsOfService:
+			missing = info.TermsOfService == ""
+		}
+		if missing {
+			return fmt.Errorf("missing required info %s, add it to an overlay or a default to the policy", field)
+		}
+	}
+	spec.Info = &info
+	return nil
+}
@@ -0,0 +1,43 @@
+package compiler
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestApplyInfoPolicy(t *testing.T) {
+	c := qt.New(t)
+	origInfo := &openapi3.Info{
+		Title:   "Registry",
+		Version: "3.0.0",
+		Contact: &openapi3.Contact{Name: "API team"},
+	}
+	spec := &openapi3.T{Info: origInfo}
+	policy := &config.InfoPolicy{
+		Required: []string{config.InfoFieldContact, config.InfoFieldLicense},
+		Contact:  &config.InfoContact{Name: "Default team"},
+		License:  &config.InfoLicense{Name: "Apache 2.0"},
+	}
+	err := applyInfoPolicy(spec, policy)
+	c.Assert(err, qt.IsNil)
+	c.Assert(spec.Info.Contact.Name, qt.Equals, "API team")
+	c.Assert(spec.Info.License, qt.DeepEquals, &openapi3.License{Name: "Apache 2.0"})
+	c.Assert(spec.Info.TermsOfService, qt.Equals, "")
+	// The info merged from resources is left as it was.
+	c.Assert(origInfo.License, qt.IsNil)
+
+	policy.Required = append(policy.Required, config.InfoFieldTermsOfService)
+	spec = &openapi3.T{Info: origInfo}
+	err = applyInfoPolicy(spec, policy)
+	c.Assert(err, qt.ErrorMatches, `missing required info terms-of-service, add it to an overlay or a default to the policy`)
+	c.Assert(spec.Info, qt.Equals, origInfo)
+
+	policy.TermsOfService = "https://example.com/terms"
+	err = applyInfoPolicy(spec, policy)
+	c.Assert(err, qt.IsNil)
+	c.Assert(spec.Info.TermsOfService, qt.Equals, "https://example.com/terms")
+}
@@ -0,0 +1,105 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+)
+
+// layoutTemplate renders the paths compiled specs are written to, in an
+// output with a custom layout. A nil template is not written.
+type layoutTemplate struct {
+	json *template.Template
+	yaml *template.Template
+}
+
+func newLayoutTemplate(apiName string, layout *config.Layout) (*layoutTemplate, error) {
+	if layout == nil {
+		return nil, nil
+	}
+	var result layoutTemplate
+	var err error
+	if layout.JSON != "" {
+		result.json, err = template.New("json").Parse(layout.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("%w (apis.%s.output.layout.json)", err, apiName)
+		}
+	}
+	if layout.YAML != "" {
+		result.yaml, err = template.New("yaml").Parse(layout.YAML)
+		if err != nil {
+			return nil, fmt.Errorf("%w (apis.%s.output.layout.yaml)", err, apiName)
+		}
+	}
+	return &result, nil
+}
+
+// layoutWriter writes compiled specs to the paths rendered by a layout,
+// making sure no two versions are written to the same path.
+type layoutWriter struct {
+	apiName string
+	out     *output
+	written map[string]string
+}
+
+func newLayoutWriter(apiName string, out *output) *layoutWriter {
+	return &layoutWriter{apiName: apiName, out: out, written: map[string]string{}}
+}
+
+// write writes a compiled spec at a version in each format in the layout.
+func (w *layoutWriter) write(version *vervet.Version, compiled *compiledSpec) error {
+	scope := &serverScope{
+		API:       w.apiName,
+		Version:   version.String(),
+		Date:      version.DateString(),
+		Stability: version.Stability.String(),
+	}
+	files := []struct {
+		format   string
+		tmpl     *template.Template
+		contents []byte
+	}{
+		{"json", w.out.layout.json, compiled.json},
+		{"yaml", w.out.layout.yaml, compiled.yaml},
+	}
+	for _, file := range files {
+		if file.tmpl == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		err := file.tmpl.Execute(&buf, scope)
+		if err != nil {
+			return fmt.Errorf("%w (apis.%s.output.layout.%s)", err, w.apiName, file.format)
+		}
+		relPath := filepath.Clean(filepath.FromSlash(buf.String()))
+		if buf.Len() == 0 || filepath.IsAbs(relPath) || relPath == ".." ||
+			strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("version %s: invalid path %q, expected a path within the output (apis.%s.output.layout.%s)",
+				version, buf.String(), w.apiName, file.format)
+		}
+		if other, ok := w.written[relPath]; ok && other != version.String() {
+			return fmt.Errorf("versions %s and %s are both written to %q (apis.%s.output.layout.%s)",
+				other, version, relPath, w.apiName, file.format)
+		}
+		w.written[relPath] = version.String()
+		specPath := filepath.Join(w.out.path, relPath)
+		err = os.MkdirAll(filepath.Dir(specPath), 0755)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(specPath, file.contents, 0644)
+		if err != nil {
+			return err
+		}
+		log.Println(specPath)
+	}
+	return nil
+}
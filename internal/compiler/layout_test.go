@@ -0,0 +1,57 @@
+package compiler
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestBuildLayout(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	build := func(layout *config.Layout) (string, error) {
+		outputPath := c.Mkdir()
+		compiler := newTestCompiler(c, outputPath, func(proj *config.Project) {
+			proj.APIs["v3-api"].Output.Layout = layout
+		})
+		return outputPath, compiler.BuildAll(ctx)
+	}
+
+	outputPath, err := build(&config.Layout{
+		JSON: "openapi-{{ .Version }}.json",
+		YAML: "{{ .Stability }}/{{ .Date }}.yaml",
+	})
+	c.Assert(err, qt.IsNil)
+	var files []string
+	err = filepath.Walk(outputPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(outputPath, path)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	c.Assert(err, qt.IsNil)
+	sort.Strings(files)
+	c.Assert(files, qt.Contains, "openapi-2021-06-04~beta.json")
+	c.Assert(files, qt.Contains, "beta/2021-06-04.yaml")
+	c.Assert(files, qt.Contains, "ga/2021-06-04.yaml")
+	c.Assert(files, qt.Not(qt.Contains), "2021-06-04/spec.json")
+	contents, err := ioutil.ReadFile(filepath.Join(outputPath, "openapi-2021-06-04~beta.json"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(contents), qt.Contains, `"openapi": "3.0.3"`)
+
+	_, err = build(&config.Layout{JSON: "{{ .Date }}.json"})
+	c.Assert(err, qt.ErrorMatches, `versions 2021-06-01~experimental and 2021-06-01~beta are both written to "2021-06-01.json" \(apis.v3-api.output.layout.json\) .*`)
+
+	_, err = build(&config.Layout{JSON: "../{{ .Version }}.json"})
+	c.Assert(err, qt.ErrorMatches, `version .*: invalid path "../.*.json", expected a path within the output \(apis.v3-api.output.layout.json\) .*`)
+}
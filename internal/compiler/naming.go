@@ -0,0 +1,233 @@
+package compiler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/snyk/vervet/config"
+)
+
+// translateNaming rewrites the property and header names in a compiled spec,
+// given as JSON, to the naming conventions of an output.
+//
+// Property names are rewritten where schemas declare them, and wherever they
+// are referred to: required properties, discriminators and references into
+// schema properties. Names are rewritten in place, so that references to
+// components remain valid.
+func translateNaming(naming *config.Naming, jsonBuf []byte) ([]byte, error) {
+	var doc interface{}
+	err := json.Unmarshal(jsonBuf, &doc)
+	if err != nil {
+		return nil, err
+	}
+	t := &namingTranslator{
+		property: propertyNamer(naming.Properties),
+		header:   headerNamer(naming.Headers),
+	}
+	t.document(doc, nil)
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func propertyNamer(naming config.PropertyNaming) func(string) string {
+	switch naming {
+	case config.PropertyNamingSnakeCase:
+		return snakeCase
+	case config.PropertyNamingCamelCase:
+		return camelCase
+	}
+	return nil
+}
+
+func headerNamer(naming config.HeaderNaming) func(string) string {
+	switch naming {
+	case config.HeaderNamingCanonical:
+		return http.CanonicalHeaderKey
+	case config.HeaderNamingLowercase:
+		return strings.ToLower
+	}
+	return nil
+}
+
+// snakeCase returns a name in snake_case, such as org_id for orgId. Leading
+// underscores, such as in _links, are kept.
+func snakeCase(s string) string {
+	prefix, s := leadingUnderscores(s)
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	prevLower := false
+	for _, r := range s {
+		switch {
+		case r == '-' || r == '_':
+			if sb.Len() > len(prefix) {
+				sb.WriteByte('_')
+			}
+			prevLower = false
+		case unicode.IsUpper(r):
+			if prevLower {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			prevLower = false
+		default:
+			sb.WriteRune(r)
+			prevLower = unicode.IsLower(r) || unicode.IsDigit(r)
+		}
+	}
+	return sb.String()
+}
+
+// camelCase returns a name in camelCase, such as orgId for org_id. Leading
+// underscores, such as in _links, are kept.
+func camelCase(s string) string {
+	prefix, s := leadingUnderscores(s)
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	upper := false
+	for _, r := range s {
+		switch {
+		case r == '-' || r == '_':
+			upper = sb.Len() > len(prefix)
+		case upper:
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func leadingUnderscores(s string) (prefix, rest string) {
+	rest = strings.TrimLeft(s, "_")
+	return s[:len(s)-len(rest)], rest
+}
+
+type namingTranslator struct {
+	property func(string) string
+	header   func(string) string
+}
+
+// document walks the parts of an OpenAPI document which are not schemas,
+// translating the schemas and headers found in them. Path is the sequence of
+// keys leading to v.
+func (t *namingTranslator) document(v interface{}, path []string) {
+	switch vv := v.(type) {
+	case []interface{}:
+		for _, item := range vv {
+			t.document(item, path)
+		}
+	case map[string]interface{}:
+		t.ref(vv)
+		if t.header != nil && vv["in"] == "header" {
+			if name, ok := vv["name"].(string); ok {
+				vv["name"] = t.header(name)
+			}
+		}
+		for k, child := range vv {
+			switch {
+			case k == "example" || k == "examples" || strings.HasPrefix(k, "x-"):
+				continue
+			case k == "schema":
+				t.schema(child)
+				continue
+			case k == "schemas" && isPath(path, "components"):
+				if schemas, ok := child.(map[string]interface{}); ok {
+					for _, schema := range schemas {
+						t.schema(schema)
+					}
+				}
+				continue
+			case k == "headers" && t.header != nil && !isPath(path, "components"):
+				// Response and encoding headers are keyed by header name;
+				// component headers are keyed by the names they are
+				// referenced by.
+				if headers, ok := child.(map[string]interface{}); ok {
+					vv[k] = renameKeys(headers, t.header)
+					child = vv[k]
+				}
+			}
+			t.document(child, append(path, k))
+		}
+	}
+}
+
+// schema translates the property names declared in a schema and the schemas
+// it is composed of.
+func (t *namingTranslator) schema(v interface{}) {
+	s, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	t.ref(s)
+	if props, ok := s["properties"].(map[string]interface{}); ok {
+		if t.property != nil {
+			props = renameKeys(props, t.property)
+			s["properties"] = props
+		}
+		for _, prop := range props {
+			t.schema(prop)
+		}
+	}
+	if t.property != nil {
+		if required, ok := s["required"].([]interface{}); ok {
+			for i := range required {
+				if name, ok := required[i].(string); ok {
+					required[i] = t.property(name)
+				}
+			}
+		}
+		if discriminator, ok := s["discriminator"].(map[string]interface{}); ok {
+			if name, ok := discriminator["propertyName"].(string); ok {
+				discriminator["propertyName"] = t.property(name)
+			}
+		}
+	}
+	for _, k := range []string{"items", "additionalProperties", "not"} {
+		t.schema(s[k])
+	}
+	for _, k := range []string{"allOf", "anyOf", "oneOf"} {
+		if schemas, ok := s[k].([]interface{}); ok {
+			for _, schema := range schemas {
+				t.schema(schema)
+			}
+		}
+	}
+}
+
+// ref translates property names in a reference which points into the
+// properties of a schema, such as #/components/schemas/Org/properties/orgId.
+func (t *namingTranslator) ref(v map[string]interface{}) {
+	ref, ok := v["$ref"].(string)
+	if !ok || t.property == nil || !strings.Contains(ref, "/properties/") {
+		return
+	}
+	parts := strings.Split(ref, "/")
+	for i := 1; i < len(parts); i++ {
+		if parts[i-1] == "properties" {
+			parts[i] = t.property(parts[i])
+		}
+	}
+	v["$ref"] = strings.Join(parts, "/")
+}
+
+func renameKeys(m map[string]interface{}, rename func(string) string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[rename(k)] = v
+	}
+	return result
+}
+
+func isPath(path []string, keys ...string) bool {
+	if len(path) != len(keys) {
+		return false
+	}
+	for i := range keys {
+		if path[i] != keys[i] {
+			return false
+		}
+	}
+	return true
+}
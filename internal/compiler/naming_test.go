@@ -0,0 +1,181 @@
+package compiler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+)
+
+func TestNamingCase(t *testing.T) {
+	c := qt.New(t)
+	tests := []struct {
+		name, snake, camel string
+	}{
+		{"orgId", "org_id", "orgId"},
+		{"org_id", "org_id", "orgId"},
+		{"created-at", "created_at", "createdAt"},
+		{"id", "id", "id"},
+		{"_links", "_links", "_links"},
+		{"_selfLink", "_self_link", "_selfLink"},
+		{"v3Api", "v3_api", "v3Api"},
+	}
+	for _, test := range tests {
+		c.Check(snakeCase(test.name), qt.Equals, test.snake, qt.Commentf(test.name))
+		c.Check(camelCase(test.name), qt.Equals, test.camel, qt.Commentf(test.name))
+	}
+}
+
+func TestTranslateNaming(t *testing.T) {
+	c := qt.New(t)
+	doc := `{
+  "paths": {
+    "/orgs": {
+      "get": {
+        "parameters": [
+          {"name": "snyk-version", "in": "header", "schema": {"type": "string"}},
+          {"name": "orgId", "in": "query", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {
+            "headers": {
+              "snyk-request-id": {"$ref": "#/components/headers/RequestID"}
+            },
+            "content": {
+              "application/json": {
+                "schema": {"$ref": "#/components/schemas/Org"},
+                "example": {"orgId": "abc"}
+              }
+            }
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "headers": {
+      "RequestID": {"schema": {"type": "string"}}
+    },
+    "schemas": {
+      "Org": {
+        "type": "object",
+        "required": ["orgId"],
+        "discriminator": {"propertyName": "orgType"},
+        "properties": {
+          "orgId": {"type": "string"},
+          "orgType": {"type": "string"},
+          "parentOrgId": {"$ref": "#/components/schemas/Org/properties/orgId"},
+          "memberList": {
+            "type": "array",
+            "items": {
+              "allOf": [{
+                "type": "object",
+                "properties": {"userId": {"type": "string"}}
+              }]
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+	buf, err := translateNaming(&config.Naming{
+		Properties: config.PropertyNamingSnakeCase,
+		Headers:    config.HeaderNamingCanonical,
+	}, []byte(doc))
+	c.Assert(err, qt.IsNil)
+	var result, expected interface{}
+	c.Assert(json.Unmarshal(buf, &result), qt.IsNil)
+	c.Assert(json.Unmarshal([]byte(`{
+  "paths": {
+    "/orgs": {
+      "get": {
+        "parameters": [
+          {"name": "Snyk-Version", "in": "header", "schema": {"type": "string"}},
+          {"name": "orgId", "in": "query", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {
+            "headers": {
+              "Snyk-Request-Id": {"$ref": "#/components/headers/RequestID"}
+            },
+            "content": {
+              "application/json": {
+                "schema": {"$ref": "#/components/schemas/Org"},
+                "example": {"orgId": "abc"}
+              }
+            }
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "headers": {
+      "RequestID": {"schema": {"type": "string"}}
+    },
+    "schemas": {
+      "Org": {
+        "type": "object",
+        "required": ["org_id"],
+        "discriminator": {"propertyName": "org_type"},
+        "properties": {
+          "org_id": {"type": "string"},
+          "org_type": {"type": "string"},
+          "parent_org_id": {"$ref": "#/components/schemas/Org/properties/org_id"},
+          "member_list": {
+            "type": "array",
+            "items": {
+              "allOf": [{
+                "type": "object",
+                "properties": {"user_id": {"type": "string"}}
+              }]
+            }
+          }
+        }
+      }
+    }
+  }
+}`), &expected), qt.IsNil)
+	c.Assert(result, qt.DeepEquals, expected)
+}
+
+func TestBuildNaming(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	outputPath := c.Mkdir()
+	compiler := newTestCompiler(c, outputPath, func(proj *config.Project) {
+		proj.APIs["v3-api"].Output.Naming = &config.Naming{
+			Properties: config.PropertyNamingCamelCase,
+			Headers:    config.HeaderNamingCanonical,
+		}
+	})
+	err := compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+
+	specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(outputPath))
+	c.Assert(err, qt.IsNil)
+	spec, err := specs.At("2021-06-13~beta")
+	c.Assert(err, qt.IsNil)
+	var headers int
+	for path, pathItem := range spec.Paths {
+		for method, op := range pathItem.Operations() {
+			for status, resp := range op.Responses {
+				for name := range resp.Value.Headers {
+					c.Check(name, qt.Not(qt.Equals), "snyk-request-id",
+						qt.Commentf("%s %s %s", method, path, status))
+					if name == "Snyk-Request-Id" {
+						headers++
+					}
+				}
+			}
+		}
+	}
+	c.Assert(headers > 0, qt.IsTrue)
+}
@@ -0,0 +1,128 @@
+package compiler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+
+	"github.com/snyk/vervet/internal/scratch"
+	"github.com/snyk/vervet/internal/types"
+)
+
+// overlayFields are the top-level fields of an OpenAPI document, which are
+// the fields an overlay may merge into compiled specs.
+var overlayFields = map[string]bool{
+	"openapi":      true,
+	"info":         true,
+	"servers":      true,
+	"paths":        true,
+	"components":   true,
+	"security":     true,
+	"tags":         true,
+	"externalDocs": true,
+}
+
+// overlayLint is an overlay to be linted along with the resources of an API.
+type overlayLint struct {
+	linter types.Linter
+
+	// include is the path of an included overlay.
+	include string
+
+	// inline is the content of an inline overlay, with environment variables
+	// expanded.
+	inline string
+}
+
+// validateOverlay checks that an overlay document, given as loaded from
+// data, is a valid part of an OpenAPI document. Overlays need not be
+// complete documents, so only the parts present are validated. Fields which
+// are not part of an OpenAPI document are errors, as these would otherwise
+// be silently ignored, such as a misspelled "server:".
+func validateOverlay(ctx context.Context, data []byte, doc *openapi3.T) error {
+	var fields map[string]interface{}
+	err := yaml.Unmarshal(data, &fields)
+	if err != nil {
+		return err
+	}
+	var unknown []string
+	for field := range fields {
+		if !overlayFields[field] && !strings.HasPrefix(field, "x-") {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown field %q", unknown[0])
+	}
+	if _, ok := fields["info"]; ok && doc.Info != nil {
+		if err := doc.Info.Validate(ctx); err != nil {
+			return fmt.Errorf("invalid info: %w", err)
+		}
+	}
+	if err := doc.Servers.Validate(ctx); err != nil {
+		return fmt.Errorf("invalid servers: %w", err)
+	}
+	if err := doc.Paths.Validate(ctx); err != nil {
+		return fmt.Errorf("invalid paths: %w", err)
+	}
+	if err := doc.Components.Validate(ctx); err != nil {
+		return fmt.Errorf("invalid components: %w", err)
+	}
+	if err := doc.Security.Validate(ctx); err != nil {
+		return fmt.Errorf("invalid security: %w", err)
+	}
+	tagNames := map[string]bool{}
+	for i, tag := range doc.Tags {
+		if tag.Name == "" {
+			return fmt.Errorf("missing name (tags[%d])", i)
+		}
+		if tagNames[tag.Name] {
+			return fmt.Errorf("duplicate tag %q (tags[%d])", tag.Name, i)
+		}
+		tagNames[tag.Name] = true
+	}
+	return nil
+}
+
+// LintOverlays checks the overlays of an API with the linters configured for
+// them.
+func (c *Compiler) LintOverlays(ctx context.Context, apiName string) error {
+	api, ok := c.apis[apiName]
+	if !ok {
+		return fmt.Errorf("api not found (apis.%s)", apiName)
+	}
+	var indexes []int
+	for overlayIndex := range api.overlayLints {
+		indexes = append(indexes, overlayIndex)
+	}
+	sort.Ints(indexes)
+	for _, overlayIndex := range indexes {
+		ol := api.overlayLints[overlayIndex]
+		suiteName := fmt.Sprintf("apis.%s.overlays[%d]", apiName, overlayIndex)
+		path := ol.include
+		if path == "" {
+			f, err := scratch.TempFile("overlay-*.yaml")
+			if err != nil {
+				return err
+			}
+			_, err = f.WriteString(ol.inline)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return fmt.Errorf("failed to write overlay for linting: %w (%s)", err, suiteName)
+			}
+			path = f.Name()
+		}
+		err := c.lint(ctx, ol.linter, suiteName, path)
+		if err != nil {
+			return fmt.Errorf("lint failed (%s)", suiteName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package compiler
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/scratch"
+)
+
+func TestValidateOverlay(t *testing.T) {
+	c := qt.New(t)
+	tests := []struct {
+		overlay, err string
+	}{{
+		overlay: `
+servers:
+  - url: https://example.com/api
+tags:
+  - name: things
+x-acme-owner: api-team
+`,
+	}, {
+		overlay: `
+server:
+  - url: https://example.com/api
+`,
+		err: `unknown field "server"`,
+	}, {
+		overlay: `
+paths:
+  things: {}
+`,
+		err: `invalid paths: path "things" does not start with a forward slash \(/\)`,
+	}, {
+		overlay: `
+servers:
+  - description: no url
+`,
+		err: `invalid servers: .*`,
+	}, {
+		overlay: `
+tags:
+  - name: things
+  - name: things
+`,
+		err: `duplicate tag "things" \(tags\[1\]\)`,
+	}}
+	for _, test := range tests {
+		c.Run("", func(c *qt.C) {
+			doc, err := openapi3.NewLoader().LoadFromData([]byte(test.overlay))
+			c.Assert(err, qt.IsNil)
+			err = validateOverlay(context.Background(), []byte(test.overlay), doc)
+			if test.err == "" {
+				c.Assert(err, qt.IsNil)
+			} else {
+				c.Assert(err, qt.ErrorMatches, test.err)
+			}
+		})
+	}
+}
+
+func TestOverlays(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	c.Cleanup(scratch.Cleanup)
+	ctx := context.Background()
+	newCompiler := func(overlays ...*config.Overlay) (*Compiler, error) {
+		proj := newTestProject(c, c.Mkdir(), func(proj *config.Project) {
+			proj.APIs["v3-api"].Overlays = append(proj.APIs["v3-api"].Overlays, overlays...)
+		})
+		return New(ctx, proj, mockLinters())
+	}
+
+	_, err := newCompiler(&config.Overlay{Inline: "server:\n  - url: https://example.com\n"})
+	c.Assert(err, qt.ErrorMatches, `invalid overlay: unknown field "server" \(apis.v3-api.overlays\[2\]\)`)
+
+	compiler, err := newCompiler(&config.Overlay{
+		Inline: "tags:\n  - name: things\n",
+		Linter: "resource-rules",
+	})
+	c.Assert(err, qt.IsNil)
+	err = compiler.LintResourcesAll(ctx)
+	c.Assert(err, qt.IsNil)
+	runs := compiler.linters["resource-rules"].(*mockLinter).runs
+	c.Assert(runs, qt.HasLen, 2)
+	c.Assert(runs[1], qt.HasLen, 1)
+	contents, err := ioutil.ReadFile(runs[1][0])
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(contents), qt.Equals, "tags:\n  - name: things\n")
+}
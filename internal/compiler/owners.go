@@ -0,0 +1,54 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/snyk/vervet/internal/codeowners"
+)
+
+// CodeOwners configures a Compiler to lint files grouped by their owners, so
+// that lint failures and reported results identify the owners responsible for
+// them. If onlyOwnedBy is not empty, only the files it owns are linted.
+func CodeOwners(owners *codeowners.Owners, onlyOwnedBy string) CompilerOption {
+	return func(c *Compiler) error {
+		c.owners = owners
+		c.onlyOwnedBy = onlyOwnedBy
+		return nil
+	}
+}
+
+// ownedFiles are files with the same owners.
+type ownedFiles struct {
+	owners []string
+	files  []string
+}
+
+// groupByOwners groups files by their owners, in the order they are first
+// owned, leaving out files not owned by the owner linted, if any.
+func (c *Compiler) groupByOwners(files []string) []*ownedFiles {
+	var groups []*ownedFiles
+	index := map[string]*ownedFiles{}
+	for _, file := range files {
+		if c.onlyOwnedBy != "" && !c.owners.IsOwner(file, c.onlyOwnedBy) {
+			continue
+		}
+		owners := c.owners.Of(file)
+		key := strings.Join(owners, " ")
+		group, ok := index[key]
+		if !ok {
+			group = &ownedFiles{owners: owners}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group.files = append(group.files, file)
+	}
+	return groups
+}
+
+// ownersString returns a description of the owners of files, for output.
+func ownersString(owners []string) string {
+	if len(owners) == 0 {
+		return "no owners"
+	}
+	return strings.Join(owners, " ")
+}
@@ -0,0 +1,66 @@
+package compiler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/codeowners"
+	"github.com/snyk/vervet/internal/types"
+)
+
+func TestLintCodeOwners(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	cwd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	owners, err := codeowners.Parse(strings.NewReader(`
+/testdata/resources/            @acme/api-guild
+/testdata/resources/projects/   @acme/projects
+`), cwd)
+	c.Assert(err, qt.IsNil)
+
+	c.Run("grouped by owner", func(c *qt.C) {
+		linter := &mockLinter{err: errors.New("lint failed")}
+		report := NewReport()
+		compiler := newTestCompiler(c, c.Mkdir(), nil, CodeOwners(owners, ""), Reporter(report),
+			LinterFactory(func(context.Context, *config.Linter) (types.Linter, error) {
+				return linter, nil
+			}))
+		err := compiler.LintResourcesAll(ctx)
+		c.Assert(err, qt.ErrorMatches, `lint failed \(apis.v3-api.resources\[0\]\)`)
+		// Each group of files with the same owners is linted, even though
+		// the first group failed.
+		c.Assert(linter.runs, qt.HasLen, 2)
+		c.Assert(linter.runs[0], qt.Not(qt.Contains), "testdata/resources/projects/2021-06-04/spec.yaml")
+		c.Assert(linter.runs[1], qt.DeepEquals, []string{"testdata/resources/projects/2021-06-04/spec.yaml"})
+
+		var buf bytes.Buffer
+		c.Assert(report.WriteJUnit(&buf), qt.IsNil)
+		c.Assert(buf.String(), qt.Contains, `
+    <testcase classname="apis.v3-api.resources[0]" name="lint (@acme/projects)">
+      <properties>
+        <property name="owners" value="@acme/projects"></property>
+      </properties>
+      <failure type="lint (@acme/projects)" message="lint failed">lint failed</failure>
+    </testcase>`)
+	})
+
+	c.Run("only owned by", func(c *qt.C) {
+		linter := &mockLinter{}
+		compiler := newTestCompiler(c, c.Mkdir(), nil, CodeOwners(owners, "acme/projects"),
+			LinterFactory(func(context.Context, *config.Linter) (types.Linter, error) {
+				return linter, nil
+			}))
+		err := compiler.LintResourcesAll(ctx)
+		c.Assert(err, qt.IsNil)
+		c.Assert(linter.runs, qt.DeepEquals, [][]string{{"testdata/resources/projects/2021-06-04/spec.yaml"}})
+	})
+}
@@ -0,0 +1,103 @@
+package compiler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet"
+)
+
+// Concurrency configures a Compiler to build up to n APIs, and compile up to
+// n specs of each, at once. By default, everything is built one at a time.
+//
+// Output is the same however many are built at once: specs are compiled
+// concurrently, but written in order of version, and errors are reported for
+// the first API and version to fail, as they would be one at a time.
+func Concurrency(n int) CompilerOption {
+	return func(c *Compiler) error {
+		if n < 1 {
+			return fmt.Errorf("invalid concurrency %d, must be at least 1", n)
+		}
+		c.concurrency = n
+		return nil
+	}
+}
+
+// compileJob is a distinct spec to compile: the resources at a version, and
+// the servers rendered for it.
+type compileJob struct {
+	key       string
+	version   *vervet.Version
+	resources []*vervet.Resource
+	servers   openapi3.Servers
+
+	compiled *compiledSpec
+	err      error
+}
+
+// compileJobs compiles the spec for each job, up to c.concurrency at once.
+// The error of the first job to fail, in the order given, is returned.
+func (c *Compiler) compileJobs(ctx context.Context, apiName string, api *api, jobs []*compileJob) error {
+	each(len(jobs), c.concurrency, func(i int) {
+		job := jobs[i]
+		job.compiled, job.err = c.compileSpecCached(ctx, apiName, api, job.version, job.resources, job.servers)
+	})
+	for _, job := range jobs {
+		if job.err != nil {
+			return job.err
+		}
+	}
+	return nil
+}
+
+// buildAPIs builds each API, up to c.concurrency at once. The error of the
+// first API to fail, in order of name, is returned.
+func (c *Compiler) buildAPIs(ctx context.Context) error {
+	apiNames := make([]string, 0, len(c.apis))
+	for apiName := range c.apis {
+		apiNames = append(apiNames, apiName)
+	}
+	sort.Strings(apiNames)
+	errs := make([]error, len(apiNames))
+	each(len(apiNames), c.concurrency, func(i int) {
+		errs[i] = c.Build(ctx, apiNames[i])
+	})
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// each calls f with each index up to n, from up to concurrency goroutines at
+// once. With a concurrency of 1 or less, f is called in order on the calling
+// goroutine.
+func each(n, concurrency int, f func(i int)) {
+	if concurrency <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,75 @@
+package compiler
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestBuildConcurrency(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	cwd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	// Output paths are relative, as in most projects, so that writing output
+	// while other resources are loaded from their own directories is
+	// covered.
+	relDir := func() string {
+		dir, err := filepath.Rel(cwd, c.Mkdir())
+		c.Assert(err, qt.IsNil)
+		return dir
+	}
+	build := func(options ...CompilerOption) (string, string) {
+		v3Output, examplesOutput := relDir(), relDir()
+		compiler := newTestCompiler(c, v3Output, func(proj *config.Project) {
+			proj.APIs["examples"] = &config.API{
+				Name: "examples",
+				Resources: []*config.ResourceSet{{
+					Path: "testdata/resources/_examples",
+				}},
+				Output: &config.Output{Path: examplesOutput},
+			}
+		}, options...)
+		err := compiler.BuildAll(ctx)
+		c.Assert(err, qt.IsNil)
+		return v3Output, examplesOutput
+	}
+	v3Serial, examplesSerial := build()
+	v3Parallel, examplesParallel := build(Concurrency(4))
+	// Output is the same however many specs are compiled at once.
+	c.Assert(readTree(c, v3Parallel), qt.DeepEquals, readTree(c, v3Serial))
+	c.Assert(readTree(c, examplesParallel), qt.DeepEquals, readTree(c, examplesSerial))
+	c.Assert(readTree(c, v3Serial), qt.Not(qt.HasLen), 0)
+
+	_, err = New(ctx, &config.Project{}, Concurrency(0))
+	c.Assert(err, qt.ErrorMatches, `invalid concurrency 0, must be at least 1`)
+}
+
+// readTree returns the contents of each file under dir, by relative path.
+func readTree(c *qt.C, dir string) map[string]string {
+	result := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result[rel] = string(buf)
+		return nil
+	})
+	c.Assert(err, qt.IsNil)
+	return result
+}
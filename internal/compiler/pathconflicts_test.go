@@ -0,0 +1,50 @@
+package compiler
+
+import (
+	"context"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestBuildPathComparison(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	tests := []struct {
+		comparison config.PathComparison
+		err        string
+	}{{
+		comparison: config.PathComparisonExact,
+	}, {
+		comparison: config.PathComparisonStructural,
+		err:        `version .*: conflicting paths "/examples/hello-world/{id}" and "/examples/hello-world/{helloId}" differ only in parameter names \(apis.v3-api.path-comparison\) \(apis.v3-api.resources\[0\]\)`,
+	}}
+	for _, test := range tests {
+		c.Run(string(test.comparison), func(c *qt.C) {
+			compiler := newTestCompiler(c, c.Mkdir(), func(proj *config.Project) {
+				api := proj.APIs["v3-api"]
+				api.PathComparison = test.comparison
+				// An overlay declaring a resource's path with a different
+				// parameter name.
+				api.Overlays = append(api.Overlays, &config.Overlay{Inline: `
+paths:
+  /examples/hello-world/{helloId}:
+    get:
+      parameters:
+        - {name: helloId, in: path, required: true, schema: {type: string}}
+      responses:
+        '200': {description: OK}
+`})
+			})
+			err := compiler.Build(ctx, "v3-api")
+			if test.err == "" {
+				c.Assert(err, qt.IsNil)
+			} else {
+				c.Assert(err, qt.ErrorMatches, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package compiler
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Build phases timed by a Profile.
+const (
+	PhaseMatch     = "match"
+	PhaseLoad      = "load"
+	PhaseMerge     = "merge"
+	PhaseOverlay   = "overlay"
+	PhaseSerialize = "serialize"
+	PhaseWrite     = "write"
+)
+
+var profilePhases = []string{
+	PhaseMatch, PhaseLoad, PhaseMerge, PhaseOverlay, PhaseSerialize, PhaseWrite,
+}
+
+// allVersions is the version under which phases that apply to an entire
+// resource set, rather than a single output version, are recorded.
+const allVersions = "*"
+
+// Profile records the time spent in each phase of compilation, per API and
+// per output version.
+type Profile struct {
+	mu    sync.Mutex
+	times map[profileKey]time.Duration
+}
+
+type profileKey struct {
+	api, version, phase string
+}
+
+// NewProfile returns a new empty Profile.
+func NewProfile() *Profile {
+	return &Profile{times: map[profileKey]time.Duration{}}
+}
+
+// Profiler configures a Compiler to record the time spent in each phase of
+// compilation to the given Profile.
+func Profiler(p *Profile) CompilerOption {
+	return func(c *Compiler) error {
+		c.profile = p
+		return nil
+	}
+}
+
+// record adds the time elapsed since start to a phase. It is safe to call on
+// a nil Profile, which records nothing.
+func (p *Profile) record(apiName, version, phase string, start time.Time) {
+	if p == nil {
+		return
+	}
+	d := time.Since(start)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.times[profileKey{api: apiName, version: version, phase: phase}] += d
+}
+
+// Duration returns the total time recorded for a phase of compilation of an
+// API at an output version. Phases which apply to all versions of a resource
+// set (match and load) are recorded under the version "*".
+func (p *Profile) Duration(apiName, version, phase string) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.times[profileKey{api: apiName, version: version, phase: phase}]
+}
+
+// WriteReport writes a table of the time spent in each phase, per API and
+// per version, with a total for each API.
+func (p *Profile) WriteReport(w io.Writer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rows := map[string]map[string]bool{}
+	for k := range p.times {
+		if rows[k.api] == nil {
+			rows[k.api] = map[string]bool{}
+		}
+		rows[k.api][k.version] = true
+	}
+	var apiNames []string
+	for apiName := range rows {
+		apiNames = append(apiNames, apiName)
+	}
+	sort.Strings(apiNames)
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(append(append([]string{"API", "Version"}, profilePhases...), "Total"))
+	for _, apiName := range apiNames {
+		var versions []string
+		for version := range rows[apiName] {
+			versions = append(versions, version)
+		}
+		// "*" sorts before version dates, so resource set phases come first.
+		sort.Strings(versions)
+		apiTotals := make([]time.Duration, len(profilePhases))
+		for _, version := range versions {
+			row := []string{apiName, version}
+			var total time.Duration
+			for i, phase := range profilePhases {
+				d := p.times[profileKey{api: apiName, version: version, phase: phase}]
+				apiTotals[i] += d
+				total += d
+				row = append(row, formatDuration(d))
+			}
+			table.Append(append(row, formatDuration(total)))
+		}
+		row := []string{apiName, "total"}
+		var total time.Duration
+		for _, d := range apiTotals {
+			total += d
+			row = append(row, formatDuration(d))
+		}
+		table.Append(append(row, formatDuration(total)))
+	}
+	table.Render()
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Microsecond).String()
+}
@@ -0,0 +1,31 @@
+package compiler
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestProfile(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	profile := NewProfile()
+	compiler := newTestCompiler(c, c.Mkdir(), nil, Profiler(profile))
+	err := compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(profile.Duration("v3-api", "*", PhaseMatch) > 0, qt.IsTrue)
+	c.Assert(profile.Duration("v3-api", "*", PhaseLoad) > 0, qt.IsTrue)
+	for _, phase := range []string{PhaseMerge, PhaseSerialize, PhaseWrite} {
+		c.Assert(profile.Duration("v3-api", "2021-06-04~experimental", phase) > 0, qt.IsTrue, qt.Commentf("phase %s", phase))
+	}
+	c.Assert(profile.Duration("v3-api", "2021-06-04~experimental", PhaseMatch), qt.Equals, time.Duration(0))
+
+	var report bytes.Buffer
+	profile.WriteReport(&report)
+	c.Assert(report.String(), qt.Matches, `(?s).*\| v3-api \| 2021-06-04~experimental \|.*\| v3-api \| total .*`)
+}
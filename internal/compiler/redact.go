@@ -0,0 +1,213 @@
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/snyk/vervet/internal/specjson"
+)
+
+// redact removes everything marked with any of the given extensions, such as
+// x-internal, from a compiled spec given as JSON: path items, operations,
+// parameters, components and schema properties.
+//
+// Components which were only referred to by what was removed are removed too,
+// so that internal schemas are not published by way of internal operations.
+// It is an error for the spec to still refer to anything which was removed.
+func redact(extensions []string, jsonBuf []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	err := json.Unmarshal(jsonBuf, &doc)
+	if err != nil {
+		return nil, err
+	}
+	r := &redactor{extensions: extensions, removed: map[string]bool{}}
+	reachedBefore := specjson.ReachableComponents(doc)
+	r.paths(doc["paths"])
+	r.components(doc)
+	r.walk(doc)
+
+	// Remove components no longer reached, now that what reached them has
+	// been removed.
+	reachedAfter := specjson.ReachableComponents(doc)
+	for ref := range reachedBefore {
+		if reachedAfter[ref] || r.removed[ref] {
+			continue
+		}
+		if specjson.DeleteComponent(doc, ref) {
+			r.removed[ref] = true
+		}
+	}
+
+	err = checkRedactedRefs(doc, r.removed, "#")
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type redactor struct {
+	extensions []string
+	removed    map[string]bool
+}
+
+// marked returns whether v is an object marked for redaction.
+func (r *redactor) marked(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, ext := range r.extensions {
+		if marked, _ := m[ext].(bool); marked {
+			return true
+		}
+	}
+	return false
+}
+
+// paths removes marked path items and operations. Path items left without
+// any operations are removed.
+func (r *redactor) paths(v interface{}) {
+	paths, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for path, pathItem := range paths {
+		if r.marked(pathItem) {
+			delete(paths, path)
+			continue
+		}
+		ops, ok := pathItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		redacted := false
+		for method, op := range ops {
+			if specjson.OperationMethods[method] && r.marked(op) {
+				delete(ops, method)
+				redacted = true
+			}
+		}
+		if redacted && !hasOperations(ops) {
+			delete(paths, path)
+		}
+	}
+}
+
+func hasOperations(pathItem map[string]interface{}) bool {
+	for method := range pathItem {
+		if specjson.OperationMethods[method] {
+			return true
+		}
+	}
+	return false
+}
+
+// components removes marked components, of any kind.
+func (r *redactor) components(doc map[string]interface{}) {
+	components, ok := doc["components"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for sectionName, v := range components {
+		section, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for name, component := range section {
+			if r.marked(component) {
+				delete(section, name)
+				r.removed["#/components/"+sectionName+"/"+specjson.EscapePointer(name)] = true
+			}
+		}
+	}
+}
+
+// walk removes marked schema properties and parameters wherever they are
+// declared. Examples are left as they are.
+func (r *redactor) walk(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if properties, ok := v["properties"].(map[string]interface{}); ok {
+			var redacted []string
+			for name, property := range properties {
+				if r.marked(property) {
+					delete(properties, name)
+					redacted = append(redacted, name)
+				}
+			}
+			if required, ok := v["required"].([]interface{}); ok && len(redacted) > 0 {
+				v["required"] = removeStrings(required, redacted)
+			}
+		}
+		if params, ok := v["parameters"].([]interface{}); ok {
+			var kept []interface{}
+			for _, param := range params {
+				if !r.marked(param) {
+					kept = append(kept, param)
+				}
+			}
+			if len(kept) == 0 {
+				delete(v, "parameters")
+			} else {
+				v["parameters"] = kept
+			}
+		}
+		for k, vv := range v {
+			if k == "example" || k == "examples" {
+				continue
+			}
+			r.walk(vv)
+		}
+	case []interface{}:
+		for _, vv := range v {
+			r.walk(vv)
+		}
+	}
+}
+
+func removeStrings(values []interface{}, remove []string) []interface{} {
+	result := []interface{}{}
+	for _, v := range values {
+		s, _ := v.(string)
+		removed := false
+		for i := range remove {
+			if s == remove[i] {
+				removed = true
+			}
+		}
+		if !removed {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// checkRedactedRefs returns an error if v refers to any removed component.
+func checkRedactedRefs(v interface{}, removed map[string]bool, location string) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok && removed[specjson.ComponentRef(ref)] {
+			return fmt.Errorf("%s refers to redacted %s", location, ref)
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			err := checkRedactedRefs(v[k], removed, location+"/"+specjson.EscapePointer(k))
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, vv := range v {
+			err := checkRedactedRefs(vv, removed, fmt.Sprintf("%s/%d", location, i))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package compiler
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+const redactSpec = `{
+  "openapi": "3.0.3",
+  "info": {"title": "test", "version": "3.0.0"},
+  "paths": {
+    "/orgs": {
+      "get": {
+        "parameters": [
+          {"$ref": "#/components/parameters/Version"},
+          {"name": "debug", "in": "query", "x-internal": true, "schema": {"type": "boolean"}}
+        ],
+        "responses": {
+          "200": {
+            "description": "ok",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Org"}}}
+          }
+        }
+      },
+      "post": {
+        "x-internal": true,
+        "requestBody": {
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/OrgMigration"}}}
+        },
+        "responses": {"204": {"description": "ok"}}
+      }
+    },
+    "/admin": {
+      "get": {
+        "x-internal": true,
+        "responses": {"204": {"description": "ok"}}
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "Version": {"name": "version", "in": "query", "schema": {"type": "string"}}
+    },
+    "schemas": {
+      "Org": {
+        "type": "object",
+        "required": ["id", "billingCode"],
+        "properties": {
+          "id": {"type": "string"},
+          "billingCode": {"type": "string", "x-internal": true}
+        },
+        "example": {"id": "1", "billingCode": "x"}
+      },
+      "OrgMigration": {
+        "type": "object",
+        "properties": {"plan": {"$ref": "#/components/schemas/MigrationPlan"}}
+      },
+      "MigrationPlan": {"type": "string"},
+      "Secret": {"type": "string", "x-internal": true},
+      "Unused": {"type": "string"}
+    }
+  }
+}`
+
+func TestRedact(t *testing.T) {
+	c := qt.New(t)
+	jsonBuf, err := redact([]string{"x-internal"}, []byte(redactSpec))
+	c.Assert(err, qt.IsNil)
+	var doc map[string]interface{}
+	c.Assert(json.Unmarshal(jsonBuf, &doc), qt.IsNil)
+
+	paths := doc["paths"].(map[string]interface{})
+	c.Assert(paths, qt.HasLen, 1)
+	orgs := paths["/orgs"].(map[string]interface{})
+	c.Assert(orgs["post"], qt.IsNil)
+	c.Assert(orgs["get"].(map[string]interface{})["parameters"], qt.DeepEquals, []interface{}{
+		map[string]interface{}{"$ref": "#/components/parameters/Version"},
+	})
+
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	org := schemas["Org"].(map[string]interface{})
+	c.Assert(org["properties"], qt.DeepEquals, map[string]interface{}{
+		"id": map[string]interface{}{"type": "string"},
+	})
+	c.Assert(org["required"], qt.DeepEquals, []interface{}{"id"})
+	// Examples are not redacted.
+	c.Assert(org["example"], qt.DeepEquals, map[string]interface{}{"id": "1", "billingCode": "x"})
+
+	// Marked components, and those only used by redacted operations, are
+	// removed. Components which were not used at all are left as they were.
+	var names []string
+	for name := range schemas {
+		names = append(names, name)
+	}
+	c.Assert(names, qt.ContentEquals, []string{"Org", "Unused"})
+}
+
+func TestRedactRefError(t *testing.T) {
+	c := qt.New(t)
+	_, err := redact([]string{"x-internal"}, []byte(`{
+  "openapi": "3.0.3",
+  "paths": {
+    "/orgs": {
+      "get": {
+        "responses": {
+          "200": {
+            "description": "ok",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Org"}}}
+          }
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Org": {"type": "object", "x-internal": true}
+    }
+  }
+}`))
+	c.Assert(err, qt.ErrorMatches,
+		`#/paths/~1orgs/get/responses/200/content/application~1json/schema refers to redacted #/components/schemas/Org`)
+}
@@ -0,0 +1,468 @@
+package compiler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/snyk/vervet/internal/github"
+	"github.com/snyk/vervet/internal/specjson"
+	"github.com/snyk/vervet/internal/types"
+)
+
+// A Report collects the results of linting and validation during
+// compilation, so that they may be rendered for CI systems.
+//
+// Results are grouped into suites, named by the configuration they were
+// checked against, such as "apis.my-api.resources[0]". Each file checked is a
+// case in its suite, failing on any error findings in that file.
+type Report struct {
+	mu     sync.Mutex
+	suites map[string]*reportSuite
+}
+
+type reportSuite struct {
+	cases map[string]*reportCase
+}
+
+type reportCase struct {
+	owners   []string
+	findings []types.Finding
+	errs     []string
+}
+
+// NewReport returns a new empty Report.
+func NewReport() *Report {
+	return &Report{suites: map[string]*reportSuite{}}
+}
+
+// Reporter configures a Compiler to record the results of linting and
+// validation to the given Report.
+func Reporter(r *Report) CompilerOption {
+	return func(c *Compiler) error {
+		c.report = r
+		return nil
+	}
+}
+
+func (r *Report) reportCase(suiteName, caseName string) *reportCase {
+	suite, ok := r.suites[suiteName]
+	if !ok {
+		suite = &reportSuite{cases: map[string]*reportCase{}}
+		r.suites[suiteName] = suite
+	}
+	rc, ok := suite.cases[caseName]
+	if !ok {
+		rc = &reportCase{}
+		suite.cases[caseName] = rc
+	}
+	return rc
+}
+
+// recordLint records the findings of linting files. Error findings fail the
+// file in which they were found; other findings are recorded as output. A
+// lint error which cannot be attributed to any finding, such as from a linter
+// which does not report its findings, is recorded as a failure of the entire
+// lint run. Owners of the files, if known, are recorded with each case. It is
+// safe to call on a nil Report, which records nothing.
+func (r *Report) recordLint(suiteName string, owners []string, files []string, findings []types.Finding, lintErr error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Linters may report absolute paths to the files they were given.
+	caseNames := map[string]string{}
+	for _, file := range files {
+		r.reportCase(suiteName, file).owners = owners
+		if absFile, err := filepath.Abs(file); err == nil {
+			caseNames[absFile] = file
+		}
+	}
+	failed := false
+	for _, f := range findings {
+		caseName, ok := caseNames[f.File]
+		if !ok {
+			caseName = f.File
+		}
+		f.File = caseName
+		rc := r.reportCase(suiteName, caseName)
+		rc.findings = append(rc.findings, f)
+		failed = failed || f.Severity == types.SeverityError
+	}
+	if lintErr != nil && !failed {
+		caseName := "lint"
+		if len(owners) > 0 {
+			caseName += " (" + ownersString(owners) + ")"
+		}
+		rc := r.reportCase(suiteName, caseName)
+		rc.owners = owners
+		rc.errs = append(rc.errs, lintErr.Error())
+	}
+}
+
+// recordError records an error, such as a build failure, as a failed case. It
+// is safe to call on a nil Report, or with a nil error, which records nothing.
+func (r *Report) recordError(suiteName, caseName string, err error) {
+	if r == nil || err == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rc := r.reportCase(suiteName, caseName)
+	rc.errs = append(rc.errs, err.Error())
+}
+
+// failures returns the number of failures in a case: errors, and findings of
+// error severity.
+func (rc *reportCase) failures() int {
+	n := len(rc.errs)
+	for _, f := range rc.findings {
+		if f.Severity == types.SeverityError {
+			n++
+		}
+	}
+	return n
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName  string           `xml:"classname,attr"`
+	Name       string           `xml:"name,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failures   []junitFailure   `xml:"failure"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML, with a test suite for each
+// configuration checked and a test case for each file.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	doc := junitTestSuites{Suites: []junitTestSuite{}}
+	var suiteNames []string
+	for suiteName := range r.suites {
+		suiteNames = append(suiteNames, suiteName)
+	}
+	sort.Strings(suiteNames)
+	for _, suiteName := range suiteNames {
+		suite := junitTestSuite{Name: suiteName}
+		var caseNames []string
+		for caseName := range r.suites[suiteName].cases {
+			caseNames = append(caseNames, caseName)
+		}
+		sort.Strings(caseNames)
+		for _, caseName := range caseNames {
+			rc := r.suites[suiteName].cases[caseName]
+			tc := junitTestCase{ClassName: suiteName, Name: caseName}
+			if len(rc.owners) > 0 {
+				tc.Properties = &junitProperties{Properties: []junitProperty{{
+					Name: "owners", Value: strings.Join(rc.owners, " "),
+				}}}
+			}
+			var output []string
+			for _, f := range rc.findings {
+				if f.Severity == types.SeverityError {
+					message := fmt.Sprintf("%s:%d %s", f.File, f.Line, f.Message)
+					tc.Failures = append(tc.Failures, junitFailure{Type: f.Rule, Message: message, Text: message})
+				} else {
+					output = append(output, fmt.Sprintf("%s:%d %s %s %s", f.File, f.Line, f.Severity, f.Rule, f.Message))
+				}
+			}
+			for _, e := range rc.errs {
+				tc.Failures = append(tc.Failures, junitFailure{Type: caseName, Message: e, Text: e})
+			}
+			tc.SystemOut = strings.Join(output, "\n")
+			suite.Cases = append(suite.Cases, tc)
+			suite.Tests++
+			if rc.failures() > 0 {
+				suite.Failures++
+			}
+		}
+		doc.Suites = append(doc.Suites, suite)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations,omitempty"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+var sarifLevels = map[types.Severity]string{
+	types.SeverityError: "error",
+	types.SeverityWarn:  "warning",
+	types.SeverityInfo:  "note",
+	types.SeverityHint:  "note",
+}
+
+// WriteSARIF writes the report as a SARIF log, for code scanning tools, with
+// a result for each finding and failure. Results are located by file, line,
+// and a JSON pointer to the part of the spec found at fault, where known. The
+// configuration checked and the owners of each file are recorded as result
+// properties.
+func (r *Report) WriteSARIF(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	driver := sarifDriver{
+		Name:           "vervet",
+		InformationURI: "https://github.com/snyk/vervet",
+		Rules:          []sarifRule{},
+	}
+	results := []sarifResult{}
+	ruleIDs := map[string]bool{}
+	addResult := func(result sarifResult) {
+		if !ruleIDs[result.RuleID] {
+			ruleIDs[result.RuleID] = true
+			driver.Rules = append(driver.Rules, sarifRule{ID: result.RuleID})
+		}
+		results = append(results, result)
+	}
+	var suiteNames []string
+	for suiteName := range r.suites {
+		suiteNames = append(suiteNames, suiteName)
+	}
+	sort.Strings(suiteNames)
+	for _, suiteName := range suiteNames {
+		var caseNames []string
+		for caseName := range r.suites[suiteName].cases {
+			caseNames = append(caseNames, caseName)
+		}
+		sort.Strings(caseNames)
+		for _, caseName := range caseNames {
+			rc := r.suites[suiteName].cases[caseName]
+			properties := map[string]string{"suite": suiteName}
+			if len(rc.owners) > 0 {
+				properties["owners"] = strings.Join(rc.owners, " ")
+			}
+			for _, f := range rc.findings {
+				location := sarifLocation{PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: repoPath(f.File)},
+				}}
+				if f.Line > 0 {
+					location.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line}
+				}
+				if len(f.Path) > 0 {
+					var pointer strings.Builder
+					for _, token := range f.Path {
+						pointer.WriteString("/" + specjson.EscapePointer(token))
+					}
+					location.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: pointer.String()}}
+				}
+				addResult(sarifResult{
+					RuleID:     f.Rule,
+					Level:      sarifLevels[f.Severity],
+					Message:    sarifMessage{Text: f.Message},
+					Locations:  []sarifLocation{location},
+					Properties: properties,
+				})
+			}
+			for _, e := range rc.errs {
+				addResult(sarifResult{
+					RuleID:     caseName,
+					Level:      "error",
+					Message:    sarifMessage{Text: e},
+					Properties: properties,
+				})
+			}
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
+
+var annotationLevels = map[types.Severity]string{
+	types.SeverityError: github.LevelFailure,
+	types.SeverityWarn:  github.LevelWarning,
+	types.SeverityInfo:  github.LevelNotice,
+	types.SeverityHint:  github.LevelNotice,
+}
+
+// CheckRun returns the report as a GitHub check run on a commit, with a
+// summary table of each configuration checked and an annotation for each
+// finding.
+func (r *Report) CheckRun(name, headSHA string) *github.CheckRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var suiteNames []string
+	for suiteName := range r.suites {
+		suiteNames = append(suiteNames, suiteName)
+	}
+	sort.Strings(suiteNames)
+	var summary, errs strings.Builder
+	summary.WriteString("| | Files | Failures |\n|---|---|---|\n")
+	var annotations []github.Annotation
+	totalFailures := 0
+	for _, suiteName := range suiteNames {
+		var caseNames []string
+		for caseName := range r.suites[suiteName].cases {
+			caseNames = append(caseNames, caseName)
+		}
+		sort.Strings(caseNames)
+		failures := 0
+		for _, caseName := range caseNames {
+			rc := r.suites[suiteName].cases[caseName]
+			failures += rc.failures()
+			for _, f := range rc.findings {
+				line := f.Line
+				if line < 1 {
+					line = 1
+				}
+				message := f.Message
+				if len(rc.owners) > 0 {
+					message += "\n\nOwners: " + strings.Join(rc.owners, " ")
+				}
+				annotations = append(annotations, github.Annotation{
+					Path:            repoPath(f.File),
+					StartLine:       line,
+					EndLine:         line,
+					AnnotationLevel: annotationLevels[f.Severity],
+					Title:           f.Rule,
+					Message:         message,
+				})
+			}
+			for _, e := range rc.errs {
+				fmt.Fprintf(&errs, "* `%s` %s: %s\n", suiteName, caseName, e)
+			}
+		}
+		fmt.Fprintf(&summary, "| `%s` | %d | %d |\n", suiteName, len(caseNames), failures)
+		totalFailures += failures
+	}
+	if errs.Len() > 0 {
+		summary.WriteString("\n")
+		summary.WriteString(errs.String())
+	}
+	run := &github.CheckRun{
+		Name:       name,
+		HeadSHA:    headSHA,
+		Conclusion: github.ConclusionSuccess,
+		Output: github.CheckRunOutput{
+			Title:       "No failures",
+			Summary:     summary.String(),
+			Annotations: annotations,
+		},
+	}
+	if totalFailures > 0 {
+		run.Conclusion = github.ConclusionFailure
+		run.Output.Title = fmt.Sprintf("%d failures", totalFailures)
+	}
+	return run
+}
+
+// repoPath returns a file path relative to the working directory, which is
+// expected to be the root of the repository, with forward slashes as GitHub
+// expects.
+func repoPath(path string) string {
+	if filepath.IsAbs(path) {
+		if wd, err := os.Getwd(); err == nil {
+			if relPath, err := filepath.Rel(wd, path); err == nil {
+				path = relPath
+			}
+		}
+	}
+	return filepath.ToSlash(path)
+}
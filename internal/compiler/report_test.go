@@ -0,0 +1,256 @@
+package compiler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/github"
+	"github.com/snyk/vervet/internal/types"
+)
+
+type mockReportingLinter struct {
+	mockLinter
+	findings []types.Finding
+}
+
+func (l *mockReportingLinter) Report(ctx context.Context, paths ...string) ([]types.Finding, error) {
+	l.runs = append(l.runs, paths)
+	return l.findings, l.err
+}
+
+func TestReportJUnit(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	report := NewReport()
+	compiler := newTestCompiler(c, c.Mkdir(), nil, Reporter(report), LinterFactory(func(context.Context, *config.Linter) (types.Linter, error) {
+		return &mockReportingLinter{
+			mockLinter: mockLinter{err: errors.New("lint failed")},
+			findings: []types.Finding{{
+				File:     "testdata/resources/projects/2021-06-04/spec.yaml",
+				Line:     12,
+				Rule:     "operation-tags",
+				Severity: types.SeverityError,
+				Message:  "Operation must have tags.",
+			}, {
+				File:     "testdata/resources/projects/2021-06-04/spec.yaml",
+				Line:     3,
+				Rule:     "info-contact",
+				Severity: types.SeverityWarn,
+				Message:  "Info object must have contact.",
+			}},
+		}, nil
+	}))
+	err := compiler.LintResourcesAll(ctx)
+	c.Assert(err, qt.ErrorMatches, `lint failed \(apis.v3-api.resources\[0\]\)`)
+
+	var buf bytes.Buffer
+	c.Assert(report.WriteJUnit(&buf), qt.IsNil)
+	c.Assert(buf.String(), qt.Contains, `<testsuites tests="4" failures="1">`)
+	c.Assert(buf.String(), qt.Contains, `
+    <testcase classname="apis.v3-api.resources[0]" name="testdata/resources/projects/2021-06-04/spec.yaml">
+      <failure type="operation-tags" message="testdata/resources/projects/2021-06-04/spec.yaml:12 Operation must have tags.">testdata/resources/projects/2021-06-04/spec.yaml:12 Operation must have tags.</failure>
+      <system-out>testdata/resources/projects/2021-06-04/spec.yaml:3 warn info-contact Info object must have contact.</system-out>
+    </testcase>`)
+	c.Assert(buf.String(), qt.Contains, `
+    <testcase classname="apis.v3-api.resources[0]" name="testdata/resources/_examples/hello-world/2021-06-01/spec.yaml"></testcase>`)
+}
+
+func TestReportRecordLint(t *testing.T) {
+	c := qt.New(t)
+	report := NewReport()
+	// A lint failure without findings fails the entire run
+	report.recordLint("apis.test.output", nil, []string{"out/spec.json"}, nil, errors.New("exit status 1"))
+	report.recordError("apis.test", "build", errors.New("version 2021-06-04: oops"))
+	report.recordError("apis.test", "build", nil)
+
+	var buf bytes.Buffer
+	c.Assert(report.WriteJUnit(&buf), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `
+<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="2">
+  <testsuite name="apis.test" tests="1" failures="1">
+    <testcase classname="apis.test" name="build">
+      <failure type="build" message="version 2021-06-04: oops">version 2021-06-04: oops</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="apis.test.output" tests="2" failures="1">
+    <testcase classname="apis.test.output" name="lint">
+      <failure type="lint" message="exit status 1">exit status 1</failure>
+    </testcase>
+    <testcase classname="apis.test.output" name="out/spec.json"></testcase>
+  </testsuite>
+</testsuites>
+`[1:])
+}
+
+func TestReportCheckRun(t *testing.T) {
+	c := qt.New(t)
+	cwd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	report := NewReport()
+	report.recordLint("apis.test.resources[0]", nil, []string{"resources/foo/2021-06-04/spec.yaml", "resources/bar/2021-06-04/spec.yaml"}, []types.Finding{{
+		File:     filepath.Join(cwd, "resources/foo/2021-06-04/spec.yaml"),
+		Line:     12,
+		Rule:     "operation-tags",
+		Severity: types.SeverityError,
+		Message:  "Operation must have tags.",
+	}, {
+		File:     filepath.Join(cwd, "resources/foo/2021-06-04/spec.yaml"),
+		Line:     3,
+		Rule:     "info-contact",
+		Severity: types.SeverityWarn,
+		Message:  "Info object must have contact.",
+	}}, errors.New("exit status 1"))
+	report.recordError("apis.test", "build", errors.New("version 2021-06-04: oops"))
+
+	run := report.CheckRun("vervet", "abc123")
+	c.Assert(run.Conclusion, qt.Equals, github.ConclusionFailure)
+	c.Assert(run.Output.Title, qt.Equals, "2 failures")
+	c.Assert(run.Output.Summary, qt.Equals, ""+
+		"| | Files | Failures |\n"+
+		"|---|---|---|\n"+
+		"| `apis.test` | 1 | 1 |\n"+
+		"| `apis.test.resources[0]` | 2 | 1 |\n"+
+		"\n"+
+		"* `apis.test` build: version 2021-06-04: oops\n")
+	c.Assert(run.Output.Annotations, qt.DeepEquals, []github.Annotation{{
+		Path:            "resources/foo/2021-06-04/spec.yaml",
+		StartLine:       12,
+		EndLine:         12,
+		AnnotationLevel: github.LevelFailure,
+		Title:           "operation-tags",
+		Message:         "Operation must have tags.",
+	}, {
+		Path:            "resources/foo/2021-06-04/spec.yaml",
+		StartLine:       3,
+		EndLine:         3,
+		AnnotationLevel: github.LevelWarning,
+		Title:           "info-contact",
+		Message:         "Info object must have contact.",
+	}})
+
+	run = NewReport().CheckRun("vervet", "abc123")
+	c.Assert(run.Conclusion, qt.Equals, github.ConclusionSuccess)
+	c.Assert(run.Output.Title, qt.Equals, "No failures")
+}
+
+func TestReportSARIF(t *testing.T) {
+	c := qt.New(t)
+	report := NewReport()
+	report.recordLint("apis.test.resources[0]", []string{"@acme/things"}, []string{"resources/foo/2021-06-04/spec.yaml"}, []types.Finding{{
+		File:     "resources/foo/2021-06-04/spec.yaml",
+		Line:     12,
+		Rule:     "operation-tags",
+		Severity: types.SeverityError,
+		Message:  "Operation must have tags.",
+		Path:     []string{"paths", "/foo", "get"},
+	}, {
+		File:     "resources/foo/2021-06-04/spec.yaml",
+		Line:     3,
+		Rule:     "info-contact",
+		Severity: types.SeverityWarn,
+		Message:  "Info object must have contact.",
+	}}, errors.New("exit status 1"))
+	report.recordError("apis.test", "build", errors.New("version 2021-06-04: oops"))
+
+	var buf bytes.Buffer
+	c.Assert(report.WriteSARIF(&buf), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `
+{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "vervet",
+          "informationUri": "https://github.com/snyk/vervet",
+          "rules": [
+            {
+              "id": "build"
+            },
+            {
+              "id": "operation-tags"
+            },
+            {
+              "id": "info-contact"
+            }
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "build",
+          "level": "error",
+          "message": {
+            "text": "version 2021-06-04: oops"
+          },
+          "properties": {
+            "suite": "apis.test"
+          }
+        },
+        {
+          "ruleId": "operation-tags",
+          "level": "error",
+          "message": {
+            "text": "Operation must have tags."
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "resources/foo/2021-06-04/spec.yaml"
+                },
+                "region": {
+                  "startLine": 12
+                }
+              },
+              "logicalLocations": [
+                {
+                  "fullyQualifiedName": "/paths/~1foo/get"
+                }
+              ]
+            }
+          ],
+          "properties": {
+            "owners": "@acme/things",
+            "suite": "apis.test.resources[0]"
+          }
+        },
+        {
+          "ruleId": "info-contact",
+          "level": "warning",
+          "message": {
+            "text": "Info object must have contact."
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "resources/foo/2021-06-04/spec.yaml"
+                },
+                "region": {
+                  "startLine": 3
+                }
+              }
+            }
+          ],
+          "properties": {
+            "owners": "@acme/things",
+            "suite": "apis.test.resources[0]"
+          }
+        }
+      ]
+    }
+  ]
+}
+`[1:])
+}
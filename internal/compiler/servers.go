@@ -0,0 +1,93 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+)
+
+// serverTemplate renders an output server for each compiled version.
+type serverTemplate struct {
+	url         *template.Template
+	description *template.Template
+}
+
+// serverScope contains the fields available to output server templates.
+type serverScope struct {
+	API       string
+	Version   string
+	Date      string
+	Stability string
+}
+
+func newServerTemplates(env *envExpander, apiName string, servers []*config.Server) ([]*serverTemplate, error) {
+	var result []*serverTemplate
+	for serverIndex, server := range servers {
+		url, err := env.expand(server.URL, fmt.Sprintf("apis.%s.output.servers[%d].url", apiName, serverIndex))
+		if err != nil {
+			return nil, err
+		}
+		urlTmpl, err := template.New("url").Parse(url)
+		if err != nil {
+			return nil, fmt.Errorf("%w (apis.%s.output.servers[%d].url)", err, apiName, serverIndex)
+		}
+		desc, err := env.expand(server.Description, fmt.Sprintf("apis.%s.output.servers[%d].description", apiName, serverIndex))
+		if err != nil {
+			return nil, err
+		}
+		descTmpl, err := template.New("description").Parse(desc)
+		if err != nil {
+			return nil, fmt.Errorf("%w (apis.%s.output.servers[%d].description)", err, apiName, serverIndex)
+		}
+		result = append(result, &serverTemplate{url: urlTmpl, description: descTmpl})
+	}
+	return result, nil
+}
+
+// renderServers returns the output servers of an API at a version, or nil if
+// the output does not define servers.
+func renderServers(apiName string, out *output, version *vervet.Version) (openapi3.Servers, error) {
+	if len(out.servers) == 0 {
+		return nil, nil
+	}
+	scope := &serverScope{
+		API:       apiName,
+		Version:   version.String(),
+		Date:      version.DateString(),
+		Stability: version.Stability.String(),
+	}
+	var result openapi3.Servers
+	for serverIndex, server := range out.servers {
+		var urlBuf, descBuf bytes.Buffer
+		if err := server.url.Execute(&urlBuf, scope); err != nil {
+			return nil, fmt.Errorf("%w (apis.%s.output.servers[%d].url)", err, apiName, serverIndex)
+		}
+		if err := server.description.Execute(&descBuf, scope); err != nil {
+			return nil, fmt.Errorf("%w (apis.%s.output.servers[%d].description)", err, apiName, serverIndex)
+		}
+		result = append(result, &openapi3.Server{
+			URL:         urlBuf.String(),
+			Description: descBuf.String(),
+		})
+	}
+	return result, nil
+}
+
+// serversKey returns a key identifying rendered servers, so that compiled
+// specs are only shared by versions whose servers are the same.
+func serversKey(servers openapi3.Servers) (string, error) {
+	if len(servers) == 0 {
+		return "", nil
+	}
+	buf, err := json.Marshal(servers)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
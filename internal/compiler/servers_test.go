@@ -0,0 +1,55 @@
+package compiler
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+)
+
+func TestOutputServers(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	c.Setenv("API_REGION", "eu")
+	ctx := context.Background()
+	outputPath := c.Mkdir()
+	compiler := newTestCompiler(c, outputPath, func(proj *config.Project) {
+		proj.APIs["v3-api"].Output.Servers = []*config.Server{{
+			URL:         "https://api.${API_REGION}.example.com/{{ .Date }}",
+			Description: "{{ .API }} {{ .Stability }}",
+		}}
+		proj.APIs["v3-api"].Output.Aliases = config.OutputAliasesIndex
+	})
+	err := compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+
+	specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(outputPath))
+	c.Assert(err, qt.IsNil)
+	tests := []struct {
+		version, url, description string
+	}{{
+		version:     "2021-06-01",
+		url:         "https://api.eu.example.com/2021-06-01",
+		description: "v3-api ga",
+	}, {
+		version:     "2021-06-01~beta",
+		url:         "https://api.eu.example.com/2021-06-01",
+		description: "v3-api beta",
+	}, {
+		// Same resource versions as 2021-06-01, but different servers
+		version:     "2021-06-04",
+		url:         "https://api.eu.example.com/2021-06-04",
+		description: "v3-api ga",
+	}}
+	for _, test := range tests {
+		spec, err := specs.At(test.version)
+		c.Assert(err, qt.IsNil)
+		c.Assert(spec.Servers, qt.HasLen, 1)
+		c.Assert(spec.Servers[0].URL, qt.Equals, test.url)
+		c.Assert(spec.Servers[0].Description, qt.Equals, test.description)
+	}
+}
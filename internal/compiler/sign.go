@@ -0,0 +1,14 @@
+package compiler
+
+import (
+	"crypto/ed25519"
+)
+
+// Signer configures a Compiler to sign the output of each API it builds with
+// key, so that it may be verified as the output of the build.
+func Signer(key ed25519.PrivateKey) CompilerOption {
+	return func(c *Compiler) error {
+		c.signingKey = key
+		return nil
+	}
+}
@@ -0,0 +1,65 @@
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/snyk/vervet"
+)
+
+// checkVersionAliases checks that each named version alias of an API refers
+// to a version, and is not itself a version which it would shadow.
+func checkVersionAliases(apiName string, versionAliases map[string]string) error {
+	for alias, target := range versionAliases {
+		if _, err := vervet.ParseVersion(alias); err == nil {
+			return fmt.Errorf("invalid version alias %q, alias is a version (apis.%s.output.version-aliases)",
+				alias, apiName)
+		}
+		if _, err := vervet.ParseVersion(target); err != nil {
+			return fmt.Errorf("%w (apis.%s.output.version-aliases.%s)", err, apiName, alias)
+		}
+	}
+	return nil
+}
+
+// writeVersionAliases resolves each named version alias to a version in the
+// compiled output, and indexes the resolved versions in the output. Aliases
+// are resolved once the build is done, so that they resolve as a spec
+// version request would when the output is served.
+func writeVersionAliases(out *output) error {
+	versionAliasesPath := out.path + "/" + vervet.CompiledVersionAliasesFile
+	if len(out.versionAliases) == 0 {
+		err := os.Remove(versionAliasesPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove version aliases: %w", err)
+		}
+		return nil
+	}
+	specVersions, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(out.path))
+	if err != nil {
+		return err
+	}
+	resolved := map[string]string{}
+	for alias, target := range out.versionAliases {
+		version, err := specVersions.Resolve(target)
+		if err == vervet.ErrNoMatchingVersion {
+			return fmt.Errorf("version alias %q: no compiled version matching %q", alias, target)
+		} else if err != nil {
+			return fmt.Errorf("version alias %q: %w", alias, err)
+		}
+		resolved[alias] = version.String()
+	}
+	buf, err := json.MarshalIndent(resolved, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(versionAliasesPath, buf, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write version aliases: %w", err)
+	}
+	log.Println(versionAliasesPath)
+	return nil
+}
@@ -0,0 +1,58 @@
+package compiler
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+)
+
+func TestBuildVersionAliases(t *testing.T) {
+	c := qt.New(t)
+	setup(c)
+	ctx := context.Background()
+	outputPath := c.Mkdir()
+	compiler := newTestCompiler(c, outputPath, func(proj *config.Project) {
+		proj.APIs["v3-api"].Output.Aliases = config.OutputAliasesIndex
+		proj.APIs["v3-api"].Output.VersionAliases = map[string]string{
+			"latest":  "2021-12-31",
+			"preview": "2021-06-05~experimental",
+		}
+	})
+	err := compiler.BuildAll(ctx)
+	c.Assert(err, qt.IsNil)
+
+	// Aliases resolve to compiled versions, including indexed versions.
+	specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(outputPath))
+	c.Assert(err, qt.IsNil)
+	version, ok := specs.VersionAlias("latest")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(version, qt.Equals, "2021-06-13")
+	version, ok = specs.VersionAlias("preview")
+	c.Assert(ok, qt.IsTrue)
+	c.Assert(version, qt.Equals, "2021-06-04~experimental")
+	_, ok = specs.VersionAlias("stable")
+	c.Assert(ok, qt.IsFalse)
+
+	// Aliases must resolve to a compiled version.
+	compiler = newTestCompiler(c, outputPath, func(proj *config.Project) {
+		proj.APIs["v3-api"].Output.Aliases = config.OutputAliasesIndex
+		proj.APIs["v3-api"].Output.VersionAliases = map[string]string{"ancient": "2020-01-01"}
+	})
+	err = compiler.BuildAll(ctx)
+	c.Assert(err, qt.ErrorMatches,
+		`version alias "ancient": no compiled version matching "2020-01-01" \(apis\.v3-api\.output\.version-aliases\)`)
+}
+
+func TestCheckVersionAliases(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(checkVersionAliases("v3-api", map[string]string{"latest": "2021-06-01~beta"}), qt.IsNil)
+	c.Assert(checkVersionAliases("v3-api", map[string]string{"2021-06-01": "2021-06-04"}), qt.ErrorMatches,
+		`invalid version alias "2021-06-01", alias is a version \(apis\.v3-api\.output\.version-aliases\)`)
+	c.Assert(checkVersionAliases("v3-api", map[string]string{"latest": "tomorrow"}), qt.ErrorMatches,
+		`invalid version "tomorrow" \(apis\.v3-api\.output\.version-aliases\.latest\)`)
+}
@@ -0,0 +1,110 @@
+// Package consumers checks compiled APIs against the versions and operations
+// pinned by their downstream consumers, so that producers can see the impact
+// of a change on the teams which depend on it before it is released.
+package consumers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+)
+
+// A Breakage is an operation pinned by a consumer which is not available in
+// the compiled API.
+type Breakage struct {
+	Consumer  string
+	Contact   string
+	API       string
+	Version   string
+	Operation string
+	Reason    string
+
+	// Pin is the index of the pin in the consumer's pins.
+	Pin int
+}
+
+// String returns a description of the breakage, which identifies the pin
+// broken.
+func (b *Breakage) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s at %s: %s", b.Operation, b.API, b.Version, b.Reason)
+	if b.Contact != "" {
+		fmt.Fprintf(&sb, ", contact %s", b.Contact)
+	}
+	fmt.Fprintf(&sb, " (consumers.%s.pins[%d])", b.Consumer, b.Pin)
+	return sb.String()
+}
+
+// Breakage reasons.
+const (
+	ReasonVersionRemoved   = "version not available"
+	ReasonOperationRemoved = "operation not found"
+)
+
+// Check returns the operations pinned by consumers which are not available in
+// the compiled specs of each API, at the pinned version. specs maps API names
+// to their compiled spec versions.
+func Check(consumers *config.Consumers, specs map[string]*vervet.SpecVersions) ([]*Breakage, error) {
+	var result []*Breakage
+	for _, name := range consumers.ConsumerNames() {
+		consumer := consumers.Consumers[name]
+		for pinIndex, pin := range consumer.Pins {
+			apiSpecs, ok := specs[pin.API]
+			if !ok {
+				return nil, fmt.Errorf("api %q not found (consumers.%s.pins[%d].api)", pin.API, name, pinIndex)
+			}
+			if _, err := vervet.ParseVersion(pin.Version); err != nil {
+				return nil, fmt.Errorf("%w (consumers.%s.pins[%d].version)", err, name, pinIndex)
+			}
+			breakage := func(operation, reason string) *Breakage {
+				return &Breakage{
+					Consumer:  name,
+					Contact:   consumer.Contact,
+					API:       pin.API,
+					Version:   pin.Version,
+					Operation: operation,
+					Reason:    reason,
+					Pin:       pinIndex,
+				}
+			}
+			doc, err := apiSpecs.At(pin.Version)
+			if errors.Is(err, vervet.ErrNoMatchingVersion) {
+				for _, operation := range pin.Operations {
+					result = append(result, breakage(operation, ReasonVersionRemoved))
+				}
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("%w (consumers.%s.pins[%d].version)", err, name, pinIndex)
+			}
+			for _, operation := range pin.Operations {
+				if !hasOperation(doc, operation) {
+					result = append(result, breakage(operation, ReasonOperationRemoved))
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// hasOperation returns whether a spec contains an operation, identified by
+// operationId or by method and path.
+func hasOperation(doc *openapi3.T, operation string) bool {
+	method, path, operationID := config.ParseOperation(operation)
+	if operationID == "" {
+		pathItem, ok := doc.Paths[path]
+		return ok && pathItem.GetOperation(method) != nil
+	}
+	for _, pathItem := range doc.Paths {
+		for _, op := range pathItem.Operations() {
+			if op.OperationID == operationID {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package consumers_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/consumers"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestCheck(t *testing.T) {
+	c := qt.New(t)
+	specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(testdata.Path("output")))
+	c.Assert(err, qt.IsNil)
+	contracts, err := config.LoadConsumers(bytes.NewBufferString(`
+consumers:
+  billing:
+    contact: billing@example.com
+    pins:
+      - api: testdata
+        version: 2021-06-13~beta
+        operations:
+          - helloWorldCreate
+          - GET /examples/hello-world/{id}
+  reports:
+    pins:
+      - api: testdata
+        version: 2021-06-01
+        operations:
+          - get /examples/hello-world/{id}
+          - helloWorldCreate
+          - POST /examples/hello-world
+      - api: testdata
+        version: 2021-05-01
+        operations:
+          - helloWorldGetOne
+`[1:]))
+	c.Assert(err, qt.IsNil)
+	breakages, err := consumers.Check(contracts, map[string]*vervet.SpecVersions{"testdata": specs})
+	c.Assert(err, qt.IsNil)
+	var result []string
+	for _, breakage := range breakages {
+		result = append(result, breakage.String())
+	}
+	c.Assert(result, qt.DeepEquals, []string{
+		"helloWorldCreate: testdata at 2021-06-01: operation not found (consumers.reports.pins[0])",
+		"POST /examples/hello-world: testdata at 2021-06-01: operation not found (consumers.reports.pins[0])",
+		"helloWorldGetOne: testdata at 2021-05-01: version not available (consumers.reports.pins[1])",
+	})
+}
+
+func TestCheckErrors(t *testing.T) {
+	c := qt.New(t)
+	specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(testdata.Path("output")))
+	c.Assert(err, qt.IsNil)
+	tests := []struct {
+		conf, err string
+	}{{
+		conf: `
+consumers:
+  billing:
+    pins:
+      - api: nope
+        version: 2021-06-13
+        operations: [helloWorldGetOne]`[1:],
+		err: `api "nope" not found \(consumers\.billing\.pins\[0\]\.api\)`,
+	}, {
+		conf: `
+consumers:
+  billing:
+    pins:
+      - api: testdata
+        version: latest
+        operations: [helloWorldGetOne]`[1:],
+		err: `invalid version "latest" \(consumers\.billing\.pins\[0\]\.version\)`,
+	}}
+	for _, test := range tests {
+		contracts, err := config.LoadConsumers(bytes.NewBufferString(test.conf))
+		c.Assert(err, qt.IsNil)
+		_, err = consumers.Check(contracts, map[string]*vervet.SpecVersions{"testdata": specs})
+		c.Assert(err, qt.ErrorMatches, test.err)
+	}
+}
@@ -0,0 +1,267 @@
+// Package gateway generates API gateway configuration from compiled OpenAPI
+// specs, so that gateway routing is derived from the versioned spec rather
+// than maintained by hand.
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+)
+
+// Files generated by each type of export.
+const (
+	KongFile          = "kong.yaml"
+	EnvoyFile         = "envoy.yaml"
+	AWSAPIGatewayFile = "spec.aws.json"
+)
+
+const generatedComment = "# Generated by vervet, DO NOT EDIT\n"
+
+// Export generates the configured gateway exports from a compiled spec. name
+// identifies the spec in the generated configuration, for example by API and
+// version. The result maps the name of each generated file to its contents.
+func Export(spec *openapi3.T, name string, exports *config.Exports) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if exports == nil {
+		return files, nil
+	}
+	if exports.Kong != nil {
+		buf, err := Kong(spec, name, exports.Kong)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export kong: %w", err)
+		}
+		files[KongFile] = buf
+	}
+	if exports.Envoy != nil {
+		buf, err := Envoy(spec, name, exports.Envoy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export envoy: %w", err)
+		}
+		files[EnvoyFile] = buf
+	}
+	if exports.AWSAPIGateway != nil {
+		buf, err := AWSAPIGateway(spec, exports.AWSAPIGateway)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export aws-api-gateway: %w", err)
+		}
+		files[AWSAPIGatewayFile] = buf
+	}
+	return files, nil
+}
+
+// route is an operation in a spec, to be routed by a gateway.
+type route struct {
+	name   string
+	path   string
+	method string
+}
+
+// routeNameRE matches characters which may not be used in route names. Kong
+// only allows unreserved URL characters.
+var routeNameRE = regexp.MustCompile(`[^A-Za-z0-9._~-]+`)
+
+// routes returns the operations in a spec, sorted by path and method. Each is
+// named by its operation ID, or by its method and path if it has none, and
+// names are made unique.
+func routes(spec *openapi3.T) []route {
+	var pathNames []string
+	for pathName := range spec.Paths {
+		pathNames = append(pathNames, pathName)
+	}
+	sort.Strings(pathNames)
+	var result []route
+	named := map[string]bool{}
+	for _, pathName := range pathNames {
+		ops := spec.Paths[pathName].Operations()
+		var methods []string
+		for method := range ops {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			name := ops[method].OperationID
+			if name == "" {
+				name = strings.ToLower(method) + pathName
+			}
+			name = strings.Trim(routeNameRE.ReplaceAllString(name, "-"), "-")
+			unique := name
+			for i := 2; named[unique]; i++ {
+				unique = fmt.Sprintf("%s-%d", name, i)
+			}
+			named[unique] = true
+			result = append(result, route{name: unique, path: pathName, method: method})
+		}
+	}
+	return result
+}
+
+var pathParamRE = regexp.MustCompile(`\{[^}]+\}`)
+
+// pathRegex returns a regular expression matching request paths of an
+// OpenAPI path template, where each path parameter matches a path segment.
+func pathRegex(pathName string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	last := 0
+	for _, loc := range pathParamRE.FindAllStringIndex(pathName, -1) {
+		sb.WriteString(regexp.QuoteMeta(pathName[last:loc[0]]))
+		sb.WriteString("[^/]+")
+		last = loc[1]
+	}
+	sb.WriteString(regexp.QuoteMeta(pathName[last:]))
+	sb.WriteString("$")
+	return sb.String()
+}
+
+func marshalYAML(v interface{}) ([]byte, error) {
+	buf, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(generatedComment), buf...), nil
+}
+
+type kongConfig struct {
+	FormatVersion string        `json:"_format_version"`
+	Services      []kongService `json:"services"`
+}
+
+type kongService struct {
+	Name   string      `json:"name"`
+	URL    string      `json:"url"`
+	Routes []kongRoute `json:"routes"`
+}
+
+type kongRoute struct {
+	Name      string   `json:"name"`
+	Paths     []string `json:"paths"`
+	Methods   []string `json:"methods"`
+	StripPath bool     `json:"strip_path"`
+}
+
+// Kong returns Kong declarative configuration for a spec, defining a service
+// with a route for each operation.
+func Kong(spec *openapi3.T, name string, export *config.KongExport) ([]byte, error) {
+	svc := kongService{Name: name, URL: export.Upstream, Routes: []kongRoute{}}
+	for _, r := range routes(spec) {
+		svc.Routes = append(svc.Routes, kongRoute{
+			Name: name + "-" + r.name,
+			// Kong treats paths prefixed with ~ as regular expressions.
+			Paths:   []string{"~" + pathRegex(r.path)},
+			Methods: []string{r.method},
+		})
+	}
+	return marshalYAML(&kongConfig{
+		FormatVersion: "3.0",
+		Services:      []kongService{svc},
+	})
+}
+
+type envoyRouteConfig struct {
+	Name         string             `json:"name"`
+	VirtualHosts []envoyVirtualHost `json:"virtual_hosts"`
+}
+
+type envoyVirtualHost struct {
+	Name    string       `json:"name"`
+	Domains []string     `json:"domains"`
+	Routes  []envoyRoute `json:"routes"`
+}
+
+type envoyRoute struct {
+	Name  string           `json:"name"`
+	Match envoyRouteMatch  `json:"match"`
+	Route envoyRouteAction `json:"route"`
+}
+
+type envoyRouteMatch struct {
+	SafeRegex envoyRegex           `json:"safe_regex"`
+	Headers   []envoyHeaderMatcher `json:"headers"`
+}
+
+type envoyRegex struct {
+	Regex string `json:"regex"`
+}
+
+type envoyHeaderMatcher struct {
+	Name        string            `json:"name"`
+	StringMatch map[string]string `json:"string_match"`
+}
+
+type envoyRouteAction struct {
+	Cluster string `json:"cluster"`
+}
+
+// Envoy returns an Envoy route configuration for a spec, routing each
+// operation to a cluster.
+func Envoy(spec *openapi3.T, name string, export *config.EnvoyExport) ([]byte, error) {
+	vhost := envoyVirtualHost{Name: name, Domains: []string{"*"}, Routes: []envoyRoute{}}
+	for _, r := range routes(spec) {
+		vhost.Routes = append(vhost.Routes, envoyRoute{
+			Name: r.name,
+			Match: envoyRouteMatch{
+				SafeRegex: envoyRegex{Regex: pathRegex(r.path)},
+				Headers: []envoyHeaderMatcher{{
+					Name:        ":method",
+					StringMatch: map[string]string{"exact": r.method},
+				}},
+			},
+			Route: envoyRouteAction{Cluster: export.Cluster},
+		})
+	}
+	return marshalYAML(&envoyRouteConfig{
+		Name:         name,
+		VirtualHosts: []envoyVirtualHost{vhost},
+	})
+}
+
+const extAmazonAPIGatewayIntegration = "x-amazon-apigateway-integration"
+
+// AWSAPIGateway returns a copy of a spec, with AWS API Gateway integration
+// extensions proxying each operation to the same path at an upstream URL.
+// The spec itself is not modified.
+func AWSAPIGateway(spec *openapi3.T, export *config.AWSAPIGatewayExport) ([]byte, error) {
+	buf, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	err = json.Unmarshal(buf, &doc)
+	if err != nil {
+		return nil, err
+	}
+	paths, _ := doc["paths"].(map[string]interface{})
+	upstream := strings.TrimSuffix(export.Upstream, "/")
+	for _, r := range routes(spec) {
+		pathItem, _ := paths[r.path].(map[string]interface{})
+		op, ok := pathItem[strings.ToLower(r.method)].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		requestParams := map[string]string{}
+		for _, param := range pathParamRE.FindAllString(r.path, -1) {
+			paramName := strings.Trim(param, "{}")
+			requestParams["integration.request.path."+paramName] = "method.request.path." + paramName
+		}
+		integration := map[string]interface{}{
+			"type":                "http_proxy",
+			"httpMethod":          r.method,
+			"uri":                 upstream + r.path,
+			"passthroughBehavior": "when_no_match",
+		}
+		if len(requestParams) > 0 {
+			integration["requestParameters"] = requestParams
+		}
+		op[extAmazonAPIGatewayIntegration] = integration
+	}
+	return vervet.ToSpecJSON(doc)
+}
@@ -0,0 +1,132 @@
+package gateway
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/testdata"
+)
+
+func loadSpec(c *qt.C) *openapi3.T {
+	doc, err := vervet.NewDocumentFile(testdata.Path("output/2021-06-13~beta/spec.json"))
+	c.Assert(err, qt.IsNil)
+	return doc.T
+}
+
+func TestPathRegex(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(pathRegex("/examples/hello-world"), qt.Equals, `^/examples/hello-world$`)
+	c.Assert(pathRegex("/orgs/{orgId}/projects/{id}.json"), qt.Equals, `^/orgs/[^/]+/projects/[^/]+\.json$`)
+}
+
+func TestRouteNames(t *testing.T) {
+	c := qt.New(t)
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.3
+info: {title: test, version: 0.0.0}
+paths:
+  /orgs/{org_id}/projects:
+    get:
+      responses: {'200': {description: OK}}
+    post:
+      operationId: get-orgs-org_id-projects
+      responses: {'200': {description: OK}}
+  /orgs/{org_id}/projects/{id}:
+    get:
+      operationId: 'getProject'
+      responses: {'200': {description: OK}}
+    delete:
+      operationId: 'getProject'
+      responses: {'200': {description: OK}}
+`))
+	c.Assert(err, qt.IsNil)
+	var names []string
+	for _, r := range routes(spec) {
+		names = append(names, r.name)
+	}
+	c.Assert(names, qt.DeepEquals, []string{
+		"get-orgs-org_id-projects",
+		"get-orgs-org_id-projects-2",
+		"getProject",
+		"getProject-2",
+	})
+
+	buf, err := Kong(spec, "testdata-2021-06-13~beta", &config.KongExport{Upstream: "http://hello:8080"})
+	c.Assert(err, qt.IsNil)
+	var conf kongConfig
+	c.Assert(yaml.Unmarshal(buf, &conf), qt.IsNil)
+	for _, route := range conf.Services[0].Routes {
+		c.Assert(route.Name, qt.Matches, `[A-Za-z0-9._~-]+`)
+	}
+}
+
+func TestKong(t *testing.T) {
+	c := qt.New(t)
+	buf, err := Kong(loadSpec(c), "testdata-2021-06-13~beta", &config.KongExport{Upstream: "http://hello:8080"})
+	c.Assert(err, qt.IsNil)
+	var conf kongConfig
+	c.Assert(yaml.Unmarshal(buf, &conf), qt.IsNil)
+	c.Assert(conf.Services, qt.HasLen, 1)
+	c.Assert(conf.Services[0].URL, qt.Equals, "http://hello:8080")
+	c.Assert(conf.Services[0].Routes, qt.Any(qt.DeepEquals), kongRoute{
+		Name:    "testdata-2021-06-13~beta-helloWorldGetOne",
+		Paths:   []string{`~^/examples/hello-world/[^/]+$`},
+		Methods: []string{"GET"},
+	})
+}
+
+func TestEnvoy(t *testing.T) {
+	c := qt.New(t)
+	buf, err := Envoy(loadSpec(c), "testdata-2021-06-13~beta", &config.EnvoyExport{Cluster: "hello"})
+	c.Assert(err, qt.IsNil)
+	var conf envoyRouteConfig
+	c.Assert(yaml.Unmarshal(buf, &conf), qt.IsNil)
+	c.Assert(conf.VirtualHosts, qt.HasLen, 1)
+	routes := conf.VirtualHosts[0].Routes
+	c.Assert(routes[0].Name, qt.Equals, "helloWorldCreate")
+	c.Assert(routes[0].Match.SafeRegex.Regex, qt.Equals, `^/examples/hello-world$`)
+	c.Assert(routes[0].Match.Headers[0].StringMatch["exact"], qt.Equals, "POST")
+	c.Assert(routes[0].Route.Cluster, qt.Equals, "hello")
+}
+
+func TestAWSAPIGateway(t *testing.T) {
+	c := qt.New(t)
+	spec := loadSpec(c)
+	buf, err := AWSAPIGateway(spec, &config.AWSAPIGatewayExport{Upstream: "https://hello.internal/"})
+	c.Assert(err, qt.IsNil)
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	c.Assert(json.Unmarshal(buf, &doc), qt.IsNil)
+	var op struct {
+		Integration map[string]interface{} `json:"x-amazon-apigateway-integration"`
+	}
+	c.Assert(json.Unmarshal(doc.Paths["/examples/hello-world/{id}"]["get"], &op), qt.IsNil)
+	integration := op.Integration
+	c.Assert(integration["uri"], qt.Equals, "https://hello.internal/examples/hello-world/{id}")
+	c.Assert(integration["httpMethod"], qt.Equals, "GET")
+	c.Assert(integration["requestParameters"], qt.DeepEquals, map[string]interface{}{
+		"integration.request.path.id": "method.request.path.id",
+	})
+	// The spec itself is not modified
+	_, ok := spec.Paths["/examples/hello-world/{id}"].Get.Extensions[extAmazonAPIGatewayIntegration]
+	c.Assert(ok, qt.IsFalse)
+}
+
+func TestExport(t *testing.T) {
+	c := qt.New(t)
+	files, err := Export(loadSpec(c), "test", &config.Exports{
+		Kong:  &config.KongExport{Upstream: "http://hello:8080"},
+		Envoy: &config.EnvoyExport{Cluster: "hello"},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(files, qt.HasLen, 2)
+	c.Assert(string(files[KongFile]), qt.Matches, `(?s)# Generated by vervet, DO NOT EDIT\n.*`)
+	c.Assert(files[EnvoyFile], qt.Not(qt.IsNil))
+}
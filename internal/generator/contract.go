@@ -0,0 +1,275 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// contractOperation describes an operation in a spec, for generating contract
+// tests of it.
+type contractOperation struct {
+	Path        string
+	Method      string
+	OperationID string
+	Summary     string
+
+	// PathParams are the names of the parameters in the path template, in
+	// the order they appear.
+	PathParams []string
+
+	// Responses are the documented responses of the operation, in order of
+	// status.
+	Responses []*contractResponse
+}
+
+// contractResponse describes a documented response of an operation.
+type contractResponse struct {
+	// Status is the documented status code, such as "200", or "default".
+	Status string
+
+	// ContentType is the media type of a JSON response body, empty if the
+	// response has none.
+	ContentType string
+
+	// Schema is a self-contained JSON schema of the response body, with
+	// references resolved, or nil if the response has no JSON body.
+	Schema interface{}
+}
+
+// contractOperations returns the operations in a spec loaded with the
+// "openapi" data format, in order of path and method, for generating contract
+// tests of each.
+func contractOperations(v interface{}) ([]*contractOperation, error) {
+	doc, ok := v.(*openapi3.T)
+	if !ok {
+		return nil, fmt.Errorf("contractOperations requires an OpenAPI document, got %T", v)
+	}
+	var paths []string
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var ops []*contractOperation
+	for _, path := range paths {
+		pathItem := doc.Paths[path]
+		methods := pathItem.Operations()
+		var names []string
+		for method := range methods {
+			names = append(names, method)
+		}
+		sort.Strings(names)
+		for _, method := range names {
+			op := methods[method]
+			contractOp := &contractOperation{
+				Path:        path,
+				Method:      method,
+				OperationID: op.OperationID,
+				Summary:     op.Summary,
+				PathParams:  pathParams(path),
+			}
+			var statuses []string
+			for status := range op.Responses {
+				statuses = append(statuses, status)
+			}
+			sort.Strings(statuses)
+			for _, status := range statuses {
+				resp := &contractResponse{Status: status}
+				if ref := op.Responses[status]; ref != nil && ref.Value != nil {
+					resp.ContentType, resp.Schema = jsonResponseSchema(ref.Value)
+				}
+				contractOp.Responses = append(contractOp.Responses, resp)
+			}
+			ops = append(ops, contractOp)
+		}
+	}
+	return ops, nil
+}
+
+// pathParams returns the names of the parameters in a path template.
+func pathParams(path string) []string {
+	var params []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, segment[1:len(segment)-1])
+		}
+	}
+	return params
+}
+
+// jsonResponseSchema returns the media type and inlined schema of a JSON
+// response body, such as application/vnd.api+json.
+func jsonResponseSchema(resp *openapi3.Response) (string, interface{}) {
+	var contentTypes []string
+	for contentType := range resp.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+	for _, contentType := range contentTypes {
+		mediaType := resp.Content[contentType]
+		if !strings.Contains(contentType, "json") || mediaType == nil || mediaType.Schema == nil {
+			continue
+		}
+		return contentType, inlineSchema(mediaType.Schema.Value, map[*openapi3.Schema]bool{})
+	}
+	return "", nil
+}
+
+// inlineSchema returns a schema as a JSON schema value, with references to
+// other schemas replaced by the schemas themselves, so that it can be used
+// without the rest of the spec. Recursive references cannot be inlined, so
+// any value is allowed where a schema refers to itself.
+func inlineSchema(s *openapi3.Schema, visiting map[*openapi3.Schema]bool) interface{} {
+	if s == nil || visiting[s] {
+		return map[string]interface{}{}
+	}
+	visiting[s] = true
+	defer delete(visiting, s)
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(buf, &result); err != nil {
+		return map[string]interface{}{}
+	}
+	inlineRef := func(ref *openapi3.SchemaRef) interface{} {
+		if ref == nil {
+			return map[string]interface{}{}
+		}
+		return inlineSchema(ref.Value, visiting)
+	}
+	inlineRefs := func(refs openapi3.SchemaRefs) []interface{} {
+		result := make([]interface{}, len(refs))
+		for i := range refs {
+			result[i] = inlineRef(refs[i])
+		}
+		return result
+	}
+	if len(s.Properties) > 0 {
+		props := map[string]interface{}{}
+		for name, prop := range s.Properties {
+			props[name] = inlineRef(prop)
+		}
+		result["properties"] = props
+	}
+	if s.Items != nil {
+		result["items"] = inlineRef(s.Items)
+	}
+	if s.AdditionalProperties != nil {
+		result["additionalProperties"] = inlineRef(s.AdditionalProperties)
+	}
+	if s.Not != nil {
+		result["not"] = inlineRef(s.Not)
+	}
+	if len(s.AllOf) > 0 {
+		result["allOf"] = inlineRefs(s.AllOf)
+	}
+	if len(s.AnyOf) > 0 {
+		result["anyOf"] = inlineRefs(s.AnyOf)
+	}
+	if len(s.OneOf) > 0 {
+		result["oneOf"] = inlineRefs(s.OneOf)
+	}
+	return result
+}
+
+// postmanCollectionSchema identifies the version of the Postman collection
+// format written by postmanCollection.
+const postmanCollectionSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanTestScript checks that the status of a response is documented, and
+// that its body matches the documented schema. It is preceded by the
+// declaration of the responses of the operation, by status.
+var postmanTestScript = []string{
+	`var status = String(pm.response.code);`,
+	`if (!(status in responses)) { status = "default"; }`,
+	`pm.test("response status " + pm.response.code + " is documented", function () {`,
+	`    pm.expect(responses).to.have.property(status);`,
+	`});`,
+	`if (responses[status]) {`,
+	`    pm.test("response body matches the schema for " + status, function () {`,
+	`        pm.response.to.have.jsonSchema(responses[status]);`,
+	`    });`,
+	`}`,
+}
+
+// postmanCollection returns a Postman collection, to be run with newman, with
+// a request for each operation in a spec loaded with the "openapi" data
+// format. Each request has a test asserting that the response matches the
+// schema documented for its status. Requests are sent to {{baseUrl}}, with
+// path parameters left for the collection to fill in, and the version given
+// as the version query parameter, if not empty.
+func postmanCollection(name, version string, v interface{}) (string, error) {
+	ops, err := contractOperations(v)
+	if err != nil {
+		return "", err
+	}
+	items := []interface{}{}
+	for _, op := range ops {
+		segments := []string{}
+		for _, segment := range strings.Split(strings.TrimPrefix(op.Path, "/"), "/") {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				segment = ":" + segment[1:len(segment)-1]
+			}
+			segments = append(segments, segment)
+		}
+		url := map[string]interface{}{
+			"raw":  "{{baseUrl}}/" + strings.Join(segments, "/"),
+			"host": []string{"{{baseUrl}}"},
+			"path": segments,
+		}
+		if version != "" {
+			url["raw"] = url["raw"].(string) + "?version=" + version
+			url["query"] = []interface{}{map[string]string{"key": "version", "value": version}}
+		}
+		if len(op.PathParams) > 0 {
+			var vars []interface{}
+			for _, param := range op.PathParams {
+				vars = append(vars, map[string]string{"key": param, "value": ""})
+			}
+			url["variable"] = vars
+		}
+		responses := map[string]interface{}{}
+		for _, resp := range op.Responses {
+			responses[resp.Status] = resp.Schema
+		}
+		responsesJSON, err := json.Marshal(responses)
+		if err != nil {
+			return "", err
+		}
+		itemName := op.OperationID
+		if itemName == "" {
+			itemName = strings.ToUpper(op.Method) + " " + op.Path
+		}
+		items = append(items, map[string]interface{}{
+			"name": itemName,
+			"request": map[string]interface{}{
+				"method": op.Method,
+				"url":    url,
+			},
+			"event": []interface{}{map[string]interface{}{
+				"listen": "test",
+				"script": map[string]interface{}{
+					"type": "text/javascript",
+					"exec": append([]string{"var responses = " + string(responsesJSON) + ";"}, postmanTestScript...),
+				},
+			}},
+		})
+	}
+	buf, err := json.MarshalIndent(map[string]interface{}{
+		"info": map[string]string{
+			"name":   name,
+			"schema": postmanCollectionSchema,
+		},
+		"variable": []interface{}{map[string]string{"key": "baseUrl", "value": "http://localhost:8080"}},
+		"item":     items,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,180 @@
+package generator
+
+import (
+	"encoding/json"
+	"go/format"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/testdata"
+)
+
+var contractTestSpec = `
+openapi: 3.0.3
+info: {title: test, version: 1.0.0}
+paths:
+  /orgs/{orgId}/things:
+    post:
+      operationId: createThing
+      responses:
+        '201':
+          description: Created
+          content:
+            application/vnd.api+json:
+              schema: {$ref: '#/components/schemas/Thing'}
+        '204':
+          description: No content
+    get:
+      operationId: listThings
+      responses:
+        '200':
+          description: OK
+          content:
+            application/vnd.api+json:
+              schema:
+                type: object
+                properties:
+                  data:
+                    type: array
+                    items: {$ref: '#/components/schemas/Thing'}
+components:
+  schemas:
+    Thing:
+      type: object
+      properties:
+        id: {type: string}
+        parent: {$ref: '#/components/schemas/Thing'}
+      required: [id]
+`[1:]
+
+func TestContractOperations(t *testing.T) {
+	c := qt.New(t)
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(contractTestSpec))
+	c.Assert(err, qt.IsNil)
+	ops, err := contractOperations(doc)
+	c.Assert(err, qt.IsNil)
+	c.Assert(ops, qt.HasLen, 2)
+	c.Assert(ops[0].Method, qt.Equals, "GET")
+	c.Assert(ops[0].OperationID, qt.Equals, "listThings")
+	c.Assert(ops[0].PathParams, qt.DeepEquals, []string{"orgId"})
+	c.Assert(ops[1].OperationID, qt.Equals, "createThing")
+	c.Assert(ops[1].Responses, qt.HasLen, 2)
+	c.Assert(ops[1].Responses[0].Status, qt.Equals, "201")
+	c.Assert(ops[1].Responses[0].ContentType, qt.Equals, "application/vnd.api+json")
+	c.Assert(ops[1].Responses[1].Status, qt.Equals, "204")
+	c.Assert(ops[1].Responses[1].Schema, qt.IsNil)
+
+	// Response schemas are self-contained, and recursion is cut off.
+	buf, err := json.Marshal(ops[1].Responses[0].Schema)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.JSONEquals, map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id":     map[string]interface{}{"type": "string"},
+			"parent": map[string]interface{}{},
+		},
+		"required": []interface{}{"id"},
+	})
+
+	_, err = contractOperations(map[string]interface{}{})
+	c.Assert(err, qt.ErrorMatches, `contractOperations requires an OpenAPI document, got map\[string\]interface {}`)
+}
+
+func TestPostmanCollection(t *testing.T) {
+	c := qt.New(t)
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(contractTestSpec))
+	c.Assert(err, qt.IsNil)
+	out, err := postmanCollection("things", "2021-06-01", doc)
+	c.Assert(err, qt.IsNil)
+	var collection struct {
+		Info struct {
+			Name, Schema string
+		}
+		Item []struct {
+			Name    string
+			Request struct {
+				Method string
+				URL    struct {
+					Raw      string
+					Path     []string
+					Variable []map[string]string
+				}
+			}
+			Event []struct {
+				Listen string
+				Script struct {
+					Exec []string
+				}
+			}
+		}
+	}
+	c.Assert(json.Unmarshal([]byte(out), &collection), qt.IsNil)
+	c.Assert(collection.Info.Name, qt.Equals, "things")
+	c.Assert(collection.Info.Schema, qt.Equals, postmanCollectionSchema)
+	c.Assert(collection.Item, qt.HasLen, 2)
+	item := collection.Item[1]
+	c.Assert(item.Name, qt.Equals, "createThing")
+	c.Assert(item.Request.Method, qt.Equals, "POST")
+	c.Assert(item.Request.URL.Raw, qt.Equals, "{{baseUrl}}/orgs/:orgId/things?version=2021-06-01")
+	c.Assert(item.Request.URL.Path, qt.DeepEquals, []string{"orgs", ":orgId", "things"})
+	c.Assert(item.Request.URL.Variable, qt.DeepEquals, []map[string]string{{"key": "orgId", "value": ""}})
+	c.Assert(item.Event, qt.HasLen, 1)
+	c.Assert(item.Event[0].Listen, qt.Equals, "test")
+	c.Assert(item.Event[0].Script.Exec[0], qt.Matches, `var responses = \{"201":\{.*\},"204":null\};`)
+}
+
+func TestContractTestGenerator(t *testing.T) {
+	c := qt.New(t)
+	dir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "contract_test.go.tmpl"), []byte(`
+package contract
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+{{ $version := .Version }}
+{{- range contractOperations .Data.Spec }}
+func Test{{ .OperationID | capitalize }}(t *testing.T) {
+	req := httptest.NewRequest("{{ .Method }}", "{{ .Path }}?version={{ $version }}", nil)
+	assertContract(t, req)
+}
+{{ end -}}
+`[1:]), 0666), qt.IsNil)
+
+	g, err := New(&config.Generator{
+		Name:     "contract-tests",
+		Scope:    config.GeneratorScopeVersion,
+		Filename: filepath.Join(dir, "{{ .Resource }}_{{ .Version }}_test.go"),
+		Template: filepath.Join(dir, "contract_test.go.tmpl"),
+		Data: map[string]*config.GeneratorData{
+			"Spec": {
+				Include: testdata.Path("resources/_examples/{{ .Resource }}/{{ .Version }}/spec.yaml"),
+				Format:  config.GeneratorDataFormatOpenAPI,
+			},
+		},
+	})
+	c.Assert(err, qt.IsNil)
+	err = g.Run(&VersionScope{
+		API:       "testdata",
+		Resource:  "hello-world",
+		Version:   "2021-06-13",
+		Stability: "beta",
+	})
+	c.Assert(err, qt.IsNil)
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "hello-world_2021-06-13_test.go"))
+	c.Assert(err, qt.IsNil)
+	_, err = format.Source(contents)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(contents), qt.Contains, `
+func TestHelloWorldCreate(t *testing.T) {
+	req := httptest.NewRequest("POST", "/examples/hello-world?version=2021-06-13", nil)
+	assertContract(t, req)
+}
+`)
+}
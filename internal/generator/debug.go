@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	excerptContextLines  = 2
+	renderedContextLines = 5
+)
+
+// templateError describes a generator template failure, with an excerpt of
+// the template source where it failed and the output rendered prior to
+// failure, if these can be determined.
+type templateError struct {
+	err      error
+	name     string
+	line     int
+	excerpt  string
+	rendered string
+}
+
+// Error implements error.
+func (e *templateError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(e.err.Error())
+	if e.excerpt != "" {
+		fmt.Fprintf(&sb, "\n%s, line %d:\n%s", e.name, e.line, e.excerpt)
+	}
+	if e.rendered != "" {
+		fmt.Fprintf(&sb, "\nrendered output prior to failure:\n%s", e.rendered)
+	}
+	return sb.String()
+}
+
+// Unwrap returns the underlying template error.
+func (e *templateError) Unwrap() error {
+	return e.err
+}
+
+var (
+	templateLocationRE = regexp.MustCompile(`template: ([^:]+):(\d+)`)
+	yamlLocationRE     = regexp.MustCompile(`yaml: line (\d+)`)
+)
+
+// newTemplateError annotates a text/template parse or execution error with
+// the location of the failure in the template sources, which are keyed by
+// template name. rendered is the output written by the template prior to the
+// failure, if any.
+func newTemplateError(err error, sources map[string]string, rendered []byte) error {
+	if te := (*templateError)(nil); errors.As(err, &te) {
+		return err
+	}
+	te := &templateError{err: err, rendered: tail(string(rendered), renderedContextLines)}
+	if m := templateLocationRE.FindStringSubmatch(err.Error()); m != nil {
+		if source, ok := sources[m[1]]; ok {
+			te.name = m[1]
+			te.line, _ = strconv.Atoi(m[2])
+			te.excerpt = excerpt(source, te.line)
+		}
+	}
+	return te
+}
+
+// newRenderedError annotates an error parsing the YAML output rendered by a
+// template, with an excerpt of the output where parsing failed.
+func newRenderedError(err error, name string, rendered []byte) error {
+	te := &templateError{err: err}
+	if m := yamlLocationRE.FindStringSubmatch(err.Error()); m != nil {
+		te.name = name + " (rendered)"
+		te.line, _ = strconv.Atoi(m[1])
+		te.excerpt = excerpt(string(rendered), te.line)
+	}
+	return te
+}
+
+// excerpt returns the lines of s surrounding line number lineno, with line
+// numbers and the line itself marked.
+func excerpt(s string, lineno int) string {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if lineno < 1 || lineno > len(lines) {
+		return ""
+	}
+	start, end := lineno-excerptContextLines, lineno+excerptContextLines
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	width := len(strconv.Itoa(end))
+	var sb strings.Builder
+	for i := start; i <= end; i++ {
+		marker := " "
+		if i == lineno {
+			marker = ">"
+		}
+		fmt.Fprintf(&sb, "%s %*d | %s\n", marker, width, i, lines[i-1])
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// tail returns the last n lines of s.
+func tail(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// dump writes an intermediate rendering of a template to the debug templates
+// directory, if one is configured.
+func (g *Generator) dump(scope templateScope, name string, contents []byte) {
+	if g.debugTemplatesDir == "" {
+		return
+	}
+	dumpPath := filepath.Join(g.debugTemplatesDir, g.name, scope.dumpPath(), name)
+	err := os.MkdirAll(filepath.Dir(dumpPath), 0777)
+	if err == nil {
+		err = ioutil.WriteFile(dumpPath, contents, 0666)
+	}
+	if err != nil {
+		log.Printf("warning: failed to dump rendered template: %v", err)
+		return
+	}
+	log.Printf("rendered generators.%s.%s => %s", g.name, name, dumpPath)
+}
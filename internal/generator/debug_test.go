@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+)
+
+func TestTemplateError(t *testing.T) {
+	c := qt.New(t)
+	dir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "template.tmpl"), []byte(`
+line one
+{{ .Resource }} is fine
+{{ .Nope.Nope }}
+line four
+`[1:]), 0666), qt.IsNil)
+	debugDir := c.Mkdir()
+	g, err := New(&config.Generator{
+		Name:     "broken",
+		Scope:    config.GeneratorScopeVersion,
+		Filename: filepath.Join(dir, "out"),
+		Template: filepath.Join(dir, "template.tmpl"),
+	}, DebugTemplates(debugDir))
+	c.Assert(err, qt.IsNil)
+	err = g.Run(&VersionScope{
+		API:       "someapi",
+		Resource:  "somerc",
+		Version:   "2021-09-01",
+		Stability: "beta",
+	})
+	c.Assert(err, qt.ErrorMatches, `(?s)template failed: template: contents:3:.*
+contents, line 3:
+  1 \| line one
+  2 \| {{ .Resource }} is fine
+> 3 \| {{ .Nope.Nope }}
+  4 \| line four
+rendered output prior to failure:
+line one
+somerc is fine.*`)
+
+	// Partially rendered output is available for troubleshooting.
+	contents, err := ioutil.ReadFile(filepath.Join(debugDir, "broken", "someapi", "somerc", "2021-09-01", "contents"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(contents), qt.Equals, "line one\nsomerc is fine\n")
+
+	// Nothing written to the target file on failure.
+	_, err = ioutil.ReadFile(filepath.Join(dir, "out"))
+	c.Assert(err, qt.ErrorMatches, ".*no such file or directory")
+}
+
+func TestTemplateParseError(t *testing.T) {
+	c := qt.New(t)
+	dir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "template.tmpl"), []byte("ok\n{{ if }}\n"), 0666), qt.IsNil)
+	_, err := New(&config.Generator{
+		Name:     "broken",
+		Scope:    config.GeneratorScopeVersion,
+		Filename: filepath.Join(dir, "out"),
+		Template: filepath.Join(dir, "template.tmpl"),
+	})
+	c.Assert(err, qt.ErrorMatches, `(?s)template: contents:2: missing value for if
+contents, line 2:
+  1 \| ok
+> 2 \| {{ if }}: \(generators\.broken\.contents\)`)
+}
+
+func TestRenderedError(t *testing.T) {
+	c := qt.New(t)
+	dir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "template.tmpl"), []byte("foo"), 0666), qt.IsNil)
+	g, err := New(&config.Generator{
+		Name:     "broken",
+		Scope:    config.GeneratorScopeVersion,
+		Files:    "a: b\nc: d\n  e: f\n",
+		Template: filepath.Join(dir, "template.tmpl"),
+	})
+	c.Assert(err, qt.IsNil)
+	err = g.Run(&VersionScope{
+		API:       "someapi",
+		Resource:  "somerc",
+		Version:   "2021-09-01",
+		Stability: "beta",
+	})
+	c.Assert(err, qt.ErrorMatches, `(?s)failed to load output as yaml: .*yaml: line 3: .*
+files \(rendered\), line 3:
+  1 \| a: b
+  2 \| c: d
+> 3 \|   e: f.*`)
+}
@@ -8,24 +8,32 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/ghodss/yaml"
 	"github.com/snyk/vervet"
 	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/releasenotes"
 )
 
 // Generator generates files for new resources from data models and templates.
 type Generator struct {
 	name     string
+	scope    config.GeneratorScope
+	builtin  string
 	filename *template.Template
 	contents *template.Template
 	files    *template.Template
-	data     map[string]*template.Template
+	data     map[string]*generatorData
+	sources  map[string]string
 
-	debug bool
-	force bool
+	debug             bool
+	debugTemplatesDir string
+	force             bool
 }
 
 var (
@@ -59,7 +67,12 @@ var (
 			}
 			return s
 		},
-		"replaceall": strings.ReplaceAll,
+		"replaceall":      strings.ReplaceAll,
+		"terraformName":   terraformName,
+		"terraformSchema": terraformSchema,
+
+		"contractOperations": contractOperations,
+		"postmanCollection":  postmanCollection,
 	}
 )
 
@@ -92,42 +105,68 @@ func NewMap(proj *config.Project, options ...Option) (map[string]*Generator, err
 // New returns a new Generator from config.
 func New(conf *config.Generator, options ...Option) (*Generator, error) {
 	g := &Generator{
-		name: conf.Name,
-		data: map[string]*template.Template{},
+		name:    conf.Name,
+		scope:   conf.Scope,
+		builtin: conf.Builtin,
+		data:    map[string]*generatorData{},
+		sources: map[string]string{},
 	}
 	for i := range options {
 		options[i](g)
 	}
+	if g.scope == config.GeneratorScopeDefault {
+		g.scope = config.GeneratorScopeVersion
+	}
+	if g.scope == config.GeneratorScopeResource || g.builtin == config.GeneratorBuiltinResourceReadme {
+		// Files generated for a resource, such as its README, are out of
+		// date as soon as there is a new version, so these are always
+		// regenerated.
+		g.force = true
+	}
 	if g.debug {
 		log.Printf("generator %s: debug logging enabled", g.name)
 	}
 
-	contentsTemplate, err := ioutil.ReadFile(conf.Template)
-	if err != nil {
-		return nil, fmt.Errorf("%w: (generators.%s.contents)", err, conf.Name)
+	var contentsTemplate []byte
+	var err error
+	if conf.Template == "" && conf.Builtin == config.GeneratorBuiltinResourceReadme {
+		contentsTemplate = []byte(releasenotes.ReadmeTemplate)
+	} else {
+		contentsTemplate, err = ioutil.ReadFile(conf.Template)
+		if err != nil {
+			return nil, fmt.Errorf("%w: (generators.%s.contents)", err, conf.Name)
+		}
 	}
+	g.sources["contents"] = string(contentsTemplate)
 	g.contents, err = template.New("contents").Funcs(templateFuncs).Parse(string(contentsTemplate))
 	if err != nil {
-		return nil, fmt.Errorf("%w: (generators.%s.contents)", err, conf.Name)
+		return nil, fmt.Errorf("%w: (generators.%s.contents)", newTemplateError(err, g.sources, nil), conf.Name)
 	}
 	if conf.Filename != "" {
+		g.sources["filename"] = conf.Filename
 		g.filename, err = template.New("filename").Funcs(templateFuncs).Parse(conf.Filename)
 		if err != nil {
-			return nil, fmt.Errorf("%w: (generators.%s.filename)", err, conf.Name)
+			return nil, fmt.Errorf("%w: (generators.%s.filename)", newTemplateError(err, g.sources, nil), conf.Name)
 		}
 	}
 	if conf.Files != "" {
+		g.sources["files"] = conf.Files
 		g.files, err = withIncludeFunc(g.contents.New("files")).Parse(conf.Files)
 		if err != nil {
-			return nil, fmt.Errorf("%w: (generators.%s.files)", err, conf.Name)
+			return nil, fmt.Errorf("%w: (generators.%s.files)", newTemplateError(err, g.sources, nil), conf.Name)
 		}
 	}
 	if len(conf.Data) > 0 {
 		for fieldName, genData := range conf.Data {
-			g.data[fieldName], err = template.New("include").Funcs(templateFuncs).Parse(genData.Include)
+			d := &generatorData{
+				format:  genData.Format,
+				sources: map[string]string{"include": genData.Include},
+			}
+			d.include, err = template.New("include").Funcs(templateFuncs).Parse(genData.Include)
 			if err != nil {
-				return nil, fmt.Errorf("%w: (generators.%s.data.%s.include)", err, conf.Name, fieldName)
+				return nil, fmt.Errorf("%w: (generators.%s.data.%s.include)", newTemplateError(err, d.sources, nil), conf.Name, fieldName)
 			}
+			g.data[fieldName] = d
 		}
 	}
 	return g, nil
@@ -143,6 +182,14 @@ func Force(force bool) Option {
 	}
 }
 
+// DebugTemplates configures the Generator to write the intermediate output
+// rendered by its templates into files under dir, for troubleshooting.
+func DebugTemplates(dir string) Option {
+	return func(g *Generator) {
+		g.debugTemplatesDir = dir
+	}
+}
+
 // Debug turns on template debug logging.
 func Debug(debug bool) Option {
 	return func(g *Generator) {
@@ -157,6 +204,14 @@ type VersionScope struct {
 	Resource  string
 	Version   string
 	Stability string
+
+	// ResourcePath is the directory of the resource, containing its version
+	// directories.
+	ResourcePath string
+
+	// Specs is the filename pattern of the spec in each version directory of
+	// the resource, config.DefaultSpecs if empty.
+	Specs string
 }
 
 func (s *VersionScope) validate() error {
@@ -176,64 +231,172 @@ type versionScope struct {
 	Data map[string]interface{}
 }
 
-// Run executes the Generator. If generated artifacts already exist, a warning
-// is logged but the file is not overwritten, unless force is true.
+func (s *versionScope) dumpPath() string {
+	return filepath.Join(s.API, s.Resource, s.Version)
+}
+
+// ResourceScope identifies a resource that the generator is building for,
+// across all of its versions.
+type ResourceScope struct {
+	API      string
+	Resource string
+
+	// ResourcePath is the directory of the resource, containing its version
+	// directories.
+	ResourcePath string
+
+	// Specs is the filename pattern of the spec in each version directory of
+	// the resource, config.DefaultSpecs if empty.
+	Specs string
+}
+
+// ResourceVersion is a version of a resource, as given to resource scope
+// generator templates.
+type ResourceVersion struct {
+	// Version is the version, as named by its directory.
+	Version   string
+	Stability string
+
+	// Spec is the spec of the version, with its references resolved.
+	Spec *openapi3.T
+}
+
+type resourceScope struct {
+	*ResourceScope
+
+	// Versions are the versions of the resource, from oldest to newest.
+	Versions []*ResourceVersion
+	Data     map[string]interface{}
+}
+
+func (s *resourceScope) dumpPath() string {
+	return filepath.Join(s.API, s.Resource)
+}
+
+// templateScope is the template data of a generator run.
+type templateScope interface {
+	// dumpPath returns the directory, relative to the debug templates
+	// directory, into which templates rendered for the run are dumped.
+	dumpPath() string
+}
+
+// Scope returns the scope of the Generator: whether it generates files for
+// each resource version, or for each resource.
+func (g *Generator) Scope() config.GeneratorScope {
+	return g.scope
+}
+
+// Run executes a version scope Generator. If generated artifacts already
+// exist, a warning is logged but the file is not overwritten, unless force is
+// true.
 func (g *Generator) Run(scope *VersionScope) error {
+	if g.scope != config.GeneratorScopeVersion {
+		return fmt.Errorf("generator %s has %s scope, not version scope", g.name, g.scope)
+	}
 	err := scope.validate()
 	if err != nil {
 		return err
 	}
+	data, err := g.loadData(scope)
+	if err != nil {
+		return err
+	}
+	if g.builtin == config.GeneratorBuiltinResourceReadme {
+		rv, err := loadResourceVersions(scope.Resource, scope.ResourcePath, scope.Specs)
+		if err != nil {
+			return fmt.Errorf("%w (generators.%s.builtin)", err, g.name)
+		}
+		data["History"], err = loadHistory(scope.Resource, rv)
+		if err != nil {
+			return fmt.Errorf("%w (generators.%s.builtin)", err, g.name)
+		}
+	}
+	return g.render(&versionScope{
+		VersionScope: scope,
+		Data:         data,
+	})
+}
+
+// RunResource executes a resource scope Generator, with all the versions of
+// the resource in its template data. Generated artifacts are always
+// overwritten, as these cover every version of the resource.
+func (g *Generator) RunResource(scope *ResourceScope) error {
+	if g.scope != config.GeneratorScopeResource {
+		return fmt.Errorf("generator %s has %s scope, not resource scope", g.name, g.scope)
+	}
+	data, err := g.loadData(scope)
+	if err != nil {
+		return err
+	}
+	rv, err := loadResourceVersions(scope.Resource, scope.ResourcePath, scope.Specs)
+	if err != nil {
+		return fmt.Errorf("%w (generators.%s)", err, g.name)
+	}
+	var versions []*ResourceVersion
+	for _, version := range rv.Versions() {
+		rc, err := rv.At(version.String())
+		if err != nil {
+			return fmt.Errorf("%w (generators.%s)", err, g.name)
+		}
+		versions = append(versions, &ResourceVersion{
+			Version:   filepath.Base(rc.RelativePath()),
+			Stability: version.Stability.String(),
+			Spec:      rc.T,
+		})
+	}
+	if g.builtin == config.GeneratorBuiltinResourceReadme {
+		data["History"], err = loadHistory(scope.Resource, rv)
+		if err != nil {
+			return fmt.Errorf("%w (generators.%s.builtin)", err, g.name)
+		}
+	}
+	return g.render(&resourceScope{
+		ResourceScope: scope,
+		Versions:      versions,
+		Data:          data,
+	})
+}
 
-	// Derive data
+// loadData loads the data included in the generator's template data, with
+// the paths of its files interpolated from scope.
+func (g *Generator) loadData(scope interface{}) (map[string]interface{}, error) {
 	data := map[string]interface{}{}
-	for fieldName, tmpl := range g.data {
+	for fieldName, genData := range g.data {
 		var buf bytes.Buffer
-		err := tmpl.ExecuteTemplate(&buf, "include", scope)
+		err := genData.include.ExecuteTemplate(&buf, "include", scope)
 		if err != nil {
-			return fmt.Errorf("failed to resolve filename: %w (generators.%s.data.%s.include)", err, g.name, fieldName)
+			return nil, fmt.Errorf("failed to resolve filename: %w (generators.%s.data.%s.include)",
+				newTemplateError(err, genData.sources, buf.Bytes()), g.name, fieldName)
 		}
 		filename := strings.TrimSpace(buf.String())
 		if g.debug {
 			log.Printf("interpolated generators.%s.data.%s.include => %q", g.name, fieldName, filename)
 		}
-		contents, err := ioutil.ReadFile(filename)
+		fieldValue, err := genData.load(filename)
 		if err != nil {
-			return fmt.Errorf("%w (generators.%s.data.%s.include)", err, g.name, fieldName)
-		}
-		fieldValue := map[string]interface{}{}
-		switch filepath.Ext(filename) {
-		case ".yaml":
-			err = yaml.Unmarshal(contents, &fieldValue)
-			if err != nil {
-				return fmt.Errorf("failed to load %q: %w (generators.%s.data.%s.include)", filename, err, g.name, fieldName)
-			}
-		case ".json":
-			err = json.Unmarshal(contents, &fieldValue)
-			if err != nil {
-				return fmt.Errorf("failed to load %q: %w (generators.%s.data.%s.include)", filename, err, g.name, fieldName)
-			}
-		default:
-			return fmt.Errorf("don't know how to load %q: %w (generators.%s.data.%s.include)", filename, err, g.name, fieldName)
+			return nil, fmt.Errorf("%w (generators.%s.data.%s.include)", err, g.name, fieldName)
 		}
 		data[fieldName] = fieldValue
 	}
-	gsc := &versionScope{
-		VersionScope: scope,
-		Data:         data,
-	}
+	return data, nil
+}
+
+func (g *Generator) render(scope templateScope) error {
 	if g.files != nil {
-		return g.runFiles(gsc)
+		return g.runFiles(scope)
 	}
-	return g.runFile(gsc)
+	return g.runFile(scope)
 }
 
-func (g *Generator) runFile(scope *versionScope) error {
+func (g *Generator) runFile(scope templateScope) error {
 	var filenameBuf bytes.Buffer
 	err := g.filename.ExecuteTemplate(&filenameBuf, "filename", scope)
 	if err != nil {
-		return fmt.Errorf("failed to resolve filename: %w (generators.%s.filename)", err, g.name)
+		return fmt.Errorf("failed to resolve filename: %w (generators.%s.filename)",
+			newTemplateError(err, g.sources, filenameBuf.Bytes()), g.name)
 	}
 	filename := filenameBuf.String()
+	g.dump(scope, "filename", filenameBuf.Bytes())
 	if g.debug {
 		log.Printf("interpolated generators.%s.filename => %q", g.name, filename)
 	}
@@ -241,28 +404,31 @@ func (g *Generator) runFile(scope *versionScope) error {
 		log.Printf("not overwriting existing file %q", filename)
 		return nil
 	}
+	var contentsBuf bytes.Buffer
+	err = g.contents.ExecuteTemplate(&contentsBuf, "contents", scope)
+	g.dump(scope, "contents", contentsBuf.Bytes())
+	if err != nil {
+		return fmt.Errorf("template failed: %w (generators.%s.filename)",
+			newTemplateError(err, g.sources, contentsBuf.Bytes()), g.name)
+	}
 	parentDir := filepath.Dir(filename)
 	err = os.MkdirAll(parentDir, 0777)
 	if err != nil {
 		return fmt.Errorf("failed to create %q: %w: (generators.%s.filename)", parentDir, err, g.name)
 	}
-	f, err := os.Create(filename)
+	err = ioutil.WriteFile(filename, contentsBuf.Bytes(), 0666)
 	if err != nil {
-		return fmt.Errorf("failed to create %q: %w: (generators.%s.filename)", filename, err, g.name)
-	}
-	defer f.Close()
-	err = g.contents.ExecuteTemplate(f, "contents", scope)
-	if err != nil {
-		return fmt.Errorf("template failed: %w (generators.%s.filename)", err, g.name)
+		return fmt.Errorf("failed to write %q: %w: (generators.%s.filename)", filename, err, g.name)
 	}
 	return nil
 }
 
-func (g *Generator) runFiles(scope *versionScope) error {
+func (g *Generator) runFiles(scope templateScope) error {
 	var filesBuf bytes.Buffer
 	err := g.files.ExecuteTemplate(&filesBuf, "files", scope)
+	g.dump(scope, "files.yaml", filesBuf.Bytes())
 	if err != nil {
-		return fmt.Errorf("%w: (generators.%s.files)", err, g.name)
+		return fmt.Errorf("%w: (generators.%s.files)", newTemplateError(err, g.sources, filesBuf.Bytes()), g.name)
 	}
 	if g.debug {
 		log.Printf("interpolated generators.%s.files => %q", g.name, filesBuf.String())
@@ -270,8 +436,8 @@ func (g *Generator) runFiles(scope *versionScope) error {
 	files := map[string]string{}
 	err = yaml.Unmarshal(filesBuf.Bytes(), &files)
 	if err != nil {
-		// TODO: dump output for debugging?
-		return fmt.Errorf("failed to load output as yaml: %w: (generators.%s.files)", err, g.name)
+		return fmt.Errorf("failed to load output as yaml: %w: (generators.%s.files)",
+			newRenderedError(err, "files", filesBuf.Bytes()), g.name)
 	}
 	for filename, contents := range files {
 		dir := filepath.Dir(filename)
@@ -290,3 +456,114 @@ func (g *Generator) runFiles(scope *versionScope) error {
 	}
 	return nil
 }
+
+type generatorData struct {
+	include *template.Template
+	format  config.GeneratorDataFormat
+	sources map[string]string
+}
+
+// load loads the data from filename into a value for use in templates. If
+// filename is a glob pattern, a list of the values loaded from each matching
+// file is returned.
+func (d *generatorData) load(filename string) (interface{}, error) {
+	if !isGlob(filename) {
+		return d.loadFile(filename)
+	}
+	base, pattern := doublestar.SplitPattern(filename)
+	matches, err := doublestar.Glob(os.DirFS(base), pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match %q: %w", filename, err)
+	}
+	sort.Strings(matches)
+	result := []interface{}{}
+	for i := range matches {
+		value, err := d.loadFile(filepath.Join(base, matches[i]))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+func (d *generatorData) loadFile(filename string) (interface{}, error) {
+	format := d.format
+	if format == config.GeneratorDataFormatOpenAPI {
+		doc, err := vervet.NewDocumentFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %q: %w", filename, err)
+		}
+		return doc.T, nil
+	}
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if format == config.GeneratorDataFormatDefault {
+		switch filepath.Ext(filename) {
+		case ".yaml", ".yml":
+			format = config.GeneratorDataFormatYAML
+		case ".json":
+			format = config.GeneratorDataFormatJSON
+		default:
+			return nil, fmt.Errorf("don't know how to load %q", filename)
+		}
+	}
+	var value interface{}
+	switch format {
+	case config.GeneratorDataFormatYAML:
+		err = yaml.Unmarshal(contents, &value)
+	case config.GeneratorDataFormatJSON:
+		err = json.Unmarshal(contents, &value)
+	case config.GeneratorDataFormatText:
+		value = string(contents)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %q: %w", filename, err)
+	}
+	return value, nil
+}
+
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[{")
+}
+
+// loadResourceVersions loads the versions of a resource from the spec in each
+// of its version directories.
+func loadResourceVersions(resource, resourcePath, specs string) (*vervet.ResourceVersions, error) {
+	if resourcePath == "" {
+		return nil, fmt.Errorf("resource path required to load the versions of %q", resource)
+	}
+	if specs == "" {
+		specs = config.DefaultSpecs
+	}
+	matches, err := doublestar.Glob(os.DirFS(resourcePath), "*/"+specs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match %q: %w", specs, err)
+	}
+	var specFiles []string
+	for _, match := range matches {
+		specFiles = append(specFiles, filepath.Join(resourcePath, filepath.FromSlash(match)))
+	}
+	rv, err := vervet.LoadResourceVersionsFileset(specFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load resource versions: %w", err)
+	}
+	return rv, nil
+}
+
+// loadHistory returns the history of the resource a generator is building
+// for.
+func loadHistory(resource string, rv *vervet.ResourceVersions) (*releasenotes.History, error) {
+	history, err := releasenotes.NewHistory(rv)
+	if err != nil {
+		return nil, err
+	}
+	if history.Resource == "" {
+		history.Resource = resource
+	}
+	return history, nil
+}
@@ -2,6 +2,7 @@ package generator
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -91,3 +92,99 @@ func TestVersionScope(t *testing.T) {
 	}
 	c.Assert(s.validate(), qt.ErrorMatches, `invalid stability "shaky"`)
 }
+
+func TestGeneratorData(t *testing.T) {
+	c := qt.New(t)
+	dir := c.Mkdir()
+	c.Assert(os.MkdirAll(filepath.Join(dir, "owners"), 0777), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "owners", "b.yaml"), []byte("team: bar\n"), 0666), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "owners", "a.json"), []byte(`{"team": "foo"}`), 0666), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "notice"), []byte("Copyright"), 0666), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "template.tmpl"), []byte(
+		`{{ .Data.Notice }}{{ range .Data.Owners }} {{ .team }}{{ end }}`), 0666), qt.IsNil)
+
+	g, err := New(&config.Generator{
+		Name:     "owners",
+		Scope:    config.GeneratorScopeVersion,
+		Filename: filepath.Join(dir, "out", "{{ .Resource }}"),
+		Template: filepath.Join(dir, "template.tmpl"),
+		Data: map[string]*config.GeneratorData{
+			"Owners": {Include: filepath.Join(dir, "owners", "*.{json,yaml}")},
+			"Notice": {Include: filepath.Join(dir, "notice"), Format: config.GeneratorDataFormatText},
+		},
+	})
+	c.Assert(err, qt.IsNil)
+	err = g.Run(&VersionScope{
+		API:       "someapi",
+		Resource:  "somerc",
+		Version:   "2021-09-01",
+		Stability: "beta",
+	})
+	c.Assert(err, qt.IsNil)
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "out", "somerc"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(contents), qt.Equals, "Copyright foo bar")
+}
+
+const resourceScopeSpec = `
+openapi: 3.0.3
+x-snyk-api-stability: %s
+info:
+  title: foo
+  version: 3.0.0
+paths:
+  /foo:
+    get:
+      operationId: %s
+      responses:
+        '200':
+          description: OK
+`
+
+func TestResourceScope(t *testing.T) {
+	c := qt.New(t)
+	dir := c.Mkdir()
+	resourceDir := filepath.Join(dir, "resources", "foo")
+	for _, v := range []struct{ version, stability, operationID string }{
+		{"2021-10-01", "ga", "listFoo"},
+		{"2021-09-01", "beta", "getFoos"},
+	} {
+		versionDir := filepath.Join(resourceDir, v.version)
+		c.Assert(os.MkdirAll(versionDir, 0777), qt.IsNil)
+		c.Assert(ioutil.WriteFile(filepath.Join(versionDir, "spec.yaml"),
+			[]byte(fmt.Sprintf(resourceScopeSpec[1:], v.stability, v.operationID)), 0666), qt.IsNil)
+	}
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "routes.tmpl"), []byte(
+		`{{ range .Versions }}{{ $.Resource }} {{ .Version }}~{{ .Stability }} {{ (index .Spec.Paths "/foo").Get.OperationID }}
+{{ end }}`), 0666), qt.IsNil)
+
+	g, err := New(&config.Generator{
+		Name:     "routes",
+		Scope:    config.GeneratorScopeResource,
+		Filename: filepath.Join(dir, "out", "{{ .Resource }}", "routes"),
+		Template: filepath.Join(dir, "routes.tmpl"),
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(g.Scope(), qt.Equals, config.GeneratorScope(config.GeneratorScopeResource))
+	err = g.RunResource(&ResourceScope{
+		API:          "someapi",
+		Resource:     "foo",
+		ResourcePath: resourceDir,
+	})
+	c.Assert(err, qt.IsNil)
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "out", "foo", "routes"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(contents), qt.Equals, `
+foo 2021-09-01~beta getFoos
+foo 2021-10-01~ga listFoo
+`[1:])
+
+	// Generators only run in their own scope.
+	err = g.Run(&VersionScope{
+		API:       "someapi",
+		Resource:  "foo",
+		Version:   "2021-10-01",
+		Stability: "ga",
+	})
+	c.Assert(err, qt.ErrorMatches, `generator routes has resource scope, not version scope`)
+}
@@ -0,0 +1,218 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Modes of schema generated by terraformSchema.
+const (
+	terraformResource   = "resource"
+	terraformDataSource = "data-source"
+)
+
+// terraformName returns a name in the snake_case used for Terraform
+// resources and attributes, such as org_id for orgId.
+func terraformName(s string) string {
+	var sb strings.Builder
+	prevLower := false
+	for _, r := range s {
+		switch {
+		case r == '-' || r == '.' || r == ' ' || r == '_':
+			if sb.Len() > 0 {
+				sb.WriteByte('_')
+			}
+			prevLower = false
+		case unicode.IsUpper(r):
+			if prevLower {
+				sb.WriteByte('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+			prevLower = false
+		default:
+			sb.WriteRune(r)
+			prevLower = unicode.IsLower(r) || unicode.IsDigit(r)
+		}
+	}
+	return sb.String()
+}
+
+// terraformSchema returns Go source declaring Terraform plugin SDK schema
+// attributes for the properties of an object schema, as a
+// map[string]*schema.Schema. The schema is an OpenAPI schema from a document
+// loaded with the "openapi" data format.
+//
+// In "resource" mode, required properties are required, read-only properties
+// are computed and all others are optional. In "data-source" mode, all
+// attributes are computed.
+func terraformSchema(mode string, v interface{}) (string, error) {
+	if mode != terraformResource && mode != terraformDataSource {
+		return "", fmt.Errorf("invalid terraform schema mode %q, expected %q or %q",
+			mode, terraformResource, terraformDataSource)
+	}
+	var s *openapi3.Schema
+	switch vs := v.(type) {
+	case *openapi3.SchemaRef:
+		if vs != nil {
+			s = vs.Value
+		}
+	case *openapi3.Schema:
+		s = vs
+	}
+	if s == nil {
+		return "", fmt.Errorf("terraformSchema requires an OpenAPI schema, got %T", v)
+	}
+	var sb strings.Builder
+	w := &terraformWriter{mode: mode, sb: &sb, visiting: map[*openapi3.Schema]bool{}}
+	w.attributes(s, 0)
+	return sb.String(), nil
+}
+
+type terraformWriter struct {
+	mode     string
+	sb       *strings.Builder
+	visiting map[*openapi3.Schema]bool
+}
+
+func (w *terraformWriter) printf(depth int, format string, args ...interface{}) {
+	w.sb.WriteString(strings.Repeat("\t", depth))
+	fmt.Fprintf(w.sb, format, args...)
+}
+
+// properties returns the properties of an object schema, including those
+// composed with allOf, and which of these are required.
+func properties(s *openapi3.Schema) (openapi3.Schemas, map[string]bool) {
+	props, required := openapi3.Schemas{}, map[string]bool{}
+	for name, prop := range s.Properties {
+		props[name] = prop
+	}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	for _, ref := range s.AllOf {
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		allOfProps, allOfRequired := properties(ref.Value)
+		for name, prop := range allOfProps {
+			props[name] = prop
+		}
+		for name := range allOfRequired {
+			required[name] = true
+		}
+	}
+	return props, required
+}
+
+func (w *terraformWriter) attributes(s *openapi3.Schema, depth int) {
+	w.visiting[s] = true
+	defer delete(w.visiting, s)
+	props, required := properties(s)
+	var names []string
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w.sb.WriteString("map[string]*schema.Schema{\n")
+	for _, name := range names {
+		prop := props[name]
+		if prop == nil || prop.Value == nil {
+			continue
+		}
+		w.printf(depth+1, "%s: {\n", strconv.Quote(terraformName(name)))
+		w.attribute(prop.Value, required[name], depth+2)
+		w.printf(depth+1, "},\n")
+	}
+	w.printf(depth, "}")
+}
+
+func (w *terraformWriter) attribute(s *openapi3.Schema, required bool, depth int) {
+	w.printf(depth, "Type: %s,\n", w.typeOf(s))
+	if s.Description != "" {
+		w.printf(depth, "Description: %s,\n", strconv.Quote(strings.TrimSpace(s.Description)))
+	}
+	switch {
+	case w.mode == terraformDataSource || s.ReadOnly:
+		w.printf(depth, "Computed: true,\n")
+	case required:
+		w.printf(depth, "Required: true,\n")
+	default:
+		w.printf(depth, "Optional: true,\n")
+	}
+	if s.Deprecated {
+		w.printf(depth, "Deprecated: %s,\n", strconv.Quote("deprecated in the API"))
+	}
+	switch {
+	case w.isBlock(s):
+		// Nested objects are lists of a single block.
+		w.printf(depth, "MaxItems: 1,\n")
+		w.block(s, depth)
+	case s.Type == "array":
+		if s.Items != nil && s.Items.Value != nil {
+			w.elem(s.Items.Value, depth)
+		}
+	case w.typeOf(s) == "schema.TypeMap":
+		if s.AdditionalProperties != nil && s.AdditionalProperties.Value != nil {
+			w.elem(s.AdditionalProperties.Value, depth)
+		} else {
+			w.printf(depth, "Elem: &schema.Schema{Type: schema.TypeString},\n")
+		}
+	}
+}
+
+// elem declares the elements of a list or map attribute.
+func (w *terraformWriter) elem(s *openapi3.Schema, depth int) {
+	if w.isBlock(s) {
+		w.block(s, depth)
+		return
+	}
+	w.printf(depth, "Elem: &schema.Schema{Type: %s},\n", w.typeOf(s))
+}
+
+// block declares the attributes of a nested object.
+func (w *terraformWriter) block(s *openapi3.Schema, depth int) {
+	w.printf(depth, "Elem: &schema.Resource{\n")
+	w.printf(depth+1, "Schema: ")
+	w.attributes(s, depth+1)
+	w.sb.WriteString(",\n")
+	w.printf(depth, "},\n")
+}
+
+// isBlock returns whether a schema is an object declared as a nested block.
+func (w *terraformWriter) isBlock(s *openapi3.Schema) bool {
+	return s.Type != "array" && w.typeOf(s) == "schema.TypeList"
+}
+
+// typeOf returns the Terraform type of a schema. Objects with properties are
+// nested blocks, other objects are maps. Recursive schemas cannot be
+// expressed in Terraform, so these are declared as strings, which may hold
+// JSON.
+func (w *terraformWriter) typeOf(s *openapi3.Schema) string {
+	switch s.Type {
+	case "string":
+		return "schema.TypeString"
+	case "integer":
+		return "schema.TypeInt"
+	case "number":
+		return "schema.TypeFloat"
+	case "boolean":
+		return "schema.TypeBool"
+	case "array":
+		return "schema.TypeList"
+	}
+	if props, _ := properties(s); len(props) > 0 {
+		if w.visiting[s] {
+			return "schema.TypeString"
+		}
+		return "schema.TypeList"
+	}
+	if s.Type == "object" {
+		return "schema.TypeMap"
+	}
+	return "schema.TypeString"
+}
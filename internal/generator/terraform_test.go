@@ -0,0 +1,171 @@
+package generator
+
+import (
+	"go/format"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestTerraformName(t *testing.T) {
+	c := qt.New(t)
+	tests := []struct {
+		name, expected string
+	}{
+		{"orgId", "org_id"},
+		{"hello-world", "hello_world"},
+		{"HelloWorld", "hello_world"},
+		{"URL", "url"},
+		{"publicId2", "public_id2"},
+		{"already_snake", "already_snake"},
+	}
+	for _, test := range tests {
+		c.Check(terraformName(test.name), qt.Equals, test.expected)
+	}
+}
+
+func TestTerraformSchema(t *testing.T) {
+	c := qt.New(t)
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.3
+info: {title: test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Thing:
+      type: object
+      properties:
+        id:
+          type: string
+          readOnly: true
+        name:
+          type: string
+          description: The name of the thing.
+        count:
+          type: integer
+        tags:
+          type: array
+          items: {type: string}
+        labels:
+          type: object
+          additionalProperties: {type: string}
+        owner:
+          type: object
+          properties:
+            orgId: {type: string}
+          required: [orgId]
+      required: [name]
+`[1:]))
+	c.Assert(err, qt.IsNil)
+	thing := doc.Components.Schemas["Thing"]
+
+	out, err := terraformSchema("resource", thing)
+	c.Assert(err, qt.IsNil)
+	c.Assert(out, qt.Equals, `
+map[string]*schema.Schema{
+	"count": {
+		Type: schema.TypeInt,
+		Optional: true,
+	},
+	"id": {
+		Type: schema.TypeString,
+		Computed: true,
+	},
+	"labels": {
+		Type: schema.TypeMap,
+		Optional: true,
+		Elem: &schema.Schema{Type: schema.TypeString},
+	},
+	"name": {
+		Type: schema.TypeString,
+		Description: "The name of the thing.",
+		Required: true,
+	},
+	"owner": {
+		Type: schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"org_id": {
+					Type: schema.TypeString,
+					Required: true,
+				},
+			},
+		},
+	},
+	"tags": {
+		Type: schema.TypeList,
+		Optional: true,
+		Elem: &schema.Schema{Type: schema.TypeString},
+	},
+}`[1:])
+
+	out, err = terraformSchema("data-source", thing.Value)
+	c.Assert(err, qt.IsNil)
+	c.Assert(out, qt.Not(qt.Contains), "Required: true")
+	c.Assert(out, qt.Not(qt.Contains), "Optional: true")
+
+	_, err = terraformSchema("provider", thing)
+	c.Assert(err, qt.ErrorMatches, `invalid terraform schema mode "provider", expected "resource" or "data-source"`)
+	_, err = terraformSchema("resource", map[string]interface{}{})
+	c.Assert(err, qt.ErrorMatches, `terraformSchema requires an OpenAPI schema, got map\[string\]interface {}`)
+}
+
+func TestTerraformGenerator(t *testing.T) {
+	c := qt.New(t)
+	dir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "resource.go.tmpl"), []byte(`
+package provider
+
+func resource{{ replaceall .Resource "-" "" | capitalize }}() *schema.Resource {
+	return &schema.Resource{
+		Schema: {{ terraformSchema "resource" .Data.Spec.Components.Schemas.HelloWorld.Value.Properties.attributes }},
+	}
+}
+`[1:]), 0666), qt.IsNil)
+
+	g, err := New(&config.Generator{
+		Name:     "terraform",
+		Scope:    config.GeneratorScopeVersion,
+		Filename: filepath.Join(dir, "resource_{{ terraformName .Resource }}.go"),
+		Template: filepath.Join(dir, "resource.go.tmpl"),
+		Data: map[string]*config.GeneratorData{
+			"Spec": {
+				Include: testdata.Path("resources/_examples/{{ .Resource }}/{{ .Version }}/spec.yaml"),
+				Format:  config.GeneratorDataFormatOpenAPI,
+			},
+		},
+	})
+	c.Assert(err, qt.IsNil)
+	err = g.Run(&VersionScope{
+		API:       "testdata",
+		Resource:  "hello-world",
+		Version:   "2021-06-13",
+		Stability: "beta",
+	})
+	c.Assert(err, qt.IsNil)
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "resource_hello_world.go"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(contents), qt.Contains, "func resourceHelloworld() *schema.Resource {\n")
+	_, err = format.Source(contents)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(contents), qt.Contains, `
+	"request_subject": {
+		Type: schema.TypeList,
+		Required: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"client_id": {
+					Type: schema.TypeString,
+					Optional: true,
+				},
+`[1:])
+}
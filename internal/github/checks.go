@@ -0,0 +1,195 @@
+// Package github posts results to GitHub as Check Runs, so that they are
+// shown on pull requests alongside the changes that caused them.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// DefaultAPIURL is the GitHub API used when not otherwise configured.
+const DefaultAPIURL = "https://api.github.com"
+
+// maxAnnotations is the maximum number of annotations GitHub accepts in a
+// single request. Further annotations are added by updating the check run.
+const maxAnnotations = 50
+
+// Annotation levels.
+const (
+	LevelNotice  = "notice"
+	LevelWarning = "warning"
+	LevelFailure = "failure"
+)
+
+// Check run conclusions.
+const (
+	ConclusionSuccess = "success"
+	ConclusionFailure = "failure"
+)
+
+// A CheckRun is the result of a check on a commit.
+type CheckRun struct {
+	Name       string         `json:"name,omitempty"`
+	HeadSHA    string         `json:"head_sha,omitempty"`
+	Status     string         `json:"status,omitempty"`
+	Conclusion string         `json:"conclusion,omitempty"`
+	Output     CheckRunOutput `json:"output"`
+}
+
+// CheckRunOutput is the summary and annotations of a CheckRun.
+type CheckRunOutput struct {
+	Title       string       `json:"title"`
+	Summary     string       `json:"summary"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+// An Annotation attaches a result to a line of a file in a CheckRun.
+type Annotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
+}
+
+// Client posts check runs to a GitHub repository.
+type Client struct {
+	// APIURL is the GitHub API URL, DefaultAPIURL if empty.
+	APIURL string
+
+	// Token authenticates requests to the GitHub API.
+	Token string
+
+	// Repository is the owner and name of the repository, as in
+	// "snyk/vervet".
+	Repository string
+
+	// HTTPClient is used to make requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// NewClientFromEnv returns a Client and the commit to check, configured from
+// the environment of a GitHub Actions workflow. On pull requests, the head of
+// the pull request is checked rather than the merge commit being built.
+func NewClientFromEnv() (*Client, string, error) {
+	client := &Client{
+		APIURL:     os.Getenv("GITHUB_API_URL"),
+		Token:      os.Getenv("GITHUB_TOKEN"),
+		Repository: os.Getenv("GITHUB_REPOSITORY"),
+	}
+	if client.Token == "" {
+		return nil, "", fmt.Errorf("missing GitHub token (GITHUB_TOKEN)")
+	}
+	if client.Repository == "" {
+		return nil, "", fmt.Errorf("missing GitHub repository (GITHUB_REPOSITORY)")
+	}
+	headSHA := os.Getenv("GITHUB_SHA")
+	if eventPath := os.Getenv("GITHUB_EVENT_PATH"); eventPath != "" {
+		buf, err := ioutil.ReadFile(eventPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read GitHub event: %w", err)
+		}
+		var event struct {
+			PullRequest *struct {
+				Head struct {
+					SHA string `json:"sha"`
+				} `json:"head"`
+			} `json:"pull_request"`
+		}
+		err = json.Unmarshal(buf, &event)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse GitHub event: %w", err)
+		}
+		if event.PullRequest != nil && event.PullRequest.Head.SHA != "" {
+			headSHA = event.PullRequest.Head.SHA
+		}
+	}
+	if headSHA == "" {
+		return nil, "", fmt.Errorf("missing commit to check (GITHUB_SHA)")
+	}
+	return client, headSHA, nil
+}
+
+// CreateCheckRun creates a completed check run. Annotations beyond the
+// number GitHub accepts in one request are added by updating the check run.
+func (c *Client) CreateCheckRun(ctx context.Context, run *CheckRun) error {
+	annotations := run.Output.Annotations
+	create := *run
+	create.Status = "completed"
+	create.Output.Annotations = batch(&annotations)
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	err := c.do(ctx, http.MethodPost, "/repos/"+c.Repository+"/check-runs", &create, &created)
+	if err != nil {
+		return fmt.Errorf("failed to create check run: %w", err)
+	}
+	for len(annotations) > 0 {
+		update := &CheckRun{Output: CheckRunOutput{
+			Title:       run.Output.Title,
+			Summary:     run.Output.Summary,
+			Annotations: batch(&annotations),
+		}}
+		err = c.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/check-runs/%d", c.Repository, created.ID), update, nil)
+		if err != nil {
+			return fmt.Errorf("failed to update check run: %w", err)
+		}
+	}
+	return nil
+}
+
+// batch removes and returns the next batch of annotations to send.
+func batch(annotations *[]Annotation) []Annotation {
+	n := len(*annotations)
+	if n > maxAnnotations {
+		n = maxAnnotations
+	}
+	result := (*annotations)[:n]
+	*annotations = (*annotations)[n:]
+	return result
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, result interface{}) error {
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	apiURL := c.APIURL
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(apiURL, "/")+path, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(respBody))
+	}
+	if result != nil {
+		return json.Unmarshal(respBody, result)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+type request struct {
+	method, path, auth string
+	body               CheckRun
+}
+
+func TestCreateCheckRun(t *testing.T) {
+	c := qt.New(t)
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{method: r.Method, path: r.URL.Path, auth: r.Header.Get("Authorization")}
+		c.Check(json.NewDecoder(r.Body).Decode(&req.body), qt.IsNil)
+		requests = append(requests, req)
+		w.Write([]byte(`{"id": 42}`))
+	}))
+	c.Cleanup(srv.Close)
+
+	var annotations []Annotation
+	for i := 0; i < 60; i++ {
+		annotations = append(annotations, Annotation{
+			Path: "spec.yaml", StartLine: i + 1, EndLine: i + 1, AnnotationLevel: LevelFailure, Message: "oops",
+		})
+	}
+	client := &Client{APIURL: srv.URL, Token: "some-token", Repository: "snyk/vervet"}
+	err := client.CreateCheckRun(context.Background(), &CheckRun{
+		Name:       "vervet",
+		HeadSHA:    "abc123",
+		Conclusion: ConclusionFailure,
+		Output: CheckRunOutput{
+			Title:       "60 failures",
+			Summary:     "summary",
+			Annotations: annotations,
+		},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(requests, qt.HasLen, 2)
+
+	c.Assert(requests[0].method, qt.Equals, "POST")
+	c.Assert(requests[0].path, qt.Equals, "/repos/snyk/vervet/check-runs")
+	c.Assert(requests[0].auth, qt.Equals, "Bearer some-token")
+	c.Assert(requests[0].body.Status, qt.Equals, "completed")
+	c.Assert(requests[0].body.HeadSHA, qt.Equals, "abc123")
+	c.Assert(requests[0].body.Output.Annotations, qt.HasLen, 50)
+
+	// Remaining annotations are added to the check run created
+	c.Assert(requests[1].method, qt.Equals, "PATCH")
+	c.Assert(requests[1].path, qt.Equals, "/repos/snyk/vervet/check-runs/42")
+	c.Assert(requests[1].body.Output.Annotations, qt.HasLen, 10)
+	c.Assert(requests[1].body.Output.Annotations[0].StartLine, qt.Equals, 51)
+}
+
+func TestCreateCheckRunError(t *testing.T) {
+	c := qt.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Resource not accessible by integration"}`, http.StatusForbidden)
+	}))
+	c.Cleanup(srv.Close)
+	client := &Client{APIURL: srv.URL, Token: "some-token", Repository: "snyk/vervet"}
+	err := client.CreateCheckRun(context.Background(), &CheckRun{Name: "vervet", HeadSHA: "abc123"})
+	c.Assert(err, qt.ErrorMatches, `failed to create check run: POST /repos/snyk/vervet/check-runs: 403 Forbidden: .*not accessible.*`)
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	c := qt.New(t)
+	c.Setenv("GITHUB_API_URL", "")
+	c.Setenv("GITHUB_TOKEN", "")
+	c.Setenv("GITHUB_REPOSITORY", "snyk/vervet")
+	c.Setenv("GITHUB_SHA", "merge123")
+	c.Setenv("GITHUB_EVENT_PATH", "")
+	_, _, err := NewClientFromEnv()
+	c.Assert(err, qt.ErrorMatches, `missing GitHub token \(GITHUB_TOKEN\)`)
+
+	c.Setenv("GITHUB_TOKEN", "some-token")
+	client, headSHA, err := NewClientFromEnv()
+	c.Assert(err, qt.IsNil)
+	c.Assert(client.Repository, qt.Equals, "snyk/vervet")
+	c.Assert(headSHA, qt.Equals, "merge123")
+
+	// Pull requests check the head commit rather than the merge commit
+	eventPath := filepath.Join(c.Mkdir(), "event.json")
+	err = ioutil.WriteFile(eventPath, []byte(`{"pull_request": {"head": {"sha": "head123"}}}`), 0666)
+	c.Assert(err, qt.IsNil)
+	c.Setenv("GITHUB_EVENT_PATH", eventPath)
+	_, headSHA, err = NewClientFromEnv()
+	c.Assert(err, qt.IsNil)
+	c.Assert(headSHA, qt.Equals, "head123")
+}
@@ -0,0 +1,166 @@
+// Package projectmetrics describes the resource versions in vervet projects
+// as Prometheus gauges, so that the hygiene of APIs can be tracked on
+// dashboards.
+package projectmetrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/snyk/vervet"
+)
+
+// ContentType is the content type of metrics written by Write, the Prometheus
+// text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type gauge struct {
+	name, help string
+	samples    []sample
+}
+
+type sample struct {
+	labels []string
+	value  float64
+}
+
+// stabilities are the stabilities versions are counted by, so that each has
+// a sample even when there are no versions at it.
+var stabilities = []vervet.Stability{
+	vervet.StabilityWIP,
+	vervet.StabilityExperimental,
+	vervet.StabilityBeta,
+	vervet.StabilityGA,
+}
+
+// Write writes gauges describing the resource versions in each API of a
+// project, as of now, in the Prometheus text exposition format:
+//
+//   - vervet_resource_versions: the number of resource versions, by stability.
+//   - vervet_resource_versions_deprecated: the number of deprecated resource
+//     versions which are not yet eligible for sunset.
+//   - vervet_resource_versions_sunset_eligible: the number of deprecated
+//     resource versions which may be removed.
+//   - vervet_newest_version_age_seconds and vervet_oldest_version_age_seconds:
+//     the time since the newest and oldest released resource versions.
+//
+// Semantic versions have no date, so they are not included in version ages.
+func Write(w io.Writer, apis []*vervet.ProjectAPI, now time.Time) error {
+	versions := &gauge{
+		name: "vervet_resource_versions",
+		help: "Number of resource versions, by stability.",
+	}
+	deprecated := &gauge{
+		name: "vervet_resource_versions_deprecated",
+		help: "Number of deprecated resource versions not yet eligible for sunset.",
+	}
+	sunsetEligible := &gauge{
+		name: "vervet_resource_versions_sunset_eligible",
+		help: "Number of deprecated resource versions eligible for sunset.",
+	}
+	newest := &gauge{
+		name: "vervet_newest_version_age_seconds",
+		help: "Time since the newest released resource version.",
+	}
+	oldest := &gauge{
+		name: "vervet_oldest_version_age_seconds",
+		help: "Time since the oldest released resource version.",
+	}
+	for _, api := range apis {
+		byStability := map[vervet.Stability]int{}
+		lifecycles := map[vervet.Lifecycle]int{}
+		var newestDate, oldestDate time.Time
+		for _, rc := range api.Resources {
+			for _, v := range rc.Versions {
+				byStability[v.Version.Stability]++
+				lifecycles[v.Lifecycle]++
+				if v.Version.Semantic || v.Lifecycle == vervet.LifecycleUnreleased {
+					continue
+				}
+				if newestDate.IsZero() || v.Version.Date.After(newestDate) {
+					newestDate = v.Version.Date
+				}
+				if oldestDate.IsZero() || v.Version.Date.Before(oldestDate) {
+					oldestDate = v.Version.Date
+				}
+			}
+		}
+		for _, stability := range stabilities {
+			versions.samples = append(versions.samples, sample{
+				labels: []string{"api", api.Name, "stability", stability.String()},
+				value:  float64(byStability[stability]),
+			})
+		}
+		apiLabels := []string{"api", api.Name}
+		deprecated.samples = append(deprecated.samples, sample{
+			labels: apiLabels, value: float64(lifecycles[vervet.LifecycleDeprecated]),
+		})
+		sunsetEligible.samples = append(sunsetEligible.samples, sample{
+			labels: apiLabels, value: float64(lifecycles[vervet.LifecycleSunsetEligible]),
+		})
+		if !newestDate.IsZero() {
+			newest.samples = append(newest.samples, sample{
+				labels: apiLabels, value: now.Sub(newestDate).Seconds(),
+			})
+			oldest.samples = append(oldest.samples, sample{
+				labels: apiLabels, value: now.Sub(oldestDate).Seconds(),
+			})
+		}
+	}
+	for _, g := range []*gauge{versions, deprecated, sunsetEligible, newest, oldest} {
+		err := g.write(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *gauge) write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	if err != nil {
+		return err
+	}
+	for _, s := range g.samples {
+		var labels []string
+		for i := 0; i+1 < len(s.labels); i += 2 {
+			labels = append(labels, fmt.Sprintf(`%s="%s"`, s.labels[i], labelValueEscaper.Replace(s.labels[i+1])))
+		}
+		_, err := fmt.Fprintf(w, "%s{%s} %g\n", g.name, strings.Join(labels, ","), s.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labelValueEscaper escapes label values as the exposition format expects.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Handler returns an HTTP handler which serves gauges describing the project
+// at the root of projectFS. The project is listed again on each request, so
+// the gauges follow changes to it without restarting.
+func Handler(projectFS fs.FS) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apis, err := vervet.ListResources(projectFS)
+		if err != nil {
+			log.Printf("failed to list project resources: %v", err)
+			http.Error(w, "failed to list project resources", http.StatusInternalServerError)
+			return
+		}
+		var buf bytes.Buffer
+		err = Write(&buf, apis, time.Now().UTC())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.Write(buf.Bytes())
+	})
+}
@@ -0,0 +1,95 @@
+package projectmetrics_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/internal/projectmetrics"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestWrite(t *testing.T) {
+	c := qt.New(t)
+	version := func(s string, lifecycle vervet.Lifecycle) *vervet.ProjectResourceVersion {
+		v, err := vervet.ParseVersion(s)
+		c.Assert(err, qt.IsNil)
+		return &vervet.ProjectResourceVersion{Version: v, Lifecycle: lifecycle}
+	}
+	apis := []*vervet.ProjectAPI{{
+		Name: "rest",
+		Resources: []*vervet.ProjectResource{{
+			Name: "orgs",
+			Versions: []*vervet.ProjectResourceVersion{
+				version("2021-06-01", vervet.LifecycleSunsetEligible),
+				version("2021-08-01~beta", vervet.LifecycleDeprecated),
+				version("2021-09-01", vervet.LifecycleReleased),
+			},
+		}, {
+			Name: "projects",
+			Versions: []*vervet.ProjectResourceVersion{
+				version("2021-08-15~experimental", vervet.LifecycleReleased),
+				version("2021-12-01~wip", vervet.LifecycleUnreleased),
+			},
+		}},
+	}, {
+		Name: "semver",
+		Resources: []*vervet.ProjectResource{{
+			Name: "things",
+			Versions: []*vervet.ProjectResourceVersion{
+				version("v1.0", vervet.LifecycleReleased),
+			},
+		}},
+	}}
+	now := time.Date(2021, time.October, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	err := projectmetrics.Write(&buf, apis, now)
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `
+# HELP vervet_resource_versions Number of resource versions, by stability.
+# TYPE vervet_resource_versions gauge
+vervet_resource_versions{api="rest",stability="wip"} 1
+vervet_resource_versions{api="rest",stability="experimental"} 1
+vervet_resource_versions{api="rest",stability="beta"} 1
+vervet_resource_versions{api="rest",stability="ga"} 2
+vervet_resource_versions{api="semver",stability="wip"} 0
+vervet_resource_versions{api="semver",stability="experimental"} 0
+vervet_resource_versions{api="semver",stability="beta"} 0
+vervet_resource_versions{api="semver",stability="ga"} 1
+# HELP vervet_resource_versions_deprecated Number of deprecated resource versions not yet eligible for sunset.
+# TYPE vervet_resource_versions_deprecated gauge
+vervet_resource_versions_deprecated{api="rest"} 1
+vervet_resource_versions_deprecated{api="semver"} 0
+# HELP vervet_resource_versions_sunset_eligible Number of deprecated resource versions eligible for sunset.
+# TYPE vervet_resource_versions_sunset_eligible gauge
+vervet_resource_versions_sunset_eligible{api="rest"} 1
+vervet_resource_versions_sunset_eligible{api="semver"} 0
+# HELP vervet_newest_version_age_seconds Time since the newest released resource version.
+# TYPE vervet_newest_version_age_seconds gauge
+vervet_newest_version_age_seconds{api="rest"} 2.592e+06
+# HELP vervet_oldest_version_age_seconds Time since the oldest released resource version.
+# TYPE vervet_oldest_version_age_seconds gauge
+vervet_oldest_version_age_seconds{api="rest"} 1.05408e+07
+`[1:])
+}
+
+func TestHandler(t *testing.T) {
+	c := qt.New(t)
+	srv := httptest.NewServer(projectmetrics.Handler(os.DirFS(testdata.Path("."))))
+	c.Cleanup(srv.Close)
+	resp, err := http.Get(srv.URL + "/metrics")
+	c.Assert(err, qt.IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), qt.Equals, projectmetrics.ContentType)
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(body), qt.Contains, `vervet_resource_versions{api="testdata",stability="beta"} 1`+"\n")
+}
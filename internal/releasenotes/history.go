@@ -0,0 +1,135 @@
+package releasenotes
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/snyk/vervet"
+)
+
+// History is the history of a resource: each of its versions, how its
+// operations changed in each, and its operations as of its latest version.
+type History struct {
+	Resource string
+
+	// Versions are the versions of the resource, from oldest to newest.
+	Versions []*HistoryVersion
+
+	// Latest is the newest version of the resource.
+	Latest *HistoryVersion
+
+	// Operations are the operations in the latest version of the resource,
+	// in order of path and method.
+	Operations []*Operation
+}
+
+// HistoryVersion is a version of a resource, and how its operations changed
+// since the prior version, at any stability.
+type HistoryVersion struct {
+	*Release
+	Stability string
+}
+
+// Operation is an operation in a resource version.
+type Operation struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Deprecated  bool
+}
+
+// NewHistory returns the history of a resource.
+func NewHistory(rv *vervet.ResourceVersions) (*History, error) {
+	h := &History{Resource: rv.Name()}
+	versions := rv.Versions()
+	var prior *vervet.Resource
+	for _, version := range versions {
+		rc, err := rv.At(version.String())
+		if err != nil {
+			return nil, err
+		}
+		h.Versions = append(h.Versions, &HistoryVersion{
+			Release:   newRelease(rv.Name(), rc, prior),
+			Stability: version.Stability.String(),
+		})
+		prior = rc
+	}
+	if prior == nil {
+		return h, nil
+	}
+	h.Latest = h.Versions[len(h.Versions)-1]
+	ops := operations(prior.T)
+	for _, key := range sortedKeys(ops) {
+		parts := strings.SplitN(key, " ", 2)
+		h.Operations = append(h.Operations, &Operation{
+			Method:      parts[0],
+			Path:        parts[1],
+			OperationID: ops[key].OperationID,
+			Summary:     ops[key].Summary,
+			Deprecated:  ops[key].Deprecated,
+		})
+	}
+	sort.SliceStable(h.Operations, func(i, j int) bool {
+		return h.Operations[i].Path < h.Operations[j].Path
+	})
+	return h, nil
+}
+
+// Recent returns up to n of the newest versions in the history, newest
+// first.
+func (h *History) Recent(n int) []*HistoryVersion {
+	var result []*HistoryVersion
+	for i := len(h.Versions) - 1; i >= 0 && len(result) < n; i-- {
+		result = append(result, h.Versions[i])
+	}
+	return result
+}
+
+// ReadmeTemplate renders the history of a resource as a Markdown README,
+// from template data with the history as .Data.History.
+const ReadmeTemplate = `
+{{- with .Data.History -}}
+# {{ .Resource }}
+
+<!-- Generated by vervet from the resource's versions. Do not edit. -->
+{{ if .Latest }}
+## Operations
+
+As of version {{ .Latest.Version }}:
+
+| Method | Path | Operation | Summary |
+|---|---|---|---|
+{{- range .Operations }}
+| {{ .Method }} | ` + "`{{ .Path }}`" + ` | {{ .OperationID }} | {{ .Summary }}{{ if .Deprecated }} (deprecated){{ end }} |
+{{- end }}
+
+## Versions
+
+| Version | Stability | Date |
+|---|---|---|
+{{- range .Versions }}
+| {{ .Version }} | {{ .Stability }} | {{ .Date }} |
+{{- end }}
+
+## Recent changes
+{{ range .Recent 5 }}
+### {{ .Version }}
+{{ range .Added }}
+* Added ` + "`{{ . }}`" + `
+{{- end }}
+{{- range .Deprecated }}
+* Deprecated ` + "`{{ . }}`" + `
+{{- end }}
+{{- range .Removed }}
+* Removed ` + "`{{ . }}`" + `
+{{- end }}
+{{- if not (or .Added .Deprecated .Removed) }}
+* Updated
+{{- end }}
+{{ end }}
+{{- else }}
+This resource has no versions yet.
+{{ end }}
+{{- end -}}
+`
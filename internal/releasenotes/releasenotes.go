@@ -0,0 +1,179 @@
+// Package releasenotes summarizes the resource versions released in a
+// project since a given date, and renders them with a template into release
+// notes for publishing.
+package releasenotes
+
+import (
+	"io"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet"
+)
+
+// Notes are the resource versions released in a project since a date.
+type Notes struct {
+	Since string
+	APIs  []*API
+}
+
+// API contains the resource versions released in an API, grouped by
+// stability, from most to least stable.
+type API struct {
+	Name        string
+	Stabilities []*Stability
+}
+
+// Stability contains the resource versions released at a stability level.
+type Stability struct {
+	Stability string
+	Releases  []*Release
+}
+
+// Release is a resource version, and how its operations changed since the
+// prior version of the resource. Operations are given as "METHOD /path".
+type Release struct {
+	Resource   string
+	Version    string
+	Date       string
+	Added      []string
+	Removed    []string
+	Deprecated []string
+}
+
+// AddAPI adds the resource versions in an API released after since.
+// Work-in-progress versions are not released, so these are not included.
+func (n *Notes) AddAPI(name string, resources []*vervet.ResourceVersions, since time.Time) error {
+	api := &API{Name: name}
+	stabilities := map[vervet.Stability]*Stability{}
+	for _, rv := range resources {
+		versions := rv.Versions()
+		for i, version := range versions {
+			if !version.Date.After(since) || version.Stability == vervet.StabilityWIP {
+				continue
+			}
+			rc, err := rv.At(version.String())
+			if err != nil {
+				return err
+			}
+			var prior *vervet.Resource
+			if i > 0 {
+				prior, err = rv.At(versions[i-1].String())
+				if err != nil {
+					return err
+				}
+			}
+			s, ok := stabilities[version.Stability]
+			if !ok {
+				s = &Stability{Stability: version.Stability.String()}
+				stabilities[version.Stability] = s
+			}
+			s.Releases = append(s.Releases, newRelease(rv.Name(), rc, prior))
+		}
+	}
+	for _, stability := range []vervet.Stability{
+		vervet.StabilityGA, vervet.StabilityBeta, vervet.StabilityExperimental,
+	} {
+		s, ok := stabilities[stability]
+		if !ok {
+			continue
+		}
+		sort.SliceStable(s.Releases, func(i, j int) bool {
+			if s.Releases[i].Date != s.Releases[j].Date {
+				return s.Releases[i].Date < s.Releases[j].Date
+			}
+			return s.Releases[i].Resource < s.Releases[j].Resource
+		})
+		api.Stabilities = append(api.Stabilities, s)
+	}
+	if len(api.Stabilities) > 0 {
+		n.APIs = append(n.APIs, api)
+	}
+	return nil
+}
+
+func newRelease(name string, rc, prior *vervet.Resource) *Release {
+	r := &Release{
+		Resource: name,
+		Version:  rc.Version.String(),
+		Date:     rc.Version.DateString(),
+	}
+	ops := operations(rc.T)
+	var priorOps map[string]*openapi3.Operation
+	if prior != nil {
+		priorOps = operations(prior.T)
+	}
+	for _, key := range sortedKeys(ops) {
+		priorOp, ok := priorOps[key]
+		if !ok {
+			r.Added = append(r.Added, key)
+		} else if ops[key].Deprecated && !priorOp.Deprecated {
+			r.Deprecated = append(r.Deprecated, key)
+		}
+	}
+	for _, key := range sortedKeys(priorOps) {
+		if _, ok := ops[key]; !ok {
+			r.Removed = append(r.Removed, key)
+		}
+	}
+	return r
+}
+
+// operations returns the operations in a document, keyed by method and path.
+func operations(doc *openapi3.T) map[string]*openapi3.Operation {
+	result := map[string]*openapi3.Operation{}
+	for path, pathItem := range doc.Paths {
+		for method, op := range pathItem.Operations() {
+			result[method+" "+path] = op
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]*openapi3.Operation) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DefaultTemplate renders release notes as Markdown, suitable for GitHub
+// Releases.
+const DefaultTemplate = `
+{{- range .APIs -}}
+# {{ if .Name }}{{ .Name }}{{ else }}API{{ end }} changes since {{ $.Since }}
+{{ range .Stabilities }}
+## {{ .Stability }}
+{{ range .Releases }}
+### {{ .Resource }} {{ .Version }}
+{{ range .Added }}
+* Added ` + "`{{ . }}`" + `
+{{- end }}
+{{- range .Deprecated }}
+* Deprecated ` + "`{{ . }}`" + `
+{{- end }}
+{{- range .Removed }}
+* Removed ` + "`{{ . }}`" + `
+{{- end }}
+{{- if not (or .Added .Deprecated .Removed) }}
+* Updated
+{{- end }}
+{{ end }}
+{{- end }}
+{{ end -}}
+`
+
+// NewTemplate parses a release notes template.
+func NewTemplate(text string) (*template.Template, error) {
+	return template.New("release-notes").Parse(text)
+}
+
+// Write renders release notes with a template.
+func (n *Notes) Write(w io.Writer, tmpl *template.Template) error {
+	return tmpl.Execute(w, n)
+}
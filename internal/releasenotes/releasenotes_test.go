@@ -0,0 +1,72 @@
+package releasenotes_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/internal/releasenotes"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestReleaseNotes(t *testing.T) {
+	c := qt.New(t)
+	specs, err := vervet.LoadSpecVersions(testdata.Path("resources"))
+	c.Assert(err, qt.IsNil)
+	notes := &releasenotes.Notes{Since: "2021-06-01"}
+	err = notes.AddAPI("testdata", specs.Resources(), time.Date(2021, time.June, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(err, qt.IsNil)
+	// No releases, so the API is omitted.
+	err = notes.AddAPI("other", specs.Resources(), time.Date(2021, time.July, 1, 0, 0, 0, 0, time.UTC))
+	c.Assert(err, qt.IsNil)
+
+	c.Assert(notes.APIs, qt.HasLen, 1)
+	c.Assert(notes.APIs[0].Stabilities[1], qt.DeepEquals, &releasenotes.Stability{
+		Stability: "beta",
+		Releases: []*releasenotes.Release{{
+			Resource: "hello-world",
+			Version:  "2021-06-13~beta",
+			Date:     "2021-06-13",
+			Added:    []string{"POST /examples/hello-world"},
+		}},
+	})
+
+	tmpl, err := releasenotes.NewTemplate(releasenotes.DefaultTemplate)
+	c.Assert(err, qt.IsNil)
+	var buf bytes.Buffer
+	err = notes.Write(&buf, tmpl)
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "# testdata changes since 2021-06-01\n"+
+		"\n## ga\n\n### hello-world 2021-06-07\n\n* Updated\n"+
+		"\n## beta\n\n### hello-world 2021-06-13~beta\n\n* Added `POST /examples/hello-world`\n"+
+		"\n## experimental\n\n### projects 2021-06-04~experimental\n\n* Added `GET /orgs/{orgId}/projects`\n\n")
+}
+
+func TestHistory(t *testing.T) {
+	c := qt.New(t)
+	specs, err := vervet.LoadSpecVersions(testdata.Path("resources"))
+	c.Assert(err, qt.IsNil)
+	var helloWorld *vervet.ResourceVersions
+	for _, rv := range specs.Resources() {
+		if rv.Name() == "hello-world" {
+			helloWorld = rv
+		}
+	}
+	c.Assert(helloWorld, qt.Not(qt.IsNil))
+	history, err := releasenotes.NewHistory(helloWorld)
+	c.Assert(err, qt.IsNil)
+	c.Assert(history.Resource, qt.Equals, "hello-world")
+	var versions []string
+	for _, v := range history.Versions {
+		versions = append(versions, v.Version+" "+v.Stability)
+	}
+	c.Assert(versions, qt.DeepEquals, []string{"2021-06-01 ga", "2021-06-07 ga", "2021-06-13~beta beta"})
+	c.Assert(history.Latest.Version, qt.Equals, "2021-06-13~beta")
+	c.Assert(history.Latest.Added, qt.DeepEquals, []string{"POST /examples/hello-world"})
+	c.Assert(history.Operations, qt.HasLen, 2)
+	c.Assert(history.Operations[0].Method+" "+history.Operations[0].Path, qt.Equals, "POST /examples/hello-world")
+	c.Assert(history.Recent(2), qt.DeepEquals, []*releasenotes.HistoryVersion{history.Versions[2], history.Versions[1]})
+}
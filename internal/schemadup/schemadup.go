@@ -0,0 +1,247 @@
+// Package schemadup finds schemas with the same structure declared in several
+// places across the resources and versions of a project, so that these may be
+// consolidated into shared components before they drift apart.
+package schemadup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet"
+)
+
+// Location is where a schema is declared: a component schema, or the inline
+// schema of a request or response body, such as "GET /orgs 200 response".
+type Location struct {
+	API      string
+	Resource string
+	Name     string
+	Versions []string
+}
+
+func (l *Location) String() string {
+	var sb strings.Builder
+	if l.API != "" {
+		sb.WriteString(l.API + " ")
+	}
+	sb.WriteString(l.Resource + " " + l.Name + " (" + strings.Join(l.Versions, ", ") + ")")
+	return sb.String()
+}
+
+// Group is a set of locations declaring schemas with the same structure.
+type Group struct {
+	Fingerprint string
+	Properties  int
+	Locations   []*Location
+}
+
+// Finder fingerprints schemas added to it, to find those with the same
+// structure.
+//
+// Fingerprints are derived from the types, formats, enums, properties and
+// required properties of a schema and the schemas it refers to or is
+// composed of. Titles, descriptions and examples are not included, so schemas
+// which differ only in documentation are found to be duplicates.
+type Finder struct {
+	minProperties int
+	groups        map[string]*Group
+	locations     map[string]*Location
+}
+
+// New returns a new Finder, which only considers object schemas with at least
+// minProperties properties. Smaller schemas are commonly the same by chance.
+func New(minProperties int) *Finder {
+	return &Finder{
+		minProperties: minProperties,
+		groups:        map[string]*Group{},
+		locations:     map[string]*Location{},
+	}
+}
+
+// AddResource adds the schemas declared in a resource version.
+func (f *Finder) AddResource(apiName, resourceName string, rc *vervet.Resource) {
+	version := rc.Version.String()
+	for name, ref := range rc.Components.Schemas {
+		if ref == nil || ref.Ref != "" {
+			continue
+		}
+		f.add(apiName, resourceName, name, version, ref.Value)
+	}
+	for path, pathItem := range rc.Paths {
+		for method, op := range pathItem.Operations() {
+			if op.RequestBody != nil && op.RequestBody.Ref == "" && op.RequestBody.Value != nil {
+				f.addContent(apiName, resourceName, method+" "+path+" request", version, op.RequestBody.Value.Content)
+			}
+			for status, resp := range op.Responses {
+				if resp == nil || resp.Ref != "" || resp.Value == nil {
+					continue
+				}
+				f.addContent(apiName, resourceName, method+" "+path+" "+status+" response", version, resp.Value.Content)
+			}
+		}
+	}
+}
+
+func (f *Finder) addContent(apiName, resourceName, name, version string, content openapi3.Content) {
+	for _, mediaType := range content {
+		if mediaType == nil || mediaType.Schema == nil || mediaType.Schema.Ref != "" {
+			continue
+		}
+		f.add(apiName, resourceName, name, version, mediaType.Schema.Value)
+	}
+}
+
+func (f *Finder) add(apiName, resourceName, name, version string, s *openapi3.Schema) {
+	if s == nil {
+		return
+	}
+	props := countProperties(s)
+	if props == 0 || props < f.minProperties {
+		return
+	}
+	fp := Fingerprint(s)
+	g, ok := f.groups[fp]
+	if !ok {
+		g = &Group{Fingerprint: fp, Properties: props}
+		f.groups[fp] = g
+	}
+	key := strings.Join([]string{fp, apiName, resourceName, name}, "\x00")
+	loc, ok := f.locations[key]
+	if !ok {
+		loc = &Location{API: apiName, Resource: resourceName, Name: name}
+		f.locations[key] = loc
+		g.Locations = append(g.Locations, loc)
+	}
+	for _, v := range loc.Versions {
+		if v == version {
+			return
+		}
+	}
+	loc.Versions = append(loc.Versions, version)
+}
+
+// Duplicates returns the groups of schemas with the same structure declared
+// under more than one name, largest schemas first.
+//
+// Schemas with the same name and structure are not duplicates: these are
+// either carried unchanged from one version of a resource to the next, or
+// shared components which resources already refer to.
+func (f *Finder) Duplicates() []*Group {
+	var result []*Group
+	for _, g := range f.groups {
+		names := map[string]bool{}
+		for _, loc := range g.Locations {
+			names[loc.Name] = true
+		}
+		if len(names) < 2 {
+			continue
+		}
+		for _, loc := range g.Locations {
+			sort.Strings(loc.Versions)
+		}
+		sort.Slice(g.Locations, func(i, j int) bool {
+			return g.Locations[i].String() < g.Locations[j].String()
+		})
+		result = append(result, g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Properties != result[j].Properties {
+			return result[i].Properties > result[j].Properties
+		}
+		return result[i].Locations[0].String() < result[j].Locations[0].String()
+	})
+	return result
+}
+
+// countProperties returns the number of properties of an object schema,
+// including those composed with allOf.
+func countProperties(s *openapi3.Schema) int {
+	n := len(s.Properties)
+	for _, ref := range s.AllOf {
+		if ref != nil && ref.Value != nil {
+			n += countProperties(ref.Value)
+		}
+	}
+	return n
+}
+
+// Fingerprint returns a digest of the structure of a schema.
+func Fingerprint(s *openapi3.Schema) string {
+	// Maps are formatted in key order, so the same shape always formats the
+	// same way.
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v", shape(s, map[*openapi3.Schema]bool{}))))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// shape returns the structure of a schema, as a value which formats the same
+// way for schemas with the same structure. Recursive schemas are
+// marked where they recur.
+func shape(s *openapi3.Schema, visiting map[*openapi3.Schema]bool) interface{} {
+	if s == nil {
+		return nil
+	}
+	if visiting[s] {
+		return "recursive"
+	}
+	visiting[s] = true
+	defer delete(visiting, s)
+	result := map[string]interface{}{}
+	if s.Type != "" {
+		result["type"] = s.Type
+	}
+	if s.Format != "" {
+		result["format"] = s.Format
+	}
+	if s.Nullable {
+		result["nullable"] = true
+	}
+	if len(s.Enum) > 0 {
+		result["enum"] = s.Enum
+	}
+	if len(s.Properties) > 0 {
+		props := map[string]interface{}{}
+		for name, prop := range s.Properties {
+			props[name] = shapeRef(prop, visiting)
+		}
+		result["properties"] = props
+	}
+	if len(s.Required) > 0 {
+		required := append([]string(nil), s.Required...)
+		sort.Strings(required)
+		result["required"] = required
+	}
+	if s.Items != nil {
+		result["items"] = shapeRef(s.Items, visiting)
+	}
+	if s.AdditionalProperties != nil {
+		result["additionalProperties"] = shapeRef(s.AdditionalProperties, visiting)
+	}
+	if s.Not != nil {
+		result["not"] = shapeRef(s.Not, visiting)
+	}
+	for k, refs := range map[string]openapi3.SchemaRefs{
+		"allOf": s.AllOf, "anyOf": s.AnyOf, "oneOf": s.OneOf,
+	} {
+		if len(refs) == 0 {
+			continue
+		}
+		shapes := make([]interface{}, len(refs))
+		for i := range refs {
+			shapes[i] = shapeRef(refs[i], visiting)
+		}
+		result[k] = shapes
+	}
+	return result
+}
+
+func shapeRef(ref *openapi3.SchemaRef, visiting map[*openapi3.Schema]bool) interface{} {
+	if ref == nil {
+		return nil
+	}
+	return shape(ref.Value, visiting)
+}
@@ -0,0 +1,83 @@
+package schemadup_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/internal/schemadup"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestFingerprint(t *testing.T) {
+	c := qt.New(t)
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.3
+info: {title: test, version: 0.0.0}
+paths: {}
+components:
+  schemas:
+    Org:
+      type: object
+      description: An organization.
+      required: [id, name]
+      properties:
+        id: {type: string, format: uuid}
+        name: {type: string, example: Acme}
+        parent: {$ref: '#/components/schemas/Org'}
+    Group:
+      type: object
+      title: A group of users.
+      required: [name, id]
+      properties:
+        id: {type: string, format: uuid, description: The group ID.}
+        name: {type: string}
+        parent: {$ref: '#/components/schemas/Group'}
+    Project:
+      type: object
+      required: [id, name]
+      properties:
+        id: {type: string}
+        name: {type: string}
+        parent: {$ref: '#/components/schemas/Project'}
+`[1:]))
+	c.Assert(err, qt.IsNil)
+	schemas := doc.Components.Schemas
+	c.Assert(schemadup.Fingerprint(schemas["Org"].Value), qt.Equals,
+		schemadup.Fingerprint(schemas["Group"].Value))
+	c.Assert(schemadup.Fingerprint(schemas["Org"].Value), qt.Not(qt.Equals),
+		schemadup.Fingerprint(schemas["Project"].Value))
+}
+
+func TestDuplicates(t *testing.T) {
+	c := qt.New(t)
+	specs, err := vervet.LoadSpecVersions(testdata.Path("resources"))
+	c.Assert(err, qt.IsNil)
+	find := func(minProperties int) []*schemadup.Group {
+		finder := schemadup.New(minProperties)
+		for _, rv := range specs.Resources() {
+			for _, version := range rv.Versions() {
+				rc, err := rv.At(version.String())
+				c.Assert(err, qt.IsNil)
+				finder.AddResource("testdata", rv.Name(), rc)
+			}
+		}
+		return finder.Duplicates()
+	}
+	groups := find(2)
+	c.Assert(groups, qt.HasLen, 1)
+	c.Assert(groups[0].Properties, qt.Equals, 3)
+	var locations []string
+	for _, loc := range groups[0].Locations {
+		locations = append(locations, loc.String())
+	}
+	c.Assert(locations, qt.DeepEquals, []string{
+		"testdata hello-world GET /examples/hello-world/{id} 200 response (2021-06-01, 2021-06-07, 2021-06-13~beta)",
+		"testdata hello-world POST /examples/hello-world 201 response (2021-06-13~beta)",
+	})
+
+	// Schemas with fewer properties are not considered.
+	c.Assert(find(4), qt.HasLen, 0)
+}
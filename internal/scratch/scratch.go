@@ -0,0 +1,84 @@
+// Package scratch manages the temporary files vervet creates while it runs,
+// such as generated linter rulesets, so that they are created in a
+// configurable location and removed when vervet exits, even if interrupted.
+package scratch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Prefix is the prefix of all temporary files and directories created by
+// vervet, which identifies them as safe to remove.
+const Prefix = "vervet-scratch-"
+
+var (
+	mu      sync.Mutex
+	dir     string
+	created []string
+)
+
+// SetDir sets the directory in which temporary files are created. If empty,
+// the default directory for temporary files is used.
+func SetDir(d string) {
+	mu.Lock()
+	defer mu.Unlock()
+	dir = d
+}
+
+// Dir returns the directory in which temporary files are created.
+func Dir() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if dir == "" {
+		return os.TempDir()
+	}
+	return dir
+}
+
+// TempFile creates a new temporary file, as ioutil.TempFile does in the
+// scratch directory, which is removed by Cleanup.
+func TempFile(pattern string) (*os.File, error) {
+	f, err := ioutil.TempFile(Dir(), Prefix+pattern)
+	if err != nil {
+		return nil, err
+	}
+	track(f.Name())
+	return f, nil
+}
+
+// TempDir creates a new temporary directory, as ioutil.TempDir does in the
+// scratch directory, which is removed along with its contents by Cleanup.
+func TempDir(pattern string) (string, error) {
+	d, err := ioutil.TempDir(Dir(), Prefix+pattern)
+	if err != nil {
+		return "", err
+	}
+	track(d)
+	return d, nil
+}
+
+func track(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+	created = append(created, path)
+}
+
+// Cleanup removes all temporary files and directories created so far.
+func Cleanup() {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range created {
+		os.RemoveAll(path)
+	}
+	created = nil
+}
+
+// Strays returns the temporary files and directories left in a scratch
+// directory by vervet processes which did not clean up after themselves,
+// such as when killed.
+func Strays(d string) ([]string, error) {
+	return filepath.Glob(filepath.Join(d, Prefix+"*"))
+}
@@ -0,0 +1,42 @@
+package scratch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestScratch(t *testing.T) {
+	c := qt.New(t)
+	d := c.Mkdir()
+	SetDir(d)
+	c.Cleanup(func() { SetDir("") })
+	c.Assert(Dir(), qt.Equals, d)
+
+	f, err := TempFile("rules-*.yaml")
+	c.Assert(err, qt.IsNil)
+	c.Assert(f.Close(), qt.IsNil)
+	c.Assert(filepath.Dir(f.Name()), qt.Equals, d)
+	tmpDir, err := TempDir("scrules-*")
+	c.Assert(err, qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(tmpDir, "ruleset.yaml"), nil, 0666), qt.IsNil)
+
+	// Other files in the scratch directory are not vervet's to remove
+	c.Assert(ioutil.WriteFile(filepath.Join(d, "vervet-v1.tar.gz"), nil, 0666), qt.IsNil)
+
+	strays, err := Strays(d)
+	c.Assert(err, qt.IsNil)
+	c.Assert(strays, qt.DeepEquals, []string{f.Name(), tmpDir})
+
+	Cleanup()
+	_, err = os.Stat(f.Name())
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+	_, err = os.Stat(tmpDir)
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+	strays, err = Strays(d)
+	c.Assert(err, qt.IsNil)
+	c.Assert(strays, qt.HasLen, 0)
+}
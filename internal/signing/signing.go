@@ -0,0 +1,195 @@
+// Package signing signs the compiled output of an API, and verifies that
+// output has not been changed since it was signed.
+//
+// Output is signed by writing a manifest of the digest of each file in it,
+// along with an ed25519 signature of the manifest. Keys are PEM encoded, as
+// PKCS #8 private keys and PKIX public keys, such as are generated with:
+//
+//     openssl genpkey -algorithm ed25519 -out signing-key.pem
+//     openssl pkey -in signing-key.pem -pubout -out signing-key.pub.pem
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// ManifestFile is the name of the manifest in signed output.
+	ManifestFile = "manifest.json"
+
+	// SignatureFile is the name of the signature of the manifest in signed
+	// output. It contains the base64 encoded ed25519 signature.
+	SignatureFile = "manifest.json.sig"
+)
+
+// A Manifest records the digest of each file in output, by its slash
+// separated path relative to the output directory. Symbolic links are
+// recorded by their target.
+type Manifest struct {
+	Files map[string]string `json:"files"`
+}
+
+// NewManifest returns the Manifest of the files currently in dir, other than
+// the manifest and its signature.
+func NewManifest(dir string) (*Manifest, error) {
+	m := &Manifest{Files: map[string]string{}}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		switch {
+		case relPath == ManifestFile || relPath == SignatureFile:
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			m.Files[relPath] = "symlink:" + filepath.ToSlash(target)
+		case d.Type().IsRegular():
+			buf, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(buf)
+			m.Files[relPath] = "sha256:" + hex.EncodeToString(sum[:])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output: %w", err)
+	}
+	return m, nil
+}
+
+// Sign writes the manifest of the files in dir, and its signature by key.
+func Sign(dir string, key ed25519.PrivateKey) error {
+	m, err := NewManifest(dir)
+	if err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, ManifestFile), buf, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, buf))
+	err = ioutil.WriteFile(filepath.Join(dir, SignatureFile), []byte(sig+"\n"), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write signature: %w", err)
+	}
+	return nil
+}
+
+// Verify checks that the manifest in dir was signed by the private key of
+// key, and returns the files which differ from the manifest: files which were
+// modified, added or removed since the output was signed. An error is
+// returned if the manifest is missing, or its signature is not valid.
+func Verify(dir string, key ed25519.PublicKey) ([]string, error) {
+	buf, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	sigBuf, err := ioutil.ReadFile(filepath.Join(dir, SignatureFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(string(sigBuf))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	if !ed25519.Verify(key, buf, sig) {
+		return nil, fmt.Errorf("manifest signature does not match key")
+	}
+	var signed Manifest
+	err = json.Unmarshal(buf, &signed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	current, err := NewManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []string
+	for path, digest := range signed.Files {
+		currentDigest, ok := current.Files[path]
+		if !ok {
+			diffs = append(diffs, path+": removed")
+		} else if currentDigest != digest {
+			diffs = append(diffs, path+": modified")
+		}
+	}
+	for path := range current.Files {
+		if _, ok := signed.Files[path]; !ok {
+			diffs = append(diffs, path+": not signed")
+		}
+	}
+	sort.Strings(diffs)
+	return diffs, nil
+}
+
+// LoadPrivateKey loads a PEM encoded PKCS #8 ed25519 private key.
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w (%s)", err, path)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T, expected ed25519 (%s)", key, path)
+	}
+	return edKey, nil
+}
+
+// LoadPublicKey loads a PEM encoded PKIX ed25519 public key.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w (%s)", err, path)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key %T, expected ed25519 (%s)", key, path)
+	}
+	return edKey, nil
+}
+
+func readPEM(path, blockType string) ([]byte, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(buf)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("no PEM encoded %s found (%s)", blockType, path)
+	}
+	return block.Bytes, nil
+}
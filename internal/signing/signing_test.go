@@ -0,0 +1,84 @@
+package signing_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/internal/signing"
+)
+
+func writeKeys(c *qt.C) (privatePath, publicPath string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, qt.IsNil)
+	dir := c.Mkdir()
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	c.Assert(err, qt.IsNil)
+	privatePath = filepath.Join(dir, "key.pem")
+	c.Assert(ioutil.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600), qt.IsNil)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	c.Assert(err, qt.IsNil)
+	publicPath = filepath.Join(dir, "key.pub.pem")
+	c.Assert(ioutil.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644), qt.IsNil)
+	return privatePath, publicPath
+}
+
+func TestSignVerify(t *testing.T) {
+	c := qt.New(t)
+	privatePath, publicPath := writeKeys(c)
+	privateKey, err := signing.LoadPrivateKey(privatePath)
+	c.Assert(err, qt.IsNil)
+	publicKey, err := signing.LoadPublicKey(publicPath)
+	c.Assert(err, qt.IsNil)
+
+	dir := c.Mkdir()
+	c.Assert(os.MkdirAll(filepath.Join(dir, "2021-06-01"), 0777), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "2021-06-01", "spec.json"), []byte(`{}`), 0644), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "2021-06-01", "spec.yaml"), []byte(`{}`), 0644), qt.IsNil)
+	c.Assert(os.Symlink("2021-06-01", filepath.Join(dir, "2021-06-04")), qt.IsNil)
+	c.Assert(signing.Sign(dir, privateKey), qt.IsNil)
+
+	m, err := signing.NewManifest(dir)
+	c.Assert(err, qt.IsNil)
+	c.Assert(m.Files, qt.HasLen, 3)
+	c.Assert(m.Files["2021-06-04"], qt.Equals, "symlink:2021-06-01")
+
+	diffs, err := signing.Verify(dir, publicKey)
+	c.Assert(err, qt.IsNil)
+	c.Assert(diffs, qt.HasLen, 0)
+
+	// Changes to output since it was signed are found.
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "2021-06-01", "spec.json"), []byte(`{"paths": {}}`), 0644), qt.IsNil)
+	c.Assert(os.Remove(filepath.Join(dir, "2021-06-01", "spec.yaml")), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "extra.json"), []byte(`{}`), 0644), qt.IsNil)
+	diffs, err = signing.Verify(dir, publicKey)
+	c.Assert(err, qt.IsNil)
+	c.Assert(diffs, qt.DeepEquals, []string{
+		"2021-06-01/spec.json: modified",
+		"2021-06-01/spec.yaml: removed",
+		"extra.json: not signed",
+	})
+
+	// The manifest itself must be signed by the key.
+	_, otherPublicPath := writeKeys(c)
+	otherPublicKey, err := signing.LoadPublicKey(otherPublicPath)
+	c.Assert(err, qt.IsNil)
+	_, err = signing.Verify(dir, otherPublicKey)
+	c.Assert(err, qt.ErrorMatches, `manifest signature does not match key`)
+}
+
+func TestLoadKeyErrors(t *testing.T) {
+	c := qt.New(t)
+	privatePath, publicPath := writeKeys(c)
+	_, err := signing.LoadPrivateKey(publicPath)
+	c.Assert(err, qt.ErrorMatches, `no PEM encoded PRIVATE KEY found \(.*key.pub.pem\)`)
+	_, err = signing.LoadPublicKey(privatePath)
+	c.Assert(err, qt.ErrorMatches, `no PEM encoded PUBLIC KEY found \(.*key.pem\)`)
+}
@@ -0,0 +1,107 @@
+package specdiff
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/snyk/vervet"
+)
+
+// Release is how a change to a resource version may be released, under
+// vervet's versioning policy.
+type Release string
+
+// Ways in which a change may be released.
+const (
+	// ReleaseNone is advised when nothing changed.
+	ReleaseNone Release = "none"
+
+	// ReleaseInPlace is advised when the change may be made to the existing
+	// resource version: it is additive, or the version is a work in
+	// progress, which is not released.
+	ReleaseInPlace Release = "in-place"
+
+	// ReleaseNewVersion is advised when the change may break clients of a
+	// released version, so it must be released as a new version date.
+	ReleaseNewVersion Release = "new-version"
+
+	// ReleaseNewStability is advised when the stability changes. The
+	// stability of a version date is fixed, so the change must be released
+	// as a new version date at the new stability.
+	ReleaseNewStability Release = "new-stability"
+)
+
+// Advice is guidance on how to release the changes in a Diff.
+type Advice struct {
+	Release Release `json:"release"`
+
+	// FromStability and ToStability are the stabilities of the documents
+	// compared.
+	FromStability string `json:"from-stability"`
+	ToStability   string `json:"to-stability"`
+
+	// Reason explains the advice.
+	Reason string `json:"reason"`
+
+	// Breaking lists the changes which may break clients, if any.
+	Breaking []*Change `json:"breaking,omitempty"`
+}
+
+// Advise returns guidance on how to release the changes in a diff, from a
+// resource version at one stability to the same resource at another.
+//
+// Additive changes may be made in place, as may any change to a work in
+// progress. Changes which may break clients of a released version require a
+// new version date, which deprecates the version changed from. A change of
+// stability always requires a new version date.
+func Advise(d *Diff, from, to vervet.Stability) *Advice {
+	a := &Advice{
+		FromStability: from.String(),
+		ToStability:   to.String(),
+		Breaking:      d.Breaking(),
+	}
+	switch {
+	case from != to:
+		a.Release = ReleaseNewStability
+		a.Reason = fmt.Sprintf("the stability changes from %s to %s, which requires a new version date at %s",
+			from, to, to)
+	case len(d.Changes) == 0:
+		a.Release = ReleaseNone
+		a.Reason = "nothing changed"
+	case from == vervet.StabilityWIP:
+		a.Release = ReleaseInPlace
+		a.Reason = "wip versions are not released, so they may change in any way"
+	case len(a.Breaking) > 0:
+		a.Release = ReleaseNewVersion
+		a.Reason = fmt.Sprintf("%d changes may break clients, which requires a new version date; "+
+			"the version changed from remains available for %d days once deprecated",
+			len(a.Breaking), int(from.SunsetPeriod().Hours()/24))
+	default:
+		a.Release = ReleaseInPlace
+		a.Reason = "the changes are additive, so they may be made to the released version"
+	}
+	return a
+}
+
+// Write writes the advice to w in the given format. Advice is not written as
+// JSON on its own, but alongside the diff it advises on.
+func (a *Advice) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatText:
+		fmt.Fprintf(w, "Advice: %s: %s.\n", a.Release, a.Reason)
+		for _, c := range a.Breaking {
+			fmt.Fprintf(w, "  breaking: %s\n", c)
+		}
+	case FormatMarkdown:
+		fmt.Fprintf(w, "## Advice\n\n**%s**: %s.\n", a.Release, a.Reason)
+		if len(a.Breaking) > 0 {
+			fmt.Fprintf(w, "\nBreaking changes:\n\n")
+		}
+		for _, c := range a.Breaking {
+			fmt.Fprintf(w, "- %s\n", c)
+		}
+	default:
+		return fmt.Errorf("invalid format %q", format)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package specdiff
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet"
+)
+
+func TestAdvise(t *testing.T) {
+	c := qt.New(t)
+	breaking := Compare(loadSpec(c, specA), loadSpec(c, specB))
+	additive := &Diff{Changes: []*Change{{Kind: PathAdded, Path: "/widgets", Message: "path added"}}}
+	tests := []struct {
+		name     string
+		diff     *Diff
+		from, to vervet.Stability
+		release  Release
+	}{{
+		name: "breaking", diff: breaking,
+		from: vervet.StabilityGA, to: vervet.StabilityGA, release: ReleaseNewVersion,
+	}, {
+		name: "breaking wip", diff: breaking,
+		from: vervet.StabilityWIP, to: vervet.StabilityWIP, release: ReleaseInPlace,
+	}, {
+		name: "additive", diff: additive,
+		from: vervet.StabilityBeta, to: vervet.StabilityBeta, release: ReleaseInPlace,
+	}, {
+		name: "unchanged", diff: &Diff{},
+		from: vervet.StabilityGA, to: vervet.StabilityGA, release: ReleaseNone,
+	}, {
+		name: "promoted", diff: additive,
+		from: vervet.StabilityBeta, to: vervet.StabilityGA, release: ReleaseNewStability,
+	}}
+	for _, test := range tests {
+		c.Run(test.name, func(c *qt.C) {
+			advice := Advise(test.diff, test.from, test.to)
+			c.Assert(advice.Release, qt.Equals, test.release)
+			c.Assert(advice.Breaking, qt.HasLen, len(test.diff.Breaking()))
+		})
+	}
+
+	var buf bytes.Buffer
+	err := Advise(breaking, vervet.StabilityBeta, vervet.StabilityBeta).Write(&buf, FormatText)
+	c.Assert(err, qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, `
+Advice: new-version: 5 changes may break clients, which requires a new version date; the version changed from remains available for 91 days once deprecated.
+  breaking: GET /things: query parameter kind: enum values removed: small
+  breaking: GET /things: query parameter limit: parameter is now required
+  breaking: GET /things: response 200 application/json: property data[].name removed
+  breaking: GET /things: response 200 application/json: type of data[].size changed from string to integer
+  breaking: /things/{id}: path removed
+`[1:])
+}
@@ -0,0 +1,38 @@
+package specdiff
+
+// Breaking returns whether the change may break clients of the document
+// changed from: a path, operation or content type they use is removed, a
+// request they send is no longer accepted, or a response they receive no
+// longer has a property they read.
+//
+// Changes which only add to what may be sent or received are not breaking,
+// nor are removed responses, which are most often error responses that are
+// no longer returned.
+func (c *Change) Breaking() bool {
+	switch c.Kind {
+	case PathRemoved, OperationRemoved, ContentRemoved, TypeChanged:
+		return true
+	case ParameterRequired:
+		return true
+	case ParameterAdded:
+		return c.Required
+	case PropertyAdded:
+		return c.Direction == Request && c.Required
+	case PropertyRequired, EnumValuesRemoved:
+		return c.Direction == Request
+	case PropertyRemoved:
+		return c.Direction == Response
+	}
+	return false
+}
+
+// Breaking returns the changes in the diff which may break clients.
+func (d *Diff) Breaking() []*Change {
+	var result []*Change
+	for _, c := range d.Changes {
+		if c.Breaking() {
+			result = append(result, c)
+		}
+	}
+	return result
+}
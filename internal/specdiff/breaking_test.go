@@ -0,0 +1,44 @@
+package specdiff
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestBreaking(t *testing.T) {
+	c := qt.New(t)
+	d := Compare(loadSpec(c, specA), loadSpec(c, specB))
+	var breaking []string
+	for _, change := range d.Breaking() {
+		breaking = append(breaking, change.String())
+	}
+	c.Assert(breaking, qt.DeepEquals, []string{
+		"GET /things: query parameter kind: enum values removed: small",
+		"GET /things: query parameter limit: parameter is now required",
+		"GET /things: response 200 application/json: property data[].name removed",
+		"GET /things: response 200 application/json: type of data[].size changed from string to integer",
+		"/things/{id}: path removed",
+	})
+
+	// Changes are breaking in one direction and not the other.
+	tests := []struct {
+		change   Change
+		breaking bool
+	}{
+		{Change{Kind: PropertyAdded, Direction: Request, Required: true}, true},
+		{Change{Kind: PropertyAdded, Direction: Request}, false},
+		{Change{Kind: PropertyAdded, Direction: Response, Required: true}, false},
+		{Change{Kind: PropertyRemoved, Direction: Request}, false},
+		{Change{Kind: PropertyRequired, Direction: Request}, true},
+		{Change{Kind: PropertyRequired, Direction: Response}, false},
+		{Change{Kind: EnumValuesRemoved, Direction: Response}, false},
+		{Change{Kind: EnumValuesAdded, Direction: Request}, false},
+		{Change{Kind: ParameterAdded, Direction: Request, Required: true}, true},
+		{Change{Kind: ParameterRemoved, Direction: Request}, false},
+		{Change{Kind: ResponseRemoved, Direction: Response}, false},
+	}
+	for _, test := range tests {
+		c.Check(test.change.Breaking(), qt.Equals, test.breaking, qt.Commentf("%+v", test.change))
+	}
+}
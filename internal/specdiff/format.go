@@ -0,0 +1,84 @@
+package specdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Format is a format in which a Diff may be written.
+type Format string
+
+// Formats in which a Diff may be written.
+const (
+	FormatText     Format = "text"
+	FormatJSON     Format = "json"
+	FormatMarkdown Format = "markdown"
+)
+
+// ParseFormat returns the Format named by s.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatText, FormatJSON, FormatMarkdown:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid format %q, expected text, json or markdown", s)
+}
+
+// Write writes the diff to w in the given format.
+func (d *Diff) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatText:
+		return d.writeText(w)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case FormatMarkdown:
+		return d.writeMarkdown(w)
+	}
+	return fmt.Errorf("invalid format %q", format)
+}
+
+func (d *Diff) writeText(w io.Writer) error {
+	if len(d.Changes) == 0 {
+		_, err := fmt.Fprintln(w, "No changes.")
+		return err
+	}
+	for _, c := range d.Changes {
+		_, err := fmt.Fprintln(w, c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMarkdown writes the changes as lists under a heading for each path or
+// operation changed.
+func (d *Diff) writeMarkdown(w io.Writer) error {
+	if len(d.Changes) == 0 {
+		_, err := fmt.Fprintln(w, "No changes.")
+		return err
+	}
+	var heading string
+	for i, c := range d.Changes {
+		if op := c.Operation(); op != heading || i == 0 {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "## `%s`\n\n", op)
+			heading = op
+		}
+		var err error
+		if c.Location == "" {
+			_, err = fmt.Fprintf(w, "- %s\n", c.Message)
+		} else {
+			_, err = fmt.Fprintf(w, "- %s: %s\n", c.Location, c.Message)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+// Package specdiff compares OpenAPI documents, such as two versions of a
+// resource, and describes the changes between them: the paths and
+// operations added or removed, the parameters changed, and the changes to
+// the properties of their request and response schemas.
+package specdiff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Kind is a kind of change between two documents.
+type Kind string
+
+// Kinds of change.
+const (
+	PathAdded         Kind = "path-added"
+	PathRemoved       Kind = "path-removed"
+	OperationAdded    Kind = "operation-added"
+	OperationRemoved  Kind = "operation-removed"
+	ParameterAdded    Kind = "parameter-added"
+	ParameterRemoved  Kind = "parameter-removed"
+	ParameterRequired Kind = "parameter-required"
+	ParameterOptional Kind = "parameter-optional"
+	ResponseAdded     Kind = "response-added"
+	ResponseRemoved   Kind = "response-removed"
+	ContentAdded      Kind = "content-added"
+	ContentRemoved    Kind = "content-removed"
+	PropertyAdded     Kind = "property-added"
+	PropertyRemoved   Kind = "property-removed"
+	PropertyRequired  Kind = "property-required"
+	PropertyOptional  Kind = "property-optional"
+	TypeChanged       Kind = "type-changed"
+	EnumValuesAdded   Kind = "enum-values-added"
+	EnumValuesRemoved Kind = "enum-values-removed"
+)
+
+// Direction is whether a change is to what an operation accepts in a
+// request, or to what it returns in a response.
+type Direction string
+
+// Directions of change.
+const (
+	Request  Direction = "request"
+	Response Direction = "response"
+)
+
+// A Change is a difference between two documents.
+type Change struct {
+	Kind Kind `json:"kind"`
+
+	// Path is the path changed, or the path of the operation changed.
+	Path string `json:"path"`
+
+	// Method is the method of the operation changed, if the change is to an
+	// operation.
+	Method string `json:"method,omitempty"`
+
+	// Direction is whether the change is to a request or a response, if the
+	// change is to either.
+	Direction Direction `json:"direction,omitempty"`
+
+	// Location is the part of the operation changed, such as "query
+	// parameter limit", "request body application/json" or "response 200
+	// application/json".
+	Location string `json:"location,omitempty"`
+
+	// Property is the property of a schema changed, as a dotted path from
+	// the schema at Location. Array items are given as "[]".
+	Property string `json:"property,omitempty"`
+
+	// Message describes the change.
+	Message string `json:"message"`
+
+	// Required is whether a parameter or property added is required.
+	Required bool `json:"required,omitempty"`
+}
+
+// Operation returns the operation changed, as "METHOD /path", or the path if
+// the change is to a path.
+func (c *Change) Operation() string {
+	if c.Method == "" {
+		return c.Path
+	}
+	return c.Method + " " + c.Path
+}
+
+// String returns a description of the change.
+func (c *Change) String() string {
+	if c.Location == "" {
+		return c.Operation() + ": " + c.Message
+	}
+	return c.Operation() + ": " + c.Location + ": " + c.Message
+}
+
+// Diff is the changes from one document to another.
+type Diff struct {
+	Changes []*Change `json:"changes"`
+}
+
+// Compare returns the changes from document a to document b. References in
+// both documents should be resolved. Changes are given in order of path,
+// method and location.
+func Compare(a, b *openapi3.T) *Diff {
+	d := &Diff{Changes: []*Change{}}
+	for _, path := range unionKeys(a.Paths, b.Paths) {
+		pa, pb := a.Paths[path], b.Paths[path]
+		switch {
+		case pa == nil:
+			d.add(&Change{Kind: PathAdded, Path: path, Message: "path added"})
+		case pb == nil:
+			d.add(&Change{Kind: PathRemoved, Path: path, Message: "path removed"})
+		default:
+			d.comparePathItems(path, pa, pb)
+		}
+	}
+	return d
+}
+
+func (d *Diff) add(c *Change) {
+	d.Changes = append(d.Changes, c)
+}
+
+func (d *Diff) comparePathItems(path string, a, b *openapi3.PathItem) {
+	opsA, opsB := a.Operations(), b.Operations()
+	for _, method := range methods {
+		opA, opB := opsA[method], opsB[method]
+		switch {
+		case opA == nil && opB == nil:
+		case opA == nil:
+			d.add(&Change{Kind: OperationAdded, Path: path, Method: method, Message: "operation added"})
+		case opB == nil:
+			d.add(&Change{Kind: OperationRemoved, Path: path, Method: method, Message: "operation removed"})
+		default:
+			op := &operationDiff{Diff: d, path: path, method: method}
+			op.compareParameters(parameters(a, opA), parameters(b, opB))
+			op.compareRequestBodies(opA.RequestBody, opB.RequestBody)
+			op.compareResponses(opA.Responses, opB.Responses)
+		}
+	}
+}
+
+var methods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+
+// operationDiff adds the changes to an operation to a Diff.
+type operationDiff struct {
+	*Diff
+	path, method string
+}
+
+func (d *operationDiff) add(c *Change) {
+	c.Path, c.Method = d.path, d.method
+	d.Diff.add(c)
+}
+
+// parameters returns the parameters of an operation, including those
+// declared for all operations on its path, keyed by location and name.
+func parameters(pathItem *openapi3.PathItem, op *openapi3.Operation) map[string]*openapi3.Parameter {
+	result := map[string]*openapi3.Parameter{}
+	for _, params := range []openapi3.Parameters{pathItem.Parameters, op.Parameters} {
+		for _, ref := range params {
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			result[ref.Value.In+" parameter "+ref.Value.Name] = ref.Value
+		}
+	}
+	return result
+}
+
+func (d *operationDiff) compareParameters(a, b map[string]*openapi3.Parameter) {
+	for _, location := range unionKeys(a, b) {
+		pa, pb := a[location], b[location]
+		switch {
+		case pa == nil:
+			d.add(&Change{Kind: ParameterAdded, Direction: Request, Location: location,
+				Message: "parameter added", Required: pb.Required})
+		case pb == nil:
+			d.add(&Change{Kind: ParameterRemoved, Direction: Request, Location: location,
+				Message: "parameter removed"})
+		default:
+			if !pa.Required && pb.Required {
+				d.add(&Change{Kind: ParameterRequired, Direction: Request, Location: location,
+					Message: "parameter is now required"})
+			} else if pa.Required && !pb.Required {
+				d.add(&Change{Kind: ParameterOptional, Direction: Request, Location: location,
+					Message: "parameter is now optional"})
+			}
+			d.compareSchemaRefs(Request, location, pa.Schema, pb.Schema)
+		}
+	}
+}
+
+func (d *operationDiff) compareRequestBodies(a, b *openapi3.RequestBodyRef) {
+	var contentA, contentB openapi3.Content
+	if a != nil && a.Value != nil {
+		contentA = a.Value.Content
+	}
+	if b != nil && b.Value != nil {
+		contentB = b.Value.Content
+	}
+	d.compareContent(Request, "request body", contentA, contentB)
+}
+
+func (d *operationDiff) compareResponses(a, b openapi3.Responses) {
+	for _, status := range unionKeys(a, b) {
+		ra, rb := a[status], b[status]
+		location := "response " + status
+		switch {
+		case ra == nil:
+			d.add(&Change{Kind: ResponseAdded, Direction: Response, Location: location,
+				Message: "response added"})
+		case rb == nil:
+			d.add(&Change{Kind: ResponseRemoved, Direction: Response, Location: location,
+				Message: "response removed"})
+		case ra.Value != nil && rb.Value != nil:
+			d.compareContent(Response, location, ra.Value.Content, rb.Value.Content)
+		}
+	}
+}
+
+func (d *operationDiff) compareContent(direction Direction, location string, a, b openapi3.Content) {
+	for _, mediaType := range unionKeys(a, b) {
+		ma, mb := a[mediaType], b[mediaType]
+		mediaLocation := location + " " + mediaType
+		switch {
+		case ma == nil:
+			d.add(&Change{Kind: ContentAdded, Direction: direction, Location: mediaLocation,
+				Message: "content added"})
+		case mb == nil:
+			d.add(&Change{Kind: ContentRemoved, Direction: direction, Location: mediaLocation,
+				Message: "content removed"})
+		default:
+			d.compareSchemaRefs(direction, mediaLocation, ma.Schema, mb.Schema)
+		}
+	}
+}
+
+func (d *operationDiff) compareSchemaRefs(direction Direction, location string, a, b *openapi3.SchemaRef) {
+	if a == nil || a.Value == nil || b == nil || b.Value == nil {
+		return
+	}
+	sd := &schemaDiff{
+		operationDiff: d,
+		direction:     direction,
+		location:      location,
+		visited:       map[[2]*openapi3.Schema]bool{},
+	}
+	sd.compare("", a.Value, b.Value)
+}
+
+// schemaDiff adds the changes to a schema in an operation to a Diff.
+type schemaDiff struct {
+	*operationDiff
+	direction Direction
+	location  string
+
+	// visited are the pairs of schemas already compared, so that recursive
+	// schemas are only compared once.
+	visited map[[2]*openapi3.Schema]bool
+}
+
+func (d *schemaDiff) add(kind Kind, property string, required bool, format string, args ...interface{}) {
+	d.operationDiff.add(&Change{
+		Kind:      kind,
+		Direction: d.direction,
+		Location:  d.location,
+		Property:  property,
+		Message:   fmt.Sprintf(format, args...),
+		Required:  required,
+	})
+}
+
+func (d *schemaDiff) compare(property string, a, b *openapi3.Schema) {
+	pair := [2]*openapi3.Schema{a, b}
+	if d.visited[pair] {
+		return
+	}
+	d.visited[pair] = true
+
+	if a.Type != b.Type {
+		d.add(TypeChanged, property, false, "type%s changed from %s to %s",
+			of(property), typeName(a.Type), typeName(b.Type))
+	}
+	if len(a.Enum) > 0 && len(b.Enum) > 0 {
+		if added := missingFrom(b.Enum, a.Enum); len(added) > 0 {
+			d.add(EnumValuesAdded, property, false, "enum values%s added: %s",
+				of(property), strings.Join(added, ", "))
+		}
+		if removed := missingFrom(a.Enum, b.Enum); len(removed) > 0 {
+			d.add(EnumValuesRemoved, property, false, "enum values%s removed: %s",
+				of(property), strings.Join(removed, ", "))
+		}
+	}
+
+	requiredA, requiredB := stringSet(a.Required), stringSet(b.Required)
+	for _, name := range unionKeys(a.Properties, b.Properties) {
+		pa, pb := a.Properties[name], b.Properties[name]
+		propertyPath := joinProperty(property, name)
+		switch {
+		case pa == nil:
+			d.add(PropertyAdded, propertyPath, requiredB[name], "property %s added", propertyPath)
+		case pb == nil:
+			d.add(PropertyRemoved, propertyPath, false, "property %s removed", propertyPath)
+		default:
+			if !requiredA[name] && requiredB[name] {
+				d.add(PropertyRequired, propertyPath, false, "property %s is now required", propertyPath)
+			} else if requiredA[name] && !requiredB[name] {
+				d.add(PropertyOptional, propertyPath, false, "property %s is now optional", propertyPath)
+			}
+			if pa.Value != nil && pb.Value != nil {
+				d.compare(propertyPath, pa.Value, pb.Value)
+			}
+		}
+	}
+	if a.Items != nil && a.Items.Value != nil && b.Items != nil && b.Items.Value != nil {
+		d.compare(property+"[]", a.Items.Value, b.Items.Value)
+	}
+}
+
+func joinProperty(property, name string) string {
+	if property == "" {
+		return name
+	}
+	return property + "." + name
+}
+
+// of qualifies a message with the property it describes, if any.
+func of(property string) string {
+	if property == "" {
+		return ""
+	}
+	return " of " + property
+}
+
+func typeName(t string) string {
+	if t == "" {
+		return "any"
+	}
+	return t
+}
+
+// missingFrom returns the values in a which are not in b, formatted.
+func missingFrom(a, b []interface{}) []string {
+	bValues := map[string]bool{}
+	for _, v := range b {
+		bValues[fmt.Sprint(v)] = true
+	}
+	var result []string
+	for _, v := range a {
+		if s := fmt.Sprint(v); !bValues[s] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func stringSet(values []string) map[string]bool {
+	result := make(map[string]bool, len(values))
+	for _, v := range values {
+		result[v] = true
+	}
+	return result
+}
+
+// unionKeys returns the keys of two maps keyed by string, sorted.
+func unionKeys(a, b interface{}) []string {
+	keys := map[string]bool{}
+	for _, m := range []interface{}{a, b} {
+		for _, k := range reflect.ValueOf(m).MapKeys() {
+			keys[k.String()] = true
+		}
+	}
+	result := make([]string, 0, len(keys))
+	for k := range keys {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
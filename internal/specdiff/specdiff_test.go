@@ -0,0 +1,196 @@
+package specdiff
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const specA = `
+openapi: 3.0.3
+info:
+  title: things
+  version: 3.0.0
+paths:
+  /things:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+        - name: kind
+          in: query
+          schema:
+            type: string
+            enum: [big, small]
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Things'
+  /things/{id}:
+    delete:
+      responses:
+        '204':
+          description: Deleted
+components:
+  schemas:
+    Things:
+      type: object
+      properties:
+        data:
+          type: array
+          items:
+            $ref: '#/components/schemas/Thing'
+    Thing:
+      type: object
+      properties:
+        name:
+          type: string
+        size:
+          type: string
+        parent:
+          $ref: '#/components/schemas/Thing'
+`
+
+const specB = `
+openapi: 3.0.3
+info:
+  title: things
+  version: 3.0.0
+paths:
+  /things:
+    get:
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+        - name: kind
+          in: query
+          schema:
+            type: string
+            enum: [big, medium]
+        - name: owner
+          in: query
+          schema:
+            type: string
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Things'
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Thing'
+      responses:
+        '201':
+          description: Created
+  /widgets:
+    get:
+      responses:
+        '200':
+          description: OK
+components:
+  schemas:
+    Things:
+      type: object
+      properties:
+        data:
+          type: array
+          items:
+            $ref: '#/components/schemas/Thing'
+    Thing:
+      type: object
+      required: [color]
+      properties:
+        size:
+          type: integer
+        color:
+          type: string
+        parent:
+          $ref: '#/components/schemas/Thing'
+`
+
+func loadSpec(c *qt.C, s string) *openapi3.T {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(s[1:]))
+	c.Assert(err, qt.IsNil)
+	return doc
+}
+
+func TestCompare(t *testing.T) {
+	c := qt.New(t)
+	d := Compare(loadSpec(c, specA), loadSpec(c, specB))
+	var changes []string
+	for _, change := range d.Changes {
+		changes = append(changes, change.String())
+	}
+	c.Assert(changes, qt.DeepEquals, []string{
+		"GET /things: query parameter kind: enum values added: medium",
+		"GET /things: query parameter kind: enum values removed: small",
+		"GET /things: query parameter limit: parameter is now required",
+		"GET /things: query parameter owner: parameter added",
+		"GET /things: response 200 application/json: property data[].color added",
+		"GET /things: response 200 application/json: property data[].name removed",
+		"GET /things: response 200 application/json: type of data[].size changed from string to integer",
+		"POST /things: operation added",
+		"/things/{id}: path removed",
+		"/widgets: path added",
+	})
+	c.Assert(d.Changes[4].Required, qt.IsTrue)
+	c.Assert(d.Changes[4].Direction, qt.Equals, Response)
+	c.Assert(d.Changes[4].Property, qt.Equals, "data[].color")
+}
+
+func TestCompareUnchanged(t *testing.T) {
+	c := qt.New(t)
+	d := Compare(loadSpec(c, specA), loadSpec(c, specA))
+	c.Assert(d.Changes, qt.HasLen, 0)
+	var buf bytes.Buffer
+	c.Assert(d.Write(&buf, FormatText), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "No changes.\n")
+	buf.Reset()
+	c.Assert(d.Write(&buf, FormatJSON), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "{\n  \"changes\": []\n}\n")
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	c := qt.New(t)
+	d := Compare(loadSpec(c, specA), loadSpec(c, specB))
+	var buf bytes.Buffer
+	c.Assert(d.Write(&buf, FormatMarkdown), qt.IsNil)
+	c.Assert(buf.String(), qt.Equals, "## `GET /things`\n\n"+
+		"- query parameter kind: enum values added: medium\n"+
+		"- query parameter kind: enum values removed: small\n"+
+		"- query parameter limit: parameter is now required\n"+
+		"- query parameter owner: parameter added\n"+
+		"- response 200 application/json: property data[].color added\n"+
+		"- response 200 application/json: property data[].name removed\n"+
+		"- response 200 application/json: type of data[].size changed from string to integer\n"+
+		"\n## `POST /things`\n\n"+
+		"- operation added\n"+
+		"\n## `/things/{id}`\n\n"+
+		"- path removed\n"+
+		"\n## `/widgets`\n\n"+
+		"- path added\n")
+}
+
+func TestParseFormat(t *testing.T) {
+	c := qt.New(t)
+	f, err := ParseFormat("markdown")
+	c.Assert(err, qt.IsNil)
+	c.Assert(f, qt.Equals, FormatMarkdown)
+	_, err = ParseFormat("html")
+	c.Assert(err, qt.ErrorMatches, `invalid format "html", expected text, json or markdown`)
+}
@@ -0,0 +1,124 @@
+// Package specjson works with OpenAPI specs in their generic JSON form, as
+// decoded into maps and slices, for transformations which rewrite a spec
+// without regard for its types.
+package specjson
+
+import (
+	"strings"
+)
+
+// OperationMethods are the keys of operations in an OpenAPI path item.
+var OperationMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true,
+	"options": true, "head": true, "patch": true, "trace": true,
+}
+
+// ReachableComponents returns references to the components reachable from
+// the parts of a spec other than its components, directly or by way of other
+// components.
+func ReachableComponents(doc map[string]interface{}) map[string]bool {
+	reached := map[string]bool{}
+	var queue []interface{}
+	for k, v := range doc {
+		if k != "components" {
+			queue = append(queue, v)
+		}
+	}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		EachRef(v, func(ref string) {
+			ref = ComponentRef(ref)
+			if ref == "" || reached[ref] {
+				return
+			}
+			reached[ref] = true
+			if component, ok := Component(doc, ref); ok {
+				queue = append(queue, component)
+			}
+		})
+	}
+	return reached
+}
+
+// EachRef calls f with each reference in v.
+func EachRef(v interface{}, f func(ref string)) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			f(ref)
+		}
+		for _, vv := range v {
+			EachRef(vv, f)
+		}
+	case []interface{}:
+		for _, vv := range v {
+			EachRef(vv, f)
+		}
+	}
+}
+
+// ComponentRef returns the reference to the component a reference refers to,
+// or into, such as #/components/schemas/Foo for
+// #/components/schemas/Foo/properties/bar. An empty string is returned if the
+// reference is not to a component.
+func ComponentRef(ref string) string {
+	if !strings.HasPrefix(ref, "#/components/") {
+		return ""
+	}
+	parts := strings.SplitN(strings.TrimPrefix(ref, "#/components/"), "/", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return "#/components/" + parts[0] + "/" + parts[1]
+}
+
+// Component returns the component a component reference refers to, if it is
+// in doc.
+func Component(doc map[string]interface{}, ref string) (interface{}, bool) {
+	section, name, ok := componentSection(doc, ref)
+	if !ok {
+		return nil, false
+	}
+	component, ok := section[name]
+	return component, ok
+}
+
+// DeleteComponent removes the component a component reference refers to from
+// doc, returning whether there was one to remove.
+func DeleteComponent(doc map[string]interface{}, ref string) bool {
+	section, name, ok := componentSection(doc, ref)
+	if !ok {
+		return false
+	}
+	if _, ok := section[name]; !ok {
+		return false
+	}
+	delete(section, name)
+	return true
+}
+
+func componentSection(doc map[string]interface{}, ref string) (map[string]interface{}, string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(ComponentRef(ref), "#/components/"), "/", 2)
+	if len(parts) != 2 {
+		return nil, "", false
+	}
+	components, _ := doc["components"].(map[string]interface{})
+	section, ok := components[parts[0]].(map[string]interface{})
+	return section, UnescapePointer(parts[1]), ok
+}
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// EscapePointer escapes a key for use in a JSON pointer.
+func EscapePointer(s string) string {
+	return pointerEscaper.Replace(s)
+}
+
+// UnescapePointer returns the key a JSON pointer token refers to.
+func UnescapePointer(s string) string {
+	return pointerUnescaper.Replace(s)
+}
@@ -1,15 +1,16 @@
 package spectral
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 
 	"github.com/ghodss/yaml"
 
+	"github.com/snyk/vervet/internal/scratch"
 	"github.com/snyk/vervet/internal/types"
 )
 
@@ -36,7 +37,7 @@ func New(ctx context.Context, rules []string, extraArgs []string) (*Spectral, er
 	}
 
 	var rulesPath string
-	rulesFile, err := ioutil.TempFile("", "*.yaml")
+	rulesFile, err := scratch.TempFile("rules-*.yaml")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp rules file: %w", err)
 	}
@@ -89,6 +90,30 @@ func (l *Spectral) Run(ctx context.Context, paths ...string) error {
 	return cmd.Run()
 }
 
+// Report runs spectral on the given paths and returns its findings, which
+// are also written to standard output. Returns an error when lint fails
+// configured rules.
+func (l *Spectral) Report(ctx context.Context, paths ...string) ([]types.Finding, error) {
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, l.spectralPath, append(append([]string{"lint", "-r", l.rulesPath}, l.extraArgs...), append([]string{"-f", "json"}, paths...)...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	runErr := cmd.Run()
+	findings, err := ParseResults(stdout.Bytes())
+	if err != nil {
+		// Spectral may fail before producing any results, such as when its
+		// rules cannot be loaded.
+		os.Stdout.Write(stdout.Bytes())
+		if runErr != nil {
+			return nil, runErr
+		}
+		return nil, err
+	}
+	WriteFindings(os.Stdout, findings)
+	return findings, runErr
+}
+
 func findSpectralAdjacent() (string, bool) {
 	if len(os.Args) < 1 {
 		// hmmm
@@ -0,0 +1,69 @@
+package spectral
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/snyk/vervet/internal/types"
+)
+
+// result is a single result in spectral's JSON output format.
+type result struct {
+	Code     interface{}   `json:"code"`
+	Message  string        `json:"message"`
+	Severity int           `json:"severity"`
+	Source   string        `json:"source"`
+	Path     []interface{} `json:"path"`
+	Range    struct {
+		Start struct {
+			Line int `json:"line"`
+		} `json:"start"`
+	} `json:"range"`
+}
+
+var severities = []types.Severity{
+	types.SeverityError, types.SeverityWarn, types.SeverityInfo, types.SeverityHint,
+}
+
+// ParseResults returns the findings in the output of `spectral lint -f json`.
+func ParseResults(buf []byte) ([]types.Finding, error) {
+	// Spectral writes nothing at all when there are no results to report.
+	buf = bytes.TrimSpace(buf)
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	var results []result
+	err := json.Unmarshal(buf, &results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse spectral output: %w", err)
+	}
+	findings := make([]types.Finding, len(results))
+	for i, r := range results {
+		severity := types.SeverityError
+		if r.Severity >= 0 && r.Severity < len(severities) {
+			severity = severities[r.Severity]
+		}
+		findings[i] = types.Finding{
+			File: r.Source,
+			// Spectral lines are zero-based
+			Line:     r.Range.Start.Line + 1,
+			Rule:     fmt.Sprint(r.Code),
+			Severity: severity,
+			Message:  r.Message,
+		}
+		for _, token := range r.Path {
+			// Spectral gives array indexes in paths as numbers.
+			findings[i].Path = append(findings[i].Path, fmt.Sprint(token))
+		}
+	}
+	return findings, nil
+}
+
+// WriteFindings writes findings to w, one per line.
+func WriteFindings(w io.Writer, findings []types.Finding) {
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s:%d %s %s %s\n", f.File, f.Line, f.Severity, f.Rule, f.Message)
+	}
+}
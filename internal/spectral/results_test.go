@@ -0,0 +1,60 @@
+package spectral
+
+import (
+	"bytes"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/internal/types"
+)
+
+func TestParseResults(t *testing.T) {
+	c := qt.New(t)
+	findings, err := ParseResults([]byte(`[{
+	"code": "operation-tags",
+	"message": "Operation must have tags.",
+	"path": ["paths", "/things", "get"],
+	"severity": 0,
+	"source": "/project/spec.yaml",
+	"range": {"start": {"line": 11, "character": 4}}
+}, {
+	"code": "parameter-description",
+	"message": "Parameter should have a description.",
+	"path": ["paths", "/things", "get", "parameters", 0],
+	"severity": 1,
+	"source": "/project/spec.yaml",
+	"range": {"start": {"line": 14, "character": 8}}
+}]`))
+	c.Assert(err, qt.IsNil)
+	c.Assert(findings, qt.DeepEquals, []types.Finding{{
+		File:     "/project/spec.yaml",
+		Line:     12,
+		Rule:     "operation-tags",
+		Severity: types.SeverityError,
+		Message:  "Operation must have tags.",
+		Path:     []string{"paths", "/things", "get"},
+	}, {
+		File:     "/project/spec.yaml",
+		Line:     15,
+		Rule:     "parameter-description",
+		Severity: types.SeverityWarn,
+		Message:  "Parameter should have a description.",
+		Path:     []string{"paths", "/things", "get", "parameters", "0"},
+	}})
+
+	var buf bytes.Buffer
+	WriteFindings(&buf, findings)
+	c.Assert(buf.String(), qt.Equals, `
+/project/spec.yaml:12 error operation-tags Operation must have tags.
+/project/spec.yaml:15 warn parameter-description Parameter should have a description.
+`[1:])
+
+	// Spectral writes nothing when there are no results.
+	findings, err = ParseResults([]byte("\n"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(findings, qt.HasLen, 0)
+
+	_, err = ParseResults([]byte("No results with a severity of 'error' found!"))
+	c.Assert(err, qt.ErrorMatches, `failed to parse spectral output: .*`)
+}
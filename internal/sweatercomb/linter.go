@@ -2,19 +2,22 @@ package sweatercomb
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/ghodss/yaml"
 
+	"github.com/snyk/vervet/internal/scratch"
+	"github.com/snyk/vervet/internal/spectral"
 	"github.com/snyk/vervet/internal/types"
 )
 
@@ -26,10 +29,27 @@ type SweaterComb struct {
 	extraArgs []string
 
 	rulesDir string
+	mounts   []mount
 
 	runner commandRunner
 }
 
+// mount is a host directory containing local rules, mounted read-only into
+// the container.
+type mount struct {
+	hostDir      string
+	containerDir string
+}
+
+const (
+	// targetDir is where the working directory is mounted in the container.
+	targetDir = "/sweater-comb/target"
+
+	// localRulesDir is where directories containing local rules outside the
+	// working directory are mounted in the container.
+	localRulesDir = "/vervet-rules"
+)
+
 type commandRunner interface {
 	run(cmd *exec.Cmd) error
 }
@@ -41,12 +61,22 @@ func (*execCommandRunner) run(cmd *exec.Cmd) error {
 }
 
 // New returns a new SweaterComb instance configured with the given rules.
+//
+// Absolute rule paths refer to rules built into the image. Relative rule paths
+// refer to local files, relative to the working directory. Local rules in the
+// working directory are found where it is mounted into the container. Local
+// rules outside of it are mounted into the container along with the rest of
+// their directory, so that any files they refer to are also available.
 func New(ctx context.Context, image string, rules []string, extraArgs []string) (*SweaterComb, error) {
+	return newSweaterComb(ctx, image, nil, rules, extraArgs)
+}
+
+func newSweaterComb(ctx context.Context, image string, mounts []mount, rules []string, extraArgs []string) (*SweaterComb, error) {
 	if len(rules) == 0 {
 		return nil, fmt.Errorf("missing spectral rules")
 	}
 
-	rulesDir, err := ioutil.TempDir("", "*-scrules")
+	rulesDir, err := scratch.TempDir("scrules-*")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp rules directory: %w", err)
 	}
@@ -55,16 +85,18 @@ func New(ctx context.Context, image string, rules []string, extraArgs []string)
 		return nil, fmt.Errorf("failed to create temp rules file: %w", err)
 	}
 	defer rulesFile.Close()
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	mounts = append([]mount(nil), mounts...)
 	resolvedRules := make([]string, len(rules))
 	for i := range rules {
 		rule := filepath.Clean(rules[i])
 		if !filepath.IsAbs(rule) {
-			rule = "/sweater-comb/target/" + rule
+			rule, mounts = resolveLocalRule(cwd, rule, mounts)
 		}
 		resolvedRules[i] = rule
-		if err != nil {
-			return nil, err
-		}
 	}
 	rulesDoc := map[string]interface{}{
 		"extends": resolvedRules,
@@ -87,18 +119,50 @@ func New(ctx context.Context, image string, rules []string, extraArgs []string)
 		image:     image,
 		rules:     resolvedRules,
 		rulesDir:  rulesDir,
+		mounts:    mounts,
 		extraArgs: extraArgs,
 		runner:    &execCommandRunner{},
 	}, nil
 }
 
+// resolveLocalRule returns the container path of a local rule, relative to
+// the working directory cwd, adding a mount for its directory if it is
+// outside of the working directory.
+func resolveLocalRule(cwd, rule string, mounts []mount) (string, []mount) {
+	hostPath := filepath.Join(cwd, rule)
+	if relPath, err := filepath.Rel(cwd, hostPath); err == nil && !strings.HasPrefix(relPath, "..") {
+		return targetDir + "/" + filepath.ToSlash(relPath), mounts
+	}
+	hostDir, base := filepath.Split(hostPath)
+	hostDir = filepath.Clean(hostDir)
+	for _, m := range mounts {
+		if m.hostDir == hostDir {
+			return m.containerDir + "/" + base, mounts
+		}
+	}
+	m := mount{hostDir: hostDir, containerDir: fmt.Sprintf("%s/%d", localRulesDir, len(mounts))}
+	return m.containerDir + "/" + base, append(mounts, m)
+}
+
 // NewRules returns a new Linter instance with additional rules appended.
 func (l *SweaterComb) NewRules(ctx context.Context, rules ...string) (types.Linter, error) {
-	return New(ctx, l.image, append(l.rules, rules...), l.extraArgs)
+	return newSweaterComb(ctx, l.image, l.mounts, append(append([]string{}, l.rules...), rules...), l.extraArgs)
 }
 
 var sweaterCombOutputRE = regexp.MustCompile(`/sweater-comb/target`)
 
+// rewriteOutput replaces container paths in spectral output with the host
+// paths they were mounted from, so that output refers to local files.
+func (l *SweaterComb) rewriteOutput(output []byte, cwd string) []byte {
+	output = sweaterCombOutputRE.ReplaceAllLiteral(output, []byte(cwd))
+	// Longest container paths first, so that /vervet-rules/1 does not
+	// replace the start of /vervet-rules/10.
+	for i := len(l.mounts) - 1; i >= 0; i-- {
+		output = bytes.ReplaceAll(output, []byte(l.mounts[i].containerDir), []byte(l.mounts[i].hostDir))
+	}
+	return output
+}
+
 // Run runs spectral on the given paths. Linting output is written to standard
 // output by spectral. Returns an error when lint fails configured rules.
 func (l *SweaterComb) Run(ctx context.Context, paths ...string) error {
@@ -106,18 +170,7 @@ func (l *SweaterComb) Run(ctx context.Context, paths ...string) error {
 	if err != nil {
 		return err
 	}
-	mountedPaths := make([]string, len(paths))
-	for i := range paths {
-		mountedPaths[i] = filepath.Join("./", paths[i])
-	}
-	cmdline := append(append([]string{
-		"run", "--rm",
-		"-v", l.rulesDir + ":/vervet", "-v", cwd + ":/sweater-comb/target",
-		l.image,
-		"lint",
-		"-r", "/vervet/ruleset.yaml",
-	}, l.extraArgs...), paths...)
-	cmd := exec.CommandContext(ctx, "docker", cmdline...)
+	cmd := l.command(ctx, cwd, l.extraArgs, paths)
 
 	pipeReader, pipeWriter := io.Pipe()
 	ch := make(chan struct{})
@@ -137,7 +190,7 @@ func (l *SweaterComb) Run(ctx context.Context, paths ...string) error {
 		defer pipeReader.Close()
 		sc := bufio.NewScanner(pipeReader)
 		for sc.Scan() {
-			fmt.Println(sweaterCombOutputRE.ReplaceAllLiteralString(sc.Text(), cwd))
+			fmt.Println(string(l.rewriteOutput(sc.Bytes(), cwd)))
 		}
 		if err := sc.Err(); err != nil {
 			fmt.Fprintf(os.Stderr, "error reading stdout: %v", err)
@@ -150,4 +203,49 @@ func (l *SweaterComb) Run(ctx context.Context, paths ...string) error {
 	return l.runner.run(cmd)
 }
 
+// Report runs spectral on the given paths and returns its findings, which
+// are also written to standard output. Returns an error when lint fails
+// configured rules.
+func (l *SweaterComb) Report(ctx context.Context, paths ...string) ([]types.Finding, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	var stdout bytes.Buffer
+	cmd := l.command(ctx, cwd, append(append([]string{}, l.extraArgs...), "-f", "json"), paths)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	runErr := l.runner.run(cmd)
+	output := l.rewriteOutput(stdout.Bytes(), cwd)
+	findings, err := spectral.ParseResults(output)
+	if err != nil {
+		// Spectral may fail before producing any results, such as when its
+		// rules cannot be loaded.
+		os.Stdout.Write(output)
+		if runErr != nil {
+			return nil, runErr
+		}
+		return nil, err
+	}
+	spectral.WriteFindings(os.Stdout, findings)
+	return findings, runErr
+}
+
+func (l *SweaterComb) command(ctx context.Context, cwd string, args []string, paths []string) *exec.Cmd {
+	cmdline := []string{
+		"run", "--rm",
+		"-v", l.rulesDir + ":/vervet", "-v", cwd + ":" + targetDir,
+	}
+	for _, m := range l.mounts {
+		cmdline = append(cmdline, "-v", m.hostDir+":"+m.containerDir+":ro")
+	}
+	cmdline = append(append(append(cmdline,
+		l.image,
+		"lint",
+		"-r", "/vervet/ruleset.yaml",
+	), args...), paths...)
+	return exec.CommandContext(ctx, "docker", cmdline...)
+}
+
 const cmdTimeout = time.Second * 10
@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/internal/types"
 )
 
 func TestLinter(t *testing.T) {
@@ -74,13 +76,111 @@ extends:
 	c.Assert(err, qt.ErrorMatches, "nope")
 }
 
+func TestLinterLocalRules(t *testing.T) {
+	c := qt.New(t)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cwd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	sharedDir := filepath.Join(filepath.Dir(cwd), "shared")
+
+	l, err := New(ctx, "some-image", []string{"/rules/apinext.yaml", "rules/local.yaml", "../shared/custom.yaml"}, nil)
+	c.Assert(err, qt.IsNil)
+	c.Assert(l.rules, qt.DeepEquals, []string{
+		"/rules/apinext.yaml",
+		"/sweater-comb/target/rules/local.yaml",
+		"/vervet-rules/0/custom.yaml",
+	})
+
+	// Overrides keep the mounts of the linter they override
+	nl, err := l.NewRules(ctx, "../shared/override.yaml", "../other/override.yaml")
+	c.Assert(err, qt.IsNil)
+	l = nl.(*SweaterComb)
+	c.Assert(l.rules, qt.DeepEquals, []string{
+		"/rules/apinext.yaml",
+		"/sweater-comb/target/rules/local.yaml",
+		"/vervet-rules/0/custom.yaml",
+		"/vervet-rules/0/override.yaml",
+		"/vervet-rules/1/override.yaml",
+	})
+
+	tempFile, err := os.Create(c.Mkdir() + "/stdout")
+	c.Assert(err, qt.IsNil)
+	c.Patch(&os.Stdout, tempFile)
+	defer tempFile.Close()
+	runner := &mockRunner{output: "/vervet-rules/0/custom.yaml:3 invalid rule"}
+	l.runner = runner
+	err = l.Run(ctx, "spec.yaml")
+	c.Assert(err, qt.IsNil)
+	c.Assert(runner.runs[0][:11], qt.DeepEquals, []string{
+		"docker", "run", "--rm",
+		"-v", l.rulesDir + ":/vervet",
+		"-v", cwd + ":/sweater-comb/target",
+		"-v", sharedDir + ":/vervet-rules/0:ro",
+		"-v", filepath.Join(filepath.Dir(cwd), "other") + ":/vervet-rules/1:ro",
+	})
+
+	// Container paths of local rules are rewritten to their host paths
+	capturedOutput, err := ioutil.ReadFile(tempFile.Name())
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(capturedOutput), qt.Equals, sharedDir+"/custom.yaml:3 invalid rule\n")
+}
+
+func TestLinterReport(t *testing.T) {
+	c := qt.New(t)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	l, err := New(ctx, "some-image", []string{"rule1"}, []string{"--some-flag"})
+	c.Assert(err, qt.IsNil)
+	cwd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	tempFile, err := os.Create(c.Mkdir() + "/stdout")
+	c.Assert(err, qt.IsNil)
+	c.Patch(&os.Stdout, tempFile)
+	defer tempFile.Close()
+
+	runner := &mockRunner{
+		output: `[{"code":"operation-tags","message":"Operation must have tags.","severity":0,` +
+			`"path":["paths","/things","get"],"source":"/sweater-comb/target/my-api/spec.yaml","range":{"start":{"line":11,"character":4}}}]`,
+		err: fmt.Errorf("exit status 1"),
+	}
+	l.runner = runner
+	findings, err := l.Report(ctx, "my-api/spec.yaml")
+	c.Assert(err, qt.ErrorMatches, "exit status 1")
+	c.Assert(runner.runs[0][len(runner.runs[0])-4:], qt.DeepEquals, []string{"--some-flag", "-f", "json", "my-api/spec.yaml"})
+	c.Assert(findings, qt.DeepEquals, []types.Finding{{
+		File:     cwd + "/my-api/spec.yaml",
+		Line:     12,
+		Rule:     "operation-tags",
+		Severity: types.SeverityError,
+		Message:  "Operation must have tags.",
+		Path:     []string{"paths", "/things", "get"},
+	}})
+
+	// Findings are also written to stdout
+	capturedOutput, err := ioutil.ReadFile(tempFile.Name())
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(capturedOutput), qt.Equals, cwd+"/my-api/spec.yaml:12 error operation-tags Operation must have tags.\n")
+
+	// No results
+	l.runner = &mockRunner{output: "[]"}
+	findings, err = l.Report(ctx, "my-api/spec.yaml")
+	c.Assert(err, qt.IsNil)
+	c.Assert(findings, qt.HasLen, 0)
+}
+
 type mockRunner struct {
-	runs [][]string
-	err  error
+	runs   [][]string
+	output string
+	err    error
 }
 
 func (r *mockRunner) run(cmd *exec.Cmd) error {
-	fmt.Fprintln(cmd.Stdout, "/sweater-comb/target is the path to things in your project")
+	if r.output != "" {
+		fmt.Fprintln(cmd.Stdout, r.output)
+	} else {
+		fmt.Fprintln(cmd.Stdout, "/sweater-comb/target is the path to things in your project")
+	}
 	r.runs = append(r.runs, cmd.Args)
 	return r.err
 }
@@ -0,0 +1,174 @@
+// Package textdiff compares text line by line, for showing people how
+// documents such as OpenAPI specs differ.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unified returns the differences between two texts in unified diff format,
+// with the given number of lines of context around each change. An empty
+// string is returned if the texts are the same.
+func Unified(aName, bName, a, b string, context int) string {
+	aLines, bLines := splitLines(a), splitLines(b)
+	ops := diff(aLines, bLines)
+	var sb strings.Builder
+	for _, h := range hunks(ops, context) {
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(h.aStart, h.aLen), hunkRange(h.bStart, h.bLen))
+		for _, o := range ops[h.start:h.end] {
+			sb.WriteByte(o.kind)
+			sb.WriteString(o.line)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+const (
+	opEqual  = ' '
+	opDelete = '-'
+	opInsert = '+'
+)
+
+type op struct {
+	kind byte
+	line string
+}
+
+// diff returns the edits which transform a into b, from the longest common
+// subsequence of their lines. Common leading and trailing lines are trimmed
+// first, as documents compared are usually mostly the same.
+func diff(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var ops []op
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{opEqual, line})
+	}
+	am, bm := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] is the length of the longest common subsequence of am[i:]
+	// and bm[j:].
+	lcs := make([][]int, len(am)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bm)+1)
+	}
+	for i := len(am) - 1; i >= 0; i-- {
+		for j := len(bm) - 1; j >= 0; j-- {
+			if am[i] == bm[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(am) || j < len(bm) {
+		switch {
+		case i < len(am) && j < len(bm) && am[i] == bm[j]:
+			ops = append(ops, op{opEqual, am[i]})
+			i++
+			j++
+		case j < len(bm) && (i == len(am) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, op{opInsert, bm[j]})
+			j++
+		default:
+			ops = append(ops, op{opDelete, am[i]})
+			i++
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{opEqual, line})
+	}
+	return ops
+}
+
+// hunk is a range of ops containing changes, with their line numbers in
+// each text.
+type hunk struct {
+	start, end   int
+	aStart, aLen int
+	bStart, bLen int
+}
+
+// hunks groups changes into hunks, with context lines around them. Changes
+// closer together than twice the context are grouped into the same hunk.
+func hunks(ops []op, context int) []hunk {
+	var result []hunk
+	aLine, bLine := 0, 0
+	// Line numbers at the start of each op
+	aLines, bLines := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, o := range ops {
+		aLines[i], bLines[i] = aLine, bLine
+		if o.kind != opInsert {
+			aLine++
+		}
+		if o.kind != opDelete {
+			bLine++
+		}
+	}
+	aLines[len(ops)], bLines[len(ops)] = aLine, bLine
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == opEqual {
+			continue
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+			// Look ahead for another change within the context
+			next := end
+			for next < len(ops) && ops[next].kind == opEqual && next-end < 2*context {
+				next++
+			}
+			if next < len(ops) && ops[next].kind != opEqual {
+				end = next
+				continue
+			}
+			break
+		}
+		end += context
+		if end > len(ops) {
+			end = len(ops)
+		}
+		result = append(result, hunk{
+			start: start, end: end,
+			aStart: aLines[start], aLen: aLines[end] - aLines[start],
+			bStart: bLines[start], bLen: bLines[end] - bLines[start],
+		})
+		i = end - 1
+	}
+	return result
+}
+
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
@@ -0,0 +1,52 @@
+package textdiff
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestUnified(t *testing.T) {
+	c := qt.New(t)
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	c.Assert(Unified("a.yaml", "b.yaml", a, b, 2), qt.Equals, `
+--- a.yaml
++++ b.yaml
+@@ -1,4 +1,4 @@
+ a
+-b
++B
+ c
+ d
+@@ -12,2 +12,3 @@
+ l
+ m
++n
+`[1:])
+
+	// Changes close together are in the same hunk
+	b = "a\nB\nc\nd\nE\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	c.Assert(Unified("a.yaml", "b.yaml", a, b, 2), qt.Equals, `
+--- a.yaml
++++ b.yaml
+@@ -1,7 +1,7 @@
+ a
+-b
++B
+ c
+ d
+-e
++E
+ f
+ g
+`[1:])
+
+	c.Assert(Unified("a.yaml", "b.yaml", a, a, 2), qt.Equals, "")
+	c.Assert(Unified("a.yaml", "b.yaml", "", "x\n", 3), qt.Equals, `
+--- a.yaml
++++ b.yaml
+@@ -0,0 +1,1 @@
++x
+`[1:])
+}
@@ -8,3 +8,37 @@ type Linter interface {
 	NewRules(ctx context.Context, files ...string) (Linter, error)
 	Run(ctx context.Context, files ...string) error
 }
+
+// A Finding is a problem found by a Linter in a file.
+type Finding struct {
+	File     string
+	Line     int
+	Rule     string
+	Severity Severity
+	Message  string
+
+	// Path is the location of the finding in the document in File, as the
+	// keys and indexes leading to it, if known.
+	Path []string
+}
+
+// Severity is the severity of a Finding.
+type Severity string
+
+// Severities of findings, from most to least severe.
+const (
+	SeverityError Severity = "error"
+	SeverityWarn  Severity = "warn"
+	SeverityInfo  Severity = "info"
+	SeverityHint  Severity = "hint"
+)
+
+// A ReportingLinter is a Linter which can also return its findings, rather
+// than only writing them to standard output.
+type ReportingLinter interface {
+	Linter
+
+	// Report runs the linter on the given files and returns its findings. As
+	// with Run, an error is returned when lint fails configured rules.
+	Report(ctx context.Context, files ...string) ([]Finding, error)
+}
@@ -0,0 +1,165 @@
+// Package vervetrules lints OpenAPI specs with rules built into vervet, so
+// that projects can be linted without Node or Docker installed.
+package vervetrules
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+	yaml3 "gopkg.in/yaml.v3"
+
+	"github.com/snyk/vervet/internal/spectral"
+	"github.com/snyk/vervet/internal/types"
+)
+
+// Linter checks OpenAPI spec files with registered rules.
+type Linter struct {
+	rules []*Rule
+}
+
+// New returns a new Linter which checks the named rules. If no rules are
+// named, all registered rules are checked.
+func New(ctx context.Context, rules []string) (*Linter, error) {
+	if len(rules) == 0 {
+		return &Linter{rules: Rules()}, nil
+	}
+	l := &Linter{}
+	seen := map[string]bool{}
+	for _, name := range rules {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		rule, ok := Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+		l.rules = append(l.rules, rule)
+	}
+	return l, nil
+}
+
+// NewRules returns a new Linter instance with additional rules checked.
+func (l *Linter) NewRules(ctx context.Context, rules ...string) (types.Linter, error) {
+	names := make([]string, 0, len(l.rules)+len(rules))
+	for _, rule := range l.rules {
+		names = append(names, rule.Name)
+	}
+	return New(ctx, append(names, rules...))
+}
+
+// Run checks the given files. Findings are written to standard output.
+// Returns an error when lint fails configured rules.
+func (l *Linter) Run(ctx context.Context, files ...string) error {
+	_, err := l.Report(ctx, files...)
+	return err
+}
+
+// Report checks the given files and returns its findings, which are also
+// written to standard output, in the same form as those of other linters.
+// Returns an error when lint fails configured rules.
+func (l *Linter) Report(ctx context.Context, files ...string) ([]types.Finding, error) {
+	var findings []types.Finding
+	for _, path := range files {
+		f, err := loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range l.rules {
+			for _, problem := range rule.Check(f) {
+				findings = append(findings, types.Finding{
+					File:     path,
+					Line:     f.line(problem.Path),
+					Rule:     rule.Name,
+					Severity: rule.Severity,
+					Message:  problem.Message,
+					Path:     problem.Path,
+				})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	spectral.WriteFindings(os.Stdout, findings)
+	errors := 0
+	for _, finding := range findings {
+		if finding.Severity == types.SeverityError {
+			errors++
+		}
+	}
+	if errors > 0 {
+		return findings, fmt.Errorf("%d findings of error severity", errors)
+	}
+	return findings, nil
+}
+
+// A File is an OpenAPI spec file being linted.
+type File struct {
+	// Path is the path of the file.
+	Path string
+
+	// Doc is the spec in the file. References are not resolved, so rules
+	// only see the file as written.
+	Doc *openapi3.T
+
+	root *yaml3.Node
+}
+
+func loadFile(path string) (*File, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc openapi3.T
+	err = yaml.Unmarshal(buf, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	var root yaml3.Node
+	err = yaml3.Unmarshal(buf, &root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", path, err)
+	}
+	return &File{Path: path, Doc: &doc, root: &root}, nil
+}
+
+// line returns the line of the file at which the keys and indexes of path
+// are found, or the line of the closest enclosing part found.
+func (f *File) line(path []string) int {
+	node := f.root
+	if node.Kind == yaml3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line := node.Line
+	for _, token := range path {
+		var next *yaml3.Node
+		switch node.Kind {
+		case yaml3.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == token {
+					next, line = node.Content[i+1], node.Content[i].Line
+					break
+				}
+			}
+		case yaml3.SequenceNode:
+			if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(node.Content) {
+				next, line = node.Content[i], node.Content[i].Line
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return line
+}
@@ -0,0 +1,121 @@
+package vervetrules
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/internal/types"
+	"github.com/snyk/vervet/testdata"
+)
+
+const badSpec = `
+openapi: 3.0.3
+x-snyk-api-stability: stable
+info:
+  title: things
+  version: 2021-06-02
+paths:
+  /things:
+    get:
+      operationId: listThings
+      responses:
+        '200':
+          description: OK
+    post:
+      responses:
+        '201':
+          description: Created
+`
+
+func TestLinter(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+	c.Patch(&os.Stdout, tempStdout(c))
+
+	specDir := filepath.Join(c.Mkdir(), "things", "2021-06-01")
+	c.Assert(os.MkdirAll(specDir, 0777), qt.IsNil)
+	specFile := filepath.Join(specDir, "spec.yaml")
+	c.Assert(ioutil.WriteFile(specFile, []byte(badSpec[1:]), 0666), qt.IsNil)
+
+	l, err := New(ctx, nil)
+	c.Assert(err, qt.IsNil)
+	findings, err := l.Report(ctx, specFile)
+	c.Assert(err, qt.ErrorMatches, `3 findings of error severity`)
+	c.Assert(findings, qt.DeepEquals, []types.Finding{{
+		File:     specFile,
+		Line:     2,
+		Rule:     RuleStabilityExtension,
+		Severity: types.SeverityError,
+		Message:  `invalid stability "stable"`,
+		Path:     []string{"x-snyk-api-stability"},
+	}, {
+		File:     specFile,
+		Line:     5,
+		Rule:     RuleVersionDirectory,
+		Severity: types.SeverityError,
+		Message:  `info version 2021-06-02 does not match version directory "2021-06-01"`,
+		Path:     []string{"info", "version"},
+	}, {
+		File:     specFile,
+		Line:     13,
+		Rule:     RuleOperationID,
+		Severity: types.SeverityError,
+		Message:  `POST /things has no operationId`,
+		Path:     []string{"paths", "/things", "post"},
+	}})
+
+	// Only the rules named are checked.
+	l, err = New(ctx, []string{RuleOperationID})
+	c.Assert(err, qt.IsNil)
+	findings, err = l.Report(ctx, specFile)
+	c.Assert(err, qt.ErrorMatches, `1 findings of error severity`)
+	c.Assert(findings, qt.HasLen, 1)
+
+	// Rules may be added, such as by linter overrides.
+	withRules, err := l.NewRules(ctx, RuleVersionDirectory, RuleOperationID)
+	c.Assert(err, qt.IsNil)
+	var names []string
+	for _, rule := range withRules.(*Linter).rules {
+		names = append(names, rule.Name)
+	}
+	c.Assert(names, qt.DeepEquals, []string{RuleOperationID, RuleVersionDirectory})
+
+	_, err = New(ctx, []string{"nope"})
+	c.Assert(err, qt.ErrorMatches, `unknown rule "nope"`)
+}
+
+func TestLinterPasses(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+	c.Patch(&os.Stdout, tempStdout(c))
+
+	l, err := New(ctx, nil)
+	c.Assert(err, qt.IsNil)
+	err = l.Run(ctx,
+		testdata.Path("resources/_examples/hello-world/2021-06-01/spec.yaml"),
+		testdata.Path("resources/projects/2021-06-04/spec.yaml"),
+	)
+	c.Assert(err, qt.IsNil)
+}
+
+func TestRules(t *testing.T) {
+	c := qt.New(t)
+	var names []string
+	for _, rule := range Rules() {
+		names = append(names, rule.Name)
+	}
+	c.Assert(names, qt.DeepEquals, []string{RuleOperationID, RuleStabilityExtension, RuleVersionDirectory})
+	c.Assert(func() { Register(&Rule{Name: RuleOperationID}) }, qt.PanicMatches, `rule "operation-id" already registered`)
+}
+
+func tempStdout(c *qt.C) *os.File {
+	f, err := os.Create(filepath.Join(c.Mkdir(), "stdout"))
+	c.Assert(err, qt.IsNil)
+	c.Cleanup(func() { f.Close() })
+	return f
+}
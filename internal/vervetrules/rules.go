@@ -0,0 +1,166 @@
+package vervetrules
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/internal/types"
+)
+
+// A Rule is a check made on each OpenAPI spec file linted.
+type Rule struct {
+	// Name identifies the rule in configuration and in findings.
+	Name string
+
+	// Description describes what the rule requires.
+	Description string
+
+	// Severity is the severity of the rule's findings. Only findings of error
+	// severity fail lint.
+	Severity types.Severity
+
+	// Check returns the problems the rule finds in a spec file.
+	Check func(f *File) []Problem
+}
+
+// A Problem is a part of a spec file which fails a rule.
+type Problem struct {
+	// Path locates the part of the spec which fails the rule, as the keys
+	// and indexes leading to it from the top of the document.
+	Path []string
+
+	// Message describes the problem.
+	Message string
+}
+
+var registry = map[string]*Rule{}
+
+// Register adds a rule to the registry of rules available to vervet-rules
+// linters. Register panics if a rule of the same name is already registered,
+// so it should only be called from init functions.
+func Register(r *Rule) {
+	if _, ok := registry[r.Name]; ok {
+		panic(fmt.Sprintf("rule %q already registered", r.Name))
+	}
+	registry[r.Name] = r
+}
+
+// Lookup returns the registered rule with the given name.
+func Lookup(name string) (*Rule, bool) {
+	r, ok := registry[name]
+	return r, ok
+}
+
+// Rules returns all the registered rules, in order of name.
+func Rules() []*Rule {
+	rules := make([]*Rule, 0, len(registry))
+	for _, r := range registry {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// Names of the built-in rules.
+const (
+	RuleOperationID        = "operation-id"
+	RuleVersionDirectory   = "version-directory"
+	RuleStabilityExtension = "stability-extension"
+)
+
+func init() {
+	Register(&Rule{
+		Name:        RuleOperationID,
+		Description: "Every operation must declare an operationId.",
+		Severity:    types.SeverityError,
+		Check:       checkOperationID,
+	})
+	Register(&Rule{
+		Name: RuleVersionDirectory,
+		Description: "A spec must be in a version directory, which matches the " +
+			"version in its info if that is a version date.",
+		Severity: types.SeverityError,
+		Check:    checkVersionDirectory,
+	})
+	Register(&Rule{
+		Name:        RuleStabilityExtension,
+		Description: "A spec must declare a valid " + vervet.ExtSnykApiStability + " extension.",
+		Severity:    types.SeverityError,
+		Check:       checkStabilityExtension,
+	})
+}
+
+func checkOperationID(f *File) []Problem {
+	var problems []Problem
+	for _, path := range sortedPaths(f) {
+		for _, method := range methods {
+			op := f.Doc.Paths[path].GetOperation(method)
+			if op == nil || op.OperationID != "" {
+				continue
+			}
+			problems = append(problems, Problem{
+				Path:    []string{"paths", path, strings.ToLower(method)},
+				Message: fmt.Sprintf("%s %s has no operationId", method, path),
+			})
+		}
+	}
+	return problems
+}
+
+func checkVersionDirectory(f *File) []Problem {
+	versionDir := filepath.Base(filepath.Dir(f.Path))
+	dirVersion, err := vervet.ParseVersion(versionDir)
+	if err != nil {
+		return []Problem{{
+			Message: fmt.Sprintf("directory %q is not a version", versionDir),
+		}}
+	}
+	if f.Doc.Info == nil {
+		return nil
+	}
+	// Only info versions which are version dates are compared; most are
+	// unrelated, such as 3.0.0.
+	infoVersion, err := vervet.ParseVersion(f.Doc.Info.Version)
+	if err != nil || infoVersion.Semantic {
+		return nil
+	}
+	if infoVersion.DateString() != dirVersion.DateString() {
+		return []Problem{{
+			Path: []string{"info", "version"},
+			Message: fmt.Sprintf("info version %s does not match version directory %q",
+				f.Doc.Info.Version, versionDir),
+		}}
+	}
+	return nil
+}
+
+func checkStabilityExtension(f *File) []Problem {
+	if _, ok := f.Doc.Extensions[vervet.ExtSnykApiStability]; !ok {
+		return []Problem{{
+			Message: fmt.Sprintf("missing %s extension", vervet.ExtSnykApiStability),
+		}}
+	}
+	path := []string{vervet.ExtSnykApiStability}
+	s, err := vervet.ExtensionString(f.Doc.ExtensionProps, vervet.ExtSnykApiStability)
+	if err != nil {
+		return []Problem{{Path: path, Message: err.Error()}}
+	}
+	if _, err := vervet.ParseStability(s); err != nil {
+		return []Problem{{Path: path, Message: err.Error()}}
+	}
+	return nil
+}
+
+var methods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+
+func sortedPaths(f *File) []string {
+	paths := make([]string, 0, len(f.Doc.Paths))
+	for path := range f.Doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,89 @@
+// Package waiver applies the waivers of breaking changes declared by an API,
+// so that acknowledged changes do not fail checks for breaking changes until
+// their waivers expire. Waivers may be signed, so that only those approved by
+// the holder of a signing key apply.
+package waiver
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/snyk/vervet/config"
+)
+
+// messageFormat identifies the format of signed waiver messages.
+const messageFormat = "vervet-waiver-1"
+
+// Message returns the message signed for a waiver of a breaking change to an
+// API: the API name and the fields of the waiver other than its signature.
+// The API is included so that a waiver signed for one API does not apply to
+// another.
+func Message(apiName string, w *config.Waiver) []byte {
+	buf, err := json.Marshal([]string{messageFormat, apiName, w.Change, w.Author, w.Reason, w.Expires})
+	if err != nil {
+		// Marshaling strings does not fail.
+		panic(err)
+	}
+	return buf
+}
+
+// Sign signs a waiver of a breaking change to an API with a private key.
+func Sign(apiName string, w *config.Waiver, key ed25519.PrivateKey) {
+	w.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, Message(apiName, w)))
+}
+
+// Verify returns an error if a waiver of a breaking change to an API was not
+// signed by the private key of a public key.
+func Verify(apiName string, w *config.Waiver, key ed25519.PublicKey) error {
+	if w.Signature == "" {
+		return errors.New("waiver is not signed")
+	}
+	sig, err := base64.StdEncoding.DecodeString(w.Signature)
+	if err != nil || !ed25519.Verify(key, Message(apiName, w), sig) {
+		return errors.New("waiver signature is not valid")
+	}
+	return nil
+}
+
+// A Checker finds the waivers which apply to breaking changes.
+type Checker struct {
+	// Key verifies the signature of each waiver. If nil, signatures are not
+	// checked.
+	Key ed25519.PublicKey
+
+	// Now is when waivers are checked for expiry.
+	Now time.Time
+}
+
+// Find returns the waiver which applies to a breaking change to an API, if
+// any. If waivers of the change were found, but none applies, because they
+// expired or are not signed, the reason is returned as an error.
+func (c *Checker) Find(apiName string, waivers []*config.Waiver, change string) (*config.Waiver, error) {
+	var notApplied error
+	for _, w := range waivers {
+		if w.Change != change {
+			continue
+		}
+		expires, err := time.Parse("2006-01-02", w.Expires)
+		if err != nil {
+			return nil, fmt.Errorf("invalid waiver expiry %q", w.Expires)
+		}
+		// Waivers apply through the day they expire.
+		if !c.Now.Before(expires.AddDate(0, 0, 1)) {
+			notApplied = fmt.Errorf("waiver by %s expired on %s", w.Author, w.Expires)
+			continue
+		}
+		if c.Key != nil {
+			if err := Verify(apiName, w, c.Key); err != nil {
+				notApplied = fmt.Errorf("%w (waiver by %s)", err, w.Author)
+				continue
+			}
+		}
+		return w, nil
+	}
+	return nil, notApplied
+}
@@ -0,0 +1,51 @@
+package waiver_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/waiver"
+)
+
+func TestFind(t *testing.T) {
+	c := qt.New(t)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, qt.IsNil)
+	w := &config.Waiver{
+		Change:  "/things/{id}: path removed",
+		Author:  "jo@example.com",
+		Reason:  "Things were never released",
+		Expires: "2021-06-30",
+	}
+	unsigned := *w
+	waiver.Sign("things", w, priv)
+	c.Assert(waiver.Verify("things", w, pub), qt.IsNil)
+	// Signatures only apply to the API they were signed for.
+	c.Assert(waiver.Verify("widgets", w, pub), qt.ErrorMatches, `waiver signature is not valid`)
+
+	checker := &waiver.Checker{Key: pub, Now: time.Date(2021, 6, 30, 23, 0, 0, 0, time.UTC)}
+	found, err := checker.Find("things", []*config.Waiver{w}, w.Change)
+	c.Assert(err, qt.IsNil)
+	c.Assert(found, qt.Equals, w)
+	found, err = checker.Find("things", []*config.Waiver{w}, "/things: path removed")
+	c.Assert(err, qt.IsNil)
+	c.Assert(found, qt.IsNil)
+	_, err = checker.Find("things", []*config.Waiver{&unsigned}, w.Change)
+	c.Assert(err, qt.ErrorMatches, `waiver is not signed \(waiver by jo@example.com\)`)
+
+	// Waivers apply through the day they expire.
+	checker.Now = checker.Now.Add(time.Hour)
+	_, err = checker.Find("things", []*config.Waiver{w}, w.Change)
+	c.Assert(err, qt.ErrorMatches, `waiver by jo@example.com expired on 2021-06-30`)
+
+	// Without a key, signatures are not checked.
+	checker = &waiver.Checker{Now: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)}
+	found, err = checker.Find("things", []*config.Waiver{&unsigned}, w.Change)
+	c.Assert(err, qt.IsNil)
+	c.Assert(found, qt.Equals, &unsigned)
+}
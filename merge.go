@@ -2,6 +2,7 @@ package vervet
 
 import (
 	"sort"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
@@ -23,6 +24,14 @@ func Merge(dst, src *openapi3.T, replace bool) {
 	mergeTags(dst, src, replace)
 }
 
+// mergeTags merges the tags of src into dst. Tags with the same name are
+// merged, the tag in src replacing that in dst if replace is set.
+//
+// When replacing, as overlays do, tags are ordered as src orders them,
+// followed by any other tags of dst in their prior order, so that an overlay
+// controls the order in which docs renderers present them. Otherwise tags are
+// sorted by name, so that the order does not depend on the order documents
+// are merged in.
 func mergeTags(dst, src *openapi3.T, replace bool) {
 	m := map[string]*openapi3.Tag{}
 	for _, t := range dst.Tags {
@@ -33,58 +42,207 @@ func mergeTags(dst, src *openapi3.T, replace bool) {
 			m[t.Name] = t
 		}
 	}
-	dst.Tags = openapi3.Tags{}
 	var tagNames []string
-	for tagName := range m {
-		tagNames = append(tagNames, tagName)
+	if replace {
+		seen := map[string]bool{}
+		for _, tags := range []openapi3.Tags{src.Tags, dst.Tags} {
+			for _, t := range tags {
+				if !seen[t.Name] {
+					seen[t.Name] = true
+					tagNames = append(tagNames, t.Name)
+				}
+			}
+		}
+	} else {
+		for tagName := range m {
+			tagNames = append(tagNames, tagName)
+		}
+		sort.Strings(tagNames)
 	}
-	sort.Strings(tagNames)
+	dst.Tags = openapi3.Tags{}
 	for _, tagName := range tagNames {
 		dst.Tags = append(dst.Tags, m[tagName])
 	}
 }
 
+// TagConflict is a tag described differently by documents merged together.
+type TagConflict struct {
+	Name string
+
+	// Descriptions are the distinct descriptions of the tag, in the order of
+	// the documents declaring them.
+	Descriptions []string
+}
+
+// TagConflicts returns the tags described differently by documents which are
+// to be merged, in order of name. When merged, the description kept depends
+// on the order of the documents, and whether they replace those merged
+// before; this is rarely what was intended.
+func TagConflicts(docs ...*openapi3.T) []TagConflict {
+	descriptions := map[string][]string{}
+	for _, doc := range docs {
+		for _, t := range doc.Tags {
+			if !containsString(descriptions[t.Name], t.Description) {
+				descriptions[t.Name] = append(descriptions[t.Name], t.Description)
+			}
+		}
+	}
+	var result []TagConflict
+	for name, descs := range descriptions {
+		if len(descs) > 1 {
+			result = append(result, TagConflict{Name: name, Descriptions: descs})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// PathConflict is a path template spelled differently by documents to be
+// merged, or within one document, such as /orgs/{orgId} and /orgs/{org_id}.
+// Such paths match the same requests, but are distinct keys in the paths of
+// a merged document, so both are published rather than merged.
+type PathConflict struct {
+	// Template is the path template with its parameter names removed, as
+	// returned by NormalizePathTemplate.
+	Template string
+
+	// Paths are the distinct spellings of the path, in the order of the
+	// documents declaring them.
+	Paths []string
+}
+
+// PathConflicts returns the paths which differ only in the names of their
+// parameters, in order of their normalized templates.
+func PathConflicts(docs ...*openapi3.T) []PathConflict {
+	spellings := map[string][]string{}
+	for _, doc := range docs {
+		paths := make([]string, 0, len(doc.Paths))
+		for path := range doc.Paths {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			template := NormalizePathTemplate(path)
+			if !containsString(spellings[template], path) {
+				spellings[template] = append(spellings[template], path)
+			}
+		}
+	}
+	var result []PathConflict
+	for template, paths := range spellings {
+		if len(paths) > 1 {
+			result = append(result, PathConflict{Template: template, Paths: paths})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Template < result[j].Template
+	})
+	return result
+}
+
+// NormalizePathTemplate returns a path template with the names of its
+// parameters removed, so that templates may be compared by their structure.
+// For example, /orgs/{orgId}/things/{id} and /orgs/{org_id}/things/{thingId}
+// both normalize to /orgs/{}/things/{}.
+func NormalizePathTemplate(path string) string {
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			break
+		}
+		sb.WriteString(path[:start])
+		sb.WriteString("{}")
+		path = path[start+end+1:]
+	}
+	sb.WriteString(path)
+	return sb.String()
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func mergeComponents(dst, src *openapi3.T, replace bool) {
+	// Components may be missing from the destination document entirely.
+	if dst.Components.Schemas == nil && len(src.Components.Schemas) > 0 {
+		dst.Components.Schemas = openapi3.Schemas{}
+	}
 	for k, v := range src.Components.Schemas {
 		if _, ok := dst.Components.Schemas[k]; !ok || replace {
 			dst.Components.Schemas[k] = v
 		}
 	}
+	if dst.Components.Parameters == nil && len(src.Components.Parameters) > 0 {
+		dst.Components.Parameters = openapi3.ParametersMap{}
+	}
 	for k, v := range src.Components.Parameters {
 		if _, ok := dst.Components.Parameters[k]; !ok || replace {
 			dst.Components.Parameters[k] = v
 		}
 	}
+	if dst.Components.Headers == nil && len(src.Components.Headers) > 0 {
+		dst.Components.Headers = openapi3.Headers{}
+	}
 	for k, v := range src.Components.Headers {
 		if _, ok := dst.Components.Headers[k]; !ok || replace {
 			dst.Components.Headers[k] = v
 		}
 	}
+	if dst.Components.RequestBodies == nil && len(src.Components.RequestBodies) > 0 {
+		dst.Components.RequestBodies = openapi3.RequestBodies{}
+	}
 	for k, v := range src.Components.RequestBodies {
 		if _, ok := dst.Components.RequestBodies[k]; !ok || replace {
 			dst.Components.RequestBodies[k] = v
 		}
 	}
+	if dst.Components.Responses == nil && len(src.Components.Responses) > 0 {
+		dst.Components.Responses = openapi3.Responses{}
+	}
 	for k, v := range src.Components.Responses {
 		if _, ok := dst.Components.Responses[k]; !ok || replace {
 			dst.Components.Responses[k] = v
 		}
 	}
+	if dst.Components.SecuritySchemes == nil && len(src.Components.SecuritySchemes) > 0 {
+		dst.Components.SecuritySchemes = openapi3.SecuritySchemes{}
+	}
 	for k, v := range src.Components.SecuritySchemes {
 		if _, ok := dst.Components.SecuritySchemes[k]; !ok || replace {
 			dst.Components.SecuritySchemes[k] = v
 		}
 	}
+	if dst.Components.Examples == nil && len(src.Components.Examples) > 0 {
+		dst.Components.Examples = openapi3.Examples{}
+	}
 	for k, v := range src.Components.Examples {
 		if _, ok := dst.Components.Examples[k]; !ok || replace {
 			dst.Components.Examples[k] = v
 		}
 	}
+	if dst.Components.Links == nil && len(src.Components.Links) > 0 {
+		dst.Components.Links = openapi3.Links{}
+	}
 	for k, v := range src.Components.Links {
 		if _, ok := dst.Components.Links[k]; !ok || replace {
 			dst.Components.Links[k] = v
 		}
 	}
+	if dst.Components.Callbacks == nil && len(src.Components.Callbacks) > 0 {
+		dst.Components.Callbacks = openapi3.Callbacks{}
+	}
 	for k, v := range src.Components.Callbacks {
 		if _, ok := dst.Components.Callbacks[k]; !ok || replace {
 			dst.Components.Callbacks[k] = v
@@ -124,7 +124,12 @@ tags:
 		src := mustLoad(c, srcYaml)
 		dst := mustLoad(c, dstYaml)
 		Merge(dst, src, true)
+		// Tags are in the order of the source, followed by the rest.
 		c.Assert(dst.Tags, qt.DeepEquals, openapi3.Tags{{
+			ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{}},
+			Name:           "foo",
+			Description:    "foo resource (src)",
+		}, {
 			ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{}},
 			Name:           "bar",
 			Description:    "bar resource (src)",
@@ -132,12 +137,17 @@ tags:
 			ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{}},
 			Name:           "baz",
 			Description:    "baz resource (dst)",
-		}, {
-			ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]interface{}{}},
-			Name:           "foo",
-			Description:    "foo resource (src)",
 		}})
 	})
+	c.Run("tag conflicts", func(c *qt.C) {
+		src := mustLoad(c, srcYaml)
+		dst := mustLoad(c, dstYaml)
+		c.Assert(TagConflicts(dst, src, dst), qt.DeepEquals, []TagConflict{{
+			Name:         "foo",
+			Descriptions: []string{"foo resource (dst)", "foo resource (src)"},
+		}})
+		c.Assert(TagConflicts(dst, dst), qt.HasLen, 0)
+	})
 }
 
 func TestMergeTopLevel(t *testing.T) {
@@ -235,3 +245,42 @@ func mustLoad(c *qt.C, s string) *openapi3.T {
 	c.Assert(err, qt.IsNil)
 	return doc
 }
+
+func TestNormalizePathTemplate(t *testing.T) {
+	c := qt.New(t)
+	tests := []struct {
+		path, template string
+	}{
+		{"/orgs/{orgId}/things/{id}", "/orgs/{}/things/{}"},
+		{"/orgs/{org_id}/things/{thingId}", "/orgs/{}/things/{}"},
+		{"/things/{id}.json", "/things/{}.json"},
+		{"/things", "/things"},
+		{"/things/{id", "/things/{id"},
+	}
+	for _, test := range tests {
+		c.Check(NormalizePathTemplate(test.path), qt.Equals, test.template)
+	}
+}
+
+func TestPathConflicts(t *testing.T) {
+	c := qt.New(t)
+	doc := func(paths ...string) *openapi3.T {
+		t := &openapi3.T{Paths: openapi3.Paths{}}
+		for _, path := range paths {
+			t.Paths[path] = &openapi3.PathItem{}
+		}
+		return t
+	}
+	a := doc("/orgs/{orgId}/things", "/orgs/{orgId}/things/{thingId}")
+	b := doc("/orgs/{org_id}/things", "/orgs/{orgId}/things/{thingId}", "/orgs/me")
+	c.Assert(PathConflicts(a, b), qt.DeepEquals, []PathConflict{{
+		Template: "/orgs/{}/things",
+		Paths:    []string{"/orgs/{orgId}/things", "/orgs/{org_id}/things"},
+	}})
+	c.Assert(PathConflicts(a, a), qt.HasLen, 0)
+	// Paths within one document may conflict.
+	c.Assert(PathConflicts(doc("/things/{id}", "/things/{thingId}")), qt.DeepEquals, []PathConflict{{
+		Template: "/things/{}",
+		Paths:    []string{"/things/{id}", "/things/{thingId}"},
+	}})
+}
@@ -0,0 +1,248 @@
+package vervet
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/ghodss/yaml"
+
+	"github.com/snyk/vervet/config"
+)
+
+// ProjectConfigFile is the name of the project configuration file, at the
+// root of a project.
+const ProjectConfigFile = ".vervet.yaml"
+
+// Lifecycle is the state of a resource version in its release lifecycle.
+type Lifecycle string
+
+const (
+	// LifecycleUnreleased is a resource version with a future release date.
+	LifecycleUnreleased Lifecycle = "unreleased"
+
+	// LifecycleReleased is a resource version which has been released, and
+	// not succeeded by a later version at its stability or greater.
+	LifecycleReleased Lifecycle = "released"
+
+	// LifecycleDeprecated is a resource version succeeded by a later version
+	// at its stability or greater, which remains available until its sunset
+	// period is over.
+	LifecycleDeprecated Lifecycle = "deprecated"
+
+	// LifecycleSunsetEligible is a deprecated resource version whose sunset
+	// period is over, which may be removed.
+	LifecycleSunsetEligible Lifecycle = "sunset-eligible"
+)
+
+// ProjectAPI describes an API in a project, and the resources in it.
+type ProjectAPI struct {
+	Name      string
+	Resources []*ProjectResource
+}
+
+// ProjectResource describes a resource in an API, and its versions.
+type ProjectResource struct {
+	Name string
+
+	// Path is the slash-separated path of the resource directory, relative to
+	// the project.
+	Path string
+
+	// Versions are the versions of the resource, in order.
+	Versions []*ProjectResourceVersion
+}
+
+// ProjectResourceVersion describes a version of a resource.
+type ProjectResourceVersion struct {
+	Version   *Version
+	Lifecycle Lifecycle
+
+	// SpecFile is the slash-separated path of the version's spec file,
+	// relative to the project.
+	SpecFile string
+
+	// DeprecatedBy is the version which succeeds a deprecated version, nil if
+	// the version is not deprecated.
+	DeprecatedBy *Version
+
+	// SunsetEligible is the date from which a deprecated version may be
+	// removed. It is zero if the version is not deprecated, or is
+	// semantically versioned.
+	SunsetEligible time.Time
+}
+
+// ListResources returns the APIs in a project, the resources in each API and
+// the versions of each resource, as configured by the project configuration
+// file at the root of projectFS. Resource versions are described without
+// loading their specs, other than to find their stability.
+//
+// Resources are listed the same way as they are compiled: resource sets are
+// searched for version directories matching their versioning, and excluded
+// spec files are not listed. Resource spec files which declare no paths are
+// not listed either.
+func ListResources(projectFS fs.FS) ([]*ProjectAPI, error) {
+	return listResources(projectFS, time.Now().UTC())
+}
+
+func listResources(projectFS fs.FS, now time.Time) ([]*ProjectAPI, error) {
+	f, err := projectFS.Open(ProjectConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	proj, err := config.Load(f)
+	if err != nil {
+		return nil, err
+	}
+	var apis []*ProjectAPI
+	for _, apiName := range proj.APINames() {
+		api := &ProjectAPI{Name: apiName}
+		resources := map[string]*ProjectResource{}
+		for rcIndex, rcConfig := range proj.APIs[apiName].Resources {
+			err := listResourceSet(projectFS, rcConfig, resources)
+			if err != nil {
+				return nil, fmt.Errorf("%w (apis.%s.resources[%d])", err, apiName, rcIndex)
+			}
+		}
+		for _, rc := range resources {
+			sort.Slice(rc.Versions, func(i, j int) bool {
+				return rc.Versions[i].Version.Compare(rc.Versions[j].Version) < 0
+			})
+			setLifecycles(rc.Versions, now)
+			api.Resources = append(api.Resources, rc)
+		}
+		sort.Slice(api.Resources, func(i, j int) bool {
+			return api.Resources[i].Path < api.Resources[j].Path
+		})
+		apis = append(apis, api)
+	}
+	return apis, nil
+}
+
+// SpecVersionPattern returns the pattern of version directory names in a
+// resource set, according to its versioning and granularity.
+func SpecVersionPattern(rcConfig *config.ResourceSet) string {
+	if rcConfig.Versioning == config.VersioningSemver {
+		return SemanticVersionPattern
+	}
+	switch rcConfig.Granularity {
+	case config.GranularityMonth:
+		return MonthVersionPattern
+	case config.GranularityWeek:
+		return WeekVersionPattern
+	}
+	return DayVersionPattern
+}
+
+// listResourceSet adds the resource versions in a resource set to resources,
+// by resource directory.
+func listResourceSet(projectFS fs.FS, rcConfig *config.ResourceSet, resources map[string]*ProjectResource) error {
+	rcPath := path.Clean(rcConfig.Path)
+	rcFS, err := fs.Sub(projectFS, rcPath)
+	if err != nil {
+		return err
+	}
+	versionDirs := map[string]string{}
+	return doublestar.GlobWalk(rcFS, SpecGlob(SpecVersionPattern(rcConfig), rcConfig.SpecsPattern()),
+		func(specPath string, d fs.DirEntry) error {
+			specFile := path.Join(rcPath, specPath)
+			if _, excluded := rcConfig.ExcludedBy(specFile); excluded {
+				return nil
+			}
+			versionDir := path.Dir(specFile)
+			if other, ok := versionDirs[versionDir]; ok {
+				return fmt.Errorf("multiple spec files %q and %q match %q",
+					other, specFile, rcConfig.SpecsPattern())
+			}
+			versionDirs[versionDir] = specFile
+			resourceDir := path.Dir(versionDir)
+			if resourceDir == rcPath {
+				// Version directories must be in a resource directory.
+				return nil
+			}
+			version, ok, err := specFileVersion(projectFS, specFile)
+			if err != nil {
+				return err
+			} else if !ok {
+				return nil
+			}
+			rc, ok := resources[resourceDir]
+			if !ok {
+				rc = &ProjectResource{Name: path.Base(resourceDir), Path: resourceDir}
+				resources[resourceDir] = rc
+			}
+			rc.Versions = append(rc.Versions, &ProjectResourceVersion{
+				Version:  version,
+				SpecFile: specFile,
+			})
+			return nil
+		})
+}
+
+// specFileVersion returns the resource version of a spec file, from the name
+// of its directory and its declared stability. False is returned if the spec
+// declares no paths, and so is not a resource version.
+func specFileVersion(projectFS fs.FS, specFile string) (*Version, bool, error) {
+	buf, err := fs.ReadFile(projectFS, specFile)
+	if err != nil {
+		return nil, false, err
+	}
+	var doc struct {
+		Stability string                 `json:"x-snyk-api-stability"`
+		Paths     map[string]interface{} `json:"paths"`
+	}
+	err = yaml.Unmarshal(buf, &doc)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load spec from %q: %w", specFile, err)
+	}
+	if doc.Stability == "" {
+		return nil, false, fmt.Errorf("extension %q not found (%s)", ExtSnykApiStability, specFile)
+	}
+	if len(doc.Paths) == 0 {
+		return nil, false, nil
+	}
+	versionStr := path.Base(path.Dir(specFile))
+	if doc.Stability != "ga" {
+		versionStr += "~" + doc.Stability
+	}
+	version, err := ParseVersion(versionStr)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid version %q (%s)", versionStr, specFile)
+	}
+	return version, true, nil
+}
+
+// setLifecycles sets the lifecycle of each version of a resource, given in
+// order. A version is deprecated by the next version with equal or greater
+// stability, as operations are by DeprecateRemovedOperations.
+func setLifecycles(versions []*ProjectResourceVersion, now time.Time) {
+	for i, v := range versions {
+		if !v.Version.Semantic && v.Version.Date.After(now) {
+			v.Lifecycle = LifecycleUnreleased
+			continue
+		}
+		var successor *Version
+		for _, next := range versions[i+1:] {
+			if next.Version.Stability.Compare(v.Version.Stability) >= 0 {
+				successor = next.Version
+				break
+			}
+		}
+		if successor == nil || (!successor.Semantic && successor.Date.After(now)) {
+			v.Lifecycle = LifecycleReleased
+			continue
+		}
+		v.Lifecycle = LifecycleDeprecated
+		v.DeprecatedBy = successor
+		if !successor.Semantic {
+			v.SunsetEligible = successor.Date.Add(v.Version.Stability.SunsetPeriod())
+			if !v.SunsetEligible.After(now) {
+				v.Lifecycle = LifecycleSunsetEligible
+			}
+		}
+	}
+}
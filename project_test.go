@@ -0,0 +1,118 @@
+package vervet_test
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/go-cmp/cmp"
+
+	. "github.com/snyk/vervet"
+	"github.com/snyk/vervet/testdata"
+)
+
+func TestListResources(t *testing.T) {
+	c := qt.New(t)
+	apis, err := ListResources(os.DirFS(testdata.Path(".")))
+	c.Assert(err, qt.IsNil)
+	c.Assert(apis, qt.HasLen, 1)
+	c.Assert(apis[0].Name, qt.Equals, "testdata")
+	c.Assert(apis[0].Resources, qt.HasLen, 2)
+
+	helloWorld := apis[0].Resources[0]
+	c.Assert(helloWorld.Name, qt.Equals, "hello-world")
+	c.Assert(helloWorld.Path, qt.Equals, "resources/_examples/hello-world")
+	type versionInfo struct {
+		version, specFile string
+		lifecycle         Lifecycle
+		deprecatedBy      string
+		sunsetEligible    string
+	}
+	var versions []versionInfo
+	for _, v := range helloWorld.Versions {
+		info := versionInfo{version: v.Version.String(), specFile: v.SpecFile, lifecycle: v.Lifecycle}
+		if v.DeprecatedBy != nil {
+			info.deprecatedBy = v.DeprecatedBy.String()
+			info.sunsetEligible = v.SunsetEligible.Format("2006-01-02")
+		}
+		versions = append(versions, info)
+	}
+	c.Assert(versions, qt.CmpEquals(cmp.AllowUnexported(versionInfo{})), []versionInfo{{
+		version:        "2021-06-01",
+		specFile:       "resources/_examples/hello-world/2021-06-01/spec.yaml",
+		lifecycle:      LifecycleSunsetEligible,
+		deprecatedBy:   "2021-06-07",
+		sunsetEligible: "2021-12-05",
+	}, {
+		version:   "2021-06-07",
+		specFile:  "resources/_examples/hello-world/2021-06-07/spec.yaml",
+		lifecycle: LifecycleReleased,
+	}, {
+		version:   "2021-06-13~beta",
+		specFile:  "resources/_examples/hello-world/2021-06-13/spec.yaml",
+		lifecycle: LifecycleReleased,
+	}})
+
+	projects := apis[0].Resources[1]
+	c.Assert(projects.Name, qt.Equals, "projects")
+	c.Assert(projects.Versions, qt.HasLen, 1)
+	c.Assert(projects.Versions[0].Version.String(), qt.Equals, "2021-06-04~experimental")
+}
+
+func TestListResourcesLifecycle(t *testing.T) {
+	c := qt.New(t)
+	spec := func(stability string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(`
+openapi: 3.0.3
+x-snyk-api-stability: ` + stability + `
+paths:
+  /things:
+    get: {}
+`)}
+	}
+	projectFS := fstest.MapFS{
+		".vervet.yaml": &fstest.MapFile{Data: []byte(`
+apis:
+  things:
+    resources:
+      - path: resources
+  widgets:
+    versioning: semver
+    resources:
+      - path: semver
+`)},
+		"resources/things/2021-01-01/spec.yaml": spec("beta"),
+		"resources/things/2999-01-01/spec.yaml": spec("ga"),
+		"resources/empty/2021-01-01/spec.yaml": &fstest.MapFile{Data: []byte(`
+openapi: 3.0.3
+x-snyk-api-stability: ga
+paths: {}
+`)},
+		"semver/widgets/v1/spec.yaml":   spec("ga"),
+		"semver/widgets/v1.1/spec.yaml": spec("ga"),
+	}
+	apis, err := ListResources(projectFS)
+	c.Assert(err, qt.IsNil)
+	c.Assert(apis, qt.HasLen, 2)
+	c.Assert(apis[0].Resources, qt.HasLen, 1)
+
+	things := apis[0].Resources[0]
+	c.Assert(things.Path, qt.Equals, "resources/things")
+	c.Assert(things.Versions, qt.HasLen, 2)
+	// Versions are not deprecated by versions yet to be released.
+	c.Assert(things.Versions[0].Lifecycle, qt.Equals, LifecycleReleased)
+	c.Assert(things.Versions[1].Lifecycle, qt.Equals, LifecycleUnreleased)
+
+	widgets := apis[1].Resources[0]
+	c.Assert(widgets.Versions, qt.HasLen, 2)
+	c.Assert(widgets.Versions[0].Lifecycle, qt.Equals, LifecycleDeprecated)
+	c.Assert(widgets.Versions[0].DeprecatedBy.String(), qt.Equals, "v1.1")
+	c.Assert(widgets.Versions[0].SunsetEligible.IsZero(), qt.IsTrue)
+	c.Assert(widgets.Versions[1].Lifecycle, qt.Equals, LifecycleReleased)
+
+	delete(projectFS, "resources/things/2999-01-01/spec.yaml")
+	projectFS["resources/things/2021-02-01/spec.yaml"] = &fstest.MapFile{Data: []byte(`paths: {"/things": {}}`)}
+	_, err = ListResources(projectFS)
+	c.Assert(err, qt.ErrorMatches, `extension "x-snyk-api-stability" not found \(resources/things/2021-02-01/spec.yaml\) \(apis.things.resources\[0\]\)`)
+}
@@ -0,0 +1,217 @@
+package vervet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RemoteRefs configures the resolution of references to remote documents,
+// such as an organization-wide library of shared schemas:
+//
+//     $ref: 'https://schemas.example.com/common.yaml#/Error'
+//
+// Remote references are not resolved unless allowed by a RemoteRefs. Only
+// https URLs are resolved, and only from allowed hosts.
+type RemoteRefs struct {
+	// AllowHosts lists the hosts from which remote references may be
+	// resolved. A leading "*." matches any subdomain of a host.
+	AllowHosts []string
+
+	// CacheDir is a directory in which remote documents are cached once
+	// fetched. Cached documents are used instead of fetching them again. If
+	// empty, remote documents are not cached.
+	CacheDir string
+
+	// Pins maps the URLs of remote documents to the digest of their expected
+	// contents, of the form "sha256:<hex>". Documents which do not match their
+	// pinned digest fail to resolve, whether fetched or cached.
+	Pins map[string]string
+
+	// Client is used to fetch remote documents. If nil, a client which gives
+	// up after 30 seconds is used. Whichever client is used, redirects are
+	// only followed to https URLs on allowed hosts.
+	Client *http.Client
+}
+
+// remoteFetchTimeout limits how long fetching a remote document may take,
+// unless RemoteRefs.Client is set.
+const remoteFetchTimeout = 30 * time.Second
+
+// DocumentOption configures how a Document is loaded.
+type DocumentOption func(*documentOptions)
+
+type documentOptions struct {
+	remoteRefs       *RemoteRefs
+	extensionSchemas *ExtensionSchemas
+	downconversion   *Downconversion
+}
+
+// WithRemoteRefs allows references to remote documents to be resolved, as
+// configured by r.
+func WithRemoteRefs(r *RemoteRefs) DocumentOption {
+	return func(o *documentOptions) {
+		o.remoteRefs = r
+	}
+}
+
+// newLoader returns an OpenAPI loader which resolves references according to
+// the document options.
+func (o *documentOptions) newLoader() *openapi3.Loader {
+	l := openapi3.NewLoader()
+	l.IsExternalRefsAllowed = true
+	l.ReadFromURIFunc = o.remoteRefs.readFromURI
+	if d := o.downconversion; d != nil {
+		l.ReadFromURIFunc = func(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+			buf, err := o.remoteRefs.readFromURI(loader, location)
+			if err != nil {
+				return nil, err
+			}
+			return d.convert(location, buf)
+		}
+	}
+	return l
+}
+
+// readFromURI reads local references as the default loader does, and remote
+// references if they are allowed. It is safe to call on a nil RemoteRefs,
+// which allows no remote references.
+func (r *RemoteRefs) readFromURI(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+	if location.Scheme == "" && location.Host == "" {
+		if location.RawQuery != "" {
+			return nil, fmt.Errorf("unsupported URI: %q", location.String())
+		}
+		return ioutil.ReadFile(location.Path)
+	}
+	if r == nil {
+		return nil, fmt.Errorf("remote reference %q not allowed", location.String())
+	}
+	if err := r.checkURL(location); err != nil {
+		return nil, fmt.Errorf("remote reference %q not allowed: %w", location.String(), err)
+	}
+	docURL := *location
+	docURL.Fragment = ""
+	return r.fetch(docURL.String())
+}
+
+// checkURL returns an error if remote documents may not be fetched from a
+// URL.
+func (r *RemoteRefs) checkURL(location *url.URL) error {
+	if location.Scheme != "https" {
+		return errors.New("only https is supported")
+	}
+	if !r.allowHost(location.Hostname()) {
+		return fmt.Errorf("host %q is not allowed", location.Hostname())
+	}
+	return nil
+}
+
+func (r *RemoteRefs) allowHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, allow := range r.AllowHosts {
+		allow = strings.ToLower(allow)
+		if strings.HasPrefix(allow, "*.") {
+			if strings.HasSuffix(host, allow[1:]) {
+				return true
+			}
+		} else if host == allow {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch returns the contents of a remote document, from the cache if
+// available, otherwise from its URL.
+func (r *RemoteRefs) fetch(docURL string) ([]byte, error) {
+	var cachePath string
+	if r.CacheDir != "" {
+		sum := sha256.Sum256([]byte(docURL))
+		cachePath = filepath.Join(r.CacheDir, hex.EncodeToString(sum[:]))
+		buf, err := ioutil.ReadFile(cachePath)
+		if err == nil {
+			if err := r.verify(docURL, buf); err != nil {
+				return nil, fmt.Errorf("%w (cached in %q)", err, cachePath)
+			}
+			return buf, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read cached %q: %w", docURL, err)
+		}
+	}
+
+	resp, err := r.client().Get(docURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %q: %w", docURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %q: %s", docURL, resp.Status)
+	}
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %q: %w", docURL, err)
+	}
+	if err := r.verify(docURL, buf); err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		err = os.MkdirAll(r.CacheDir, 0777)
+		if err == nil {
+			err = ioutil.WriteFile(cachePath, buf, 0666)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to cache %q: %w", docURL, err)
+		}
+	}
+	return buf, nil
+}
+
+// client returns the client with which remote documents are fetched. It
+// follows redirects only to URLs from which remote documents may be fetched.
+func (r *RemoteRefs) client() *http.Client {
+	client := &http.Client{Timeout: remoteFetchTimeout}
+	if r.Client != nil {
+		configured := *r.Client
+		client = &configured
+	}
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := r.checkURL(req.URL); err != nil {
+			return fmt.Errorf("redirect to %q not allowed: %w", req.URL.String(), err)
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return client
+}
+
+// verify returns an error if the contents of a remote document do not match
+// its pinned digest.
+func (r *RemoteRefs) verify(docURL string, buf []byte) error {
+	pin, ok := r.Pins[docURL]
+	if !ok {
+		return nil
+	}
+	sum := sha256.Sum256(buf)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if !strings.EqualFold(pin, digest) {
+		return fmt.Errorf("digest of %q is %s, does not match pinned digest %s", docURL, digest, pin)
+	}
+	return nil
+}
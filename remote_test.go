@@ -0,0 +1,163 @@
+package vervet_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	. "github.com/snyk/vervet"
+)
+
+const remoteCommonYAML = `
+components:
+  schemas:
+    Error:
+      type: object
+      properties:
+        detail:
+          type: string
+`
+
+const remoteSpecYAML = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 0.0.0
+paths:
+  /foo:
+    get:
+      responses:
+        '400':
+          description: error
+          content:
+            application/json:
+              schema:
+                $ref: 'REMOTE/common.yaml#/components/schemas/Error'
+`
+
+func setupRemote(c *qt.C) (srv *httptest.Server, specFile string, requests *int) {
+	requests = new(int)
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if r.URL.Path != "/common.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(remoteCommonYAML))
+	}))
+	c.Cleanup(srv.Close)
+	specFile = filepath.Join(c.Mkdir(), "spec.yaml")
+	err := ioutil.WriteFile(specFile, []byte(strings.Replace(remoteSpecYAML, "REMOTE", srv.URL, 1)), 0666)
+	c.Assert(err, qt.IsNil)
+	return srv, specFile, requests
+}
+
+func TestRemoteRefs(t *testing.T) {
+	c := qt.New(t)
+	srv, specFile, requests := setupRemote(c)
+
+	c.Run("not allowed by default", func(c *qt.C) {
+		_, err := NewDocumentFile(specFile)
+		c.Assert(err, qt.ErrorMatches, `.*remote reference "https://127\.0\.0\.1:\d+/common\.yaml" not allowed`)
+	})
+	c.Run("host not allowed", func(c *qt.C) {
+		_, err := NewDocumentFile(specFile, WithRemoteRefs(&RemoteRefs{
+			AllowHosts: []string{"*.example.com"},
+			Client:     srv.Client(),
+		}))
+		c.Assert(err, qt.ErrorMatches, `.*host "127\.0\.0\.1" is not allowed`)
+	})
+	c.Run("allowed", func(c *qt.C) {
+		doc, err := NewDocumentFile(specFile, WithRemoteRefs(&RemoteRefs{
+			AllowHosts: []string{"127.0.0.1"},
+			Client:     srv.Client(),
+		}))
+		c.Assert(err, qt.IsNil)
+		schema := doc.Paths["/foo"].Get.Responses["400"].Value.Content["application/json"].Schema
+		c.Assert(schema.Value.Properties["detail"], qt.Not(qt.IsNil))
+	})
+
+	sum := sha256.Sum256([]byte(remoteCommonYAML))
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	c.Run("pinned", func(c *qt.C) {
+		_, err := NewDocumentFile(specFile, WithRemoteRefs(&RemoteRefs{
+			AllowHosts: []string{"127.0.0.1"},
+			Pins:       map[string]string{srv.URL + "/common.yaml": digest},
+			Client:     srv.Client(),
+		}))
+		c.Assert(err, qt.IsNil)
+	})
+	c.Run("pin mismatch", func(c *qt.C) {
+		_, err := NewDocumentFile(specFile, WithRemoteRefs(&RemoteRefs{
+			AllowHosts: []string{"127.0.0.1"},
+			Pins:       map[string]string{srv.URL + "/common.yaml": "sha256:" + strings.Repeat("0", 64)},
+			Client:     srv.Client(),
+		}))
+		c.Assert(err, qt.ErrorMatches, `.*does not match pinned digest sha256:0+`)
+	})
+	c.Run("cached", func(c *qt.C) {
+		remoteRefs := &RemoteRefs{
+			AllowHosts: []string{"127.0.0.1"},
+			CacheDir:   c.Mkdir(),
+			Pins:       map[string]string{srv.URL + "/common.yaml": digest},
+			Client:     srv.Client(),
+		}
+		_, err := NewDocumentFile(specFile, WithRemoteRefs(remoteRefs))
+		c.Assert(err, qt.IsNil)
+		fetched := *requests
+		_, err = NewDocumentFile(specFile, WithRemoteRefs(remoteRefs))
+		c.Assert(err, qt.IsNil)
+		c.Assert(*requests, qt.Equals, fetched)
+	})
+}
+
+func TestRemoteRefsRedirect(t *testing.T) {
+	c := qt.New(t)
+	srv, _, _ := setupRemote(c)
+	redirects := map[string]string{
+		// The same server, by a host name which is not allowed.
+		"/other-host.yaml": strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/common.yaml",
+		"/http.yaml":       strings.Replace(srv.URL, "https:", "http:", 1) + "/common.yaml",
+		"/allowed.yaml":    srv.URL + "/common.yaml",
+	}
+	redirector := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, redirects[r.URL.Path], http.StatusFound)
+	}))
+	c.Cleanup(redirector.Close)
+
+	tests := []struct {
+		path, err string
+	}{{
+		path: "/other-host.yaml",
+		err:  `.*redirect to "https://localhost:\d+/common\.yaml" not allowed: host "localhost" is not allowed`,
+	}, {
+		path: "/http.yaml",
+		err:  `.*redirect to "http://127\.0\.0\.1:\d+/common\.yaml" not allowed: only https is supported`,
+	}, {
+		path: "/allowed.yaml",
+	}}
+	for _, test := range tests {
+		c.Run(test.path, func(c *qt.C) {
+			specFile := filepath.Join(c.Mkdir(), "spec.yaml")
+			err := ioutil.WriteFile(specFile, []byte(strings.Replace(remoteSpecYAML,
+				"REMOTE/common.yaml", redirector.URL+test.path, 1)), 0666)
+			c.Assert(err, qt.IsNil)
+			_, err = NewDocumentFile(specFile, WithRemoteRefs(&RemoteRefs{
+				AllowHosts: []string{"127.0.0.1"},
+				Client:     redirector.Client(),
+			}))
+			if test.err == "" {
+				c.Assert(err, qt.IsNil)
+			} else {
+				c.Assert(err, qt.ErrorMatches, test.err)
+			}
+		})
+	}
+}
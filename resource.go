@@ -3,6 +3,7 @@ package vervet
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"sort"
@@ -45,6 +46,15 @@ func (e *Resource) Validate(ctx context.Context) error {
 	return nil
 }
 
+// sourceLocation returns the location of a part of the resource's spec,
+// located by a JSON pointer, in its source file.
+func (e *Resource) sourceLocation(pointer string) string {
+	if e.Document == nil || e.path == "" {
+		return e.sourcePrefix
+	}
+	return e.SourceLocation(pointer)
+}
+
 // ResourceVersions defines a collection of multiple versions of an Resource.
 type ResourceVersions struct {
 	versions resourceVersionSlice
@@ -84,7 +94,7 @@ func (e *ResourceVersions) At(vs string) (*Resource, error) {
 	}
 	for i := len(e.versions) - 1; i >= 0; i-- {
 		ev := e.versions[i].Version
-		if (ev.Date.Before(v.Date) || ev.Date.Equal(v.Date)) && v.Stability.Compare(ev.Stability) <= 0 {
+		if ev.compareRelease(v) <= 0 && v.Stability.Compare(ev.Stability) <= 0 {
 			return e.versions[i], nil
 		}
 	}
@@ -121,15 +131,15 @@ func (vs versionSlice) Swap(i, j int) { vs[i], vs[j] = vs[j], vs[i] }
 // The endpoint version stability level is defined by the
 // ExtSnykApiStability extension value at the top-level of the OpenAPI
 // document.
-func LoadResourceVersions(epPath string) (*ResourceVersions, error) {
+func LoadResourceVersions(epPath string, options ...DocumentOption) (*ResourceVersions, error) {
 	specYamls, err := filepath.Glob(epPath + "/*/spec.yaml")
 	if err != nil {
 		return nil, err
 	}
-	return LoadResourceVersionsFileset(specYamls)
+	return LoadResourceVersionsFileset(specYamls, options...)
 }
 
-func LoadResourceVersionsFileset(specYamls []string) (*ResourceVersions, error) {
+func LoadResourceVersionsFileset(specYamls []string, options ...DocumentOption) (*ResourceVersions, error) {
 	var eps ResourceVersions
 	var err error
 	for i := range specYamls {
@@ -139,7 +149,7 @@ func LoadResourceVersionsFileset(specYamls []string) (*ResourceVersions, error)
 		}
 		versionDir := filepath.Dir(specYamls[i])
 		versionBase := filepath.Base(versionDir)
-		ep, err := loadResource(specYamls[i], versionBase)
+		ep, err := loadResource(specYamls[i], versionBase, options...)
 		if err != nil {
 			return nil, err
 		}
@@ -174,12 +184,23 @@ func ExtensionString(extProps openapi3.ExtensionProps, key string) (string, erro
 	}
 }
 
-func loadResource(specPath string, versionStr string) (*Resource, error) {
+func loadResource(specPath string, versionStr string, options ...DocumentOption) (*Resource, error) {
 	name := filepath.Base(filepath.Dir(filepath.Dir(specPath)))
-	doc, err := NewDocumentFile(specPath)
+	doc, err := NewDocumentFile(specPath, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load spec from %q: %w", specPath, err)
 	}
+	if doc.options.extensionSchemas != nil {
+		// Extensions are validated before they are used, so that a
+		// misspelled extension is reported as such.
+		err = doc.options.extensionSchemas.Validate(doc.T)
+		var pointerErr *PointerError
+		if errors.As(err, &pointerErr) {
+			return nil, doc.SourceError(pointerErr.Pointer, err)
+		} else if err != nil {
+			return nil, fmt.Errorf("%w (%s)", err, specPath)
+		}
+	}
 
 	stabilityStr, err := ExtensionString(doc.T.ExtensionProps, ExtSnykApiStability)
 	if err != nil {
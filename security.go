@@ -0,0 +1,96 @@
+package vervet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet/internal/specjson"
+)
+
+// CheckSecurityRequirements returns an error if a security requirement in an
+// OpenAPI document, either top-level or on an operation, refers to a security
+// scheme which is not defined in the document's components.
+//
+// Compiled specs should be checked once all overlays are merged, as security
+// schemes are often contributed by an overlay rather than by each resource.
+func CheckSecurityRequirements(t *openapi3.T) error {
+	if err := checkSecurityRequirements(t, t.Security); err != nil {
+		return fmt.Errorf("%w (security)", err)
+	}
+	var pathNames []string
+	for pathName := range t.Paths {
+		pathNames = append(pathNames, pathName)
+	}
+	sort.Strings(pathNames)
+	for _, pathName := range pathNames {
+		ops := t.Paths[pathName].Operations()
+		var methods []string
+		for method := range ops {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			op := ops[method]
+			if op.Security == nil {
+				continue
+			}
+			if err := checkSecurityRequirements(t, *op.Security); err != nil {
+				return fmt.Errorf("%w (paths.%s.%s.security)", err, pathName, method)
+			}
+		}
+	}
+	return nil
+}
+
+func checkSecurityRequirements(t *openapi3.T, reqs openapi3.SecurityRequirements) error {
+	for _, req := range reqs {
+		for schemeName := range req {
+			if _, ok := t.Components.SecuritySchemes[schemeName]; !ok {
+				return fmt.Errorf("security scheme %q not defined in components.securitySchemes", schemeName)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckSecuritySchemeConflicts returns an error if resources define security
+// schemes with the same name, but different definitions.
+//
+// Merging resources keeps the first definition of each security scheme, and
+// identical definitions from multiple resources are merged into one. A
+// conflicting definition would otherwise be silently dropped.
+func CheckSecuritySchemeConflicts(resources []*Resource) error {
+	type source struct {
+		location string
+		buf      []byte
+	}
+	schemes := map[string]source{}
+	for _, rc := range resources {
+		var schemeNames []string
+		for schemeName := range rc.Components.SecuritySchemes {
+			schemeNames = append(schemeNames, schemeName)
+		}
+		sort.Strings(schemeNames)
+		for _, schemeName := range schemeNames {
+			buf, err := json.Marshal(rc.Components.SecuritySchemes[schemeName].Value)
+			if err != nil {
+				return err
+			}
+			pointer := "#/components/securitySchemes/" + specjson.EscapePointer(schemeName)
+			prior, ok := schemes[schemeName]
+			if !ok {
+				schemes[schemeName] = source{location: rc.sourceLocation(pointer), buf: buf}
+				continue
+			}
+			if !bytes.Equal(prior.buf, buf) {
+				return fmt.Errorf("conflicting definitions of security scheme %q in %q and %q",
+					schemeName, prior.location, rc.sourceLocation(pointer))
+			}
+		}
+	}
+	return nil
+}
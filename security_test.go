@@ -0,0 +1,76 @@
+package vervet_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	. "github.com/snyk/vervet"
+)
+
+func mustLoadSpec(c *qt.C, s string) *openapi3.T {
+	t, err := openapi3.NewLoader().LoadFromData([]byte(s))
+	c.Assert(err, qt.IsNil)
+	return t
+}
+
+const securitySpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 0.0.0
+paths:
+  /foo:
+    get:
+      security:
+        - BearerAuth: []
+      responses:
+        '204':
+          description: ok
+`
+
+const securitySchemesSpec = `
+openapi: 3.0.3
+info:
+  title: test
+  version: 0.0.0
+paths: {}
+components:
+  securitySchemes:
+    BearerAuth:
+      type: http
+      scheme: bearer
+`
+
+func TestCheckSecurityRequirements(t *testing.T) {
+	c := qt.New(t)
+	spec := mustLoadSpec(c, securitySpec)
+	err := CheckSecurityRequirements(spec)
+	c.Assert(err, qt.ErrorMatches, `security scheme "BearerAuth" not defined in components.securitySchemes \(paths./foo.GET.security\)`)
+
+	// Schemes are often contributed by an overlay
+	Merge(spec, mustLoadSpec(c, securitySchemesSpec), true)
+	c.Assert(CheckSecurityRequirements(spec), qt.IsNil)
+
+	spec.Security = openapi3.SecurityRequirements{{"ApiKeyAuth": []string{}}}
+	err = CheckSecurityRequirements(spec)
+	c.Assert(err, qt.ErrorMatches, `security scheme "ApiKeyAuth" not defined in components.securitySchemes \(security\)`)
+}
+
+func TestCheckSecuritySchemeConflicts(t *testing.T) {
+	c := qt.New(t)
+	resource := func(spec string) *Resource {
+		return &Resource{Document: &Document{T: mustLoadSpec(c, spec)}}
+	}
+	same := []*Resource{resource(securitySchemesSpec), resource(securitySchemesSpec), resource(securitySpec)}
+	c.Assert(CheckSecuritySchemeConflicts(same), qt.IsNil)
+	merged, err := MergeResources(same)
+	c.Assert(err, qt.IsNil)
+	c.Assert(merged.Components.SecuritySchemes, qt.HasLen, 1)
+
+	conflicting := resource(securitySchemesSpec)
+	conflicting.Components.SecuritySchemes["BearerAuth"].Value.BearerFormat = "JWT"
+	err = CheckSecuritySchemeConflicts([]*Resource{resource(securitySchemesSpec), conflicting})
+	c.Assert(err, qt.ErrorMatches, `conflicting definitions of security scheme "BearerAuth" in "" and ""`)
+}
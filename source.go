@@ -0,0 +1,177 @@
+package vervet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/snyk/vervet/internal/specjson"
+)
+
+// A PointerError is an error in the part of an OpenAPI document located by a
+// JSON pointer, such as #/paths/~1orgs/get.
+type PointerError struct {
+	Pointer string
+	Err     error
+}
+
+// Error implements error.
+func (e *PointerError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error in the located part of the document.
+func (e *PointerError) Unwrap() error {
+	return e.Err
+}
+
+// A SourceError is an error located at a line and column of the source file
+// of a document, with an excerpt of the source around it.
+type SourceError struct {
+	File         string
+	Line, Column int
+	Excerpt      string
+	Err          error
+}
+
+// Error implements error.
+func (e *SourceError) Error() string {
+	msg := fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Err)
+	if e.Excerpt != "" {
+		msg += "\n" + e.Excerpt
+	}
+	return msg
+}
+
+// Unwrap returns the error at the source location.
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// sourceExcerptLines is the number of lines shown on either side of the line
+// a SourceError is located at.
+const sourceExcerptLines = 1
+
+// Position returns the line and column in the document's source file of the
+// part of the document located by a JSON pointer. Object members are located
+// by their key. If the pointer refers to something not in the source, such as
+// a part of the document added once it was loaded, the closest enclosing part
+// in the source is located. False is returned if the document has no source
+// file, or it cannot be parsed.
+func (d *Document) Position(pointer string) (line, column int, ok bool) {
+	root, err := d.sourceNode()
+	if err != nil || root == nil {
+		return 0, 0, false
+	}
+	node, at := root, root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node, at = node.Content[0], node.Content[0]
+	}
+	pointer = strings.TrimPrefix(strings.TrimPrefix(pointer, "#"), "/")
+	if pointer == "" {
+		return at.Line, at.Column, true
+	}
+	for _, token := range strings.Split(pointer, "/") {
+		token = specjson.UnescapePointer(token)
+		for node.Kind == yaml.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+		var next, nextAt *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == token {
+					next, nextAt = node.Content[i+1], node.Content[i]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(node.Content) {
+				next, nextAt = node.Content[i], node.Content[i]
+			}
+		}
+		if next == nil {
+			break
+		}
+		node, at = next, nextAt
+	}
+	return at.Line, at.Column, true
+}
+
+// SourceLocation returns the location in the document's source file of the
+// part of the document located by a JSON pointer, as file:line:column, or
+// just the file if it cannot be located.
+func (d *Document) SourceLocation(pointer string) string {
+	if line, column, ok := d.Position(pointer); ok {
+		return fmt.Sprintf("%s:%d:%d", d.path, line, column)
+	}
+	return d.path
+}
+
+// SourceError returns err located in the document's source file at the part
+// of the document located by a JSON pointer. If it cannot be located, err is
+// returned with the path of the file.
+func (d *Document) SourceError(pointer string, err error) error {
+	line, column, ok := d.Position(pointer)
+	if !ok {
+		return fmt.Errorf("%w (%s)", err, d.path)
+	}
+	return &SourceError{
+		File:    d.path,
+		Line:    line,
+		Column:  column,
+		Excerpt: sourceExcerpt(d.source, line),
+		Err:     err,
+	}
+}
+
+// sourceNode returns the YAML node tree of the document's source, parsed
+// from its file on first use.
+func (d *Document) sourceNode() (*yaml.Node, error) {
+	d.sourceOnce.Do(func() {
+		// Only documents loaded from a file have a source to locate
+		// errors in.
+		if d.path == "" || d.url == nil {
+			return
+		}
+		d.source, d.sourceErr = ioutil.ReadFile(d.path)
+		if d.sourceErr != nil {
+			return
+		}
+		var node yaml.Node
+		d.sourceErr = yaml.Unmarshal(d.source, &node)
+		if d.sourceErr == nil {
+			d.sourceRoot = &node
+		}
+	})
+	return d.sourceRoot, d.sourceErr
+}
+
+// sourceExcerpt returns the lines of source around a line, numbered, with
+// the line itself marked.
+func sourceExcerpt(source []byte, line int) string {
+	lines := strings.Split(string(source), "\n")
+	first, last := line-sourceExcerptLines, line+sourceExcerptLines
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	width := len(strconv.Itoa(last))
+	var sb strings.Builder
+	for i := first; i <= last; i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&sb, "%s %*d | %s", marker, width, i, lines[i-1])
+		if i < last {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+package vervet_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	. "github.com/snyk/vervet"
+	"github.com/snyk/vervet/testdata"
+)
+
+const sourceSpec = `openapi: 3.0.3
+info:
+  title: test
+  version: 3.0.0
+paths:
+  /orgs/{orgId}:
+    get:
+      parameters:
+        - name: orgId
+          in: path
+          required: true
+          schema: {type: string}
+      responses:
+        "204": {description: ok}
+`
+
+func TestDocumentPosition(t *testing.T) {
+	c := qt.New(t)
+	specFile := filepath.Join(c.Mkdir(), "spec.yaml")
+	c.Assert(ioutil.WriteFile(specFile, []byte(sourceSpec), 0644), qt.IsNil)
+	doc, err := NewDocumentFile(specFile)
+	c.Assert(err, qt.IsNil)
+	tests := []struct {
+		pointer      string
+		line, column int
+	}{
+		{"#", 1, 1},
+		{"#/info/title", 3, 3},
+		{"#/paths/~1orgs~1{orgId}/get", 7, 5},
+		{"#/paths/~1orgs~1{orgId}/get/parameters/0/in", 10, 11},
+		// Parts of the document not in the source are located by the
+		// closest part which is.
+		{"#/paths/~1orgs~1{orgId}/post", 6, 3},
+	}
+	for _, test := range tests {
+		c.Run(test.pointer, func(c *qt.C) {
+			line, column, ok := doc.Position(test.pointer)
+			c.Assert(ok, qt.IsTrue)
+			c.Assert([]int{line, column}, qt.DeepEquals, []int{test.line, test.column})
+		})
+	}
+	c.Assert(doc.SourceLocation("#/info/title"), qt.Equals, specFile+":3:3")
+
+	err = doc.SourceError("#/info/title", fmt.Errorf("bad title"))
+	c.Assert(err, qt.ErrorMatches, fmt.Sprintf(`%s:3:3: bad title
+  2 \| info:
+> 3 \|   title: test
+  4 \|   version: 3.0.0`, regexp.QuoteMeta(specFile)))
+}
+
+func TestConflictSourceLocation(t *testing.T) {
+	c := qt.New(t)
+	_, err := LoadSpecVersions(testdata.Path("conflict"))
+	c.Assert(err, qt.ErrorMatches, `conflict: ".*/spec.yaml:[0-9]+:3" ".*/spec.yaml:[0-9]+:3"`)
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet/internal/specjson"
 )
 
 // SpecGlobPattern defines the expected directory structure for the versioned
@@ -17,25 +19,33 @@ import (
 // YYYY-mm-dd, each containing a spec.yaml file.
 const SpecGlobPattern = "**/[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]/spec.yaml"
 
+// SpecGlob returns a glob pattern like SpecGlobPattern, matching spec files
+// named by a filename pattern, such as openapi.yaml or *.oas.yaml, in version
+// directories named by a version pattern, such as MonthVersionPattern.
+func SpecGlob(versionPattern, filename string) string {
+	return "**/" + versionPattern + "/" + filename
+}
+
 // SpecVersions defines an OpenAPI specification consisting of one or more
 // versioned resources.
 type SpecVersions struct {
-	resources []*ResourceVersions
+	resources      []*ResourceVersions
+	versionAliases map[string]string
 }
 
 // LoadSpecVersions returns SpecVersions loaded from a directory structure
 // containing one or more Resource subdirectories.
-func LoadSpecVersions(root string) (*SpecVersions, error) {
+func LoadSpecVersions(root string, options ...DocumentOption) (*SpecVersions, error) {
 	epPaths, err := findResources(root)
 	if err != nil {
 		return nil, err
 	}
-	return LoadSpecVersionsFileset(epPaths)
+	return LoadSpecVersionsFileset(epPaths, options...)
 }
 
 // LoadSpecVersionsFileset returns SpecVersions loaded from a set of spec
 // files.
-func LoadSpecVersionsFileset(epPaths []string) (*SpecVersions, error) {
+func LoadSpecVersionsFileset(epPaths []string, options ...DocumentOption) (*SpecVersions, error) {
 	resourceMap := map[string][]string{}
 	for i := range epPaths {
 		resourcePath := filepath.Dir(filepath.Dir(epPaths[i]))
@@ -52,7 +62,7 @@ func LoadSpecVersionsFileset(epPaths []string) (*SpecVersions, error) {
 	svs := &SpecVersions{}
 	for _, resourcePath := range resourceNames {
 		specFiles := resourceMap[resourcePath]
-		eps, err := LoadResourceVersionsFileset(specFiles)
+		eps, err := LoadResourceVersionsFileset(specFiles, options...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load resource at %q: %w", resourcePath, err)
 		}
@@ -67,7 +77,7 @@ func LoadSpecVersionsFileset(epPaths []string) (*SpecVersions, error) {
 // Validate returns an error if there are conflicting resources at a spec version.
 func (s *SpecVersions) Validate() error {
 	for _, v := range s.Versions() {
-		resourcePaths := map[string]string{}
+		resourcePaths := map[string]*Resource{}
 		for _, eps := range s.resources {
 			ep, err := eps.At(v.String())
 			if err == ErrNoMatchingVersion {
@@ -77,9 +87,11 @@ func (s *SpecVersions) Validate() error {
 			}
 			for path := range ep.Paths {
 				if conflict, ok := resourcePaths[path]; ok {
-					return fmt.Errorf("conflict: %q %q", conflict, ep.sourcePrefix)
+					pointer := "#/paths/" + specjson.EscapePointer(path)
+					return fmt.Errorf("conflict: %q %q",
+						conflict.sourceLocation(pointer), ep.sourceLocation(pointer))
 				}
-				resourcePaths[path] = ep.sourcePrefix
+				resourcePaths[path] = ep
 			}
 		}
 	}
@@ -111,8 +123,25 @@ func (s *SpecVersions) Versions() []*Version {
 	return versions
 }
 
-// At returns the OpenAPI document matching a version string.
+// At returns the OpenAPI document matching a version string. The document
+// is a copy, which shares nothing with the loaded spec versions, so it may be
+// modified without affecting other versions.
 func (s *SpecVersions) At(vs string) (*openapi3.T, error) {
+	resources, err := s.ResourcesAt(vs)
+	if err != nil {
+		return nil, err
+	}
+	return MergeResources(resources)
+}
+
+// ResourcesAt returns the resource versions which make up the OpenAPI
+// document matching a version string, in the order in which they are merged.
+// ErrNoMatchingVersion is returned if no resource has a matching version.
+//
+// Versions which resolve to the same resource versions have identical
+// documents, so this may be used to avoid merging the same document more than
+// once.
+func (s *SpecVersions) ResourcesAt(vs string) ([]*Resource, error) {
 	if vs == "" {
 		vs = time.Now().UTC().Format("2006-01-02")
 	}
@@ -120,7 +149,7 @@ func (s *SpecVersions) At(vs string) (*openapi3.T, error) {
 	if err != nil {
 		return nil, err
 	}
-	var result *openapi3.T
+	var result []*Resource
 	for _, eps := range s.resources {
 		ep, err := eps.At(v.String())
 		if err == ErrNoMatchingVersion {
@@ -128,30 +157,157 @@ func (s *SpecVersions) At(vs string) (*openapi3.T, error) {
 		} else if err != nil {
 			return nil, err
 		}
+		result = append(result, ep)
+	}
+	if len(result) == 0 {
+		return nil, ErrNoMatchingVersion
+	}
+	return result, nil
+}
+
+// MergeResources returns a new OpenAPI document merged from resource
+// versions, such as those returned by ResourcesAt. The document shares nothing
+// with the resources, so it may be modified without affecting them. Nil is
+// returned if there are no resources to merge.
+func MergeResources(resources []*Resource) (*openapi3.T, error) {
+	shared := MergeResourcesShared(resources)
+	if shared == nil {
+		return nil, nil
+	}
+	// The merged document is serialized and loaded again, so that none of
+	// its parts are those of the resources.
+	buf, err := shared.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return openapi3.NewLoader().LoadFromData(buf)
+}
+
+// MergeResourcesShared returns a new OpenAPI document merged from resource
+// versions, as MergeResources does, but without copying their contents. It
+// is much cheaper, for building many versions from the same resources.
+//
+// Only the top-level maps and slices of the document, such as its paths,
+// tags and component maps, belong to it: entries may be added, replaced or
+// removed, as Merge does. Everything in them, such as path items,
+// operations and component schemas, is shared with the resources, and must
+// not be modified; doing so changes every other document merged from the
+// same resources. Nil is returned if there are no resources to merge.
+func MergeResourcesShared(resources []*Resource) *openapi3.T {
+	var result *openapi3.T
+	for _, ep := range resources {
 		if result == nil {
-			// Assign a clean copy of the contents of the first resource to the
-			// resulting spec. Marshaling is used to ensure that references in
-			// the source resource are dropped from the result, which could be
-			// modified on subsequent merges.
-			buf, err := ep.T.MarshalJSON()
-			if err != nil {
-				return nil, err
-			}
-			result = &openapi3.T{}
-			err = result.UnmarshalJSON(buf)
-			if err != nil {
-				return nil, err
-			}
+			// Start from a copy of the first resource which shares its
+			// contents, but not the containers that merging modifies, so
+			// that loaded resources can be reused across versions without
+			// being changed by the merge.
+			result = mergeableCopy(ep.T)
 		}
 		Merge(result, ep.T, false)
 	}
 	if result == nil {
-		return nil, ErrNoMatchingVersion
+		return nil
 	}
 	// Remove the API stability extension from the merged OpenAPI spec, this
 	// extension is only applicable to individual resource version specs.
 	delete(result.ExtensionProps.Extensions, ExtSnykApiStability)
-	return result, nil
+	return result
+}
+
+// mergeableCopy returns a shallow copy of an OpenAPI document, with copies of
+// the top-level maps and slices which may be modified by Merge. Everything
+// else, such as path items and component schemas, is shared with the
+// original document.
+//
+// Merge only adds, replaces or removes entries in these containers; it never
+// modifies the values in them. So the original document is not affected by
+// merging into the copy, and it is much cheaper to copy this way than to clone
+// the entire document.
+func mergeableCopy(t *openapi3.T) *openapi3.T {
+	result := *t
+	result.ExtensionProps.Extensions = make(map[string]interface{}, len(t.Extensions))
+	for k, v := range t.Extensions {
+		result.Extensions[k] = v
+	}
+	result.Paths = make(openapi3.Paths, len(t.Paths))
+	for k, v := range t.Paths {
+		result.Paths[k] = v
+	}
+	result.Tags = append(openapi3.Tags(nil), t.Tags...)
+	result.Security = append(openapi3.SecurityRequirements(nil), t.Security...)
+	result.Servers = append(openapi3.Servers(nil), t.Servers...)
+
+	src, dst := &t.Components, &result.Components
+	dst.Schemas = make(openapi3.Schemas, len(src.Schemas))
+	for k, v := range src.Schemas {
+		dst.Schemas[k] = v
+	}
+	dst.Parameters = make(openapi3.ParametersMap, len(src.Parameters))
+	for k, v := range src.Parameters {
+		dst.Parameters[k] = v
+	}
+	dst.Headers = make(openapi3.Headers, len(src.Headers))
+	for k, v := range src.Headers {
+		dst.Headers[k] = v
+	}
+	dst.RequestBodies = make(openapi3.RequestBodies, len(src.RequestBodies))
+	for k, v := range src.RequestBodies {
+		dst.RequestBodies[k] = v
+	}
+	dst.Responses = make(openapi3.Responses, len(src.Responses))
+	for k, v := range src.Responses {
+		dst.Responses[k] = v
+	}
+	dst.SecuritySchemes = make(openapi3.SecuritySchemes, len(src.SecuritySchemes))
+	for k, v := range src.SecuritySchemes {
+		dst.SecuritySchemes[k] = v
+	}
+	dst.Examples = make(openapi3.Examples, len(src.Examples))
+	for k, v := range src.Examples {
+		dst.Examples[k] = v
+	}
+	dst.Links = make(openapi3.Links, len(src.Links))
+	for k, v := range src.Links {
+		dst.Links[k] = v
+	}
+	dst.Callbacks = make(openapi3.Callbacks, len(src.Callbacks))
+	for k, v := range src.Callbacks {
+		dst.Callbacks[k] = v
+	}
+	return &result
+}
+
+// Resolve returns the version of the spec that At would return for a version
+// string. This is the most recent version date, on or before the requested
+// date, at which a resource version of equal or greater stability was
+// introduced; at the requested stability level. ErrNoMatchingVersion is
+// returned if there is no such version.
+func (s *SpecVersions) Resolve(vs string) (*Version, error) {
+	if vs == "" {
+		vs = time.Now().UTC().Format("2006-01-02")
+	}
+	v, err := ParseVersion(vs)
+	if err != nil {
+		return nil, err
+	}
+	versions := s.Versions()
+	for i := len(versions) - 1; i >= 0; i-- {
+		ev := versions[i]
+		if ev.compareRelease(v) <= 0 && v.Stability.Compare(ev.Stability) <= 0 {
+			resolved := *ev
+			resolved.Stability = v.Stability
+			return &resolved, nil
+		}
+	}
+	return nil, ErrNoMatchingVersion
+}
+
+// VersionAlias returns the version named by an alias, such as "latest" or
+// "stable", if the alias is indexed in the compiled output the spec versions
+// were loaded from.
+func (s *SpecVersions) VersionAlias(alias string) (string, bool) {
+	version, ok := s.versionAliases[alias]
+	return version, ok
 }
 
 func findResources(root string) ([]string, error) {
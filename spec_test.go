@@ -2,6 +2,7 @@ package vervet_test
 
 import (
 	"testing"
+	"time"
 
 	qt "github.com/frankban/quicktest"
 
@@ -87,3 +88,191 @@ func TestSpecs(t *testing.T) {
 		}
 	}
 }
+
+func TestSpecsResolve(t *testing.T) {
+	c := qt.New(t)
+	specs, err := LoadSpecVersions(testdata.Path("resources"))
+	c.Assert(err, qt.IsNil)
+	tests := []struct {
+		query, resolved, err string
+	}{{
+		query:    "2021-07-01~experimental",
+		resolved: "2021-06-13~experimental",
+	}, {
+		query:    "2021-06-05~experimental",
+		resolved: "2021-06-04~experimental",
+	}, {
+		query:    "2021-06-05~beta",
+		resolved: "2021-06-01~beta",
+	}, {
+		query:    "2021-07-01",
+		resolved: "2021-06-07",
+	}, {
+		query: "2021-05-01",
+		err:   "no matching version",
+	}, {
+		query: "nope",
+		err:   `invalid version "nope"`,
+	}}
+	for i, t := range tests {
+		c.Logf("test#%d: %#v", i, t)
+		v, err := specs.Resolve(t.query)
+		if t.err != "" {
+			c.Assert(err, qt.ErrorMatches, t.err)
+			continue
+		}
+		c.Assert(err, qt.IsNil)
+		c.Assert(v.String(), qt.Equals, t.resolved)
+
+		// The resolved version is equivalent to the query.
+		expected, err := specs.At(t.query)
+		c.Assert(err, qt.IsNil)
+		actual, err := specs.At(v.String())
+		c.Assert(err, qt.IsNil)
+		c.Assert(actual, openapiCmp, expected)
+	}
+}
+
+func TestSpecsAtCopies(t *testing.T) {
+	c := qt.New(t)
+	specs, err := LoadSpecVersions(testdata.Path("resources"))
+	c.Assert(err, qt.IsNil)
+	before, err := specs.At("2021-07-01")
+	c.Assert(err, qt.IsNil)
+	expected, err := before.MarshalJSON()
+	c.Assert(err, qt.IsNil)
+
+	// Modifying the document at one version leaves the documents at others,
+	// which are merged from the same resources, unchanged.
+	doc, err := specs.At("2021-06-07")
+	c.Assert(err, qt.IsNil)
+	op := doc.Paths["/examples/hello-world/{id}"].Get
+	c.Assert(op, qt.Not(qt.IsNil))
+	op.Description = "changed"
+	op.Parameters = nil
+	for _, schema := range doc.Components.Schemas {
+		schema.Value.Description = "changed"
+	}
+	for _, pathItem := range doc.Paths {
+		pathItem.Summary = "changed"
+	}
+
+	after, err := specs.At("2021-07-01")
+	c.Assert(err, qt.IsNil)
+	actual, err := after.MarshalJSON()
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(actual), qt.Equals, string(expected))
+}
+
+func TestSpecsResolveMonotonic(t *testing.T) {
+	c := qt.New(t)
+	specs, err := LoadSpecVersions(testdata.Path("resources"))
+	c.Assert(err, qt.IsNil)
+	stabilities := []Stability{StabilityWIP, StabilityExperimental, StabilityBeta, StabilityGA}
+	start := time.Date(2021, time.May, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, time.August, 1, 0, 0, 0, 0, time.UTC)
+	for date := start; date.Before(end); date = date.AddDate(0, 0, 1) {
+		var prior *Version
+		for i := len(stabilities) - 1; i >= 0; i-- {
+			query := &Version{Date: date, Stability: stabilities[i]}
+			resolved, err := specs.Resolve(query.String())
+			if err == ErrNoMatchingVersion {
+				// Nothing resolves at a lower stability or an earlier date
+				// unless something resolves at a higher one.
+				c.Assert(prior, qt.IsNil, qt.Commentf("%s", query))
+				continue
+			}
+			c.Assert(err, qt.IsNil)
+			checkResolved(c, specs, query, resolved)
+			if prior != nil {
+				// Lowering the stability resolves to the same or a later
+				// release.
+				c.Assert(resolved.DateString() >= prior.DateString(), qt.IsTrue,
+					qt.Commentf("%s resolved to %s, before %s", query, resolved, prior))
+			}
+			prior = resolved
+
+			// Moving the date forward resolves to the same or a later
+			// release, at the same stability.
+			next, err := specs.Resolve((&Version{Date: date.AddDate(0, 0, 1), Stability: query.Stability}).String())
+			c.Assert(err, qt.IsNil)
+			c.Assert(next.DateString() >= resolved.DateString(), qt.IsTrue,
+				qt.Commentf("%s resolved to %s, after %s", query, resolved, next))
+		}
+	}
+}
+
+// checkResolved checks the properties of a version resolved by a query.
+func checkResolved(t testing.TB, specs *SpecVersions, query, resolved *Version) {
+	t.Helper()
+	if resolved.Stability != query.Stability {
+		t.Fatalf("%s resolved to %s, at another stability", query, resolved)
+	}
+	if resolved.Compare(query) > 0 {
+		t.Fatalf("%s resolved to a later version %s", query, resolved)
+	}
+	// The release resolved introduced a resource version at the query's
+	// stability or greater.
+	found := false
+	for _, v := range specs.Versions() {
+		if v.DateString() == resolved.DateString() && v.Stability.Compare(query.Stability) >= 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("%s resolved to %s, which has no resource version of stability %s or greater",
+			query, resolved, query.Stability)
+	}
+	// Resolution is idempotent.
+	again, err := specs.Resolve(resolved.String())
+	if err != nil {
+		t.Fatalf("failed to resolve %s, resolved from %s: %v", resolved, query, err)
+	}
+	if again.Compare(resolved) != 0 {
+		t.Fatalf("%s resolved to %s, which resolves to %s", query, resolved, again)
+	}
+}
+
+func TestSpecsAtIsolated(t *testing.T) {
+	c := qt.New(t)
+	specs, err := LoadSpecVersions(testdata.Path("resources"))
+	c.Assert(err, qt.IsNil)
+	overlay, err := NewDocumentFile(testdata.Path("resources/include.yaml"))
+	c.Assert(err, qt.IsNil)
+
+	// Merging into a resolved spec, as the compiler does with overlays, must
+	// not leak into specs resolved afterwards.
+	spec, err := specs.At("2021-06-04~experimental")
+	c.Assert(err, qt.IsNil)
+	Merge(spec, overlay.T, true)
+	c.Assert(spec.Paths["/openapi"], qt.Not(qt.IsNil))
+
+	spec, err = specs.At("2021-06-04~experimental")
+	c.Assert(err, qt.IsNil)
+	c.Assert(spec.Paths["/openapi"], qt.IsNil)
+	c.Assert(spec.Paths["/orgs/{orgId}/projects"], qt.Not(qt.IsNil))
+	c.Assert(spec.Paths["/examples/hello-world/{id}"], qt.Not(qt.IsNil))
+	for _, rc := range specs.Resources() {
+		for _, v := range rc.Versions() {
+			r, err := rc.At(v.String())
+			c.Assert(err, qt.IsNil)
+			c.Assert(r.Paths["/openapi"], qt.IsNil)
+		}
+	}
+}
+
+func TestSpecsResourcesAt(t *testing.T) {
+	c := qt.New(t)
+	specs, err := LoadSpecVersions(testdata.Path("resources"))
+	c.Assert(err, qt.IsNil)
+	ga, err := specs.ResourcesAt("2021-06-05")
+	c.Assert(err, qt.IsNil)
+	c.Assert(ga, qt.HasLen, 1)
+	c.Assert(ga[0].Version.String(), qt.Equals, "2021-06-01")
+	experimental, err := specs.ResourcesAt("2021-06-05~experimental")
+	c.Assert(err, qt.IsNil)
+	c.Assert(experimental, qt.HasLen, 2)
+	c.Assert(experimental[0], qt.Equals, ga[0])
+	_, err = specs.ResourcesAt("2021-01-01")
+	c.Assert(err, qt.Equals, ErrNoMatchingVersion)
+}
@@ -3,27 +3,56 @@ package vervet
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // Version defines an API version. API versions may be dates of the form
 // "YYYY-mm-dd", or stability tags "beta", "experimental".
+//
+// Resources versioned monthly or weekly are versioned by the first day of
+// the month ("YYYY-mm") or the Monday of the ISO week ("YYYY-Www").
+//
+// APIs may instead be versioned semantically, as "v1" or "v1.2". Semantic
+// versions are ordered by their Major and Minor numbers, and have no date.
 type Version struct {
 	Date      time.Time
 	Stability Stability
+
+	Semantic     bool
+	Major, Minor int
 }
 
 // DateString returns the string representation of the version date in
-// YYYY-mm-dd form.
+// YYYY-mm-dd form. For semantic versions, this is the release, such as v1.2.
 func (v *Version) DateString() string {
+	if v.Semantic {
+		if v.Minor == 0 {
+			return fmt.Sprintf("v%d", v.Major)
+		}
+		return fmt.Sprintf("v%d.%d", v.Major, v.Minor)
+	}
 	return v.Date.Format("2006-01-02")
 }
 
+// MonthString returns the string representation of the version date in
+// YYYY-mm form.
+func (v *Version) MonthString() string {
+	return v.Date.Format("2006-01")
+}
+
+// WeekString returns the string representation of the version date as an
+// ISO week, in YYYY-Www form.
+func (v *Version) WeekString() string {
+	year, week := v.Date.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
+
 // String returns the string representation of the version in
-// YYYY-mm-dd~Stability form.
+// YYYY-mm-dd~Stability form, or vMajor.Minor~Stability for semantic versions.
 func (v *Version) String() string {
-	d := v.Date.Format("2006-01-02")
+	d := v.DateString()
 	if v.Stability != StabilityGA {
 		return d + "~" + v.Stability.String()
 	}
@@ -67,23 +96,103 @@ func (s Stability) String() string {
 
 // ParseVersion parses a version string into a Version type, returning an error
 // if the string is invalid.
+//
+// The version date may be given as YYYY-mm-dd, YYYY-mm for the first day of
+// a month, or YYYY-Www for the Monday of an ISO week. Semantic versions are
+// given as vMajor or vMajor.Minor.
 func ParseVersion(s string) (*Version, error) {
 	parts := strings.Split(s, "~")
-	if len(parts) < 1 {
+	if len(parts) > 2 {
 		return nil, fmt.Errorf("invalid version %q", s)
 	}
-	d, err := time.ParseInLocation("2006-01-02", parts[0], time.UTC)
+	v := &Version{Stability: StabilityGA}
+	var err error
+	if strings.HasPrefix(parts[0], "v") {
+		v.Semantic = true
+		v.Major, v.Minor, err = parseSemver(parts[0][1:])
+	} else {
+		v.Date, err = parseVersionDate(parts[0])
+		v.Date = v.Date.UTC()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("invalid version %q", s)
 	}
-	stab := StabilityGA
 	if len(parts) > 1 {
-		stab, err = ParseStability(parts[1])
+		v.Stability, err = ParseStability(parts[1])
 		if err != nil {
 			return nil, err
 		}
 	}
-	return &Version{Date: d.UTC(), Stability: stab}, nil
+	return v, nil
+}
+
+// SemanticVersionPattern is the pattern of version directory names in
+// semantically versioned APIs.
+const SemanticVersionPattern = "v[0-9]*"
+
+func parseSemver(s string) (major, minor int, err error) {
+	parts := strings.Split(s, ".")
+	if len(parts) > 2 {
+		return 0, 0, fmt.Errorf("invalid semantic version %q", s)
+	}
+	nums := make([]int, 2)
+	for i := range parts {
+		if parts[i] == "" || strings.Trim(parts[i], "0123456789") != "" {
+			return 0, 0, fmt.Errorf("invalid semantic version %q", s)
+		}
+		nums[i], err = strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return nums[0], nums[1], nil
+}
+
+// Version directory name patterns, for each granularity at which resources
+// may be versioned.
+const (
+	DayVersionPattern   = "[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]"
+	MonthVersionPattern = "[0-9][0-9][0-9][0-9]-[0-9][0-9]"
+	WeekVersionPattern  = "[0-9][0-9][0-9][0-9]-W[0-9][0-9]"
+)
+
+func parseVersionDate(s string) (time.Time, error) {
+	if i := strings.Index(s, "-W"); i >= 0 {
+		// Years and weeks are given as digits only, as they are formatted,
+		// so that every version parsed may be formatted and parsed again.
+		if i != 4 || !isDigits(s[:i]) || len(s[i+2:]) != 2 || !isDigits(s[i+2:]) {
+			return time.Time{}, fmt.Errorf("invalid week %q", s)
+		}
+		year, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return time.Time{}, err
+		}
+		week, err := strconv.Atoi(s[i+2:])
+		if err != nil {
+			return time.Time{}, err
+		}
+		return isoWeekStart(year, week)
+	}
+	layout := "2006-01-02"
+	if len(s) == len("2006-01") {
+		layout = "2006-01"
+	}
+	return time.ParseInLocation(layout, s, time.UTC)
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// isoWeekStart returns the date of the Monday starting an ISO week.
+func isoWeekStart(year, week int) (time.Time, error) {
+	// January 4th is always in the first week of the year.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*7)
+	if y, w := monday.ISOWeek(); week < 1 || y != year || w != week {
+		return time.Time{}, fmt.Errorf("invalid week %d of %d", week, year)
+	}
+	return monday, nil
 }
 
 // ParseStability parses a stability string into a Stability type, returning an
@@ -96,6 +205,8 @@ func ParseStability(s string) (Stability, error) {
 		return StabilityExperimental, nil
 	case "beta":
 		return StabilityBeta, nil
+	case "ga":
+		return StabilityGA, nil
 	default:
 		return stabilityUndefined, fmt.Errorf("invalid stability %q", s)
 	}
@@ -115,14 +226,45 @@ func (s Stability) Compare(sr Stability) int {
 // Compare returns -1 if the given version is less than, 0 if equal to, and 1
 // if greater than the caller target version.
 func (v *Version) Compare(vr *Version) int {
-	if v.Date.Before(vr.Date) {
+	if c := v.compareRelease(vr); c != 0 {
+		return c
+	}
+	// Dates are equal
+	return 0 - v.Stability.Compare(vr.Stability)
+}
+
+// compareRelease compares the dates, or semantic versions, of versions,
+// regardless of stability.
+//
+// Dated versions order after all semantic versions, so that requesting the
+// current date resolves to the latest release of a semantically versioned
+// API.
+func (v *Version) compareRelease(vr *Version) int {
+	switch {
+	case v.Semantic && vr.Semantic:
+		if v.Major != vr.Major {
+			return compareInt(v.Major, vr.Major)
+		}
+		return compareInt(v.Minor, vr.Minor)
+	case v.Semantic:
+		return -1
+	case vr.Semantic:
+		return 1
+	case v.Date.Before(vr.Date):
 		return -1
+	case v.Date.After(vr.Date):
+		return 1
 	}
-	if v.Date.After(vr.Date) {
+	return 0
+}
+
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
 		return 1
 	}
-	// Dates are equal
-	return 0 - v.Stability.Compare(vr.Stability)
+	return 0
 }
 
 // VersionDateStrings returns a slice of distinct version date strings for a
@@ -0,0 +1,110 @@
+//go:build go1.18
+// +build go1.18
+
+package vervet_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/snyk/vervet"
+	"github.com/snyk/vervet/testdata"
+)
+
+var versionSeeds = []string{
+	"2021-01-01",
+	"2021-02-02~beta",
+	"2021-03-03~experimental",
+	"2021-04-04~wip",
+	"2021-06",
+	"2021-W41~beta",
+	"2020-W53",
+	"v1",
+	"v1.2~beta",
+	"v0.10",
+	"",
+	"~beta",
+	"2021-13-01",
+	"2021-W54",
+	"v1.2.3",
+	"vnext",
+	"-5-W01",
+	"10000-W01",
+	"2021-W+1",
+	"2021-01-01~beta~ga",
+}
+
+func FuzzParseVersion(f *testing.F) {
+	for _, seed := range versionSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		v, err := ParseVersion(s)
+		if err != nil {
+			return
+		}
+		// A version parsed is the same version when formatted and parsed
+		// again.
+		vs := v.String()
+		v2, err := ParseVersion(vs)
+		if err != nil {
+			t.Fatalf("%q parsed as %q, which does not parse: %v", s, vs, err)
+		}
+		if v.Compare(v2) != 0 || v2.String() != vs {
+			t.Fatalf("%q parsed as %q, which parses as %q", s, vs, v2)
+		}
+		if _, err := ParseVersion(v.DateString()); err != nil {
+			t.Fatalf("%q has date %q, which does not parse: %v", s, v.DateString(), err)
+		}
+	})
+}
+
+func FuzzParseStability(f *testing.F) {
+	for _, seed := range []string{"wip", "experimental", "beta", "ga", "", "GA", "stable"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		stability, err := ParseStability(s)
+		if err != nil {
+			return
+		}
+		if stability.String() != s {
+			t.Fatalf("%q parsed as stability %q", s, stability)
+		}
+	})
+}
+
+var (
+	fuzzSpecsOnce sync.Once
+	fuzzSpecs     *SpecVersions
+	fuzzSpecsErr  error
+)
+
+func FuzzSpecVersionsResolve(f *testing.F) {
+	for _, seed := range versionSeeds {
+		f.Add(seed)
+	}
+	for _, seed := range []string{"2021-06-04~experimental", "2021-06-05~beta", "2021-06-13", "2021-07-01~wip", "2021-05-31"} {
+		f.Add(seed)
+	}
+	fuzzSpecsOnce.Do(func() {
+		fuzzSpecs, fuzzSpecsErr = LoadSpecVersions(testdata.Path("resources"))
+	})
+	if fuzzSpecsErr != nil {
+		f.Fatal(fuzzSpecsErr)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		query, err := ParseVersion(s)
+		if err != nil || s == "" {
+			return
+		}
+		resolved, err := fuzzSpecs.Resolve(s)
+		if errors.Is(err, ErrNoMatchingVersion) {
+			return
+		} else if err != nil {
+			t.Fatalf("failed to resolve %q: %v", s, err)
+		}
+		checkResolved(t, fuzzSpecs, query, resolved)
+	})
+}
@@ -11,10 +11,11 @@ import (
 func TestParseVersion(t *testing.T) {
 	c := qt.New(t)
 	tests := []struct {
-		vs   string
-		d    string
-		stab Stability
-		err  string
+		vs     string
+		d      string
+		semver string
+		stab   Stability
+		err    string
 	}{{
 		vs:   "2021-01-01",
 		d:    "2021-01-01",
@@ -27,6 +28,72 @@ func TestParseVersion(t *testing.T) {
 		vs:   "2021-03-03~experimental",
 		d:    "2021-03-03",
 		stab: StabilityExperimental,
+	}, {
+		vs:   "2021-04-04~ga",
+		d:    "2021-04-04",
+		stab: StabilityGA,
+	}, {
+		vs:  "2021-05-05~stable",
+		err: `invalid stability "stable"`,
+	}, {
+		vs:   "2021-06",
+		d:    "2021-06-01",
+		stab: StabilityGA,
+	}, {
+		vs:   "2021-06~beta",
+		d:    "2021-06-01",
+		stab: StabilityBeta,
+	}, {
+		vs:   "2021-W41",
+		d:    "2021-10-11",
+		stab: StabilityGA,
+	}, {
+		vs:   "2021-W01~experimental",
+		d:    "2021-01-04",
+		stab: StabilityExperimental,
+	}, {
+		vs:   "2020-W53",
+		d:    "2020-12-28",
+		stab: StabilityGA,
+	}, {
+		vs:  "2021-W53",
+		err: `invalid version "2021-W53"`,
+	}, {
+		vs:  "2021-W1",
+		err: `invalid version "2021-W1"`,
+	}, {
+		vs:  "2021-W+1",
+		err: `invalid version "2021-W\+1"`,
+	}, {
+		vs:  "-5-W01",
+		err: `invalid version "-5-W01"`,
+	}, {
+		vs:  "10000-W01",
+		err: `invalid version "10000-W01"`,
+	}, {
+		vs:  "2021-13",
+		err: `invalid version "2021-13"`,
+	}, {
+		vs:  "2021-01-01~beta~ga",
+		err: `invalid version "2021-01-01~beta~ga"`,
+	}, {
+		vs:     "v1",
+		stab:   StabilityGA,
+		semver: "v1",
+	}, {
+		vs:     "v1.2~beta",
+		stab:   StabilityBeta,
+		semver: "v1.2",
+	}, {
+		vs:     "v2.0",
+		stab:   StabilityGA,
+		semver: "v2",
+	}, {
+		vs:  "v1.2.3",
+		err: `invalid version "v1.2.3"`,
+	}, {
+		vs:  "v1.x",
+		err: `invalid version "v1.x"`,
 	}, {
 		vs:  "unknown",
 		err: `invalid version "unknown"`,
@@ -36,6 +103,10 @@ func TestParseVersion(t *testing.T) {
 		v, err := ParseVersion(tests[i].vs)
 		if tests[i].err != "" {
 			c.Assert(err, qt.ErrorMatches, tests[i].err)
+		} else if tests[i].semver != "" {
+			c.Assert(v.Semantic, qt.IsTrue)
+			c.Assert(v.DateString(), qt.Equals, tests[i].semver)
+			c.Assert(v.Stability, qt.Equals, tests[i].stab)
 		} else {
 			c.Assert(v.Date.Format("2006-01-02"), qt.Equals, tests[i].d)
 			c.Assert(v.Stability, qt.Equals, tests[i].stab)
@@ -43,6 +114,16 @@ func TestParseVersion(t *testing.T) {
 	}
 }
 
+func TestVersionGranularityStrings(t *testing.T) {
+	c := qt.New(t)
+	v := mustParseVersion("2021-10-14~beta")
+	c.Assert(v.DateString(), qt.Equals, "2021-10-14")
+	c.Assert(v.MonthString(), qt.Equals, "2021-10")
+	c.Assert(v.WeekString(), qt.Equals, "2021-W41")
+	c.Assert(mustParseVersion(v.WeekString()).DateString(), qt.Equals, "2021-10-11")
+	c.Assert(mustParseVersion(v.MonthString()).DateString(), qt.Equals, "2021-10-01")
+}
+
 func mustParseVersion(s string) *Version {
 	v, err := ParseVersion(s)
 	if err != nil {
@@ -83,6 +164,18 @@ func TestVersionOrder(t *testing.T) {
 		l: "2021-08-01~experimental", r: "2021-08-01~experimental", cmp: 0,
 	}, {
 		l: "2021-08-01~wip", r: "2021-08-01~experimental", cmp: 1,
+	}, {
+		// Compare semantic versions
+		l: "v1", r: "v1.2", cmp: -1,
+	}, {
+		l: "v1.10", r: "v1.2", cmp: 1,
+	}, {
+		l: "v2", r: "v1.10", cmp: 1,
+	}, {
+		l: "v1.2", r: "v1.2~beta", cmp: -1,
+	}, {
+		// Semantic versions precede all dates
+		l: "v99", r: "2021-08-01", cmp: -1,
 	}}
 	for i := range tests {
 		c.Logf("test %d %#v", i, tests[i])
@@ -0,0 +1,285 @@
+// Package versions creates new resource versions in a vervet project, as
+// `vervet version new` does, so that services can create resource versions
+// without running the vervet command.
+package versions
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/ghodss/yaml"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/generator"
+)
+
+// Request identifies the resource version to create.
+type Request struct {
+	// API is the name of the API in the project configuration containing the
+	// resource.
+	API string
+
+	// Resource is the name of the resource.
+	Resource string
+
+	// Version is the version date, or semantic version such as v1.2 if the
+	// API is versioned semantically. Defaults to today's date, in UTC.
+	Version string
+
+	// Stability is the stability of the new version. Defaults to wip.
+	Stability string
+}
+
+// Version is a resource version which was created.
+type Version struct {
+	API       string
+	Resource  string
+	Version   string
+	Stability vervet.Stability
+
+	// Dir is the directory of the version, relative to the project
+	// directory.
+	Dir string
+}
+
+// ExistsError is returned when creating a resource version which already
+// exists, without the Force option.
+type ExistsError struct {
+	Resource string
+	Version  string
+	Dir      string
+}
+
+// Error implements error.
+func (e *ExistsError) Error() string {
+	return fmt.Sprintf("version %s of resource %q already exists in %q", e.Version, e.Resource, e.Dir)
+}
+
+type options struct {
+	generatorOptions []generator.Option
+	force            bool
+}
+
+// Option configures how a resource version is created.
+type Option func(*options)
+
+// Force overwrites a resource version and the files generated for it, if
+// these already exist.
+func Force(force bool) Option {
+	return func(o *options) {
+		o.force = force
+		if force {
+			o.generatorOptions = append(o.generatorOptions, generator.Force(true))
+		}
+	}
+}
+
+// Debug turns on template debug logging.
+func Debug(debug bool) Option {
+	return func(o *options) {
+		if debug {
+			o.generatorOptions = append(o.generatorOptions, generator.Debug(true))
+		}
+	}
+}
+
+// DebugTemplates writes the intermediate output rendered by generator
+// templates into files under dir, for troubleshooting.
+func DebugTemplates(dir string) Option {
+	return func(o *options) {
+		o.generatorOptions = append(o.generatorOptions, generator.DebugTemplates(dir))
+	}
+}
+
+// chdirMu serializes Create, which changes the working directory of the
+// process.
+var chdirMu sync.Mutex
+
+// Create creates a new resource version in the project in projectDir, with
+// the project configuration proj. The version directory is created, the
+// generators of the API's resource set are run, and the stability of the new
+// version is declared in each spec generated. Resource scope generators are
+// given every version of the resource, including the new one.
+//
+// Paths in the project configuration are relative to the project directory,
+// so Create changes the working directory to projectDir while it runs,
+// restoring it when done. Calls to Create are serialized, but it is not safe
+// to use concurrently with anything else depending on the working directory.
+func Create(projectDir string, proj *config.Project, req *Request, opts ...Option) (_ *Version, retErr error) {
+	var o options
+	for i := range opts {
+		opts[i](&o)
+	}
+	if req.API == "" || req.Resource == "" {
+		return nil, fmt.Errorf("api and resource are required")
+	}
+	api, ok := proj.APIs[req.API]
+	if !ok {
+		return nil, fmt.Errorf("API %q not found", req.API)
+	}
+	if len(api.Resources) == 0 {
+		return nil, fmt.Errorf("API %q does not have a resource set defined", req.API)
+	}
+	resourceSet := api.Resources[0]
+
+	versionString := req.Version
+	if versionString == "" {
+		versionString = time.Now().UTC().Format("2006-01-02")
+	}
+	versionDate, err := vervet.ParseVersion(versionString)
+	if err != nil || strings.Contains(versionString, "~") {
+		return nil, fmt.Errorf("invalid version %q, expected a date", versionString)
+	}
+	if semver := api.Versioning == config.VersioningSemver; semver != versionDate.Semantic {
+		if semver {
+			return nil, fmt.Errorf("invalid version %q, API %q is versioned semantically (v1, v1.2)",
+				versionString, req.API)
+		}
+		return nil, fmt.Errorf("invalid version %q, API %q is versioned by date", versionString, req.API)
+	}
+	// Versions are named at the granularity of the resource set, so a date
+	// within a month or week is a version of that month or week.
+	var version string
+	switch resourceSet.Granularity {
+	case config.GranularityMonth:
+		version = versionDate.MonthString()
+	case config.GranularityWeek:
+		version = versionDate.WeekString()
+	default:
+		version = versionDate.DateString()
+	}
+	stabilityString := req.Stability
+	if stabilityString == "" {
+		stabilityString = vervet.StabilityWIP.String()
+	}
+	stability, err := vervet.ParseStability(stabilityString)
+	if err != nil {
+		return nil, fmt.Errorf("%w, expected one of wip, experimental, beta, ga", err)
+	}
+
+	chdirMu.Lock()
+	defer chdirMu.Unlock()
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	err = os.Chdir(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.Chdir(cwd); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+
+	generators, err := generator.NewMap(proj, o.generatorOptions...)
+	if err != nil {
+		return nil, err
+	}
+	resourceDir := resourceSet.Path
+	versionDir := filepath.Join(resourceDir, req.Resource, version)
+	if _, err := os.Stat(versionDir); err == nil && !o.force {
+		return nil, &ExistsError{Resource: req.Resource, Version: version, Dir: versionDir}
+	}
+	err = os.MkdirAll(versionDir, 0777)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create version path %q: %w", versionDir, err)
+	}
+
+	for _, genName := range resourceSet.Generators {
+		gen := generators[genName]
+		var err error
+		if gen.Scope() == config.GeneratorScopeResource {
+			err = gen.RunResource(&generator.ResourceScope{
+				API:          req.API,
+				Resource:     req.Resource,
+				ResourcePath: filepath.Join(resourceDir, req.Resource),
+				Specs:        resourceSet.SpecsPattern(),
+			})
+		} else {
+			err = gen.Run(&generator.VersionScope{
+				API:          req.API,
+				Resource:     req.Resource,
+				Version:      version,
+				Stability:    stability.String(),
+				ResourcePath: filepath.Join(resourceDir, req.Resource),
+				Specs:        resourceSet.SpecsPattern(),
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w (generators.%s)", err, genName)
+		}
+		// Stability is declared as soon as a spec is generated, so that
+		// later generators may load it.
+		err = setVersionStability(versionDir, resourceSet.SpecsPattern(), stability)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Version{
+		API:       req.API,
+		Resource:  req.Resource,
+		Version:   version,
+		Stability: stability,
+		Dir:       versionDir,
+	}, nil
+}
+
+// setVersionStability declares the stability of a new resource version in
+// each spec in its version directory.
+func setVersionStability(versionDir, specsPattern string, stability vervet.Stability) error {
+	specFiles, err := doublestar.Glob(os.DirFS(versionDir), specsPattern)
+	if err != nil {
+		return err
+	}
+	for _, specFile := range specFiles {
+		err = setSpecStability(filepath.Join(versionDir, specFile), stability)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setSpecStability declares the stability of a new resource version in its
+// spec, if the templates that generated it did not. It is an error for the
+// spec to declare a different stability. Does nothing if no spec was
+// generated.
+func setSpecStability(specFile string, stability vervet.Stability) error {
+	buf, err := ioutil.ReadFile(specFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	err = yaml.Unmarshal(buf, &doc)
+	if err != nil {
+		return fmt.Errorf("failed to parse generated spec %q: %w", specFile, err)
+	}
+	if specStability, ok := doc[vervet.ExtSnykApiStability]; ok {
+		if specStability != stability.String() {
+			return fmt.Errorf("generated spec %q declares %s %v, expected %s",
+				specFile, vervet.ExtSnykApiStability, specStability, stability)
+		}
+		return nil
+	}
+	// Prepend rather than re-marshal, which would lose the formatting and
+	// comments of the generated spec.
+	ext := []byte(vervet.ExtSnykApiStability + ": " + stability.String() + "\n")
+	if bytes.HasPrefix(buf, []byte("---\n")) {
+		buf = append(append([]byte("---\n"), ext...), buf[len("---\n"):]...)
+	} else {
+		buf = append(ext, buf...)
+	}
+	return ioutil.WriteFile(specFile, buf, 0666)
+}
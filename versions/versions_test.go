@@ -0,0 +1,201 @@
+package versions_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/versions"
+)
+
+const testConfig = `
+generators:
+  version-spec:
+    scope: version
+    filename: "resources/{{ .Resource }}/{{ .Version }}/spec.yaml"
+    template: "spec.yaml.tmpl"
+apis:
+  test:
+    resources:
+      - path: resources
+        granularity: month
+        generators:
+          - version-spec
+`
+
+const testTemplate = `
+openapi: 3.0.3
+info:
+  title: {{ .Resource }}
+  version: 3.0.0
+paths: {}
+`
+
+func setup(c *qt.C) (string, *config.Project) {
+	projectDir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(projectDir, "spec.yaml.tmpl"), []byte(testTemplate[1:]), 0666), qt.IsNil)
+	proj, err := config.Load(bytes.NewBufferString(testConfig))
+	c.Assert(err, qt.IsNil)
+	return projectDir, proj
+}
+
+func TestCreate(t *testing.T) {
+	c := qt.New(t)
+	projectDir, proj := setup(c)
+	cwd, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+
+	v, err := versions.Create(projectDir, proj, &versions.Request{
+		API:       "test",
+		Resource:  "foo",
+		Version:   "2021-10-14",
+		Stability: "beta",
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(v, qt.DeepEquals, &versions.Version{
+		API:       "test",
+		Resource:  "foo",
+		Version:   "2021-10",
+		Stability: vervet.StabilityBeta,
+		Dir:       filepath.Join("resources", "foo", "2021-10"),
+	})
+
+	// The working directory is restored.
+	after, err := os.Getwd()
+	c.Assert(err, qt.IsNil)
+	c.Assert(after, qt.Equals, cwd)
+
+	buf, err := ioutil.ReadFile(filepath.Join(projectDir, v.Dir, "spec.yaml"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Equals, `
+x-snyk-api-stability: beta
+openapi: 3.0.3
+info:
+  title: foo
+  version: 3.0.0
+paths: {}
+`[1:])
+
+	// An existing version is not overwritten unless forced.
+	_, err = versions.Create(projectDir, proj, &versions.Request{
+		API: "test", Resource: "foo", Version: "2021-10-01", Stability: "ga",
+	})
+	var existsErr *versions.ExistsError
+	c.Assert(errors.As(err, &existsErr), qt.IsTrue)
+	c.Assert(err, qt.ErrorMatches, `version 2021-10 of resource "foo" already exists in "resources/foo/2021-10"`)
+
+	v, err = versions.Create(projectDir, proj, &versions.Request{
+		API: "test", Resource: "foo", Version: "2021-10-01", Stability: "ga",
+	}, versions.Force(true))
+	c.Assert(err, qt.IsNil)
+	c.Assert(v.Stability, qt.Equals, vervet.StabilityGA)
+	buf, err = ioutil.ReadFile(filepath.Join(projectDir, v.Dir, "spec.yaml"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Matches, `x-snyk-api-stability: ga\n(?s).*`)
+}
+
+func TestCreateDefaults(t *testing.T) {
+	c := qt.New(t)
+	projectDir, proj := setup(c)
+	v, err := versions.Create(projectDir, proj, &versions.Request{API: "test", Resource: "foo"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(v.Stability, qt.Equals, vervet.StabilityWIP)
+	_, err = vervet.ParseVersion(v.Version)
+	c.Assert(err, qt.IsNil)
+}
+
+func TestCreateErrors(t *testing.T) {
+	c := qt.New(t)
+	projectDir, proj := setup(c)
+	tests := []struct {
+		req *versions.Request
+		err string
+	}{{
+		req: &versions.Request{API: "test"},
+		err: `api and resource are required`,
+	}, {
+		req: &versions.Request{API: "nope", Resource: "foo"},
+		err: `API "nope" not found`,
+	}, {
+		req: &versions.Request{API: "test", Resource: "foo", Version: "v1"},
+		err: `invalid version "v1", API "test" is versioned by date`,
+	}, {
+		req: &versions.Request{API: "test", Resource: "foo", Version: "2021-10-01~beta"},
+		err: `invalid version "2021-10-01~beta", expected a date`,
+	}, {
+		req: &versions.Request{API: "test", Resource: "foo", Stability: "stable"},
+		err: `invalid stability "stable", expected one of wip, experimental, beta, ga`,
+	}}
+	for _, test := range tests {
+		_, err := versions.Create(projectDir, proj, test.req)
+		c.Assert(err, qt.ErrorMatches, test.err)
+	}
+	_, err := os.Stat(filepath.Join(projectDir, "resources"))
+	c.Assert(os.IsNotExist(err), qt.IsTrue)
+}
+
+const resourceScopeConfig = `
+generators:
+  version-spec:
+    scope: version
+    filename: "resources/{{ .Resource }}/{{ .Version }}/spec.yaml"
+    template: "spec.yaml.tmpl"
+  resource-versions:
+    scope: resource
+    filename: "resources/{{ .Resource }}/versions.txt"
+    template: "versions.txt.tmpl"
+apis:
+  test:
+    resources:
+      - path: resources
+        generators:
+          - version-spec
+          - resource-versions
+`
+
+func TestCreateResourceScope(t *testing.T) {
+	c := qt.New(t)
+	projectDir := c.Mkdir()
+	c.Assert(ioutil.WriteFile(filepath.Join(projectDir, "spec.yaml.tmpl"), []byte(`
+openapi: 3.0.3
+info:
+  title: {{ .Resource }}
+  version: 3.0.0
+paths:
+  /{{ .Resource }}:
+    get:
+      responses:
+        '200':
+          description: OK
+`[1:]), 0666), qt.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(projectDir, "versions.txt.tmpl"), []byte(
+		`{{ range .Versions }}{{ .Version }}~{{ .Stability }}
+{{ end }}`), 0666), qt.IsNil)
+	proj, err := config.Load(bytes.NewBufferString(resourceScopeConfig))
+	c.Assert(err, qt.IsNil)
+
+	// Resource scope generators are given every version of the resource,
+	// including the one just created, and regenerate their files for each
+	// new version.
+	for _, req := range []*versions.Request{{
+		API: "test", Resource: "foo", Version: "2021-09-01", Stability: "beta",
+	}, {
+		API: "test", Resource: "foo", Version: "2021-10-01", Stability: "ga",
+	}} {
+		_, err := versions.Create(projectDir, proj, req)
+		c.Assert(err, qt.IsNil)
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(projectDir, "resources", "foo", "versions.txt"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(string(buf), qt.Equals, `
+2021-09-01~beta
+2021-10-01~ga
+`[1:])
+}
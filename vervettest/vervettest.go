@@ -0,0 +1,215 @@
+// Package vervettest builds vervet projects in temporary directories, so that
+// tools built on vervet's Go API can be tested without hand-crafting fixture
+// repositories.
+//
+// A project is built up from resource versions, then compiled:
+//
+//     p := vervettest.NewProject(t)
+//     p.AddResourceVersion("rest", "things", "2021-06-01",
+//         vervettest.ResourceSpec("beta", "/things"))
+//     p.Compile()
+//     specs := p.CompiledSpecs("rest")
+//
+// Each API is laid out in the project directory as <api>/resources, with a
+// directory for each resource containing its versions, and is compiled to
+// <api>/versions.
+package vervettest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/config"
+	"github.com/snyk/vervet/internal/compiler"
+)
+
+// Project is a vervet project in a temporary directory.
+type Project struct {
+	// Dir is the directory containing the project.
+	Dir string
+
+	t    testing.TB
+	apis map[string]bool
+}
+
+// NewProject returns a new empty project in a temporary directory, which is
+// removed when the test completes.
+func NewProject(t testing.TB) *Project {
+	t.Helper()
+	return &Project{Dir: t.TempDir(), t: t, apis: map[string]bool{}}
+}
+
+// AddResourceVersion adds a version of a resource to an API in the project,
+// with the given spec, returning the path of the spec file. The version is
+// the name of its directory, such as 2021-06-01.
+func (p *Project) AddResourceVersion(api, resource, version string, spec []byte) string {
+	p.t.Helper()
+	specPath := filepath.Join(p.ResourcesDir(api), resource, version, config.DefaultSpecs)
+	p.WriteFile(specPath, spec)
+	p.apis[api] = true
+	return specPath
+}
+
+// WriteFile writes a file in the project, creating the directories
+// containing it. Relative paths are relative to the project directory.
+func (p *Project) WriteFile(path string, contents []byte) {
+	p.t.Helper()
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.Dir, path)
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	err = ioutil.WriteFile(path, contents, 0666)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// ResourcesDir returns the directory containing the resources of an API.
+func (p *Project) ResourcesDir(api string) string {
+	return filepath.Join(p.Dir, api, "resources")
+}
+
+// OutputDir returns the directory an API is compiled to.
+func (p *Project) OutputDir(api string) string {
+	return filepath.Join(p.Dir, api, "versions")
+}
+
+// ConfigFile writes the project configuration, declaring each API with
+// resource versions in the project, and returns its path. Paths in the
+// configuration are relative to the project directory, as vervet expects
+// when run in it.
+func (p *Project) ConfigFile() string {
+	p.t.Helper()
+	var apis []string
+	for api := range p.apis {
+		apis = append(apis, api)
+	}
+	sort.Strings(apis)
+	var buf bytes.Buffer
+	buf.WriteString("apis:\n")
+	for _, api := range apis {
+		fmt.Fprintf(&buf, "  %q:\n", api)
+		fmt.Fprintf(&buf, "    resources:\n      - path: %q\n", filepath.ToSlash(filepath.Join(api, "resources")))
+		fmt.Fprintf(&buf, "    output:\n      path: %q\n", filepath.ToSlash(filepath.Join(api, "versions")))
+	}
+	configPath := filepath.Join(p.Dir, vervet.ProjectConfigFile)
+	p.WriteFile(configPath, buf.Bytes())
+	return configPath
+}
+
+// Config returns the project configuration, written as by ConfigFile. Paths
+// in it are absolute, so that the project may be compiled from any
+// directory.
+func (p *Project) Config() *config.Project {
+	p.t.Helper()
+	f, err := os.Open(p.ConfigFile())
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	defer f.Close()
+	proj, err := config.Load(f)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	for apiName, api := range proj.APIs {
+		for _, rc := range api.Resources {
+			rc.Path = p.ResourcesDir(apiName)
+		}
+		api.Output.Path = p.OutputDir(apiName)
+	}
+	return proj
+}
+
+// Compile compiles each API in the project to its output directory.
+func (p *Project) Compile() {
+	p.t.Helper()
+	ctx := context.Background()
+	comp, err := compiler.New(ctx, p.Config())
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	err = comp.BuildAll(ctx)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+// CompiledSpecs returns the compiled specs of an API, once the project has
+// been compiled.
+func (p *Project) CompiledSpecs(api string) *vervet.SpecVersions {
+	p.t.Helper()
+	specs, err := vervet.LoadCompiledSpecVersionsFS(os.DirFS(p.OutputDir(api)))
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return specs
+}
+
+// ResourceSpec returns a minimal valid spec of a resource version at a
+// stability, such as "beta", with a GET operation on each of the given
+// paths. Operation IDs are derived from the paths.
+func ResourceSpec(stability string, paths ...string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "openapi: 3.0.3\n")
+	fmt.Fprintf(&buf, "x-snyk-api-stability: %s\n", stability)
+	fmt.Fprintf(&buf, "info:\n  title: test\n  version: 3.0.0\n")
+	if len(paths) == 0 {
+		buf.WriteString("paths: {}\n")
+		return buf.Bytes()
+	}
+	buf.WriteString("paths:\n")
+	for _, path := range paths {
+		fmt.Fprintf(&buf, "  %q:\n", path)
+		fmt.Fprintf(&buf, "    get:\n")
+		fmt.Fprintf(&buf, "      operationId: %s\n", operationID(path))
+		if params := pathParams(path); len(params) > 0 {
+			buf.WriteString("      parameters:\n")
+			for _, param := range params {
+				fmt.Fprintf(&buf, "        - {name: %s, in: path, required: true, schema: {type: string}}\n", param)
+			}
+		}
+		fmt.Fprintf(&buf, "      responses:\n        '200':\n          description: OK\n")
+	}
+	return buf.Bytes()
+}
+
+// operationID returns an operation ID for a GET on a path, such as
+// getOrgsThings for /orgs/{orgId}/things, or getHelloWorld for
+// /hello-world.
+func operationID(path string) string {
+	var b strings.Builder
+	b.WriteString("get")
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") {
+			continue
+		}
+		for _, word := range strings.Split(segment, "-") {
+			if word != "" {
+				b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+			}
+		}
+	}
+	return b.String()
+}
+
+// pathParams returns the names of the parameters in a path template.
+func pathParams(path string) []string {
+	var params []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params = append(params, segment[1:len(segment)-1])
+		}
+	}
+	return params
+}
@@ -0,0 +1,50 @@
+package vervettest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/snyk/vervet"
+	"github.com/snyk/vervet/vervettest"
+)
+
+func TestProject(t *testing.T) {
+	c := qt.New(t)
+	p := vervettest.NewProject(c)
+	specPath := p.AddResourceVersion("rest", "things", "2021-06-01",
+		vervettest.ResourceSpec("beta", "/orgs/{orgId}/things"))
+	c.Assert(specPath, qt.Equals, filepath.Join(p.Dir, "rest", "resources", "things", "2021-06-01", "spec.yaml"))
+	p.AddResourceVersion("rest", "things", "2021-07-01",
+		vervettest.ResourceSpec("ga", "/orgs/{orgId}/things", "/orgs/{orgId}/things/{thingId}"))
+	p.AddResourceVersion("rest", "hello-world", "2021-06-15",
+		vervettest.ResourceSpec("experimental", "/hello-world"))
+
+	// The spec of a resource version is valid.
+	doc, err := openapi3.NewLoader().LoadFromData(vervettest.ResourceSpec("ga", "/orgs/{orgId}/things/{thingId}"))
+	c.Assert(err, qt.IsNil)
+	c.Assert(doc.Validate(openapi3.NewLoader().Context), qt.IsNil)
+	c.Assert(doc.Paths["/orgs/{orgId}/things/{thingId}"].Get.OperationID, qt.Equals, "getOrgsThings")
+
+	// The project can be loaded and listed with vervet's API.
+	_, err = os.Stat(p.ConfigFile())
+	c.Assert(err, qt.IsNil)
+	apis, err := vervet.ListResources(os.DirFS(p.Dir))
+	c.Assert(err, qt.IsNil)
+	c.Assert(apis, qt.HasLen, 1)
+	c.Assert(apis[0].Resources, qt.HasLen, 2)
+
+	p.Compile()
+	specs := p.CompiledSpecs("rest")
+	spec, err := specs.At("2021-06-15~experimental")
+	c.Assert(err, qt.IsNil)
+	c.Assert(spec.Paths["/hello-world"], qt.IsNotNil)
+	c.Assert(spec.Paths["/orgs/{orgId}/things"], qt.IsNotNil)
+	c.Assert(spec.Paths["/orgs/{orgId}/things/{thingId}"], qt.IsNil)
+	spec, err = specs.At("2021-07-01")
+	c.Assert(err, qt.IsNil)
+	c.Assert(spec.Paths["/orgs/{orgId}/things/{thingId}"], qt.IsNotNil)
+}